@@ -0,0 +1,286 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"golang.org/x/exp/slog"
+
+	"homework9/internal/adapters/adrepo"
+	"homework9/internal/adapters/diskblob"
+	"homework9/internal/adapters/sqliterepo"
+	"homework9/internal/app"
+	"homework9/internal/config"
+	"homework9/internal/health"
+	"homework9/internal/idempotency"
+	"homework9/internal/logging"
+	"homework9/internal/metrics"
+	grpcPort "homework9/internal/ports/grpc"
+	"homework9/internal/ports/httpgin"
+	"homework9/internal/ports/multiplex"
+	"homework9/internal/ratelimit"
+	"homework9/internal/tracing"
+)
+
+func main() {
+	// Адреса, хранилище, ограничения объявлений, токены, лимиты запросов,
+	// корзина, идемпотентность, остановка и уровень логов задаются файлом
+	// -config, переменными окружения AD_* или флагами.
+	configFlags := config.BindFlags(flag.CommandLine)
+	photosDir := flag.String("photos-dir", "photos", "directory for uploaded ad photos (empty - photo uploads are disabled)")
+	premoderation := flag.Bool("premoderation", false, "publish ads only after a moderator approves them")
+	admins := flag.String("admins", "", "comma-separated IDs of users that are always admins")
+	legacyUserID := flag.Bool("allow-legacy-user-id", false, "trust the deprecated user_id fields in requests without an access token")
+	traceExporter := flag.String("trace-exporter", "", "where to export request traces (stdout; empty - tracing is disabled)")
+	flag.Parse()
+
+	cfg, err := configFlags.Load(os.LookupEnv)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Все строки лога - JSON в stdout, включая log.Printf, идентификатор
+	// запроса добавляется из контекста.
+	logger := logging.NewWithLevel(os.Stdout, cfg.LogLevel())
+	slog.SetDefault(logger)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	var repo app.Repository
+	switch cfg.Storage.Backend {
+	case "memory":
+		if cfg.Storage.WALDir == "" {
+			repo = adrepo.New()
+			break
+		}
+		memRepo, err := adrepo.NewPersistent(cfg.Storage.WALDir, cfg.Storage.CompactEvery, adrepo.WithLogger(logger))
+		if err != nil {
+			log.Fatalf("memory storage: %s", err)
+		}
+		defer memRepo.Close()
+		repo = memRepo
+	case "sqlite":
+		sqliteRepo, err := sqliterepo.New(ctx, cfg.Storage.SQLitePath)
+		if err != nil {
+			log.Fatalf("sqlite storage: %s", err)
+		}
+		defer sqliteRepo.Close()
+		repo = sqliteRepo
+	}
+
+	var tp *sdktrace.TracerProvider
+	switch *traceExporter {
+	case "":
+	case "stdout":
+		exporter, err := tracing.NewStdoutExporter(os.Stdout)
+		if err != nil {
+			log.Fatalf("trace exporter: %s", err)
+		}
+		tp = tracing.NewProvider(exporter)
+		repo = tracing.WrapRepository(repo, tp)
+	default:
+		log.Fatalf("unknown trace exporter %q, expected stdout", *traceExporter)
+	}
+
+	appOpts := []app.Option{
+		app.WithTokenSecret([]byte(cfg.Auth.TokenSecret)),
+		app.WithTokenTTL(cfg.Auth.TokenTTL),
+		app.WithTrashRetention(cfg.Trash.Retention),
+		app.WithLogger(logger),
+		app.WithMaxTitleLen(cfg.Ads.MaxTitleLen),
+		app.WithMaxTextLen(cfg.Ads.MaxTextLen),
+	}
+	if *premoderation {
+		appOpts = append(appOpts, app.WithPremoderation())
+	}
+	adminIDs, err := parseIDs(*admins)
+	if err != nil {
+		log.Fatalf("-admins: %s", err)
+	}
+	appOpts = append(appOpts, app.WithAdmins(adminIDs...))
+	if *photosDir != "" {
+		blobs, err := diskblob.New(*photosDir)
+		if err != nil {
+			log.Fatalf("photo storage: %s", err)
+		}
+		appOpts = append(appOpts, app.WithBlobStore(blobs))
+	}
+
+	a := app.NewApp(repo, appOpts...)
+	if tp != nil {
+		a = tracing.WrapApp(a, tp)
+	}
+
+	// Лимиты общие для REST и gRPC: переход на другой протокол их не обходит.
+	limiter := ratelimit.New(cfg.RateLimit.Limits())
+	store := idempotency.New(cfg.Idempotency.TTL)
+	// Метрики gRPC отдаются вместе с метриками REST по GET /metrics.
+	m := metrics.New(a)
+	checker := health.New(a)
+	httpOpts := []httpgin.Option{
+		httpgin.WithRateLimiter(limiter), httpgin.WithIdempotency(store), httpgin.WithMetrics(m),
+		httpgin.WithLogger(logger), httpgin.WithHealth(checker),
+	}
+	grpcOpts := []grpcPort.Option{
+		grpcPort.WithRateLimiter(limiter), grpcPort.WithIdempotency(store), grpcPort.WithMetrics(m),
+		grpcPort.WithLogger(logger), grpcPort.WithHealth(checker),
+	}
+	if tp != nil {
+		httpOpts = append(httpOpts, httpgin.WithTracing(tp))
+		grpcOpts = append(grpcOpts, grpcPort.WithTracing(tp))
+	}
+	if *legacyUserID {
+		httpOpts = append(httpOpts, httpgin.WithLegacyUserID())
+		grpcOpts = append(grpcOpts, grpcPort.WithLegacyUserID())
+	}
+
+	var httpLis, grpcLis net.Listener
+	var mux *multiplex.Mux
+	addr := cfg.HTTP.Addr
+	if cfg.Listen != "" {
+		addr = cfg.Listen
+		lis, err := net.Listen("tcp", addr)
+		if err != nil {
+			log.Fatalf("listen: %s", err)
+		}
+		mux = multiplex.New(lis)
+		httpLis, grpcLis = mux.HTTP(), mux.GRPC()
+	} else {
+		if httpLis, err = net.Listen("tcp", cfg.HTTP.Addr); err != nil {
+			log.Fatalf("http listen: %s", err)
+		}
+		if grpcLis, err = net.Listen("tcp", cfg.GRPC.Addr); err != nil {
+			log.Fatalf("grpc listen: %s", err)
+		}
+	}
+
+	httpServer := httpgin.NewHTTPServer(addr, a, httpOpts...)
+	grpcServer := grpcPort.NewGRPCServer(a, grpcOpts...)
+
+	var wg sync.WaitGroup
+	wg.Add(4)
+
+	if mux != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			log.Printf("serving http and grpc on %s", addr)
+			if err := mux.Serve(); err != nil {
+				log.Printf("listener: %s", err)
+				stop()
+			}
+		}()
+	}
+
+	go func() {
+		defer wg.Done()
+		log.Printf("http server listening on %s", httpLis.Addr())
+		if err := httpServer.Serve(httpLis); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("http server: %s", err)
+			stop()
+		}
+	}()
+
+	go func() {
+		defer wg.Done()
+		log.Printf("grpc server listening on %s", grpcLis.Addr())
+		if err := grpcServer.Serve(grpcLis); err != nil {
+			log.Printf("grpc server: %s", err)
+			stop()
+		}
+	}()
+
+	// Очистка корзины и планировщик публикаций останавливаются по сигналу вместе
+	// с серверами, до закрытия хранилища.
+	go func() {
+		defer wg.Done()
+		app.RunPurger(ctx, a, cfg.Trash.PurgeInterval, logger)
+	}()
+
+	go func() {
+		defer wg.Done()
+		a.RunScheduler(ctx)
+	}()
+
+	<-ctx.Done()
+	log.Print("shutting down")
+
+	// Проверки готовности сразу начинают отвечать 503 и NOT_SERVING, но порты
+	// еще shutdown.delay принимают запросы, пока балансировщики это заметят.
+	checker.Shutdown()
+	time.Sleep(cfg.Shutdown.Delay)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Shutdown.Timeout)
+	defer cancel()
+
+	// Общий порт перестает принимать соединения сразу, а уже принятые
+	// соединения дорабатывают в своих серверах.
+	if mux != nil {
+		_ = mux.Close()
+	}
+
+	// Серверы дорабатывают запросы одновременно, чтобы оба уложились в общий
+	// shutdown.timeout. Потоки WatchAds и Chat уже завершены checker.Shutdown.
+	var drain sync.WaitGroup
+	drain.Add(2)
+	go func() {
+		defer drain.Done()
+		if err := httpServer.Shutdown(shutdownCtx); err != nil {
+			log.Printf("http server shutdown: %s", err)
+		}
+	}()
+	go func() {
+		defer drain.Done()
+		stopped := make(chan struct{})
+		go func() {
+			grpcServer.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-shutdownCtx.Done():
+			grpcServer.Stop()
+		}
+	}()
+	drain.Wait()
+
+	wg.Wait()
+
+	// Спаны, накопленные в пачке экспортера, отправляются до выхода.
+	if tp != nil {
+		if err := tp.Shutdown(shutdownCtx); err != nil {
+			log.Printf("tracer shutdown: %s", err)
+		}
+	}
+}
+
+// parseIDs разбирает список ID через запятую.
+func parseIDs(s string) ([]int64, error) {
+	var ids []int64
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(field, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,349 @@
+package adrepo
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/exp/slog"
+
+	"homework9/internal/ads"
+	"homework9/internal/categories"
+	"homework9/internal/favorites"
+	"homework9/internal/messages"
+	"homework9/internal/money"
+	"homework9/internal/users"
+)
+
+const (
+	logFileName      = "wal.log"
+	snapshotFileName = "snapshot.json"
+
+	// recordHeaderSize - длина payload (uint32) и его crc32 (uint32).
+	recordHeaderSize = 8
+	// maxRecordSize ограничивает payload, чтобы поврежденная длина не привела к огромной аллокации.
+	maxRecordSize = 16 << 20
+)
+
+type op string
+
+const (
+	opPutAd      op = "put_ad"
+	opDeleteAd   op = "delete_ad"
+	opPutUser    op = "put_user"
+	opDeleteUser op = "delete_user"
+
+	opPutCategory    op = "put_category"
+	opDeleteCategory op = "delete_category"
+
+	opPutRate op = "put_rate"
+
+	opAddRevision op = "add_revision"
+
+	opAddFavorite     op = "add_favorite"
+	opRemoveFavorite  op = "remove_favorite"
+	opAddNotification op = "add_notification"
+
+	opPutThread  op = "put_thread"
+	opAddMessage op = "add_message"
+)
+
+// record - одна мутация в журнале. Put-записи содержат сущность целиком,
+// поэтому повторное применение журнала поверх снапшота идемпотентно.
+type record struct {
+	Op   op          `json:"op"`
+	ID   int64       `json:"id,omitempty"`
+	Ad   *ads.Ad     `json:"ad,omitempty"`
+	User *users.User `json:"user,omitempty"`
+
+	Category *categories.Category `json:"category,omitempty"`
+	Rate     *money.Rate          `json:"rate,omitempty"`
+	Revision *ads.Revision        `json:"revision,omitempty"`
+
+	Favorite     *favorites.Favorite     `json:"favorite,omitempty"`
+	Notification *favorites.Notification `json:"notification,omitempty"`
+
+	Thread  *messages.Thread  `json:"thread,omitempty"`
+	Message *messages.Message `json:"message,omitempty"`
+}
+
+type snapshot struct {
+	Ads        []ads.Ad     `json:"ads"`
+	NextAdID   int64        `json:"next_ad_id"`
+	Users      []users.User `json:"users"`
+	NextUserID int64        `json:"next_user_id"`
+
+	Categories     []categories.Category `json:"categories"`
+	NextCategoryID int64                 `json:"next_category_id"`
+
+	Rates     []money.Rate   `json:"rates"`
+	Revisions []ads.Revision `json:"revisions"`
+
+	Favorites          []favorites.Favorite     `json:"favorites"`
+	Notifications      []favorites.Notification `json:"notifications"`
+	NextNotificationID int64                    `json:"next_notification_id"`
+
+	Threads       []messages.Thread  `json:"threads"`
+	NextThreadID  int64              `json:"next_thread_id"`
+	Messages      []messages.Message `json:"messages"`
+	NextMessageID int64              `json:"next_message_id"`
+}
+
+// journalFile - открытый файл журнала.
+type journalFile interface {
+	io.ReadWriteSeeker
+	io.Closer
+	Truncate(size int64) error
+	Sync() error
+	Stat() (os.FileInfo, error)
+}
+
+// journal - append-only журнал мутаций с периодическим сжатием в снапшот.
+// Запись в журнале: длина payload, crc32 payload (оба uint32, little endian), payload в JSON.
+type journal struct {
+	dir          string
+	file         journalFile
+	records      int
+	compactEvery int
+	logger       *slog.Logger
+	// failed - причина, по которой журнал остался в неизвестном состоянии:
+	// запись не удалась, а оборванный хвост не получилось убрать. После этого
+	// любая запись возвращает ошибку, иначе новые записи оказались бы за
+	// поврежденной и пропали бы при воспроизведении.
+	failed error
+}
+
+func openJournal(dir string, compactEvery int, logger *slog.Logger, wrap func(f *os.File) journalFile) (*journal, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	osFile, err := os.OpenFile(filepath.Join(dir, logFileName), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	var file journalFile = osFile
+	if wrap != nil {
+		file = wrap(osFile)
+	}
+
+	return &journal{
+		dir:          dir,
+		file:         file,
+		compactEvery: compactEvery,
+		logger:       logger,
+	}, nil
+}
+
+// loadSnapshot читает последний снапшот, если он есть.
+func (j *journal) loadSnapshot() (snapshot, error) {
+	data, err := os.ReadFile(filepath.Join(j.dir, snapshotFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return snapshot{}, nil
+	}
+	if err != nil {
+		return snapshot{}, err
+	}
+
+	var snap snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return snapshot{}, fmt.Errorf("decode %s: %w", snapshotFileName, err)
+	}
+
+	return snap, nil
+}
+
+// replay вызывает apply для каждой целой записи журнала. Если хвост журнала
+// оборван или поврежден, он обрезается до последней целой записи.
+func (j *journal) replay(apply func(record)) error {
+	if _, err := j.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	r := bufio.NewReader(j.file)
+	var offset int64
+	for {
+		rec, n, err := readRecord(r)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			if err := j.truncate(offset, err); err != nil {
+				return err
+			}
+			break
+		}
+
+		apply(rec)
+		offset += n
+		j.records++
+	}
+
+	_, err := j.file.Seek(offset, io.SeekStart)
+	return err
+}
+
+func (j *journal) truncate(offset int64, cause error) error {
+	info, err := j.file.Stat()
+	if err != nil {
+		return err
+	}
+
+	j.logger.Warn("dropping corrupted journal tail",
+		"file", filepath.Join(j.dir, logFileName),
+		"bytes", info.Size()-offset,
+		"offset", offset,
+		"error", cause)
+
+	if err := j.file.Truncate(offset); err != nil {
+		return err
+	}
+
+	return j.file.Sync()
+}
+
+// append дописывает запись в журнал и дожидается fsync. Если запись не
+// удалась, журнал обрезается до прежней длины, чтобы оборванная запись не
+// скрыла при воспроизведении следующие за ней. Если не удалось и это, журнал
+// отказывает во всех следующих записях.
+func (j *journal) append(rec record) error {
+	if j.failed != nil {
+		return fmt.Errorf("journal failed earlier: %w", j.failed)
+	}
+
+	payload, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+
+	buf := make([]byte, recordHeaderSize+len(payload))
+	binary.LittleEndian.PutUint32(buf[0:4], uint32(len(payload)))
+	binary.LittleEndian.PutUint32(buf[4:8], crc32.ChecksumIEEE(payload))
+	copy(buf[recordHeaderSize:], payload)
+
+	offset, err := j.file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+
+	if err := j.write(buf); err != nil {
+		if rerr := j.rollback(offset); rerr != nil {
+			j.failed = fmt.Errorf("rollback to offset %d: %w", offset, rerr)
+			return fmt.Errorf("%w (%s)", err, j.failed)
+		}
+		return err
+	}
+	j.records++
+
+	return nil
+}
+
+func (j *journal) write(buf []byte) error {
+	if _, err := j.file.Write(buf); err != nil {
+		return err
+	}
+
+	return j.file.Sync()
+}
+
+// rollback убирает из журнала все, что записано после offset.
+func (j *journal) rollback(offset int64) error {
+	if err := j.file.Truncate(offset); err != nil {
+		return err
+	}
+	if _, err := j.file.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+
+	return j.file.Sync()
+}
+
+func (j *journal) needsCompaction() bool {
+	return j.compactEvery > 0 && j.records >= j.compactEvery
+}
+
+// compact атомарно заменяет снапшот и очищает журнал. Если процесс упадет
+// между этими шагами, журнал просто применится к новому снапшоту еще раз.
+func (j *journal) compact(snap snapshot) error {
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return err
+	}
+
+	tmp := filepath.Join(j.dir, snapshotFileName+".tmp")
+	if err := writeFileSync(tmp, data); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, filepath.Join(j.dir, snapshotFileName)); err != nil {
+		return err
+	}
+
+	if err := j.file.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := j.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	j.records = 0
+
+	return j.file.Sync()
+}
+
+func (j *journal) close() error {
+	return j.file.Close()
+}
+
+func readRecord(r io.Reader) (record, int64, error) {
+	var header [recordHeaderSize]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		if errors.Is(err, io.EOF) {
+			return record{}, 0, io.EOF
+		}
+		return record{}, 0, fmt.Errorf("read header: %w", err)
+	}
+
+	size := binary.LittleEndian.Uint32(header[0:4])
+	sum := binary.LittleEndian.Uint32(header[4:8])
+	if size > maxRecordSize {
+		return record{}, 0, fmt.Errorf("record size %d exceeds limit", size)
+	}
+
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return record{}, 0, fmt.Errorf("read payload: %w", err)
+	}
+	if crc32.ChecksumIEEE(payload) != sum {
+		return record{}, 0, errors.New("checksum mismatch")
+	}
+
+	var rec record
+	if err := json.Unmarshal(payload, &rec); err != nil {
+		return record{}, 0, fmt.Errorf("decode payload: %w", err)
+	}
+
+	return rec, int64(recordHeaderSize + len(payload)), nil
+}
+
+func writeFileSync(name string, data []byte) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
@@ -0,0 +1,123 @@
+package adrepo
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"homework9/internal/ads"
+	"homework9/internal/app"
+	"homework9/internal/categories"
+	"homework9/internal/logging"
+)
+
+// failingFile обрывает запись в журнал на середине, пока failWrites, и не
+// может обрезать файл, пока failTruncate.
+type failingFile struct {
+	*os.File
+	failWrites   *bool
+	failTruncate *bool
+}
+
+func (f failingFile) Write(p []byte) (int, error) {
+	if *f.failWrites {
+		n, _ := f.File.Write(p[:len(p)/2])
+		return n, errors.New("disk is full")
+	}
+
+	return f.File.Write(p)
+}
+
+func (f failingFile) Truncate(size int64) error {
+	if *f.failTruncate {
+		return errors.New("i/o error")
+	}
+
+	return f.File.Truncate(size)
+}
+
+func newFailingRepo(t *testing.T, dir string) (repo *Repo, failWrites, failTruncate *bool) {
+	failWrites, failTruncate = new(bool), new(bool)
+	repo, err := NewPersistent(dir, 0, withJournalFile(func(f *os.File) journalFile {
+		return failingFile{File: f, failWrites: failWrites, failTruncate: failTruncate}
+	}))
+	assert.NoError(t, err)
+
+	return repo, failWrites, failTruncate
+}
+
+func TestPersistentAdRepo_FailedWrite(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	repo, failWrites, _ := newFailingRepo(t, dir)
+
+	ad, err := repo.AddAd(ctx, ads.Ad{Title: "hello", Text: "world", AuthorID: 123, CategoryID: categories.OtherID}, nil)
+	assert.NoError(t, err)
+
+	*failWrites = true
+	_, err = repo.AddAd(ctx, ads.Ad{Title: "lost", Text: "ad", AuthorID: 123, CategoryID: categories.OtherID}, nil)
+	assert.Error(t, err)
+
+	// Оборванная запись убрана из журнала и не прячет следующие.
+	*failWrites = false
+	next, err := repo.AddAd(ctx, ads.Ad{Title: "best cat", Text: "not for sale", AuthorID: 123, CategoryID: categories.OtherID}, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, repo.Close())
+
+	var logs bytes.Buffer
+	repo, err = NewPersistent(dir, 0, WithLogger(logging.New(&logs)))
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		repo.Close()
+	})
+	assert.NotContains(t, logs.String(), "dropping corrupted journal tail")
+
+	list, err := repo.ListAds(ctx, app.AdFilter{})
+	assert.NoError(t, err)
+	if assert.Len(t, list, 2) {
+		assert.Equal(t, ad.ID, list[0].ID)
+		assert.Equal(t, next.ID, list[1].ID)
+		assert.Equal(t, "best cat", list[1].Title)
+	}
+}
+
+func TestPersistentAdRepo_FailedRollback(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	repo, failWrites, failTruncate := newFailingRepo(t, dir)
+
+	ad, err := repo.AddAd(ctx, ads.Ad{Title: "hello", Text: "world", AuthorID: 123, CategoryID: categories.OtherID}, nil)
+	assert.NoError(t, err)
+
+	*failWrites, *failTruncate = true, true
+	_, err = repo.AddAd(ctx, ads.Ad{Title: "lost", Text: "ad", AuthorID: 123, CategoryID: categories.OtherID}, nil)
+	assert.Error(t, err)
+
+	// Оборванный хвост остался в журнале, поэтому следующие записи пропали бы
+	// при воспроизведении - журнал отказывает в них, даже когда диск ожил.
+	*failWrites, *failTruncate = false, false
+	_, err = repo.AddAd(ctx, ads.Ad{Title: "best cat", Text: "not for sale", AuthorID: 123, CategoryID: categories.OtherID}, nil)
+	assert.ErrorContains(t, err, "journal failed earlier")
+	ad.Title = "hello!"
+	assert.Error(t, repo.UpdateAd(ctx, ad, nil))
+	assert.NoError(t, repo.Close())
+
+	// Успешно записанное до сбоя сохранилось, отклоненное - нет.
+	repo, err = NewPersistent(dir, 0, WithLogger(logging.New(&bytes.Buffer{})))
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		repo.Close()
+	})
+
+	list, err := repo.ListAds(ctx, app.AdFilter{})
+	assert.NoError(t, err)
+	if assert.Len(t, list, 1) {
+		assert.Equal(t, "hello", list[0].Title)
+	}
+}
@@ -0,0 +1,1062 @@
+package adrepo
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"golang.org/x/exp/slog"
+
+	"homework9/internal/ads"
+	"homework9/internal/app"
+	"homework9/internal/categories"
+	"homework9/internal/favorites"
+	"homework9/internal/logging"
+	"homework9/internal/messages"
+	"homework9/internal/money"
+	"homework9/internal/users"
+)
+
+// DefaultCompactEvery - после скольких записей журнал сжимается в снапшот.
+const DefaultCompactEvery = 1000
+
+// Repo хранит объявления и пользователей в памяти. Репозиторий, созданный
+// через NewPersistent, дополнительно пишет каждую мутацию в журнал на диске.
+type Repo struct {
+	mu sync.RWMutex
+
+	ads        map[int64]ads.Ad
+	adIDs      []int64 // ID объявлений по возрастанию, для постраничной выдачи
+	nextAdID   int64
+	users      map[int64]users.User
+	nextUserID int64
+
+	categories     map[int64]categories.Category
+	nextCategoryID int64
+
+	rates     map[money.Currency]money.Rate
+	revisions map[int64][]ads.Revision // по ID объявления, по возрастанию версии
+
+	favorites          map[int64]map[int64]time.Time      // по ID пользователя: ID объявления -> время добавления
+	watchers           map[int64]map[int64]bool           // по ID объявления: ID пользователей, добавивших его в избранное
+	notifications      map[int64][]favorites.Notification // по ID пользователя, по возрастанию ID
+	nextNotificationID int64
+
+	threads        map[int64]messages.Thread // без полей, которые вычисляются при чтении
+	nextThreadID   int64
+	threadMessages map[int64][]messages.Message // по ID переписки, по возрастанию ID
+	nextMessageID  int64
+
+	journal *journal
+}
+
+func New() app.Repository {
+	return newRepo()
+}
+
+func newRepo() *Repo {
+	return &Repo{
+		ads:   make(map[int64]ads.Ad),
+		users: make(map[int64]users.User),
+		categories: map[int64]categories.Category{
+			categories.OtherID: {ID: categories.OtherID, Name: categories.OtherName},
+		},
+		nextCategoryID: categories.OtherID + 1,
+		rates:          make(map[money.Currency]money.Rate),
+		revisions:      make(map[int64][]ads.Revision),
+		favorites:      make(map[int64]map[int64]time.Time),
+		watchers:       make(map[int64]map[int64]bool),
+		notifications:  make(map[int64][]favorites.Notification),
+		threads:        make(map[int64]messages.Thread),
+		threadMessages: make(map[int64][]messages.Message),
+		nextMessageID:  1,
+	}
+}
+
+type options struct {
+	logger   *slog.Logger
+	wrapFile func(f *os.File) journalFile
+}
+
+type Option func(o *options)
+
+// WithLogger задает логгер предупреждений журнала, по умолчанию строки JSON
+// пишутся в stderr.
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// withJournalFile подменяет файл журнала результатом wrap, чтобы тесты могли
+// проверить обработку ошибок записи на диск.
+func withJournalFile(wrap func(f *os.File) journalFile) Option {
+	return func(o *options) {
+		o.wrapFile = wrap
+	}
+}
+
+// NewPersistent восстанавливает состояние из снапшота и журнала в каталоге dir
+// и дальше сохраняет туда каждую мутацию. Журнал сжимается в снапшот каждые
+// compactEvery записей (0 - никогда).
+func NewPersistent(dir string, compactEvery int, opts ...Option) (*Repo, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.logger == nil {
+		o.logger = logging.New(os.Stderr)
+	}
+
+	j, err := openJournal(dir, compactEvery, o.logger.With("component", "adrepo"), o.wrapFile)
+	if err != nil {
+		return nil, fmt.Errorf("open journal in %s: %w", dir, err)
+	}
+
+	r := newRepo()
+	snap, err := j.loadSnapshot()
+	if err != nil {
+		j.close()
+		return nil, err
+	}
+	r.restore(snap)
+
+	if err := j.replay(func(rec record) { r.apply(upgradeRecord(rec)) }); err != nil {
+		j.close()
+		return nil, fmt.Errorf("replay journal in %s: %w", dir, err)
+	}
+	r.journal = j
+
+	return r, nil
+}
+
+// Close закрывает журнал. Для репозитория без журнала ничего не делает.
+func (r *Repo) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.journal == nil {
+		return nil
+	}
+
+	return r.journal.close()
+}
+
+// Ping проверяет, что журнал не закрыт. Репозиторий без журнала всегда доступен.
+func (r *Repo) Ping(_ context.Context) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.journal == nil {
+		return nil
+	}
+	if _, err := r.journal.file.Stat(); err != nil {
+		return fmt.Errorf("journal: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Repo) AddAd(ctx context.Context, ad ads.Ad, revision *ads.Revision) (ads.Ad, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.checkAdCategory(ad); err != nil {
+		return ads.Ad{}, err
+	}
+
+	ad.ID = r.nextAdID
+	ad.Favorites = 0
+	if err := r.commit(ctx, record{Op: opPutAd, Ad: &ad, Revision: revisionOf(ad, revision)}); err != nil {
+		return ads.Ad{}, err
+	}
+
+	return ad, nil
+}
+
+func (r *Repo) GetAd(_ context.Context, adID int64) (ads.Ad, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ad, ok := r.ads[adID]
+	if !ok {
+		return ads.Ad{}, fmt.Errorf("ad %d: %w", adID, app.ErrNotFound)
+	}
+
+	return r.withFavorites(ad), nil
+}
+
+func (r *Repo) UpdateAd(ctx context.Context, ad ads.Ad, revision *ads.Revision) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.ads[ad.ID]
+	if !ok {
+		return fmt.Errorf("ad %d: %w", ad.ID, app.ErrNotFound)
+	}
+	if stored.Version != ad.Version {
+		return fmt.Errorf("ad %d: %w", ad.ID, app.ErrVersionConflict)
+	}
+	if err := r.checkAdCategory(ad); err != nil {
+		return err
+	}
+
+	ad.Version++
+	ad.Favorites = 0
+	return r.commit(ctx, record{Op: opPutAd, Ad: &ad, Revision: revisionOf(ad, revision)})
+}
+
+// checkAdCategory проверяет, что категория объявления существует. Вызывается под r.mu.
+func (r *Repo) checkAdCategory(ad ads.Ad) error {
+	if _, ok := r.categories[ad.CategoryID]; !ok {
+		return fmt.Errorf("%w: unknown category %d", app.ErrValidation, ad.CategoryID)
+	}
+
+	return nil
+}
+
+// revisionOf возвращает копию записи истории с ID и версией сохраняемого
+// объявления: она попадает в журнал той же записью, что и объявление.
+func revisionOf(ad ads.Ad, revision *ads.Revision) *ads.Revision {
+	if revision == nil {
+		return nil
+	}
+
+	rev := *revision
+	rev.AdID = ad.ID
+	rev.Version = ad.Version
+	return &rev
+}
+
+func (r *Repo) DeleteAd(ctx context.Context, adID int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.ads[adID]; !ok {
+		return fmt.Errorf("ad %d: %w", adID, app.ErrNotFound)
+	}
+
+	return r.commit(ctx, record{Op: opDeleteAd, ID: adID})
+}
+
+func (r *Repo) ListAds(_ context.Context, filter app.AdFilter) ([]ads.Ad, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	// Объявления хранятся по возрастанию ID, другой порядок требует отобрать все
+	// подходящие и отсортировать их.
+	byID := filter.Sort == app.SortByID && !filter.Desc
+
+	list := make([]ads.Ad, 0)
+	start := sort.Search(len(r.adIDs), func(i int) bool { return r.adIDs[i] >= filter.FromID })
+	for _, id := range r.adIDs[start:] {
+		if byID && filter.Limit > 0 && len(list) == filter.Limit {
+			break
+		}
+
+		ad := r.ads[id]
+		if !matchTrash(filter, ad) {
+			continue
+		}
+		if filter.PublishedOnly && !ad.Published {
+			continue
+		}
+		if filter.AuthorID != nil && ad.AuthorID != *filter.AuthorID {
+			continue
+		}
+		if filter.FavoritedBy != nil {
+			if _, ok := r.favorites[*filter.FavoritedBy][ad.ID]; !ok {
+				continue
+			}
+		}
+		if filter.Scheduled && !ad.Scheduled() {
+			continue
+		}
+		if filter.Moderation != ads.ModerationNone && ad.Moderation != filter.Moderation {
+			continue
+		}
+		if len(filter.CategoryIDs) > 0 && !containsID(filter.CategoryIDs, ad.CategoryID) {
+			continue
+		}
+		if !filter.Match(ad) {
+			continue
+		}
+		list = append(list, r.withFavorites(ad))
+	}
+
+	if !byID {
+		sort.Slice(list, func(i, j int) bool { return filter.Before(filter.KeyOf(list[i]), filter.KeyOf(list[j])) })
+		if filter.Limit > 0 && len(list) > filter.Limit {
+			list = list[:filter.Limit]
+		}
+	}
+
+	return list, nil
+}
+
+func (r *Repo) AddUser(ctx context.Context, user users.User) (users.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user.ID = r.nextUserID
+	if err := r.commit(ctx, record{Op: opPutUser, User: &user}); err != nil {
+		return users.User{}, err
+	}
+
+	return user, nil
+}
+
+func (r *Repo) GetUser(_ context.Context, userID int64) (users.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.users[userID]
+	if !ok {
+		return users.User{}, fmt.Errorf("user %d: %w", userID, app.ErrNotFound)
+	}
+
+	return user, nil
+}
+
+func (r *Repo) UpdateUser(ctx context.Context, user users.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.users[user.ID]; !ok {
+		return fmt.Errorf("user %d: %w", user.ID, app.ErrNotFound)
+	}
+
+	return r.commit(ctx, record{Op: opPutUser, User: &user})
+}
+
+func (r *Repo) DeleteUser(ctx context.Context, userID int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.users[userID]; !ok {
+		return fmt.Errorf("user %d: %w", userID, app.ErrNotFound)
+	}
+
+	return r.commit(ctx, record{Op: opDeleteUser, ID: userID})
+}
+
+func (r *Repo) ListTrashedUsers(_ context.Context, deletedBefore time.Time) ([]users.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	list := make([]users.User, 0)
+	for _, user := range r.users {
+		if user.Trashed() && (deletedBefore.IsZero() || user.DeletedAt.Before(deletedBefore)) {
+			list = append(list, user)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+
+	return list, nil
+}
+
+func (r *Repo) AddCategory(ctx context.Context, category categories.Category) (categories.Category, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.checkCategoryParent(category); err != nil {
+		return categories.Category{}, err
+	}
+
+	category.ID = r.nextCategoryID
+	if err := r.commit(ctx, record{Op: opPutCategory, Category: &category}); err != nil {
+		return categories.Category{}, err
+	}
+
+	return category, nil
+}
+
+func (r *Repo) GetCategory(_ context.Context, categoryID int64) (categories.Category, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	category, ok := r.categories[categoryID]
+	if !ok {
+		return categories.Category{}, fmt.Errorf("category %d: %w", categoryID, app.ErrNotFound)
+	}
+
+	return category, nil
+}
+
+func (r *Repo) UpdateCategory(ctx context.Context, category categories.Category) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.categories[category.ID]; !ok {
+		return fmt.Errorf("category %d: %w", category.ID, app.ErrNotFound)
+	}
+	if err := r.checkCategoryParent(category); err != nil {
+		return err
+	}
+
+	return r.commit(ctx, record{Op: opPutCategory, Category: &category})
+}
+
+func (r *Repo) DeleteCategory(ctx context.Context, categoryID int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.categories[categoryID]; !ok {
+		return fmt.Errorf("category %d: %w", categoryID, app.ErrNotFound)
+	}
+	for _, c := range r.categories {
+		if c.ParentID == categoryID {
+			return fmt.Errorf("%w: category %d has subcategories", app.ErrValidation, categoryID)
+		}
+	}
+	// Объявления из корзины тоже держат категорию: их можно восстановить.
+	for _, ad := range r.ads {
+		if ad.CategoryID == categoryID {
+			return fmt.Errorf("%w: category %d has ads", app.ErrValidation, categoryID)
+		}
+	}
+
+	return r.commit(ctx, record{Op: opDeleteCategory, ID: categoryID})
+}
+
+// checkCategoryParent проверяет, что родитель категории c существует и не
+// является ею самой или ее потомком. Вызывается под r.mu.
+func (r *Repo) checkCategoryParent(c categories.Category) error {
+	for id := c.ParentID; id != 0; id = r.categories[id].ParentID {
+		if c.ID != 0 && id == c.ID {
+			return fmt.Errorf("%w: category %d cannot be moved into itself", app.ErrValidation, c.ID)
+		}
+		if _, ok := r.categories[id]; !ok {
+			return fmt.Errorf("%w: unknown parent category %d", app.ErrValidation, c.ParentID)
+		}
+	}
+
+	return nil
+}
+
+func (r *Repo) ListCategories(_ context.Context) ([]categories.Category, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	list := make([]categories.Category, 0, len(r.categories))
+	for _, category := range r.categories {
+		list = append(list, category)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+
+	return list, nil
+}
+
+func (r *Repo) CountPublishedAds(_ context.Context) (map[int64]int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	counts := make(map[int64]int)
+	for _, ad := range r.ads {
+		if ad.Published && !ad.Trashed() {
+			counts[ad.CategoryID]++
+		}
+	}
+
+	return counts, nil
+}
+
+func (r *Repo) Stats(_ context.Context) (app.Stats, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var stats app.Stats
+	for _, ad := range r.ads {
+		switch {
+		case ad.Trashed():
+			stats.TrashedAds++
+		case ad.Published:
+			stats.PublishedAds++
+			stats.Ads++
+		default:
+			stats.Ads++
+		}
+	}
+	for _, user := range r.users {
+		if user.Trashed() {
+			stats.TrashedUsers++
+		} else {
+			stats.Users++
+		}
+	}
+
+	return stats, nil
+}
+
+func (r *Repo) SetRate(ctx context.Context, rate money.Rate) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.commit(ctx, record{Op: opPutRate, Rate: &rate})
+}
+
+func (r *Repo) ListRates(_ context.Context) ([]money.Rate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	list := make([]money.Rate, 0, len(r.rates))
+	for _, rate := range r.rates {
+		list = append(list, rate)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Currency < list[j].Currency })
+
+	return list, nil
+}
+
+func (r *Repo) ListRevisions(_ context.Context, adID int64) ([]ads.Revision, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if _, ok := r.ads[adID]; !ok {
+		return nil, fmt.Errorf("ad %d: %w", adID, app.ErrNotFound)
+	}
+
+	return append([]ads.Revision{}, r.revisions[adID]...), nil
+}
+
+func (r *Repo) AddFavorite(ctx context.Context, favorite favorites.Favorite) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.ads[favorite.AdID]; !ok {
+		return fmt.Errorf("ad %d: %w", favorite.AdID, app.ErrNotFound)
+	}
+	if _, ok := r.favorites[favorite.UserID][favorite.AdID]; ok {
+		return nil
+	}
+
+	return r.commit(ctx, record{Op: opAddFavorite, Favorite: &favorite})
+}
+
+func (r *Repo) RemoveFavorite(ctx context.Context, userID int64, adID int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.favorites[userID][adID]; !ok {
+		return fmt.Errorf("ad %d in favorites of user %d: %w", adID, userID, app.ErrNotFound)
+	}
+
+	return r.commit(ctx, record{Op: opRemoveFavorite, Favorite: &favorites.Favorite{UserID: userID, AdID: adID}})
+}
+
+func (r *Repo) ListWatchers(_ context.Context, adID int64) ([]int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	list := make([]int64, 0, len(r.watchers[adID]))
+	for userID := range r.watchers[adID] {
+		list = append(list, userID)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i] < list[j] })
+
+	return list, nil
+}
+
+func (r *Repo) AddNotification(ctx context.Context, notification favorites.Notification) (favorites.Notification, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	notification.ID = r.nextNotificationID
+	if err := r.commit(ctx, record{Op: opAddNotification, Notification: &notification}); err != nil {
+		return favorites.Notification{}, err
+	}
+
+	return notification, nil
+}
+
+func (r *Repo) ListNotifications(_ context.Context, userID int64, fromID int64, limit int) ([]favorites.Notification, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	all := r.notifications[userID]
+	start := sort.Search(len(all), func(i int) bool { return all[i].ID >= fromID })
+	list := make([]favorites.Notification, 0)
+	for _, notification := range all[start:] {
+		if limit > 0 && len(list) == limit {
+			break
+		}
+		list = append(list, notification)
+	}
+
+	return list, nil
+}
+
+func (r *Repo) AddThread(ctx context.Context, thread messages.Thread) (messages.Thread, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.ads[thread.AdID]; !ok {
+		return messages.Thread{}, fmt.Errorf("ad %d: %w", thread.AdID, app.ErrNotFound)
+	}
+	for _, existing := range r.threads {
+		if existing.AdID == thread.AdID && existing.BuyerID == thread.BuyerID {
+			return r.withUnread(existing), nil
+		}
+	}
+
+	thread = messages.Thread{
+		ID:        r.nextThreadID,
+		AdID:      thread.AdID,
+		BuyerID:   thread.BuyerID,
+		SellerID:  thread.SellerID,
+		CreatedAt: thread.CreatedAt,
+	}
+	if err := r.commit(ctx, record{Op: opPutThread, Thread: &thread}); err != nil {
+		return messages.Thread{}, err
+	}
+
+	return thread, nil
+}
+
+func (r *Repo) GetThread(_ context.Context, threadID int64) (messages.Thread, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	thread, ok := r.threads[threadID]
+	if !ok {
+		return messages.Thread{}, fmt.Errorf("thread %d: %w", threadID, app.ErrNotFound)
+	}
+
+	return r.withUnread(thread), nil
+}
+
+func (r *Repo) ListThreads(_ context.Context, userID int64, fromID int64, limit int) ([]messages.Thread, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	list := make([]messages.Thread, 0)
+	for _, thread := range r.threads {
+		if thread.ID >= fromID && thread.Participant(userID) {
+			list = append(list, thread)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	if limit > 0 && len(list) > limit {
+		list = list[:limit]
+	}
+	for i := range list {
+		list[i] = r.withUnread(list[i])
+	}
+
+	return list, nil
+}
+
+func (r *Repo) MarkThreadRead(ctx context.Context, threadID int64, userID int64, readID int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	thread, ok := r.threads[threadID]
+	if !ok || !thread.Participant(userID) {
+		return fmt.Errorf("thread %d of user %d: %w", threadID, userID, app.ErrNotFound)
+	}
+	if readID <= thread.ReadID(userID) {
+		return nil
+	}
+
+	if userID == thread.BuyerID {
+		thread.BuyerReadID = readID
+	} else {
+		thread.SellerReadID = readID
+	}
+
+	return r.commit(ctx, record{Op: opPutThread, Thread: &thread})
+}
+
+func (r *Repo) CountUnread(_ context.Context, userID int64) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	unread := 0
+	for _, thread := range r.threads {
+		if thread.Participant(userID) {
+			unread += r.withUnread(thread).Unread(userID)
+		}
+	}
+
+	return unread, nil
+}
+
+func (r *Repo) AddMessage(ctx context.Context, message messages.Message) (messages.Message, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.threads[message.ThreadID]; !ok {
+		return messages.Message{}, fmt.Errorf("thread %d: %w", message.ThreadID, app.ErrNotFound)
+	}
+
+	message.ID = r.nextMessageID
+	if err := r.commit(ctx, record{Op: opAddMessage, Message: &message}); err != nil {
+		return messages.Message{}, err
+	}
+
+	return message, nil
+}
+
+func (r *Repo) ListMessages(_ context.Context, threadID int64, fromID int64, limit int) ([]messages.Message, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if _, ok := r.threads[threadID]; !ok {
+		return nil, fmt.Errorf("thread %d: %w", threadID, app.ErrNotFound)
+	}
+
+	all := r.threadMessages[threadID]
+	start := sort.Search(len(all), func(i int) bool { return all[i].ID >= fromID })
+	list := make([]messages.Message, 0)
+	for _, message := range all[start:] {
+		if limit > 0 && len(list) == limit {
+			break
+		}
+		list = append(list, message)
+	}
+
+	return list, nil
+}
+
+// commit записывает мутацию в журнал (если он есть) и только потом применяет
+// ее к состоянию в памяти. Вызывается под r.mu.
+func (r *Repo) commit(ctx context.Context, rec record) error {
+	if r.journal != nil {
+		if err := r.journal.append(rec); err != nil {
+			return fmt.Errorf("write journal: %w", err)
+		}
+	}
+
+	r.apply(rec)
+
+	if r.journal != nil && r.journal.needsCompaction() {
+		if err := r.journal.compact(r.snapshot()); err != nil {
+			// Мутация уже в журнале, поэтому сжатие можно повторить позже.
+			r.journal.logger.WarnCtx(ctx, "compact journal", "error", err)
+		}
+	}
+
+	return nil
+}
+
+func (r *Repo) apply(rec record) {
+	switch rec.Op {
+	case opPutAd:
+		if _, ok := r.ads[rec.Ad.ID]; !ok {
+			r.insertAdID(rec.Ad.ID)
+		}
+		r.ads[rec.Ad.ID] = *rec.Ad
+		if rec.Ad.ID >= r.nextAdID {
+			r.nextAdID = rec.Ad.ID + 1
+		}
+		if rec.Revision != nil {
+			r.addRevision(*rec.Revision)
+		}
+	case opDeleteAd:
+		if _, ok := r.ads[rec.ID]; ok {
+			r.removeAdID(rec.ID)
+		}
+		delete(r.ads, rec.ID)
+		delete(r.revisions, rec.ID)
+		for userID := range r.watchers[rec.ID] {
+			delete(r.favorites[userID], rec.ID)
+		}
+		delete(r.watchers, rec.ID)
+		r.deleteThreads(func(thread messages.Thread) bool { return thread.AdID == rec.ID })
+	case opPutUser:
+		r.users[rec.User.ID] = *rec.User
+		if rec.User.ID >= r.nextUserID {
+			r.nextUserID = rec.User.ID + 1
+		}
+	case opDeleteUser:
+		delete(r.users, rec.ID)
+		for adID := range r.favorites[rec.ID] {
+			delete(r.watchers[adID], rec.ID)
+		}
+		delete(r.favorites, rec.ID)
+		delete(r.notifications, rec.ID)
+		r.deleteThreads(func(thread messages.Thread) bool { return thread.Participant(rec.ID) })
+	case opPutCategory:
+		r.categories[rec.Category.ID] = *rec.Category
+		if rec.Category.ID >= r.nextCategoryID {
+			r.nextCategoryID = rec.Category.ID + 1
+		}
+	case opDeleteCategory:
+		delete(r.categories, rec.ID)
+	case opPutRate:
+		r.rates[rec.Rate.Currency] = *rec.Rate
+	case opAddRevision:
+		// Отдельными записями история писалась до того, как ее стали
+		// сохранять вместе с объявлением в opPutAd.
+		r.addRevision(*rec.Revision)
+	case opAddFavorite:
+		r.addFavorite(*rec.Favorite)
+	case opRemoveFavorite:
+		delete(r.favorites[rec.Favorite.UserID], rec.Favorite.AdID)
+		delete(r.watchers[rec.Favorite.AdID], rec.Favorite.UserID)
+	case opAddNotification:
+		r.addNotification(*rec.Notification)
+	case opPutThread:
+		r.threads[rec.Thread.ID] = *rec.Thread
+		if rec.Thread.ID >= r.nextThreadID {
+			r.nextThreadID = rec.Thread.ID + 1
+		}
+	case opAddMessage:
+		r.addMessage(*rec.Message)
+	}
+}
+
+// upgradeRecord дополняет записи журнала, сделанные старыми версиями сервиса.
+func upgradeRecord(rec record) record {
+	if rec.Ad != nil {
+		ad := withCategory(*rec.Ad)
+		rec.Ad = &ad
+	}
+
+	return rec
+}
+
+// withCategory относит объявления, записанные до появления категорий, к categories.OtherID.
+func withCategory(ad ads.Ad) ads.Ad {
+	if ad.CategoryID == 0 {
+		ad.CategoryID = categories.OtherID
+	}
+
+	return ad
+}
+
+// addRevision вставляет запись истории с сохранением порядка версий, повторная
+// запись той же версии при воспроизведении журнала заменяет прежнюю.
+func (r *Repo) addRevision(revision ads.Revision) {
+	list := r.revisions[revision.AdID]
+	i := sort.Search(len(list), func(i int) bool { return list[i].Version >= revision.Version })
+	if i < len(list) && list[i].Version == revision.Version {
+		list[i] = revision
+		return
+	}
+
+	list = append(list, ads.Revision{})
+	copy(list[i+1:], list[i:])
+	list[i] = revision
+	r.revisions[revision.AdID] = list
+}
+
+func (r *Repo) addFavorite(favorite favorites.Favorite) {
+	if r.favorites[favorite.UserID] == nil {
+		r.favorites[favorite.UserID] = make(map[int64]time.Time)
+	}
+	r.favorites[favorite.UserID][favorite.AdID] = favorite.CreatedAt
+
+	if r.watchers[favorite.AdID] == nil {
+		r.watchers[favorite.AdID] = make(map[int64]bool)
+	}
+	r.watchers[favorite.AdID][favorite.UserID] = true
+}
+
+// addNotification - addRevision для уведомлений: порядок по ID, повтор ID заменяет прежнее.
+func (r *Repo) addNotification(notification favorites.Notification) {
+	list := r.notifications[notification.UserID]
+	i := sort.Search(len(list), func(i int) bool { return list[i].ID >= notification.ID })
+	if i < len(list) && list[i].ID == notification.ID {
+		list[i] = notification
+	} else {
+		list = append(list, favorites.Notification{})
+		copy(list[i+1:], list[i:])
+		list[i] = notification
+		r.notifications[notification.UserID] = list
+	}
+
+	if notification.ID >= r.nextNotificationID {
+		r.nextNotificationID = notification.ID + 1
+	}
+}
+
+// addMessage - addRevision для сообщений: порядок по ID, повтор ID заменяет прежнее.
+func (r *Repo) addMessage(message messages.Message) {
+	list := r.threadMessages[message.ThreadID]
+	i := sort.Search(len(list), func(i int) bool { return list[i].ID >= message.ID })
+	if i < len(list) && list[i].ID == message.ID {
+		list[i] = message
+	} else {
+		list = append(list, messages.Message{})
+		copy(list[i+1:], list[i:])
+		list[i] = message
+		r.threadMessages[message.ThreadID] = list
+	}
+
+	if message.ID >= r.nextMessageID {
+		r.nextMessageID = message.ID + 1
+	}
+}
+
+// deleteThreads удаляет переписки, для которых match возвращает true, вместе с сообщениями.
+func (r *Repo) deleteThreads(match func(thread messages.Thread) bool) {
+	for id, thread := range r.threads {
+		if match(thread) {
+			delete(r.threads, id)
+			delete(r.threadMessages, id)
+		}
+	}
+}
+
+// withUnread дополняет переписку ID последнего сообщения и счетчиками непрочитанных.
+func (r *Repo) withUnread(thread messages.Thread) messages.Thread {
+	for _, message := range r.threadMessages[thread.ID] {
+		thread.LastMessageID = message.ID
+		if message.SenderID != thread.BuyerID && message.ID > thread.BuyerReadID {
+			thread.BuyerUnread++
+		}
+		if message.SenderID != thread.SellerID && message.ID > thread.SellerReadID {
+			thread.SellerUnread++
+		}
+	}
+
+	return thread
+}
+
+// withFavorites дополняет объявление числом добавлений в избранное.
+func (r *Repo) withFavorites(ad ads.Ad) ads.Ad {
+	ad.Favorites = len(r.watchers[ad.ID])
+	return ad
+}
+
+// matchTrash проверяет объявление по AdFilter.Trash и AdFilter.DeletedBefore.
+func matchTrash(filter app.AdFilter, ad ads.Ad) bool {
+	switch filter.Trash {
+	case app.TrashExcluded:
+		return !ad.Trashed()
+	case app.TrashOnly:
+		return ad.Trashed() && (filter.DeletedBefore.IsZero() || ad.DeletedAt.Before(filter.DeletedBefore))
+	}
+
+	return true
+}
+
+func containsID(ids []int64, id int64) bool {
+	for _, x := range ids {
+		if x == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+// insertAdID добавляет ID в отсортированный r.adIDs. Новые объявления
+// получают максимальный ID, так что обычно это добавление в конец.
+func (r *Repo) insertAdID(id int64) {
+	i := sort.Search(len(r.adIDs), func(i int) bool { return r.adIDs[i] >= id })
+	r.adIDs = append(r.adIDs, 0)
+	copy(r.adIDs[i+1:], r.adIDs[i:])
+	r.adIDs[i] = id
+}
+
+func (r *Repo) removeAdID(id int64) {
+	i := sort.Search(len(r.adIDs), func(i int) bool { return r.adIDs[i] >= id })
+	r.adIDs = append(r.adIDs[:i], r.adIDs[i+1:]...)
+}
+
+func (r *Repo) snapshot() snapshot {
+	snap := snapshot{
+		Ads:        make([]ads.Ad, 0, len(r.ads)),
+		NextAdID:   r.nextAdID,
+		Users:      make([]users.User, 0, len(r.users)),
+		NextUserID: r.nextUserID,
+
+		Categories:     make([]categories.Category, 0, len(r.categories)),
+		NextCategoryID: r.nextCategoryID,
+
+		Rates:     make([]money.Rate, 0, len(r.rates)),
+		Revisions: make([]ads.Revision, 0),
+
+		Favorites:          make([]favorites.Favorite, 0),
+		Notifications:      make([]favorites.Notification, 0),
+		NextNotificationID: r.nextNotificationID,
+
+		Threads:       make([]messages.Thread, 0, len(r.threads)),
+		NextThreadID:  r.nextThreadID,
+		Messages:      make([]messages.Message, 0),
+		NextMessageID: r.nextMessageID,
+	}
+	for _, ad := range r.ads {
+		snap.Ads = append(snap.Ads, ad)
+	}
+	for _, user := range r.users {
+		snap.Users = append(snap.Users, user)
+	}
+	for _, category := range r.categories {
+		snap.Categories = append(snap.Categories, category)
+	}
+	for _, rate := range r.rates {
+		snap.Rates = append(snap.Rates, rate)
+	}
+	for _, list := range r.revisions {
+		snap.Revisions = append(snap.Revisions, list...)
+	}
+	for userID, adIDs := range r.favorites {
+		for adID, createdAt := range adIDs {
+			snap.Favorites = append(snap.Favorites, favorites.Favorite{UserID: userID, AdID: adID, CreatedAt: createdAt})
+		}
+	}
+	for _, list := range r.notifications {
+		snap.Notifications = append(snap.Notifications, list...)
+	}
+	for _, thread := range r.threads {
+		snap.Threads = append(snap.Threads, thread)
+	}
+	for _, list := range r.threadMessages {
+		snap.Messages = append(snap.Messages, list...)
+	}
+
+	return snap
+}
+
+func (r *Repo) restore(snap snapshot) {
+	for _, ad := range snap.Ads {
+		r.ads[ad.ID] = withCategory(ad)
+		r.adIDs = append(r.adIDs, ad.ID)
+	}
+	sort.Slice(r.adIDs, func(i, j int) bool { return r.adIDs[i] < r.adIDs[j] })
+	for _, user := range snap.Users {
+		r.users[user.ID] = user
+	}
+	r.nextAdID = snap.NextAdID
+	r.nextUserID = snap.NextUserID
+	// В снапшотах до появления категорий есть только созданная в newRepo категория "Прочее".
+	for _, category := range snap.Categories {
+		r.categories[category.ID] = category
+	}
+	if snap.NextCategoryID > r.nextCategoryID {
+		r.nextCategoryID = snap.NextCategoryID
+	}
+	for _, rate := range snap.Rates {
+		r.rates[rate.Currency] = rate
+	}
+	for _, revision := range snap.Revisions {
+		r.addRevision(revision)
+	}
+	for _, favorite := range snap.Favorites {
+		r.addFavorite(favorite)
+	}
+	for _, notification := range snap.Notifications {
+		r.addNotification(notification)
+	}
+	if snap.NextNotificationID > r.nextNotificationID {
+		r.nextNotificationID = snap.NextNotificationID
+	}
+	for _, thread := range snap.Threads {
+		r.threads[thread.ID] = thread
+	}
+	if snap.NextThreadID > r.nextThreadID {
+		r.nextThreadID = snap.NextThreadID
+	}
+	for _, message := range snap.Messages {
+		r.addMessage(message)
+	}
+	if snap.NextMessageID > r.nextMessageID {
+		r.nextMessageID = snap.NextMessageID
+	}
+}
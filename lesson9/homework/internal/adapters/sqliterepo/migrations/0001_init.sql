@@ -0,0 +1,19 @@
+CREATE TABLE sequences (
+    name    TEXT PRIMARY KEY,
+    next_id INTEGER NOT NULL
+);
+
+INSERT INTO sequences (name, next_id) VALUES ('ads', 0), ('users', 0);
+
+CREATE TABLE ads (
+    id        INTEGER PRIMARY KEY,
+    title     TEXT    NOT NULL,
+    text      TEXT    NOT NULL,
+    author_id INTEGER NOT NULL,
+    published INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE TABLE users (
+    id   INTEGER PRIMARY KEY,
+    name TEXT NOT NULL
+);
@@ -0,0 +1 @@
+ALTER TABLE ads ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
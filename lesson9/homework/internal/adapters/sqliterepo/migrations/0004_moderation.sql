@@ -0,0 +1,6 @@
+ALTER TABLE users ADD COLUMN role TEXT NOT NULL DEFAULT 'user';
+
+ALTER TABLE ads ADD COLUMN moderation TEXT NOT NULL DEFAULT '';
+ALTER TABLE ads ADD COLUMN moderation_reason TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX ads_moderation ON ads (moderation, id);
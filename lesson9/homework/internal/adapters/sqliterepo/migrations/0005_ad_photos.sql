@@ -0,0 +1,2 @@
+-- Фотографии хранятся JSON массивом: они читаются и меняются только вместе с объявлением.
+ALTER TABLE ads ADD COLUMN photos TEXT NOT NULL DEFAULT '[]';
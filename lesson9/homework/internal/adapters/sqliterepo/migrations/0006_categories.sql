@@ -0,0 +1,13 @@
+CREATE TABLE categories (
+    id        INTEGER PRIMARY KEY,
+    parent_id INTEGER NOT NULL DEFAULT 0,
+    name      TEXT    NOT NULL
+);
+
+-- Категория "Прочее" (categories.OtherID), в нее попадают уже существующие объявления.
+INSERT INTO categories (id, parent_id, name) VALUES (1, 0, 'Прочее');
+INSERT INTO sequences (name, next_id) VALUES ('categories', 2);
+
+ALTER TABLE ads ADD COLUMN category_id INTEGER NOT NULL DEFAULT 1;
+
+CREATE INDEX ads_category ON ads (category_id, id);
@@ -0,0 +1,12 @@
+-- Цена в минимальных единицах валюты, пустая валюта - цена не указана.
+ALTER TABLE ads ADD COLUMN price INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE ads ADD COLUMN currency TEXT NOT NULL DEFAULT '';
+
+-- Время в наносекундах Unix (UTC), 0 - объявление создано до появления дат.
+ALTER TABLE ads ADD COLUMN created_at INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE ads ADD COLUMN updated_at INTEGER NOT NULL DEFAULT 0;
+
+CREATE TABLE exchange_rates (
+    currency TEXT PRIMARY KEY,
+    rate     TEXT NOT NULL
+);
@@ -0,0 +1,11 @@
+CREATE TABLE ad_revisions (
+    ad_id         INTEGER NOT NULL,
+    version       INTEGER NOT NULL,
+    user_id       INTEGER NOT NULL,
+    created_at    INTEGER NOT NULL,
+    reverted_from INTEGER NOT NULL DEFAULT 0,
+    -- ads.Content и []ads.FieldChange в JSON.
+    content       TEXT    NOT NULL,
+    changes       TEXT    NOT NULL,
+    PRIMARY KEY (ad_id, version)
+);
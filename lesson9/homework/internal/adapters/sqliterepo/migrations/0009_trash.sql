@@ -0,0 +1,3 @@
+-- Время перемещения в корзину в наносекундах Unix (UTC), 0 - не в корзине.
+ALTER TABLE ads ADD COLUMN deleted_at INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN deleted_at INTEGER NOT NULL DEFAULT 0;
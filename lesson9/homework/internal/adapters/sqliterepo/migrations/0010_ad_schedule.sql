@@ -0,0 +1,3 @@
+-- Запланированные публикация и снятие с публикации в наносекундах Unix (UTC), 0 - не запланировано.
+ALTER TABLE ads ADD COLUMN publish_at INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE ads ADD COLUMN unpublish_at INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,20 @@
+CREATE TABLE favorites (
+    user_id    INTEGER NOT NULL,
+    ad_id      INTEGER NOT NULL,
+    created_at INTEGER NOT NULL,
+    PRIMARY KEY (user_id, ad_id)
+);
+CREATE INDEX favorites_ad_id ON favorites (ad_id);
+
+CREATE TABLE notifications (
+    id         INTEGER PRIMARY KEY,
+    user_id    INTEGER NOT NULL,
+    ad_id      INTEGER NOT NULL,
+    ad_version INTEGER NOT NULL,
+    -- []ads.FieldChange в JSON.
+    changes    TEXT    NOT NULL,
+    created_at INTEGER NOT NULL
+);
+CREATE INDEX notifications_user_id ON notifications (user_id, id);
+
+INSERT INTO sequences (name, next_id) VALUES ('notifications', 0);
@@ -0,0 +1,24 @@
+CREATE TABLE threads (
+    id             INTEGER PRIMARY KEY,
+    ad_id          INTEGER NOT NULL,
+    buyer_id       INTEGER NOT NULL,
+    seller_id      INTEGER NOT NULL,
+    created_at     INTEGER NOT NULL,
+    buyer_read_id  INTEGER NOT NULL DEFAULT 0,
+    seller_read_id INTEGER NOT NULL DEFAULT 0,
+    UNIQUE (ad_id, buyer_id)
+);
+CREATE INDEX threads_buyer_id ON threads (buyer_id);
+CREATE INDEX threads_seller_id ON threads (seller_id);
+
+CREATE TABLE messages (
+    id         INTEGER PRIMARY KEY,
+    thread_id  INTEGER NOT NULL,
+    sender_id  INTEGER NOT NULL,
+    text       TEXT    NOT NULL,
+    created_at INTEGER NOT NULL
+);
+CREATE INDEX messages_thread_id ON messages (thread_id, id);
+
+-- ID сообщений начинаются с 1: 0 в *_read_id означает, что ничего не прочитано.
+INSERT INTO sequences (name, next_id) VALUES ('threads', 0), ('messages', 1);
@@ -0,0 +1,1055 @@
+package sqliterepo
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite" // регистрирует драйвер "sqlite"
+
+	"homework9/internal/ads"
+	"homework9/internal/app"
+	"homework9/internal/categories"
+	"homework9/internal/favorites"
+	"homework9/internal/messages"
+	"homework9/internal/money"
+	"homework9/internal/users"
+)
+
+// Repo - реализация app.Repository поверх встроенной базы SQLite.
+type Repo struct {
+	db *sql.DB
+}
+
+var _ app.Repository = (*Repo)(nil)
+
+// adColumns - столбцы таблицы ads в порядке полей, которые читает scanAd.
+const adColumns = `id, title, text, author_id, published, version, moderation, moderation_reason, photos,
+	category_id, price, currency, created_at, updated_at, deleted_at, publish_at, unpublish_at`
+
+// adSelect - adColumns и число добавлений в избранное, которое scanAd читает последним.
+const adSelect = adColumns + `, (SELECT COUNT(*) FROM favorites WHERE favorites.ad_id = ads.id)`
+
+// threadSelect выбирает переписки с ID последнего сообщения и счетчиками
+// непрочитанных в порядке полей, которые читает scanThread.
+const threadSelect = `SELECT id, ad_id, buyer_id, seller_id, created_at, buyer_read_id, seller_read_id,
+	(SELECT COALESCE(MAX(id), 0) FROM messages WHERE thread_id = threads.id),
+	(SELECT COUNT(*) FROM messages
+		WHERE thread_id = threads.id AND sender_id <> threads.buyer_id AND id > threads.buyer_read_id),
+	(SELECT COUNT(*) FROM messages
+		WHERE thread_id = threads.id AND sender_id <> threads.seller_id AND id > threads.seller_read_id)
+	FROM threads`
+
+// userColumns - столбцы таблицы users в порядке полей, которые читает scanUser.
+const userColumns = `id, name, password_hash, role, deleted_at`
+
+// New открывает базу по пути path (":memory:" - база в памяти) и применяет миграции.
+func New(ctx context.Context, path string) (*Repo, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("open sqlite %q: %w", path, err)
+	}
+	// SQLite допускает только одного писателя, а база ":memory:" существует
+	// в рамках одного соединения, поэтому держим ровно одно соединение.
+	db.SetMaxOpenConns(1)
+
+	if err := migrate(ctx, db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("migrate sqlite %q: %w", path, err)
+	}
+
+	return &Repo{db: db}, nil
+}
+
+func (r *Repo) Close() error {
+	return r.db.Close()
+}
+
+func (r *Repo) Ping(ctx context.Context) error {
+	return r.db.PingContext(ctx)
+}
+
+func (r *Repo) AddAd(ctx context.Context, ad ads.Ad, revision *ads.Revision) (ads.Ad, error) {
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		if err := checkAdCategory(ctx, tx, ad); err != nil {
+			return err
+		}
+
+		id, err := nextID(ctx, tx, "ads")
+		if err != nil {
+			return err
+		}
+		ad.ID = id
+
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO ads (`+adColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			ad.ID, ad.Title, ad.Text, ad.AuthorID, ad.Published, ad.Version,
+			ad.Moderation, ad.ModerationReason, encodePhotos(ad.Photos), ad.CategoryID,
+			ad.Price.Amount, ad.Price.Currency, encodeTime(ad.CreatedAt), encodeTime(ad.UpdatedAt),
+			encodeTime(ad.DeletedAt), encodeTime(ad.PublishAt), encodeTime(ad.UnpublishAt))
+		if err != nil {
+			return err
+		}
+
+		return addRevision(ctx, tx, ad, revision)
+	})
+	if err != nil {
+		return ads.Ad{}, err
+	}
+
+	return ad, nil
+}
+
+func (r *Repo) GetAd(ctx context.Context, adID int64) (ads.Ad, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+adSelect+` FROM ads WHERE id = ?`, adID)
+
+	ad, err := scanAd(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ads.Ad{}, fmt.Errorf("ad %d: %w", adID, app.ErrNotFound)
+	}
+
+	return ad, err
+}
+
+func (r *Repo) UpdateAd(ctx context.Context, ad ads.Ad, revision *ads.Revision) error {
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		if err := checkAdCategory(ctx, tx, ad); err != nil {
+			return err
+		}
+
+		res, err := tx.ExecContext(ctx,
+			`UPDATE ads SET title = ?, text = ?, author_id = ?, published = ?,
+				moderation = ?, moderation_reason = ?, photos = ?, category_id = ?,
+				price = ?, currency = ?, created_at = ?, updated_at = ?, deleted_at = ?,
+				publish_at = ?, unpublish_at = ?, version = version + 1
+			WHERE id = ? AND version = ?`,
+			ad.Title, ad.Text, ad.AuthorID, ad.Published,
+			ad.Moderation, ad.ModerationReason, encodePhotos(ad.Photos), ad.CategoryID,
+			ad.Price.Amount, ad.Price.Currency, encodeTime(ad.CreatedAt), encodeTime(ad.UpdatedAt),
+			encodeTime(ad.DeletedAt), encodeTime(ad.PublishAt), encodeTime(ad.UnpublishAt), ad.ID, ad.Version)
+		if err != nil {
+			return err
+		}
+
+		n, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			// Ничего не обновилось: либо объявления нет, либо у него уже другая версия.
+			var exists int
+			err := tx.QueryRowContext(ctx, `SELECT 1 FROM ads WHERE id = ?`, ad.ID).Scan(&exists)
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("ad %d: %w", ad.ID, app.ErrNotFound)
+			}
+			if err != nil {
+				return err
+			}
+
+			return fmt.Errorf("ad %d: %w", ad.ID, app.ErrVersionConflict)
+		}
+
+		ad.Version++
+		return addRevision(ctx, tx, ad, revision)
+	})
+}
+
+func (r *Repo) DeleteAd(ctx context.Context, adID int64) error {
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, `DELETE FROM ads WHERE id = ?`, adID)
+		if err != nil {
+			return err
+		}
+		if err := checkAffected(res, "ad", adID); err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, `DELETE FROM ad_revisions WHERE ad_id = ?`, adID); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM favorites WHERE ad_id = ?`, adID); err != nil {
+			return err
+		}
+		return deleteThreads(ctx, tx, `ad_id = ?`, adID)
+	})
+}
+
+func (r *Repo) ListAds(ctx context.Context, filter app.AdFilter) ([]ads.Ad, error) {
+	limit := -1 // в SQLite отрицательный LIMIT означает "без ограничения"
+	if filter.Limit > 0 {
+		limit = filter.Limit
+	}
+
+	from, args := adsWithKeys(filter)
+	query := `SELECT ` + adSelect + ` FROM ` + from + `
+		WHERE id >= ? AND (published = 1 OR NOT ?) AND (? = '' OR moderation = ?)`
+	args = append(args, filter.FromID, filter.PublishedOnly, filter.Moderation, filter.Moderation)
+	switch filter.Trash {
+	case app.TrashExcluded:
+		query += ` AND deleted_at = 0`
+	case app.TrashOnly:
+		query += ` AND deleted_at != 0`
+		if !filter.DeletedBefore.IsZero() {
+			query += ` AND deleted_at < ?`
+			args = append(args, encodeTime(filter.DeletedBefore))
+		}
+	}
+	if filter.AuthorID != nil {
+		query += ` AND author_id = ?`
+		args = append(args, *filter.AuthorID)
+	}
+	if filter.Scheduled {
+		query += ` AND (publish_at != 0 OR unpublish_at != 0)`
+	}
+	if filter.FavoritedBy != nil {
+		query += ` AND id IN (SELECT ad_id FROM favorites WHERE user_id = ?)`
+		args = append(args, *filter.FavoritedBy)
+	}
+	if len(filter.CategoryIDs) > 0 {
+		query += ` AND category_id IN (?` + strings.Repeat(`, ?`, len(filter.CategoryIDs)-1) + `)`
+		for _, id := range filter.CategoryIDs {
+			args = append(args, id)
+		}
+	}
+	if filter.MinPriceKey != nil {
+		query += ` AND price_key >= ?`
+		args = append(args, *filter.MinPriceKey)
+	}
+	if filter.MaxPriceKey != nil {
+		query += ` AND price_key <= ?`
+		args = append(args, *filter.MaxPriceKey)
+	}
+
+	cmp, dir := `>`, ``
+	if filter.Desc {
+		cmp, dir = `<`, ` DESC`
+	}
+	switch {
+	case filter.After == nil:
+	case filter.After.Key == nil:
+		query += ` AND sort_key IS NULL AND id ` + cmp + ` ?`
+		args = append(args, filter.After.ID)
+	default:
+		query += ` AND (sort_key ` + cmp + ` ? OR sort_key = ? AND id ` + cmp + ` ? OR sort_key IS NULL)`
+		args = append(args, *filter.After.Key, *filter.After.Key, filter.After.ID)
+	}
+	if filter.Sort == app.SortByID {
+		query += ` ORDER BY id` + dir
+	} else {
+		query += ` ORDER BY sort_key IS NULL, sort_key` + dir + `, id` + dir
+	}
+	query += ` LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]ads.Ad, 0)
+	for rows.Next() {
+		ad, err := scanAd(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, ad)
+	}
+
+	return list, rows.Err()
+}
+
+// adsWithKeys возвращает источник строк для ListAds: таблицу ads, а для
+// сортировки не по ID и фильтров по цене - ее же со столбцами sort_key и
+// price_key, которые вычисляются так же, как app.AdFilter.KeyOf и PriceKey.
+func adsWithKeys(filter app.AdFilter) (string, []any) {
+	if filter.Sort == app.SortByID && !filter.Desc && filter.After == nil &&
+		filter.MinPriceKey == nil && filter.MaxPriceKey == nil {
+		return `ads`, nil
+	}
+
+	// При переполнении произведения SQLite переходит к REAL, а MIN возвращает
+	// ключ к math.MaxInt64, как PriceKey.
+	price, args := `NULL`, []any{}
+	if len(filter.PriceFactors) > 0 {
+		price = `CASE currency`
+		for currency, factor := range filter.PriceFactors {
+			price += ` WHEN ? THEN MIN(price * ?, 9223372036854775807)`
+			args = append(args, currency, factor)
+		}
+		price += ` END`
+	}
+
+	var key string
+	switch filter.Sort {
+	case app.SortByPrice:
+		key = `price_key`
+	case app.SortByCreated:
+		key = `NULLIF(created_at, 0)`
+	case app.SortByUpdated:
+		key = `NULLIF(updated_at, 0)`
+	default:
+		key = `NULL`
+	}
+
+	return `(SELECT *, ` + key + ` AS sort_key FROM (SELECT *, ` + price + ` AS price_key FROM ads)) AS ads`, args
+}
+
+func (r *Repo) AddUser(ctx context.Context, user users.User) (users.User, error) {
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		id, err := nextID(ctx, tx, "users")
+		if err != nil {
+			return err
+		}
+		user.ID = id
+
+		_, err = tx.ExecContext(ctx, `INSERT INTO users (`+userColumns+`) VALUES (?, ?, ?, ?, ?)`,
+			user.ID, user.Name, user.PasswordHash, user.Role, encodeTime(user.DeletedAt))
+		return err
+	})
+	if err != nil {
+		return users.User{}, err
+	}
+
+	return user, nil
+}
+
+func (r *Repo) GetUser(ctx context.Context, userID int64) (users.User, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+userColumns+` FROM users WHERE id = ?`, userID)
+
+	user, err := scanUser(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return users.User{}, fmt.Errorf("user %d: %w", userID, app.ErrNotFound)
+	}
+
+	return user, err
+}
+
+func (r *Repo) UpdateUser(ctx context.Context, user users.User) error {
+	res, err := r.db.ExecContext(ctx, `UPDATE users SET name = ?, password_hash = ?, role = ?, deleted_at = ? WHERE id = ?`,
+		user.Name, user.PasswordHash, user.Role, encodeTime(user.DeletedAt), user.ID)
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(res, "user", user.ID)
+}
+
+func (r *Repo) DeleteUser(ctx context.Context, userID int64) error {
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, `DELETE FROM users WHERE id = ?`, userID)
+		if err != nil {
+			return err
+		}
+		if err := checkAffected(res, "user", userID); err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, `DELETE FROM favorites WHERE user_id = ?`, userID); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM notifications WHERE user_id = ?`, userID); err != nil {
+			return err
+		}
+		return deleteThreads(ctx, tx, `buyer_id = ? OR seller_id = ?`, userID, userID)
+	})
+}
+
+func (r *Repo) ListTrashedUsers(ctx context.Context, deletedBefore time.Time) ([]users.User, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+userColumns+` FROM users WHERE deleted_at != 0 AND (? = 0 OR deleted_at < ?) ORDER BY id`,
+		encodeTime(deletedBefore), encodeTime(deletedBefore))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]users.User, 0)
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, user)
+	}
+
+	return list, rows.Err()
+}
+
+func (r *Repo) AddCategory(ctx context.Context, category categories.Category) (categories.Category, error) {
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		if err := checkCategoryParent(ctx, tx, category); err != nil {
+			return err
+		}
+
+		id, err := nextID(ctx, tx, "categories")
+		if err != nil {
+			return err
+		}
+		category.ID = id
+
+		_, err = tx.ExecContext(ctx, `INSERT INTO categories (id, parent_id, name) VALUES (?, ?, ?)`,
+			category.ID, category.ParentID, category.Name)
+		return err
+	})
+	if err != nil {
+		return categories.Category{}, err
+	}
+
+	return category, nil
+}
+
+func (r *Repo) GetCategory(ctx context.Context, categoryID int64) (categories.Category, error) {
+	var category categories.Category
+	err := r.db.QueryRowContext(ctx, `SELECT id, parent_id, name FROM categories WHERE id = ?`, categoryID).
+		Scan(&category.ID, &category.ParentID, &category.Name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return categories.Category{}, fmt.Errorf("category %d: %w", categoryID, app.ErrNotFound)
+	}
+
+	return category, err
+}
+
+func (r *Repo) UpdateCategory(ctx context.Context, category categories.Category) error {
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		if err := checkCategoryParent(ctx, tx, category); err != nil {
+			return err
+		}
+
+		res, err := tx.ExecContext(ctx, `UPDATE categories SET parent_id = ?, name = ? WHERE id = ?`,
+			category.ParentID, category.Name, category.ID)
+		if err != nil {
+			return err
+		}
+
+		return checkAffected(res, "category", category.ID)
+	})
+}
+
+func (r *Repo) DeleteCategory(ctx context.Context, categoryID int64) error {
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		var hasChildren, hasAds bool
+		err := tx.QueryRowContext(ctx, `SELECT
+			EXISTS (SELECT 1 FROM categories WHERE parent_id = ?),
+			EXISTS (SELECT 1 FROM ads WHERE category_id = ?)`, categoryID, categoryID).
+			Scan(&hasChildren, &hasAds)
+		switch {
+		case err != nil:
+			return err
+		case hasChildren:
+			return fmt.Errorf("%w: category %d has subcategories", app.ErrValidation, categoryID)
+		case hasAds:
+			// Объявления из корзины тоже держат категорию: их можно восстановить.
+			return fmt.Errorf("%w: category %d has ads", app.ErrValidation, categoryID)
+		}
+
+		res, err := tx.ExecContext(ctx, `DELETE FROM categories WHERE id = ?`, categoryID)
+		if err != nil {
+			return err
+		}
+
+		return checkAffected(res, "category", categoryID)
+	})
+}
+
+// checkAdCategory проверяет в транзакции, что категория объявления существует.
+func checkAdCategory(ctx context.Context, tx *sql.Tx, ad ads.Ad) error {
+	var exists bool
+	err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM categories WHERE id = ?)`, ad.CategoryID).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("%w: unknown category %d", app.ErrValidation, ad.CategoryID)
+	}
+
+	return nil
+}
+
+// checkCategoryParent проверяет в транзакции, что родитель категории c
+// существует и не является ею самой или ее потомком.
+func checkCategoryParent(ctx context.Context, tx *sql.Tx, c categories.Category) error {
+	for id := c.ParentID; id != 0; {
+		if c.ID != 0 && id == c.ID {
+			return fmt.Errorf("%w: category %d cannot be moved into itself", app.ErrValidation, c.ID)
+		}
+
+		err := tx.QueryRowContext(ctx, `SELECT parent_id FROM categories WHERE id = ?`, id).Scan(&id)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: unknown parent category %d", app.ErrValidation, c.ParentID)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *Repo) ListCategories(ctx context.Context) ([]categories.Category, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT id, parent_id, name FROM categories ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]categories.Category, 0)
+	for rows.Next() {
+		var category categories.Category
+		if err := rows.Scan(&category.ID, &category.ParentID, &category.Name); err != nil {
+			return nil, err
+		}
+		list = append(list, category)
+	}
+
+	return list, rows.Err()
+}
+
+func (r *Repo) CountPublishedAds(ctx context.Context) (map[int64]int, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT category_id, COUNT(*) FROM ads WHERE published = 1 AND deleted_at = 0 GROUP BY category_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[int64]int)
+	for rows.Next() {
+		var categoryID int64
+		var n int
+		if err := rows.Scan(&categoryID, &n); err != nil {
+			return nil, err
+		}
+		counts[categoryID] = n
+	}
+
+	return counts, rows.Err()
+}
+
+func (r *Repo) Stats(ctx context.Context) (app.Stats, error) {
+	var stats app.Stats
+	err := r.db.QueryRowContext(ctx,
+		`SELECT
+			(SELECT COUNT(*) FROM ads WHERE deleted_at = 0),
+			(SELECT COUNT(*) FROM ads WHERE deleted_at = 0 AND published = 1),
+			(SELECT COUNT(*) FROM ads WHERE deleted_at != 0),
+			(SELECT COUNT(*) FROM users WHERE deleted_at = 0),
+			(SELECT COUNT(*) FROM users WHERE deleted_at != 0)`,
+	).Scan(&stats.Ads, &stats.PublishedAds, &stats.TrashedAds, &stats.Users, &stats.TrashedUsers)
+	if err != nil {
+		return app.Stats{}, err
+	}
+
+	return stats, nil
+}
+
+func (r *Repo) SetRate(ctx context.Context, rate money.Rate) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO exchange_rates (currency, rate) VALUES (?, ?)
+		ON CONFLICT (currency) DO UPDATE SET rate = excluded.rate`,
+		rate.Currency, rate.Value)
+	return err
+}
+
+func (r *Repo) ListRates(ctx context.Context) ([]money.Rate, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT currency, rate FROM exchange_rates ORDER BY currency`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]money.Rate, 0)
+	for rows.Next() {
+		var rate money.Rate
+		if err := rows.Scan(&rate.Currency, &rate.Value); err != nil {
+			return nil, err
+		}
+		list = append(list, rate)
+	}
+
+	return list, rows.Err()
+}
+
+// addRevision добавляет в транзакции tx запись истории с ID и версией
+// сохраненного объявления ad, если revision не nil.
+func addRevision(ctx context.Context, tx *sql.Tx, ad ads.Ad, revision *ads.Revision) error {
+	if revision == nil {
+		return nil
+	}
+
+	content, err := json.Marshal(revision.Content)
+	if err != nil {
+		return err
+	}
+	changes, err := json.Marshal(revision.Changes)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO ad_revisions (ad_id, version, user_id, created_at, reverted_from, content, changes)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		ad.ID, ad.Version, revision.UserID, encodeTime(revision.CreatedAt), revision.RevertedFrom,
+		string(content), string(changes))
+	return err
+}
+
+func (r *Repo) ListRevisions(ctx context.Context, adID int64) ([]ads.Revision, error) {
+	if _, err := r.GetAd(ctx, adID); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT ad_id, version, user_id, created_at, reverted_from, content, changes
+		FROM ad_revisions WHERE ad_id = ? ORDER BY version`, adID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]ads.Revision, 0)
+	for rows.Next() {
+		var (
+			revision         ads.Revision
+			createdAt        int64
+			content, changes string
+		)
+		err := rows.Scan(&revision.AdID, &revision.Version, &revision.UserID, &createdAt, &revision.RevertedFrom,
+			&content, &changes)
+		if err != nil {
+			return nil, err
+		}
+		revision.CreatedAt = decodeTime(createdAt)
+
+		if err := json.Unmarshal([]byte(content), &revision.Content); err != nil {
+			return nil, fmt.Errorf("ad %d revision %d: %w", adID, revision.Version, err)
+		}
+		if err := json.Unmarshal([]byte(changes), &revision.Changes); err != nil {
+			return nil, fmt.Errorf("ad %d revision %d: %w", adID, revision.Version, err)
+		}
+		list = append(list, revision)
+	}
+
+	return list, rows.Err()
+}
+
+func (r *Repo) AddFavorite(ctx context.Context, favorite favorites.Favorite) error {
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		var exists bool
+		err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM ads WHERE id = ?)`, favorite.AdID).Scan(&exists)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("ad %d: %w", favorite.AdID, app.ErrNotFound)
+		}
+
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO favorites (user_id, ad_id, created_at) VALUES (?, ?, ?) ON CONFLICT DO NOTHING`,
+			favorite.UserID, favorite.AdID, encodeTime(favorite.CreatedAt))
+		return err
+	})
+}
+
+func (r *Repo) RemoveFavorite(ctx context.Context, userID int64, adID int64) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM favorites WHERE user_id = ? AND ad_id = ?`, userID, adID)
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(res, "favorite ad", adID)
+}
+
+func (r *Repo) ListWatchers(ctx context.Context, adID int64) ([]int64, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT user_id FROM favorites WHERE ad_id = ? ORDER BY user_id`, adID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]int64, 0)
+	for rows.Next() {
+		var userID int64
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		list = append(list, userID)
+	}
+
+	return list, rows.Err()
+}
+
+func (r *Repo) AddNotification(ctx context.Context, notification favorites.Notification) (favorites.Notification, error) {
+	changes, err := json.Marshal(notification.Changes)
+	if err != nil {
+		return favorites.Notification{}, err
+	}
+
+	err = r.inTx(ctx, func(tx *sql.Tx) error {
+		id, err := nextID(ctx, tx, "notifications")
+		if err != nil {
+			return err
+		}
+		notification.ID = id
+
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO notifications (id, user_id, ad_id, ad_version, changes, created_at) VALUES (?, ?, ?, ?, ?, ?)`,
+			notification.ID, notification.UserID, notification.AdID, notification.AdVersion, string(changes),
+			encodeTime(notification.CreatedAt))
+		return err
+	})
+	if err != nil {
+		return favorites.Notification{}, err
+	}
+
+	return notification, nil
+}
+
+func (r *Repo) ListNotifications(ctx context.Context, userID int64, fromID int64, limit int) ([]favorites.Notification, error) {
+	if limit <= 0 {
+		limit = -1
+	}
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id, user_id, ad_id, ad_version, changes, created_at
+		FROM notifications WHERE user_id = ? AND id >= ? ORDER BY id LIMIT ?`, userID, fromID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]favorites.Notification, 0)
+	for rows.Next() {
+		var (
+			notification favorites.Notification
+			changes      string
+			createdAt    int64
+		)
+		err := rows.Scan(&notification.ID, &notification.UserID, &notification.AdID, &notification.AdVersion,
+			&changes, &createdAt)
+		if err != nil {
+			return nil, err
+		}
+		notification.CreatedAt = decodeTime(createdAt)
+
+		if err := json.Unmarshal([]byte(changes), &notification.Changes); err != nil {
+			return nil, fmt.Errorf("notification %d: %w", notification.ID, err)
+		}
+		list = append(list, notification)
+	}
+
+	return list, rows.Err()
+}
+
+func (r *Repo) AddThread(ctx context.Context, thread messages.Thread) (messages.Thread, error) {
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		var exists bool
+		err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM ads WHERE id = ?)`, thread.AdID).Scan(&exists)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("ad %d: %w", thread.AdID, app.ErrNotFound)
+		}
+
+		err = tx.QueryRowContext(ctx, `SELECT id FROM threads WHERE ad_id = ? AND buyer_id = ?`,
+			thread.AdID, thread.BuyerID).Scan(&thread.ID)
+		if !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		thread.ID, err = nextID(ctx, tx, "threads")
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO threads (id, ad_id, buyer_id, seller_id, created_at) VALUES (?, ?, ?, ?, ?)`,
+			thread.ID, thread.AdID, thread.BuyerID, thread.SellerID, encodeTime(thread.CreatedAt))
+		return err
+	})
+	if err != nil {
+		return messages.Thread{}, err
+	}
+
+	return r.GetThread(ctx, thread.ID)
+}
+
+func (r *Repo) GetThread(ctx context.Context, threadID int64) (messages.Thread, error) {
+	row := r.db.QueryRowContext(ctx, threadSelect+` WHERE id = ?`, threadID)
+
+	thread, err := scanThread(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return messages.Thread{}, fmt.Errorf("thread %d: %w", threadID, app.ErrNotFound)
+	}
+
+	return thread, err
+}
+
+func (r *Repo) ListThreads(ctx context.Context, userID int64, fromID int64, limit int) ([]messages.Thread, error) {
+	if limit <= 0 {
+		limit = -1
+	}
+
+	rows, err := r.db.QueryContext(ctx,
+		threadSelect+` WHERE (buyer_id = ? OR seller_id = ?) AND id >= ? ORDER BY id LIMIT ?`,
+		userID, userID, fromID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]messages.Thread, 0)
+	for rows.Next() {
+		thread, err := scanThread(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, thread)
+	}
+
+	return list, rows.Err()
+}
+
+func (r *Repo) MarkThreadRead(ctx context.Context, threadID int64, userID int64, readID int64) error {
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE threads SET
+			buyer_read_id = CASE WHEN buyer_id = ? THEN MAX(buyer_read_id, ?) ELSE buyer_read_id END,
+			seller_read_id = CASE WHEN seller_id = ? THEN MAX(seller_read_id, ?) ELSE seller_read_id END
+		WHERE id = ? AND (buyer_id = ? OR seller_id = ?)`,
+		userID, readID, userID, readID, threadID, userID, userID)
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(res, "thread", threadID)
+}
+
+func (r *Repo) CountUnread(ctx context.Context, userID int64) (int, error) {
+	var unread int
+	err := r.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM messages JOIN threads ON threads.id = messages.thread_id
+		WHERE messages.sender_id <> ? AND (
+			(threads.buyer_id = ? AND messages.id > threads.buyer_read_id) OR
+			(threads.seller_id = ? AND messages.id > threads.seller_read_id))`,
+		userID, userID, userID).Scan(&unread)
+
+	return unread, err
+}
+
+func (r *Repo) AddMessage(ctx context.Context, message messages.Message) (messages.Message, error) {
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		var exists bool
+		err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM threads WHERE id = ?)`, message.ThreadID).
+			Scan(&exists)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("thread %d: %w", message.ThreadID, app.ErrNotFound)
+		}
+
+		message.ID, err = nextID(ctx, tx, "messages")
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO messages (id, thread_id, sender_id, text, created_at) VALUES (?, ?, ?, ?, ?)`,
+			message.ID, message.ThreadID, message.SenderID, message.Text, encodeTime(message.CreatedAt))
+		return err
+	})
+	if err != nil {
+		return messages.Message{}, err
+	}
+
+	return message, nil
+}
+
+func (r *Repo) ListMessages(ctx context.Context, threadID int64, fromID int64, limit int) ([]messages.Message, error) {
+	if _, err := r.GetThread(ctx, threadID); err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = -1
+	}
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id, thread_id, sender_id, text, created_at
+		FROM messages WHERE thread_id = ? AND id >= ? ORDER BY id LIMIT ?`, threadID, fromID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]messages.Message, 0)
+	for rows.Next() {
+		var (
+			message   messages.Message
+			createdAt int64
+		)
+		if err := rows.Scan(&message.ID, &message.ThreadID, &message.SenderID, &message.Text, &createdAt); err != nil {
+			return nil, err
+		}
+		message.CreatedAt = decodeTime(createdAt)
+		list = append(list, message)
+	}
+
+	return list, rows.Err()
+}
+
+// deleteThreads удаляет переписки, подходящие под условие where, вместе с сообщениями.
+func deleteThreads(ctx context.Context, tx *sql.Tx, where string, args ...any) error {
+	_, err := tx.ExecContext(ctx,
+		`DELETE FROM messages WHERE thread_id IN (SELECT id FROM threads WHERE `+where+`)`, args...)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM threads WHERE `+where, args...)
+	return err
+}
+
+func (r *Repo) inTx(ctx context.Context, f func(tx *sql.Tx) error) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	if err := f(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// nextID выдает следующий идентификатор из таблицы sequences. В отличие от
+// AUTOINCREMENT, нумерация начинается с 0, как и в adrepo.
+func nextID(ctx context.Context, tx *sql.Tx, sequence string) (int64, error) {
+	var id int64
+	err := tx.QueryRowContext(ctx, `SELECT next_id FROM sequences WHERE name = ?`, sequence).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("sequence %s: %w", sequence, err)
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE sequences SET next_id = ? WHERE name = ?`, id+1, sequence)
+	if err != nil {
+		return 0, fmt.Errorf("sequence %s: %w", sequence, err)
+	}
+
+	return id, nil
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanAd(s scanner) (ads.Ad, error) {
+	var (
+		ad                   ads.Ad
+		photos               string
+		createdAt, updatedAt int64
+		deletedAt            int64
+		publishAt            int64
+		unpublishAt          int64
+	)
+	err := s.Scan(&ad.ID, &ad.Title, &ad.Text, &ad.AuthorID, &ad.Published, &ad.Version,
+		&ad.Moderation, &ad.ModerationReason, &photos, &ad.CategoryID,
+		&ad.Price.Amount, &ad.Price.Currency, &createdAt, &updatedAt, &deletedAt,
+		&publishAt, &unpublishAt, &ad.Favorites)
+	if err != nil {
+		return ads.Ad{}, err
+	}
+	ad.CreatedAt = decodeTime(createdAt)
+	ad.UpdatedAt = decodeTime(updatedAt)
+	ad.DeletedAt = decodeTime(deletedAt)
+	ad.PublishAt = decodeTime(publishAt)
+	ad.UnpublishAt = decodeTime(unpublishAt)
+
+	ad.Photos, err = decodePhotos(photos)
+	if err != nil {
+		return ads.Ad{}, fmt.Errorf("ad %d photos: %w", ad.ID, err)
+	}
+
+	return ad, nil
+}
+
+func scanThread(s scanner) (messages.Thread, error) {
+	var (
+		thread    messages.Thread
+		createdAt int64
+	)
+	err := s.Scan(&thread.ID, &thread.AdID, &thread.BuyerID, &thread.SellerID, &createdAt,
+		&thread.BuyerReadID, &thread.SellerReadID, &thread.LastMessageID, &thread.BuyerUnread, &thread.SellerUnread)
+	if err != nil {
+		return messages.Thread{}, err
+	}
+	thread.CreatedAt = decodeTime(createdAt)
+
+	return thread, nil
+}
+
+func scanUser(s scanner) (users.User, error) {
+	var (
+		user      users.User
+		deletedAt int64
+	)
+	err := s.Scan(&user.ID, &user.Name, &user.PasswordHash, &user.Role, &deletedAt)
+	if err != nil {
+		return users.User{}, err
+	}
+	user.DeletedAt = decodeTime(deletedAt)
+
+	return user, nil
+}
+
+func encodePhotos(photos []ads.Photo) string {
+	if len(photos) == 0 {
+		return "[]"
+	}
+
+	data, _ := json.Marshal(photos)
+	return string(data)
+}
+
+// decodePhotos возвращает nil для пустого списка, как и adrepo.
+func decodePhotos(s string) ([]ads.Photo, error) {
+	if s == "" || s == "[]" {
+		return nil, nil
+	}
+
+	var photos []ads.Photo
+	err := json.Unmarshal([]byte(s), &photos)
+	return photos, err
+}
+
+// encodeTime хранит время в наносекундах Unix, нулевое время - 0.
+func encodeTime(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+
+	return t.UnixNano()
+}
+
+func decodeTime(ns int64) time.Time {
+	if ns == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(0, ns).UTC()
+}
+
+func checkAffected(res sql.Result, entity string, id int64) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("%s %d: %w", entity, id, app.ErrNotFound)
+	}
+
+	return nil
+}
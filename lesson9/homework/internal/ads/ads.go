@@ -1,9 +1,70 @@
 package ads
 
+import (
+	"time"
+
+	"homework9/internal/money"
+)
+
 type Ad struct {
-	ID        int64
-	Title     string
-	Text      string
-	AuthorID  int64
-	Published bool
+	ID       int64
+	Title    string
+	Text     string
+	AuthorID int64
+	// CategoryID - категория объявления, см. пакет categories.
+	CategoryID int64
+	Published  bool
+	// Version увеличивается при каждом изменении объявления, начиная с 1.
+	Version int64
+	// Moderation - состояние проверки модератором, ModerationReason - причина
+	// отклонения, которую видит автор.
+	Moderation       Moderation
+	ModerationReason string
+	// Photos - фотографии в порядке показа.
+	Photos []Photo
+	// Price - цена, нулевое значение - цена не указана.
+	Price money.Money
+	// CreatedAt и UpdatedAt (в UTC) нулевые у объявлений, созданных до их появления.
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	// PublishAt и UnpublishAt (в UTC) - когда опубликовать объявление и снять
+	// его с публикации, нулевые - не запланировано. Сбрасываются после выполнения.
+	PublishAt   time.Time
+	UnpublishAt time.Time
+	// DeletedAt - время перемещения в корзину (UTC), нулевое - объявление не в корзине.
+	DeletedAt time.Time
+	// Favorites - сколько пользователей добавили объявление в избранное.
+	// Заполняется хранилищем при чтении, UpdateAd его не сохраняет.
+	Favorites int
+}
+
+// Scheduled сообщает, запланирована ли публикация или снятие с публикации.
+func (ad Ad) Scheduled() bool {
+	return !ad.PublishAt.IsZero() || !ad.UnpublishAt.IsZero()
+}
+
+// Trashed сообщает, находится ли объявление в корзине.
+func (ad Ad) Trashed() bool {
+	return !ad.DeletedAt.IsZero()
+}
+
+// Photo - загруженная фотография. Содержимое оригинала и миниатюр хранится
+// в app.BlobStore.
+type Photo struct {
+	ID          string
+	ContentType string
+	Width       int
+	Height      int
+	Size        int64
 }
+
+// Moderation - состояние проверки объявления модератором.
+type Moderation string
+
+const (
+	// ModerationNone - объявление не отправлялось на проверку.
+	ModerationNone     Moderation = ""
+	ModerationPending  Moderation = "pending"
+	ModerationApproved Moderation = "approved"
+	ModerationRejected Moderation = "rejected"
+)
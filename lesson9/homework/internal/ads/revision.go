@@ -0,0 +1,79 @@
+package ads
+
+import (
+	"strconv"
+	"time"
+
+	"homework9/internal/money"
+)
+
+// Revision - запись истории объявления: его содержимое после изменения,
+// которое дало версию Version, кто и когда его сделал и что изменилось.
+type Revision struct {
+	AdID    int64
+	Version int64
+	// UserID - пользователь, сделавший изменение (автор или модератор).
+	UserID    int64
+	CreatedAt time.Time
+	// RevertedFrom - версия, к содержимому которой вернули объявление, 0 - обычное изменение.
+	RevertedFrom int64
+	Content      Content
+	Changes      []FieldChange
+}
+
+// Content - поля объявления, которые сохраняются в истории.
+type Content struct {
+	Title      string
+	Text       string
+	CategoryID int64
+	Price      money.Money
+	Published  bool
+	Moderation Moderation
+}
+
+// FieldChange - изменение одного поля, значения в текстовом виде.
+type FieldChange struct {
+	Field string
+	Old   string
+	New   string
+}
+
+// Content возвращает сохраняемые в истории поля объявления.
+func (ad Ad) Content() Content {
+	return Content{
+		Title:      ad.Title,
+		Text:       ad.Text,
+		CategoryID: ad.CategoryID,
+		Price:      ad.Price,
+		Published:  ad.Published,
+		Moderation: ad.Moderation,
+	}
+}
+
+// Diff возвращает изменившиеся поля в фиксированном порядке.
+func Diff(old, new Content) []FieldChange {
+	var changes []FieldChange
+	add := func(field, o, n string) {
+		if o != n {
+			changes = append(changes, FieldChange{Field: field, Old: o, New: n})
+		}
+	}
+
+	add("title", old.Title, new.Title)
+	add("text", old.Text, new.Text)
+	add("category_id", formatID(old.CategoryID), formatID(new.CategoryID))
+	add("price", old.Price.String(), new.Price.String())
+	add("published", strconv.FormatBool(old.Published), strconv.FormatBool(new.Published))
+	add("moderation", string(old.Moderation), string(new.Moderation))
+
+	return changes
+}
+
+// formatID возвращает пустую строку для 0, чтобы у нового объявления не было изменения "0 -> 1".
+func formatID(id int64) string {
+	if id == 0 {
+		return ""
+	}
+
+	return strconv.FormatInt(id, 10)
+}
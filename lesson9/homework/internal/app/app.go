@@ -0,0 +1,734 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/exp/slog"
+
+	"homework9/internal/ads"
+	"homework9/internal/auth"
+	"homework9/internal/categories"
+	"homework9/internal/favorites"
+	"homework9/internal/logging"
+	"homework9/internal/messages"
+	"homework9/internal/money"
+	"homework9/internal/photos"
+	"homework9/internal/search"
+	"homework9/internal/users"
+)
+
+// Ограничения длины объявления в символах по умолчанию.
+const (
+	DefaultMaxTitleLen = 100
+	DefaultMaxTextLen  = 500
+)
+
+var (
+	ErrValidation = errors.New("validation error")
+	ErrForbidden  = errors.New("forbidden")
+	ErrNotFound   = errors.New("not found")
+	// ErrVersionConflict - объявление было изменено после того, как клиент его прочитал.
+	ErrVersionConflict = errors.New("version conflict")
+)
+
+// App - бизнес-логика сервиса объявлений. Методы, изменяющие объявления,
+// действуют от имени пользователя из контекста (см. WithUserID) и без него
+// возвращают ErrUnauthenticated.
+type App interface {
+	CreateAd(ctx context.Context, title string, text string, categoryID int64, price money.Money) (*ads.Ad, error)
+	// ChangeAdStatus и UpdateAd с expectedVersion != 0 изменяют объявление, только если
+	// его текущая версия равна expectedVersion, иначе возвращают ErrVersionConflict.
+	ChangeAdStatus(ctx context.Context, adID int64, published bool, expectedVersion int64) (*ads.Ad, error)
+	// ScheduleAd планирует публикацию объявления и снятие его с публикации,
+	// нулевое время отменяет запланированное. Доступен только автору.
+	ScheduleAd(ctx context.Context, adID int64, publishAt time.Time, unpublishAt time.Time,
+		expectedVersion int64) (*ads.Ad, error)
+	// RunScheduler выполняет запланированные изменения статуса, пока не отменен ctx.
+	// Пропущенные за время простоя изменения выполняются сразу после запуска.
+	RunScheduler(ctx context.Context)
+	// UpdateAd с categoryID == 0 оставляет категорию объявления прежней, а с
+	// нулевой price - прежнюю цену.
+	UpdateAd(ctx context.Context, adID int64, title string, text string, categoryID int64, price money.Money,
+		expectedVersion int64) (*ads.Ad, error)
+	// GetAd возвращает опубликованное объявление, а неопубликованное - только
+	// автору и модераторам, остальным - ErrNotFound.
+	GetAd(ctx context.Context, adID int64) (*ads.Ad, error)
+	ListAds(ctx context.Context, query AdsQuery) (*AdsPage, error)
+	SearchAds(ctx context.Context, query string, limit int) ([]AdSearchResult, error)
+	WatchAds(ctx context.Context, filter WatchFilter) (*Subscription, error)
+	// DeleteAd перемещает объявление в корзину, RestoreAd возвращает его оттуда,
+	// пока не истек срок хранения. Оба метода доступны только автору.
+	DeleteAd(ctx context.Context, adID int64) error
+	RestoreAd(ctx context.Context, adID int64) (*ads.Ad, error)
+	// TrashedAds возвращает страницу объявлений текущего пользователя из корзины.
+	TrashedAds(ctx context.Context, pageSize int, pageToken string) (*AdsPage, error)
+
+	// CreateUser создает пользователя. Пользователь с пустым паролем не может войти.
+	CreateUser(ctx context.Context, name string, password string) (*users.User, error)
+	GetUser(ctx context.Context, userID int64) (*users.User, error)
+	// DeleteUser (самому пользователю или администратору) перемещает пользователя
+	// в корзину, RestoreUser (только для администраторов) возвращает его оттуда, пока не истек срок хранения.
+	DeleteUser(ctx context.Context, userID int64) error
+	RestoreUser(ctx context.Context, userID int64) (*users.User, error)
+	// PurgeTrash окончательно удаляет объявления и пользователей с истекшим
+	// сроком хранения в корзине и возвращает их число. Вызывается из RunPurger.
+	PurgeTrash(ctx context.Context) (int, error)
+
+	Login(ctx context.Context, userID int64, password string) (*Token, error)
+	Authenticate(ctx context.Context, token string) (int64, error)
+
+	// Методы модерации доступны модераторам и администраторам.
+	ModerationQueue(ctx context.Context, pageSize int, pageToken string) (*AdsPage, error)
+	ApproveAd(ctx context.Context, adID int64) (*ads.Ad, error)
+	RejectAd(ctx context.Context, adID int64, reason string) (*ads.Ad, error)
+	// SetUserRole доступен только администраторам.
+	SetUserRole(ctx context.Context, userID int64, role users.Role) (*users.User, error)
+
+	// AddPhotos, ReorderPhotos и DeletePhoto доступны только автору объявления.
+	AddPhotos(ctx context.Context, adID int64, files [][]byte) (*ads.Ad, error)
+	ReorderPhotos(ctx context.Context, adID int64, photoIDs []string, expectedVersion int64) (*ads.Ad, error)
+	DeletePhoto(ctx context.Context, adID int64, photoID string) (*ads.Ad, error)
+	GetPhoto(ctx context.Context, adID int64, photoID string, variant photos.Variant) (io.ReadCloser, string, error)
+
+	CategoryTree(ctx context.Context) ([]CategoryNode, error)
+	// CreateCategory, UpdateCategory и DeleteCategory доступны только администраторам.
+	CreateCategory(ctx context.Context, name string, parentID int64) (*categories.Category, error)
+	UpdateCategory(ctx context.Context, categoryID int64, name string, parentID int64) (*categories.Category, error)
+	DeleteCategory(ctx context.Context, categoryID int64) error
+
+	// ExchangeRates возвращает курсы валют к money.Base, кроме курса самой money.Base.
+	ExchangeRates(ctx context.Context) ([]money.Rate, error)
+	// SetExchangeRate доступен только администраторам.
+	SetExchangeRate(ctx context.Context, currency money.Currency, value string) (*money.Rate, error)
+
+	// AdHistory доступен автору объявления, модераторам и администраторам,
+	// RevertAd - только автору.
+	AdHistory(ctx context.Context, adID int64) ([]ads.Revision, error)
+	RevertAd(ctx context.Context, adID int64, version int64, expectedVersion int64) (*ads.Ad, error)
+
+	// AddFavorite, RemoveFavorite, Favorites и Notifications работают с избранным
+	// текущего пользователя. Объявления из корзины не показываются в избранном,
+	// пока их не восстановят, а после очистки корзины удаляются из него.
+	AddFavorite(ctx context.Context, adID int64) (*ads.Ad, error)
+	RemoveFavorite(ctx context.Context, adID int64) error
+	Favorites(ctx context.Context, pageSize int, pageToken string) (*AdsPage, error)
+	// Notifications возвращает уведомления об изменении цены и текста объявлений
+	// из избранного от старых к новым.
+	Notifications(ctx context.Context, pageSize int, pageToken string) (*NotificationsPage, error)
+
+	// Переписки покупателей с авторами объявлений доступны только их участникам.
+	// OpenThread возвращает переписку текущего пользователя с автором
+	// объявления, создавая ее при первом обращении.
+	OpenThread(ctx context.Context, adID int64) (*messages.Thread, error)
+	Threads(ctx context.Context, pageSize int, pageToken string) (*ThreadsPage, error)
+	SendMessage(ctx context.Context, threadID int64, text string) (*messages.Message, error)
+	Messages(ctx context.Context, threadID int64, pageSize int, pageToken string) (*MessagesPage, error)
+	// MarkThreadRead отмечает прочитанными сообщения переписки до readID
+	// включительно, readID == 0 - все сообщения.
+	MarkThreadRead(ctx context.Context, threadID int64, readID int64) (*messages.Thread, error)
+	// UnreadMessages возвращает число непрочитанных сообщений во всех переписках.
+	UnreadMessages(ctx context.Context) (int, error)
+	// Chat подписывает на новые сообщения и отметки о прочтении во всех
+	// переписках текущего пользователя.
+	Chat(ctx context.Context) (*ChatSubscription, error)
+
+	// Stats возвращает число объявлений и пользователей для метрик, права не проверяются.
+	Stats(ctx context.Context) (Stats, error)
+	// Ping проверяет, что хранилище доступно, для проверки готовности сервиса.
+	Ping(ctx context.Context) error
+}
+
+// Repository хранит объявления и пользователей. Если сущность не найдена,
+// методы должны возвращать ошибку, оборачивающую ErrNotFound.
+type Repository interface {
+	// AddAd и UpdateAd вместе с объявлением атомарно добавляют в его историю
+	// revision, если она не nil. ID объявления и версию записи истории задает
+	// хранилище по сохраненному объявлению. Если категории объявления нет,
+	// возвращают ошибку, оборачивающую ErrValidation: ее могли удалить после
+	// проверки в приложении.
+	AddAd(ctx context.Context, ad ads.Ad, revision *ads.Revision) (ads.Ad, error)
+	GetAd(ctx context.Context, adID int64) (ads.Ad, error)
+	// UpdateAd сохраняет ad, если версия в хранилище равна ad.Version, и увеличивает
+	// ее на 1. Если версия уже другая, возвращает ошибку, оборачивающую ErrVersionConflict.
+	UpdateAd(ctx context.Context, ad ads.Ad, revision *ads.Revision) error
+	// DeleteAd удаляет объявление окончательно, в корзину его перемещает UpdateAd с DeletedAt.
+	DeleteAd(ctx context.Context, adID int64) error
+	ListAds(ctx context.Context, filter AdFilter) ([]ads.Ad, error)
+
+	AddUser(ctx context.Context, user users.User) (users.User, error)
+	GetUser(ctx context.Context, userID int64) (users.User, error)
+	UpdateUser(ctx context.Context, user users.User) error
+	DeleteUser(ctx context.Context, userID int64) error
+	// ListTrashedUsers возвращает пользователей из корзины по возрастанию ID,
+	// если deletedBefore не нулевое - только перемещенных туда раньше него.
+	ListTrashedUsers(ctx context.Context, deletedBefore time.Time) ([]users.User, error)
+
+	// Хранилище создает категорию categories.OtherID при инициализации.
+	// AddCategory и UpdateCategory атомарно с записью проверяют, что родитель
+	// существует и не является самой категорией или ее потомком, иначе
+	// возвращают ошибку, оборачивающую ErrValidation.
+	AddCategory(ctx context.Context, category categories.Category) (categories.Category, error)
+	GetCategory(ctx context.Context, categoryID int64) (categories.Category, error)
+	UpdateCategory(ctx context.Context, category categories.Category) error
+	// DeleteCategory атомарно с удалением проверяет, что у категории нет
+	// подкатегорий и объявлений (в том числе в корзине), иначе возвращает
+	// ошибку, оборачивающую ErrValidation.
+	DeleteCategory(ctx context.Context, categoryID int64) error
+	ListCategories(ctx context.Context) ([]categories.Category, error)
+	// CountPublishedAds возвращает число опубликованных объявлений в каждой
+	// категории без учета подкатегорий и объявлений из корзины.
+	CountPublishedAds(ctx context.Context) (map[int64]int, error)
+
+	// SetRate добавляет или заменяет курс валюты, ListRates возвращает курсы по коду валюты.
+	SetRate(ctx context.Context, rate money.Rate) error
+	ListRates(ctx context.Context) ([]money.Rate, error)
+
+	// ListRevisions возвращает историю объявления по возрастанию версии.
+	// DeleteAd удаляет и историю объявления.
+	ListRevisions(ctx context.Context, adID int64) ([]ads.Revision, error)
+
+	// AddFavorite добавляет объявление в избранное пользователя, повторное
+	// добавление ничего не меняет. RemoveFavorite возвращает ErrNotFound, если
+	// объявления нет в избранном. DeleteAd и DeleteUser удаляют и избранное.
+	AddFavorite(ctx context.Context, favorite favorites.Favorite) error
+	RemoveFavorite(ctx context.Context, userID int64, adID int64) error
+	// ListWatchers возвращает по возрастанию ID пользователей, добавивших объявление в избранное.
+	ListWatchers(ctx context.Context, adID int64) ([]int64, error)
+
+	// AddNotification сохраняет уведомление и возвращает его с присвоенным ID.
+	// ListNotifications возвращает уведомления пользователя по возрастанию ID,
+	// начиная с fromID, не больше limit (0 - без ограничения). DeleteUser
+	// удаляет и уведомления пользователя.
+	AddNotification(ctx context.Context, notification favorites.Notification) (favorites.Notification, error)
+	ListNotifications(ctx context.Context, userID int64, fromID int64, limit int) ([]favorites.Notification, error)
+
+	// AddThread сохраняет переписку и возвращает ее с присвоенным ID, а если у
+	// покупателя уже есть переписка по объявлению - возвращает ее. Переписки
+	// возвращаются с заполненными LastMessageID и счетчиками непрочитанных.
+	// DeleteAd и DeleteUser удаляют и переписки с сообщениями.
+	AddThread(ctx context.Context, thread messages.Thread) (messages.Thread, error)
+	GetThread(ctx context.Context, threadID int64) (messages.Thread, error)
+	// ListThreads возвращает переписки пользователя по возрастанию ID, начиная
+	// с fromID, не больше limit (0 - без ограничения).
+	ListThreads(ctx context.Context, userID int64, fromID int64, limit int) ([]messages.Thread, error)
+	// MarkThreadRead запоминает ID последнего прочитанного участником сообщения,
+	// если он больше сохраненного.
+	MarkThreadRead(ctx context.Context, threadID int64, userID int64, readID int64) error
+	// CountUnread возвращает число непрочитанных пользователем сообщений во всех переписках.
+	CountUnread(ctx context.Context, userID int64) (int, error)
+
+	// AddMessage сохраняет сообщение и возвращает его с присвоенным ID.
+	// ListMessages возвращает сообщения переписки по возрастанию ID, начиная с
+	// fromID, не больше limit (0 - без ограничения).
+	AddMessage(ctx context.Context, message messages.Message) (messages.Message, error)
+	ListMessages(ctx context.Context, threadID int64, fromID int64, limit int) ([]messages.Message, error)
+
+	Stats(ctx context.Context) (Stats, error)
+	// Ping возвращает ошибку, если хранилище не может обслуживать запросы.
+	Ping(ctx context.Context) error
+}
+
+type app struct {
+	repo   Repository
+	events *broker
+	chats  *chatHub
+	tokens *auth.Signer
+	// passwordCost - стоимость bcrypt-хеширования паролей.
+	passwordCost int
+
+	premoderation bool
+	admins        map[int64]bool
+	blobs         BlobStore
+	maxPhotos     int
+	maxTitleLen   int
+	maxTextLen    int
+
+	trashRetention time.Duration
+	clock          Clock
+	logger         *slog.Logger
+	// scheduleWake будит RunScheduler после изменения расписания.
+	scheduleWake chan struct{}
+	// trashMu не дает очистке корзины удалить объявление или пользователя,
+	// которых в это время восстанавливают.
+	trashMu sync.Mutex
+
+	indexMu sync.Mutex
+	index   *search.Index // nil, пока не было ни одного поиска
+	// indexedVersions - версии объявлений, по которым обновлялся индекс.
+	indexedVersions map[int64]int64
+}
+
+type options struct {
+	tokenSecret   []byte
+	tokenTTL      time.Duration
+	passwordCost  int
+	premoderation bool
+	admins        []int64
+	blobs         BlobStore
+	maxPhotos     int
+	maxTitleLen   int
+	maxTextLen    int
+
+	trashRetention time.Duration
+	clock          Clock
+	logger         *slog.Logger
+}
+
+type Option func(o *options)
+
+// WithLogger задает логгер ошибок, которые не возвращаются вызывающему, по
+// умолчанию строки JSON пишутся в stderr. Строки получают идентификатор
+// запроса из контекста, если логгер создан logging.New.
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// WithMaxTitleLen задает наибольшую длину заголовка объявления в символах,
+// по умолчанию DefaultMaxTitleLen.
+func WithMaxTitleLen(n int) Option {
+	return func(o *options) {
+		o.maxTitleLen = n
+	}
+}
+
+// WithMaxTextLen задает наибольшую длину текста объявления в символах,
+// по умолчанию DefaultMaxTextLen.
+func WithMaxTextLen(n int) Option {
+	return func(o *options) {
+		o.maxTextLen = n
+	}
+}
+
+func NewApp(repo Repository, opts ...Option) App {
+	o := options{
+		passwordCost:   bcrypt.DefaultCost,
+		maxPhotos:      defaultMaxPhotos,
+		maxTitleLen:    DefaultMaxTitleLen,
+		maxTextLen:     DefaultMaxTextLen,
+		trashRetention: DefaultTrashRetention,
+		clock:          systemClock{},
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.logger == nil {
+		o.logger = logging.New(os.Stderr)
+	}
+
+	admins := make(map[int64]bool, len(o.admins))
+	for _, id := range o.admins {
+		admins[id] = true
+	}
+
+	return &app{
+		repo:          repo,
+		events:        newBroker(),
+		chats:         newChatHub(),
+		tokens:        newSigner(o),
+		passwordCost:  o.passwordCost,
+		premoderation: o.premoderation,
+		admins:        admins,
+		blobs:         o.blobs,
+		maxPhotos:     o.maxPhotos,
+		maxTitleLen:   o.maxTitleLen,
+		maxTextLen:    o.maxTextLen,
+
+		trashRetention: o.trashRetention,
+		clock:          o.clock,
+		logger:         o.logger,
+		scheduleWake:   make(chan struct{}, 1),
+	}
+}
+
+func (a *app) CreateAd(ctx context.Context, title string, text string, categoryID int64, price money.Money) (*ads.Ad, error) {
+	userID, err := currentUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := a.validateAd(title, text); err != nil {
+		return nil, err
+	}
+	if err := validatePrice(price); err != nil {
+		return nil, err
+	}
+	if categoryID == 0 {
+		return nil, fmt.Errorf("%w: category is required", ErrValidation)
+	}
+	if err := a.checkCategory(ctx, categoryID); err != nil {
+		return nil, err
+	}
+
+	now := a.now()
+	ad := ads.Ad{
+		Title:      title,
+		Text:       text,
+		AuthorID:   userID,
+		CategoryID: categoryID,
+		Price:      price,
+		Version:    1,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	ad, err = a.repo.AddAd(ctx, ad, newRevision(ctx, ads.Content{}, ad, 0))
+	if err != nil {
+		return nil, err
+	}
+	a.indexAd(ad)
+	a.events.publish(AdCreated, ad)
+
+	return &ad, nil
+}
+
+// ChangeAdStatus публикует объявление или снимает его с публикации. При
+// премодерации, а также для отклоненных модератором объявлений, публикация
+// только ставит объявление в очередь на проверку. Снятие с публикации
+// объявления из очереди отзывает его с проверки.
+func (a *app) ChangeAdStatus(ctx context.Context, adID int64, published bool, expectedVersion int64) (*ads.Ad, error) {
+	var wasPublished bool
+	ad, changed, err := a.modifyAd(ctx, adID, expectedVersion, func(ad *ads.Ad) bool {
+		wasPublished = ad.Published
+		return a.setPublished(ad, published)
+	})
+	if err != nil || !changed {
+		return ad, err
+	}
+
+	a.publishAdChange(*ad, wasPublished)
+
+	return ad, nil
+}
+
+// setPublished меняет статус объявления по правилам ChangeAdStatus и
+// сообщает, изменилось ли объявление.
+func (a *app) setPublished(ad *ads.Ad, published bool) bool {
+	switch {
+	case !published && ad.Moderation == ads.ModerationPending:
+		ad.Moderation = ads.ModerationNone
+	case ad.Published == published, ad.Moderation == ads.ModerationPending:
+		return false
+	case published && (a.premoderation || ad.Moderation == ads.ModerationRejected):
+		ad.Moderation = ads.ModerationPending
+		ad.ModerationReason = ""
+	default:
+		ad.Published = published
+	}
+
+	return true
+}
+
+func (a *app) UpdateAd(ctx context.Context, adID int64, title string, text string, categoryID int64,
+	price money.Money, expectedVersion int64) (*ads.Ad, error) {
+	if err := a.validateAd(title, text); err != nil {
+		return nil, err
+	}
+	if err := validatePrice(price); err != nil {
+		return nil, err
+	}
+	if categoryID != 0 {
+		if err := a.checkCategory(ctx, categoryID); err != nil {
+			return nil, err
+		}
+	}
+
+	ad, _, err := a.modifyAd(ctx, adID, expectedVersion, func(ad *ads.Ad) bool {
+		ad.Title = title
+		ad.Text = text
+		if categoryID != 0 {
+			ad.CategoryID = categoryID
+		}
+		if !price.IsZero() {
+			ad.Price = price
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	a.indexAd(*ad)
+	a.events.publish(AdUpdated, *ad)
+
+	return ad, nil
+}
+
+func (a *app) GetAd(ctx context.Context, adID int64) (*ads.Ad, error) {
+	ad, err := a.getVisibleAd(ctx, adID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ad, nil
+}
+
+// ListAds возвращает страницу опубликованных объявлений, по умолчанию по возрастанию ID.
+func (a *app) ListAds(ctx context.Context, query AdsQuery) (*AdsPage, error) {
+	if !query.Sort.Valid() {
+		return nil, fmt.Errorf("%w: unknown sort order %q", ErrValidation, query.Sort)
+	}
+
+	filter := AdFilter{PublishedOnly: true}
+	if query.CategoryID != 0 {
+		ids, err := a.categoryWithDescendants(ctx, query.CategoryID)
+		if err != nil {
+			return nil, err
+		}
+		filter.CategoryIDs = ids
+	}
+
+	byID := query.Sort == SortByID && !query.Desc && query.MinPrice.IsZero() && query.MaxPrice.IsZero()
+	if byID && query.Currency == "" {
+		return a.listPage(ctx, filter, query.PageSize, query.PageToken)
+	}
+
+	rates, err := a.exchangeRates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := rates[query.Currency]; query.Currency != "" && !ok {
+		return nil, fmt.Errorf("%w: no exchange rate for %q", ErrValidation, query.Currency)
+	}
+
+	var page *AdsPage
+	if byID {
+		page, err = a.listPage(ctx, filter, query.PageSize, query.PageToken)
+	} else {
+		page, err = a.listSorted(ctx, filter, query, rates)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if query.Currency != "" {
+		page.DisplayPrices = displayPrices(page.Ads, query.Currency, rates)
+	}
+
+	return page, nil
+}
+
+// listPage возвращает страницу объявлений, подходящих под filter.
+func (a *app) listPage(ctx context.Context, filter AdFilter, pageSize int, pageToken string) (*AdsPage, error) {
+	size, err := normalizePageSize(pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := decodePageToken(pageToken)
+	if err != nil {
+		return nil, err
+	}
+	if cursor.Sort != SortByID || cursor.Desc {
+		return nil, fmt.Errorf("%w: page token was issued for another sort order", ErrValidation)
+	}
+
+	// Берем на одно объявление больше, чтобы понять, есть ли следующая страница.
+	filter.FromID = cursor.FromID
+	filter.Limit = size + 1
+	list, err := a.repo.ListAds(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &AdsPage{Ads: list}
+	if len(list) > size {
+		page.Ads = list[:size]
+		page.NextPageToken = encodePageToken(pageCursor{FromID: list[size].ID})
+	}
+
+	return page, nil
+}
+
+// DeleteAd перемещает объявление в корзину. Фотографии удаляются только
+// вместе с объявлением при очистке корзины.
+func (a *app) DeleteAd(ctx context.Context, adID int64) error {
+	ad, _, err := a.modifyAd(ctx, adID, 0, func(ad *ads.Ad) bool {
+		ad.DeletedAt = a.now()
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	a.indexAd(*ad)
+	a.events.publish(AdDeleted, *ad)
+
+	return nil
+}
+
+func (a *app) CreateUser(ctx context.Context, name string, password string) (*users.User, error) {
+	if name == "" {
+		return nil, fmt.Errorf("%w: empty name", ErrValidation)
+	}
+
+	user := users.User{Name: name, Role: users.RoleUser}
+	if password != "" {
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), a.passwordCost)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrValidation, err)
+		}
+		user.PasswordHash = hash
+	}
+
+	user, err := a.repo.AddUser(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+func (a *app) GetUser(ctx context.Context, userID int64) (*users.User, error) {
+	user, err := a.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	user.Role = a.roleOf(user)
+
+	return &user, nil
+}
+
+// DeleteUser перемещает пользователя в корзину: он пропадает из выдачи и не может войти.
+// Удалить можно только себя, других пользователей - только администратору.
+func (a *app) DeleteUser(ctx context.Context, userID int64) error {
+	callerID, err := currentUserID(ctx)
+	if err != nil {
+		return err
+	}
+	if callerID != userID {
+		if _, err := a.requireRole(ctx, users.RoleAdmin); err != nil {
+			return err
+		}
+	}
+
+	user, err := a.getUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	user.DeletedAt = a.now()
+	return a.repo.UpdateUser(ctx, user)
+}
+
+// modifyAd применяет change к объявлению текущего пользователя и сохраняет его,
+// если change вернул true. При expectedVersion == 0 конфликт с параллельным
+// изменением не считается ошибкой: изменение повторяется на свежей версии.
+func (a *app) modifyAd(ctx context.Context, adID int64, expectedVersion int64,
+	change func(ad *ads.Ad) bool) (*ads.Ad, bool, error) {
+	return a.modifyAdWith(ctx, adID, expectedVersion, a.getOwnAd, change)
+}
+
+// modifyAdWith - modifyAd, в котором объявление читается через load.
+func (a *app) modifyAdWith(ctx context.Context, adID int64, expectedVersion int64,
+	load func(ctx context.Context, adID int64) (ads.Ad, error), change func(ad *ads.Ad) bool) (*ads.Ad, bool, error) {
+	return a.modifyAdRevision(ctx, adID, expectedVersion, load, change, 0)
+}
+
+// modifyAdRevision - modifyAdWith, который записывает изменение в историю
+// как возврат к версии revertedFrom, если она не 0.
+func (a *app) modifyAdRevision(ctx context.Context, adID int64, expectedVersion int64,
+	load func(ctx context.Context, adID int64) (ads.Ad, error), change func(ad *ads.Ad) bool,
+	revertedFrom int64) (*ads.Ad, bool, error) {
+	for {
+		ad, err := load(ctx, adID)
+		if err != nil {
+			return nil, false, err
+		}
+
+		if expectedVersion != 0 && ad.Version != expectedVersion {
+			return nil, false, fmt.Errorf("%w: ad %d has version %d, expected %d",
+				ErrVersionConflict, adID, ad.Version, expectedVersion)
+		}
+
+		before := ad.Content()
+		if !change(&ad) {
+			return &ad, false, nil
+		}
+		ad.UpdatedAt = a.now()
+
+		revision := newRevision(ctx, before, ad, revertedFrom)
+		err = a.repo.UpdateAd(ctx, ad, revision)
+		// Конфликт значит, что чье-то изменение уже записано, поэтому повтор
+		// без ограничения числа попыток не зацикливается.
+		if errors.Is(err, ErrVersionConflict) && expectedVersion == 0 && ctx.Err() == nil {
+			continue
+		}
+		if err != nil {
+			return nil, false, err
+		}
+
+		ad.Version++
+		if revision != nil {
+			a.notifyWatchers(ctx, ad, revision.Changes)
+		}
+
+		return &ad, true, nil
+	}
+}
+
+// now возвращает текущее время в UTC, без показаний монотонных часов, чтобы
+// время одинаково сравнивалось до и после сохранения в хранилище.
+func (a *app) now() time.Time {
+	return a.clock.Now().UTC()
+}
+
+// getOwnAd возвращает объявление, если его автор - текущий пользователь, иначе ErrForbidden.
+func (a *app) getOwnAd(ctx context.Context, adID int64) (ads.Ad, error) {
+	userID, err := currentUserID(ctx)
+	if err != nil {
+		return ads.Ad{}, err
+	}
+
+	ad, err := a.getAd(ctx, adID)
+	if err != nil {
+		return ads.Ad{}, err
+	}
+
+	return ad, checkAuthor(ad, userID)
+}
+
+func checkAuthor(ad ads.Ad, userID int64) error {
+	if ad.AuthorID != userID {
+		return fmt.Errorf("%w: user %d is not the author of ad %d", ErrForbidden, userID, ad.ID)
+	}
+
+	return nil
+}
+
+// publishAdChange обновляет поисковый индекс и рассылает событие об изменении
+// объявления, которое до изменения было опубликовано, если wasPublished.
+func (a *app) publishAdChange(ad ads.Ad, wasPublished bool) {
+	a.indexAd(ad)
+	switch {
+	case ad.Published && !wasPublished:
+		a.events.publish(AdPublished, ad)
+	case !ad.Published && wasPublished:
+		a.events.publish(AdUnpublished, ad)
+	default:
+		a.events.publish(AdUpdated, ad)
+	}
+}
+
+func (a *app) validateAd(title string, text string) error {
+	switch {
+	case title == "":
+		return fmt.Errorf("%w: empty title", ErrValidation)
+	case utf8.RuneCountInString(title) > a.maxTitleLen:
+		return fmt.Errorf("%w: title is longer than %d characters", ErrValidation, a.maxTitleLen)
+	case text == "":
+		return fmt.Errorf("%w: empty text", ErrValidation)
+	case utf8.RuneCountInString(text) > a.maxTextLen:
+		return fmt.Errorf("%w: text is longer than %d characters", ErrValidation, a.maxTextLen)
+	}
+
+	return nil
+}
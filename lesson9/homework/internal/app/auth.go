@@ -0,0 +1,120 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"homework9/internal/auth"
+)
+
+const defaultTokenTTL = 24 * time.Hour
+
+// ErrUnauthenticated - личность вызывающего не установлена или токен недействителен.
+var ErrUnauthenticated = errors.New("unauthenticated")
+
+type userIDKey struct{}
+
+// WithUserID возвращает контекст, в котором действия выполняются от имени userID.
+// Его заполняют транспорты по токену из заголовка Authorization.
+func WithUserID(ctx context.Context, userID int64) context.Context {
+	return context.WithValue(ctx, userIDKey{}, userID)
+}
+
+// UserIDFromContext возвращает пользователя, от имени которого выполняется вызов.
+func UserIDFromContext(ctx context.Context) (int64, bool) {
+	userID, ok := ctx.Value(userIDKey{}).(int64)
+	return userID, ok
+}
+
+func currentUserID(ctx context.Context) (int64, error) {
+	userID, ok := UserIDFromContext(ctx)
+	if !ok {
+		return 0, ErrUnauthenticated
+	}
+
+	return userID, nil
+}
+
+// Token - выданный при входе токен доступа.
+type Token struct {
+	Value     string
+	ExpiresAt time.Time
+}
+
+// Login проверяет пароль пользователя и выдает ему токен доступа.
+func (a *app) Login(ctx context.Context, userID int64, password string) (*Token, error) {
+	user, err := a.getUser(ctx, userID)
+	if errors.Is(err, ErrNotFound) {
+		return nil, fmt.Errorf("%w: invalid user or password", ErrUnauthenticated)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(user.PasswordHash) == 0 || bcrypt.CompareHashAndPassword(user.PasswordHash, []byte(password)) != nil {
+		return nil, fmt.Errorf("%w: invalid user or password", ErrUnauthenticated)
+	}
+
+	value, expiresAt := a.tokens.Issue(user.ID)
+	return &Token{Value: value, ExpiresAt: expiresAt}, nil
+}
+
+// Authenticate проверяет токен доступа и возвращает ID его владельца. Токены
+// пользователей из корзины и удаленных навсегда отклоняются до истечения срока.
+func (a *app) Authenticate(ctx context.Context, token string) (int64, error) {
+	userID, err := a.tokens.Verify(token)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s", ErrUnauthenticated, err)
+	}
+
+	_, err = a.getUser(ctx, userID)
+	if errors.Is(err, ErrNotFound) {
+		return 0, fmt.Errorf("%w: user %d does not exist", ErrUnauthenticated, userID)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return userID, nil
+}
+
+// WithTokenSecret задает ключ подписи токенов. По умолчанию используется
+// случайный ключ, и токены не переживают рестарт сервиса.
+func WithTokenSecret(secret []byte) Option {
+	return func(o *options) {
+		o.tokenSecret = secret
+	}
+}
+
+// WithTokenTTL задает срок действия токенов, по умолчанию сутки.
+func WithTokenTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		o.tokenTTL = ttl
+	}
+}
+
+// WithPasswordCost задает стоимость bcrypt-хеширования паролей, по умолчанию
+// bcrypt.DefaultCost. Значения меньше bcrypt.MinCost заменяются на DefaultCost.
+func WithPasswordCost(cost int) Option {
+	return func(o *options) {
+		o.passwordCost = cost
+	}
+}
+
+func newSigner(o options) *auth.Signer {
+	secret := o.tokenSecret
+	if len(secret) == 0 {
+		secret = auth.RandomSecret()
+	}
+
+	ttl := o.tokenTTL
+	if ttl <= 0 {
+		ttl = defaultTokenTTL
+	}
+
+	return auth.NewSigner(secret, ttl)
+}
@@ -0,0 +1,181 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"homework9/internal/categories"
+	"homework9/internal/users"
+)
+
+const maxCategoryNameLen = 100
+
+// CategoryNode - категория с подкатегориями. PublishedAds - число
+// опубликованных объявлений в категории вместе со всеми подкатегориями.
+type CategoryNode struct {
+	categories.Category
+	PublishedAds int
+	Children     []CategoryNode
+}
+
+// CategoryTree возвращает дерево категорий. Категории одного уровня
+// упорядочены по названию.
+func (a *app) CategoryTree(ctx context.Context) ([]CategoryNode, error) {
+	list, err := a.repo.ListCategories(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	counts, err := a.repo.CountPublishedAds(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	children := childrenByParent(list)
+	var build func(parentID int64) []CategoryNode
+	build = func(parentID int64) []CategoryNode {
+		nodes := make([]CategoryNode, 0, len(children[parentID]))
+		for _, c := range children[parentID] {
+			node := CategoryNode{Category: c, PublishedAds: counts[c.ID], Children: build(c.ID)}
+			for _, child := range node.Children {
+				node.PublishedAds += child.PublishedAds
+			}
+			nodes = append(nodes, node)
+		}
+		return nodes
+	}
+
+	return build(0), nil
+}
+
+func (a *app) CreateCategory(ctx context.Context, name string, parentID int64) (*categories.Category, error) {
+	if _, err := a.requireRole(ctx, users.RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	c := categories.Category{ParentID: parentID, Name: strings.TrimSpace(name)}
+	if err := a.validateCategory(ctx, c); err != nil {
+		return nil, err
+	}
+
+	c, err := a.repo.AddCategory(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+// UpdateCategory переименовывает категорию и переносит ее под parentID
+// вместе с подкатегориями и объявлениями.
+func (a *app) UpdateCategory(ctx context.Context, categoryID int64, name string, parentID int64) (*categories.Category, error) {
+	if _, err := a.requireRole(ctx, users.RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	if _, err := a.repo.GetCategory(ctx, categoryID); err != nil {
+		return nil, err
+	}
+
+	c := categories.Category{ID: categoryID, ParentID: parentID, Name: strings.TrimSpace(name)}
+	if err := a.validateCategory(ctx, c); err != nil {
+		return nil, err
+	}
+
+	if err := a.repo.UpdateCategory(ctx, c); err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+// DeleteCategory удаляет пустую категорию: без подкатегорий и объявлений.
+func (a *app) DeleteCategory(ctx context.Context, categoryID int64) error {
+	if _, err := a.requireRole(ctx, users.RoleAdmin); err != nil {
+		return err
+	}
+
+	if categoryID == categories.OtherID {
+		return fmt.Errorf("%w: category %d cannot be deleted", ErrValidation, categoryID)
+	}
+
+	// Подкатегории и объявления проверяет хранилище вместе с удалением.
+	return a.repo.DeleteCategory(ctx, categoryID)
+}
+
+// validateCategory проверяет название категории c. Родителя проверяет
+// хранилище при записи: иначе параллельные изменения могли бы создать цикл.
+func (a *app) validateCategory(ctx context.Context, c categories.Category) error {
+	switch {
+	case c.Name == "":
+		return fmt.Errorf("%w: empty category name", ErrValidation)
+	case utf8.RuneCountInString(c.Name) > maxCategoryNameLen:
+		return fmt.Errorf("%w: category name is longer than %d characters", ErrValidation, maxCategoryNameLen)
+	}
+
+	list, err := a.repo.ListCategories(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, other := range list {
+		if other.ID != c.ID && other.ParentID == c.ParentID && strings.EqualFold(other.Name, c.Name) {
+			return fmt.Errorf("%w: category %q already exists", ErrValidation, c.Name)
+		}
+	}
+
+	return nil
+}
+
+// checkCategory возвращает ErrValidation, если категории categoryID нет.
+func (a *app) checkCategory(ctx context.Context, categoryID int64) error {
+	_, err := a.repo.GetCategory(ctx, categoryID)
+	if errors.Is(err, ErrNotFound) {
+		return fmt.Errorf("%w: unknown category %d", ErrValidation, categoryID)
+	}
+
+	return err
+}
+
+// categoryWithDescendants возвращает ID категории и всех ее подкатегорий.
+func (a *app) categoryWithDescendants(ctx context.Context, categoryID int64) ([]int64, error) {
+	if err := a.checkCategory(ctx, categoryID); err != nil {
+		return nil, err
+	}
+
+	list, err := a.repo.ListCategories(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	children := childrenByParent(list)
+	ids := []int64{categoryID}
+	for i := 0; i < len(ids); i++ {
+		for _, c := range children[ids[i]] {
+			ids = append(ids, c.ID)
+		}
+	}
+
+	return ids, nil
+}
+
+func childrenByParent(list []categories.Category) map[int64][]categories.Category {
+	children := make(map[int64][]categories.Category)
+	for _, c := range list {
+		children[c.ParentID] = append(children[c.ParentID], c)
+	}
+	for _, siblings := range children {
+		sort.Slice(siblings, func(i, j int) bool {
+			if siblings[i].Name != siblings[j].Name {
+				return siblings[i].Name < siblings[j].Name
+			}
+			return siblings[i].ID < siblings[j].ID
+		})
+	}
+
+	return children
+}
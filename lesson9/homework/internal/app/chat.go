@@ -0,0 +1,131 @@
+package app
+
+import (
+	"context"
+	"sync"
+
+	"homework9/internal/messages"
+)
+
+type ChatEventType int
+
+const (
+	ChatMessage ChatEventType = iota + 1
+	ChatRead
+)
+
+// ChatEvent - новое сообщение в переписке (ChatMessage) или отметка участника
+// о прочтении (ChatRead). Thread - состояние переписки после события.
+type ChatEvent struct {
+	Type    ChatEventType
+	Thread  messages.Thread
+	Message messages.Message // только для ChatMessage
+}
+
+// ChatSubscription - подписка на события в переписках пользователя, включая
+// его собственные сообщения. Должна быть закрыта через Close.
+type ChatSubscription struct {
+	hub    *chatHub
+	userID int64
+	events chan ChatEvent
+
+	err error // выставляется хабом под chatHub.mu перед закрытием events
+}
+
+// UserID возвращает пользователя, события переписок которого получает подписка.
+func (s *ChatSubscription) UserID() int64 {
+	return s.userID
+}
+
+// Next возвращает следующее событие, блокируясь до его появления или отмены ctx.
+func (s *ChatSubscription) Next(ctx context.Context) (ChatEvent, error) {
+	select {
+	case e, ok := <-s.events:
+		if !ok {
+			s.hub.mu.Lock()
+			defer s.hub.mu.Unlock()
+			return ChatEvent{}, s.err
+		}
+		return e, nil
+	case <-ctx.Done():
+		return ChatEvent{}, ctx.Err()
+	}
+}
+
+func (s *ChatSubscription) Close() {
+	s.hub.unsubscribe(s)
+}
+
+// chatHub рассылает события переписок их участникам. Как и broker, он не
+// блокируется: подписчик с заполненным буфером отключается с ErrSlowSubscriber.
+// Истории событий нет, пропущенное клиент перечитывает через Threads и Messages.
+type chatHub struct {
+	mu   sync.Mutex
+	subs map[int64]map[*ChatSubscription]struct{} // по ID пользователя
+}
+
+func newChatHub() *chatHub {
+	return &chatHub{subs: make(map[int64]map[*ChatSubscription]struct{})}
+}
+
+func (h *chatHub) publish(e ChatEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, userID := range []int64{e.Thread.BuyerID, e.Thread.SellerID} {
+		for s := range h.subs[userID] {
+			select {
+			case s.events <- e:
+			default:
+				s.err = ErrSlowSubscriber
+				close(s.events)
+				h.remove(s)
+			}
+		}
+	}
+}
+
+func (h *chatHub) subscribe(userID int64) *ChatSubscription {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s := &ChatSubscription{
+		hub:    h,
+		userID: userID,
+		events: make(chan ChatEvent, subscriberBuffer),
+	}
+	if h.subs[userID] == nil {
+		h.subs[userID] = make(map[*ChatSubscription]struct{})
+	}
+	h.subs[userID][s] = struct{}{}
+
+	return s
+}
+
+func (h *chatHub) unsubscribe(s *ChatSubscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.subs[s.userID][s]; ok {
+		h.remove(s)
+		close(s.events)
+		s.err = context.Canceled
+	}
+}
+
+// remove удаляет подписку из хаба. Вызывается под h.mu.
+func (h *chatHub) remove(s *ChatSubscription) {
+	delete(h.subs[s.userID], s)
+	if len(h.subs[s.userID]) == 0 {
+		delete(h.subs, s.userID)
+	}
+}
+
+func (a *app) Chat(ctx context.Context) (*ChatSubscription, error) {
+	userID, err := currentUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.chats.subscribe(userID), nil
+}
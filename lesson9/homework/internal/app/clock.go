@@ -0,0 +1,29 @@
+package app
+
+import "time"
+
+// Clock - источник текущего времени и таймеров. По умолчанию используются
+// системные часы, тесты подменяют их через WithClock.
+type Clock interface {
+	Now() time.Time
+	// After возвращает канал, в который придет текущее время, когда пройдет d.
+	After(d time.Duration) <-chan time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// WithClock задает часы, по которым приложение ставит даты и выполняет
+// отложенную публикацию объявлений.
+func WithClock(c Clock) Option {
+	return func(o *options) {
+		o.clock = c
+	}
+}
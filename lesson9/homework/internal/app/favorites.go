@@ -0,0 +1,132 @@
+package app
+
+import (
+	"context"
+
+	"homework9/internal/ads"
+	"homework9/internal/favorites"
+)
+
+// NotificationsPage - страница уведомлений. NextPageToken пуст на последней странице.
+type NotificationsPage struct {
+	Notifications []favorites.Notification
+	NextPageToken string
+}
+
+// AddFavorite добавляет объявление в избранное. Неопубликованное объявление
+// может добавить только тот, кто его видит: автор или модератор.
+func (a *app) AddFavorite(ctx context.Context, adID int64) (*ads.Ad, error) {
+	userID, err := currentUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := a.getVisibleAd(ctx, adID); err != nil {
+		return nil, err
+	}
+	err = a.repo.AddFavorite(ctx, favorites.Favorite{UserID: userID, AdID: adID, CreatedAt: a.now()})
+	if err != nil {
+		return nil, err
+	}
+
+	// Перечитываем объявление, чтобы вернуть его с новым числом добавлений в избранное.
+	return a.GetAd(ctx, adID)
+}
+
+// RemoveFavorite удаляет объявление из избранного, даже если оно уже в корзине.
+func (a *app) RemoveFavorite(ctx context.Context, adID int64) error {
+	userID, err := currentUserID(ctx)
+	if err != nil {
+		return err
+	}
+
+	return a.repo.RemoveFavorite(ctx, userID, adID)
+}
+
+// Favorites возвращает страницу избранного по возрастанию ID объявлений.
+// Снятые с публикации объявления остаются в избранном с Published = false.
+func (a *app) Favorites(ctx context.Context, pageSize int, pageToken string) (*AdsPage, error) {
+	userID, err := currentUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.listPage(ctx, AdFilter{FavoritedBy: &userID}, pageSize, pageToken)
+}
+
+func (a *app) Notifications(ctx context.Context, pageSize int, pageToken string) (*NotificationsPage, error) {
+	userID, err := currentUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	size, err := normalizePageSize(pageSize)
+	if err != nil {
+		return nil, err
+	}
+	cursor, err := decodePageToken(pageToken)
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := a.repo.ListNotifications(ctx, userID, cursor.FromID, size+1)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &NotificationsPage{Notifications: list}
+	if len(list) > size {
+		page.Notifications = list[:size]
+		page.NextPageToken = encodePageToken(pageCursor{FromID: list[size].ID})
+	}
+
+	return page, nil
+}
+
+// notifyWatchers записывает уведомления пользователям, добавившим объявление
+// в избранное, если изменились поля из favorites.WatchedFields. Тот, кто
+// изменил объявление, уведомление не получает, а об изменениях снятого с
+// публикации объявления не уведомляется никто: в избранном оно остается, но
+// его содержимое видят только автор и модераторы. Объявление к этому моменту
+// уже сохранено, поэтому ошибки только пишутся в лог.
+func (a *app) notifyWatchers(ctx context.Context, ad ads.Ad, changes []ads.FieldChange) {
+	if !ad.Published {
+		return
+	}
+
+	var watched []ads.FieldChange
+	for _, change := range changes {
+		for _, field := range favorites.WatchedFields {
+			if change.Field == field {
+				watched = append(watched, change)
+			}
+		}
+	}
+	if len(watched) == 0 {
+		return
+	}
+
+	watchers, err := a.repo.ListWatchers(ctx, ad.ID)
+	if err != nil {
+		a.logger.ErrorCtx(ctx, "notify watchers", "ad_id", ad.ID, "error", err)
+		return
+	}
+
+	editorID, _ := UserIDFromContext(ctx)
+	for _, userID := range watchers {
+		if userID == editorID {
+			continue
+		}
+
+		_, err := a.repo.AddNotification(ctx, favorites.Notification{
+			UserID:    userID,
+			AdID:      ad.ID,
+			AdVersion: ad.Version,
+			Changes:   watched,
+			CreatedAt: ad.UpdatedAt,
+		})
+		if err != nil {
+			a.logger.ErrorCtx(ctx, "notify watcher", "user_id", userID, "ad_id", ad.ID, "error", err)
+		}
+	}
+}
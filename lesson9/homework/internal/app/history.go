@@ -0,0 +1,93 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"homework9/internal/ads"
+	"homework9/internal/users"
+)
+
+// AdHistory возвращает историю объявления от первой версии к последней.
+func (a *app) AdHistory(ctx context.Context, adID int64) ([]ads.Revision, error) {
+	_, err := a.getOwnAd(ctx, adID)
+	if errors.Is(err, ErrForbidden) {
+		_, err = a.requireRole(ctx, users.RoleModerator, users.RoleAdmin)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return a.repo.ListRevisions(ctx, adID)
+}
+
+// RevertAd возвращает заголовок, текст, категорию и цену объявления к версии
+// version. Статус публикации при этом не меняется: для него есть ChangeAdStatus
+// с проверками модерации. Возврат записывается в историю как новая версия.
+func (a *app) RevertAd(ctx context.Context, adID int64, version int64, expectedVersion int64) (*ads.Ad, error) {
+	if _, err := a.getOwnAd(ctx, adID); err != nil {
+		return nil, err
+	}
+
+	revisions, err := a.repo.ListRevisions(ctx, adID)
+	if err != nil {
+		return nil, err
+	}
+
+	var target *ads.Revision
+	for i := range revisions {
+		if revisions[i].Version == version {
+			target = &revisions[i]
+		}
+	}
+	if target == nil {
+		return nil, fmt.Errorf("%w: ad %d has no revision %d", ErrNotFound, adID, version)
+	}
+
+	content := target.Content
+	if err := a.checkCategory(ctx, content.CategoryID); err != nil {
+		return nil, err
+	}
+
+	ad, changed, err := a.modifyAdRevision(ctx, adID, expectedVersion, a.getOwnAd, func(ad *ads.Ad) bool {
+		if ad.Title == content.Title && ad.Text == content.Text &&
+			ad.CategoryID == content.CategoryID && ad.Price == content.Price {
+			return false
+		}
+		ad.Title = content.Title
+		ad.Text = content.Text
+		ad.CategoryID = content.CategoryID
+		ad.Price = content.Price
+		return true
+	}, version)
+	if err != nil || !changed {
+		return ad, err
+	}
+
+	a.indexAd(*ad)
+	a.events.publish(AdUpdated, *ad)
+
+	return ad, nil
+}
+
+// newRevision возвращает запись истории об изменении объявления, содержимое
+// которого до изменения было before, для сохранения вместе с ad. Если не
+// изменилось ни одно поле из истории (например, изменились только фотографии),
+// возвращает nil.
+func newRevision(ctx context.Context, before ads.Content, ad ads.Ad, revertedFrom int64) *ads.Revision {
+	changes := ads.Diff(before, ad.Content())
+	if len(changes) == 0 {
+		return nil
+	}
+
+	userID, _ := UserIDFromContext(ctx)
+	return &ads.Revision{
+		AdID:         ad.ID,
+		UserID:       userID,
+		CreatedAt:    ad.UpdatedAt,
+		RevertedFrom: revertedFrom,
+		Content:      ad.Content(),
+		Changes:      changes,
+	}
+}
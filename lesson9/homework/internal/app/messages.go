@@ -0,0 +1,211 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"homework9/internal/messages"
+)
+
+// maxMessageLen - максимальная длина сообщения в символах.
+const maxMessageLen = 1000
+
+// ThreadsPage - страница переписок. NextPageToken пуст на последней странице.
+type ThreadsPage struct {
+	Threads       []messages.Thread
+	NextPageToken string
+}
+
+// MessagesPage - страница сообщений переписки от старых к новым.
+type MessagesPage struct {
+	Messages      []messages.Message
+	NextPageToken string
+}
+
+func (a *app) OpenThread(ctx context.Context, adID int64) (*messages.Thread, error) {
+	userID, err := currentUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ad, err := a.getAd(ctx, adID)
+	if err != nil {
+		return nil, err
+	}
+	if ad.AuthorID == userID {
+		return nil, fmt.Errorf("%w: ad %d is your own", ErrValidation, adID)
+	}
+
+	thread, err := a.repo.AddThread(ctx, messages.Thread{
+		AdID:      ad.ID,
+		BuyerID:   userID,
+		SellerID:  ad.AuthorID,
+		CreatedAt: a.now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &thread, nil
+}
+
+// Threads возвращает страницу переписок текущего пользователя по возрастанию ID.
+func (a *app) Threads(ctx context.Context, pageSize int, pageToken string) (*ThreadsPage, error) {
+	userID, err := currentUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	size, err := normalizePageSize(pageSize)
+	if err != nil {
+		return nil, err
+	}
+	cursor, err := decodePageToken(pageToken)
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := a.repo.ListThreads(ctx, userID, cursor.FromID, size+1)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &ThreadsPage{Threads: list}
+	if len(list) > size {
+		page.Threads = list[:size]
+		page.NextPageToken = encodePageToken(pageCursor{FromID: list[size].ID})
+	}
+
+	return page, nil
+}
+
+func (a *app) SendMessage(ctx context.Context, threadID int64, text string) (*messages.Message, error) {
+	userID, _, err := a.getThread(ctx, threadID)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateMessage(text); err != nil {
+		return nil, err
+	}
+
+	message, err := a.repo.AddMessage(ctx, messages.Message{
+		ThreadID:  threadID,
+		SenderID:  userID,
+		Text:      text,
+		CreatedAt: a.now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Сообщение уже сохранено, поэтому ошибка чтения переписки только пишется в лог:
+	// подписчики увидят сообщение, перечитав переписку.
+	thread, err := a.repo.GetThread(ctx, threadID)
+	if err != nil {
+		a.logger.ErrorCtx(ctx, "publish message", "message_id", message.ID, "thread_id", threadID, "error", err)
+	} else {
+		a.chats.publish(ChatEvent{Type: ChatMessage, Thread: thread, Message: message})
+	}
+
+	return &message, nil
+}
+
+func (a *app) Messages(ctx context.Context, threadID int64, pageSize int, pageToken string) (*MessagesPage, error) {
+	if _, _, err := a.getThread(ctx, threadID); err != nil {
+		return nil, err
+	}
+
+	size, err := normalizePageSize(pageSize)
+	if err != nil {
+		return nil, err
+	}
+	cursor, err := decodePageToken(pageToken)
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := a.repo.ListMessages(ctx, threadID, cursor.FromID, size+1)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &MessagesPage{Messages: list}
+	if len(list) > size {
+		page.Messages = list[:size]
+		page.NextPageToken = encodePageToken(pageCursor{FromID: list[size].ID})
+	}
+
+	return page, nil
+}
+
+// MarkThreadRead не сдвигает отметку о прочтении назад: readID меньше уже
+// прочитанного ничего не меняет.
+func (a *app) MarkThreadRead(ctx context.Context, threadID int64, readID int64) (*messages.Thread, error) {
+	userID, thread, err := a.getThread(ctx, threadID)
+	if err != nil {
+		return nil, err
+	}
+
+	if readID < 0 || readID > thread.LastMessageID {
+		return nil, fmt.Errorf("%w: thread %d has no message %d", ErrValidation, threadID, readID)
+	}
+	if readID == 0 {
+		readID = thread.LastMessageID
+	}
+	if readID <= thread.ReadID(userID) {
+		return &thread, nil
+	}
+
+	if err := a.repo.MarkThreadRead(ctx, threadID, userID, readID); err != nil {
+		return nil, err
+	}
+	thread, err = a.repo.GetThread(ctx, threadID)
+	if err != nil {
+		return nil, err
+	}
+	a.chats.publish(ChatEvent{Type: ChatRead, Thread: thread})
+
+	return &thread, nil
+}
+
+func (a *app) UnreadMessages(ctx context.Context) (int, error) {
+	userID, err := currentUserID(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return a.repo.CountUnread(ctx, userID)
+}
+
+// getThread возвращает текущего пользователя и переписку, если он в ней
+// участвует, иначе ErrForbidden.
+func (a *app) getThread(ctx context.Context, threadID int64) (int64, messages.Thread, error) {
+	userID, err := currentUserID(ctx)
+	if err != nil {
+		return 0, messages.Thread{}, err
+	}
+
+	thread, err := a.repo.GetThread(ctx, threadID)
+	if err != nil {
+		return 0, messages.Thread{}, err
+	}
+	if !thread.Participant(userID) {
+		return 0, messages.Thread{}, fmt.Errorf("%w: user %d is not a participant of thread %d",
+			ErrForbidden, userID, threadID)
+	}
+
+	return userID, thread, nil
+}
+
+func validateMessage(text string) error {
+	switch {
+	case strings.TrimSpace(text) == "":
+		return fmt.Errorf("%w: empty message", ErrValidation)
+	case utf8.RuneCountInString(text) > maxMessageLen:
+		return fmt.Errorf("%w: message is longer than %d characters", ErrValidation, maxMessageLen)
+	}
+
+	return nil
+}
@@ -0,0 +1,209 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"homework9/internal/ads"
+	"homework9/internal/users"
+)
+
+const maxReasonLen = 500
+
+// WithPremoderation включает премодерацию: опубликованное автором объявление
+// появляется в выдаче только после одобрения модератором.
+func WithPremoderation() Option {
+	return func(o *options) {
+		o.premoderation = true
+	}
+}
+
+// WithAdmins делает пользователей с указанными ID администраторами независимо
+// от сохраненной роли. Нужен, чтобы назначить первых модераторов.
+func WithAdmins(userIDs ...int64) Option {
+	return func(o *options) {
+		o.admins = append(o.admins, userIDs...)
+	}
+}
+
+// ModerationQueue возвращает объявления, ожидающие проверки, по возрастанию ID.
+func (a *app) ModerationQueue(ctx context.Context, pageSize int, pageToken string) (*AdsPage, error) {
+	if _, err := a.requireRole(ctx, users.RoleModerator, users.RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	return a.listPage(ctx, AdFilter{Moderation: ads.ModerationPending}, pageSize, pageToken)
+}
+
+// ApproveAd одобряет объявление из очереди на проверку и публикует его.
+func (a *app) ApproveAd(ctx context.Context, adID int64) (*ads.Ad, error) {
+	if _, err := a.requireRole(ctx, users.RoleModerator, users.RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	var notPending bool
+	ad, _, err := a.modifyAdWith(ctx, adID, 0, a.getAd, func(ad *ads.Ad) bool {
+		notPending = ad.Moderation != ads.ModerationPending
+		if notPending {
+			return false
+		}
+		ad.Published = true
+		ad.Moderation = ads.ModerationApproved
+		ad.ModerationReason = ""
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	if notPending {
+		return nil, fmt.Errorf("%w: ad %d is not pending moderation", ErrValidation, adID)
+	}
+
+	a.publishAdChange(*ad, false)
+
+	return ad, nil
+}
+
+// RejectAd отклоняет объявление из очереди или снимает с публикации уже
+// опубликованное. Причина обязательна и видна автору.
+func (a *app) RejectAd(ctx context.Context, adID int64, reason string) (*ads.Ad, error) {
+	if _, err := a.requireRole(ctx, users.RoleModerator, users.RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	reason = strings.TrimSpace(reason)
+	switch {
+	case reason == "":
+		return nil, fmt.Errorf("%w: empty rejection reason", ErrValidation)
+	case utf8.RuneCountInString(reason) > maxReasonLen:
+		return nil, fmt.Errorf("%w: reason is longer than %d characters", ErrValidation, maxReasonLen)
+	}
+
+	var wasPublished bool
+	ad, _, err := a.modifyAdWith(ctx, adID, 0, a.getAd, func(ad *ads.Ad) bool {
+		wasPublished = ad.Published
+		ad.Published = false
+		ad.Moderation = ads.ModerationRejected
+		ad.ModerationReason = reason
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	a.publishAdChange(*ad, wasPublished)
+
+	return ad, nil
+}
+
+func (a *app) SetUserRole(ctx context.Context, userID int64, role users.Role) (*users.User, error) {
+	if _, err := a.requireRole(ctx, users.RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	if !role.Valid() {
+		return nil, fmt.Errorf("%w: unknown role %q", ErrValidation, role)
+	}
+
+	user, err := a.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	user.Role = role
+	if err := a.repo.UpdateUser(ctx, user); err != nil {
+		return nil, err
+	}
+	user.Role = a.roleOf(user)
+
+	return &user, nil
+}
+
+// requireRole возвращает текущего пользователя, если у него одна из ролей roles,
+// иначе ErrForbidden.
+func (a *app) requireRole(ctx context.Context, roles ...users.Role) (users.User, error) {
+	userID, err := currentUserID(ctx)
+	if err != nil {
+		return users.User{}, err
+	}
+
+	user, err := a.getUser(ctx, userID)
+	if errors.Is(err, ErrNotFound) {
+		return users.User{}, fmt.Errorf("%w: user %d does not exist", ErrUnauthenticated, userID)
+	}
+	if err != nil {
+		return users.User{}, err
+	}
+
+	role := a.roleOf(user)
+	for _, r := range roles {
+		if role == r {
+			return user, nil
+		}
+	}
+
+	return users.User{}, fmt.Errorf("%w: user %d has role %s", ErrForbidden, userID, role)
+}
+
+func (a *app) roleOf(user users.User) users.Role {
+	switch {
+	case a.admins[user.ID]:
+		return users.RoleAdmin
+	case user.Role == "":
+		return users.RoleUser
+	}
+
+	return user.Role
+}
+
+// getAd возвращает объявление, если оно не в корзине, иначе ErrNotFound.
+func (a *app) getAd(ctx context.Context, adID int64) (ads.Ad, error) {
+	ad, err := a.repo.GetAd(ctx, adID)
+	if err != nil {
+		return ads.Ad{}, err
+	}
+	if ad.Trashed() {
+		return ads.Ad{}, fmt.Errorf("ad %d is in the trash: %w", adID, ErrNotFound)
+	}
+
+	return ad, nil
+}
+
+// getVisibleAd возвращает объявление, если его может видеть текущий
+// пользователь: опубликованное - любой, остальные - автор и модераторы.
+// Для остальных объявление не существует (ErrNotFound).
+func (a *app) getVisibleAd(ctx context.Context, adID int64) (ads.Ad, error) {
+	ad, err := a.getAd(ctx, adID)
+	if err != nil || ad.Published {
+		return ad, err
+	}
+
+	if userID, err := currentUserID(ctx); err == nil && userID == ad.AuthorID {
+		return ad, nil
+	}
+	_, err = a.requireRole(ctx, users.RoleModerator, users.RoleAdmin)
+	if errors.Is(err, ErrForbidden) || errors.Is(err, ErrUnauthenticated) {
+		return ads.Ad{}, fmt.Errorf("ad %d is not published: %w", adID, ErrNotFound)
+	}
+	if err != nil {
+		return ads.Ad{}, err
+	}
+
+	return ad, nil
+}
+
+// getUser возвращает пользователя, если он не в корзине, иначе ErrNotFound.
+func (a *app) getUser(ctx context.Context, userID int64) (users.User, error) {
+	user, err := a.repo.GetUser(ctx, userID)
+	if err != nil {
+		return users.User{}, err
+	}
+	if user.Trashed() {
+		return users.User{}, fmt.Errorf("user %d is in the trash: %w", userID, ErrNotFound)
+	}
+
+	return user, nil
+}
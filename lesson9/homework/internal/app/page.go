@@ -0,0 +1,252 @@
+package app
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"time"
+
+	"homework9/internal/ads"
+	"homework9/internal/money"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 1000
+)
+
+// AdFilter ограничивает выборку в Repository.ListAds. Объявления возвращаются
+// по возрастанию ID, если не задан другой порядок в Sort и Desc.
+type AdFilter struct {
+	PublishedOnly bool
+	// Moderation, если не пуст, оставляет только объявления в этом состоянии модерации.
+	Moderation ads.Moderation
+	// CategoryIDs, если не пуст, оставляет только объявления из этих категорий.
+	CategoryIDs []int64
+	// Trash определяет, возвращать ли объявления из корзины, по умолчанию - нет.
+	Trash TrashFilter
+	// DeletedBefore, если не нулевое, оставляет только объявления, перемещенные
+	// в корзину раньше этого времени. Учитывается с TrashOnly.
+	DeletedBefore time.Time
+	// Scheduled оставляет только объявления с запланированной публикацией или снятием с публикации.
+	Scheduled bool
+	// AuthorID, если не nil, оставляет только объявления этого автора.
+	AuthorID *int64
+	// FavoritedBy, если не nil, оставляет только объявления из избранного этого пользователя.
+	FavoritedBy *int64
+	// FromID - вернуть только объявления с ID >= FromID.
+	FromID int64
+	// Limit - максимальное число объявлений, 0 - без ограничения.
+	Limit int
+
+	// Sort и Desc задают порядок по ключу из KeyOf. Объявления без ключа идут
+	// после остальных при любом направлении, при равных ключах порядок задает ID.
+	Sort AdSort
+	Desc bool
+	// After, если не nil, оставляет только объявления после него в этом порядке.
+	After *AdKey
+	// PriceFactors переводят цены в ключи (см. PriceKey) для SortByPrice и
+	// границ MinPriceKey и MaxPriceKey. С любой из границ объявления без ключа
+	// цены не возвращаются.
+	PriceFactors map[money.Currency]int64
+	MinPriceKey  *int64
+	MaxPriceKey  *int64
+}
+
+// AdKey - позиция объявления в порядке AdFilter: ключ сортировки (nil - ключа
+// нет) и ID.
+type AdKey struct {
+	Key *int64
+	ID  int64
+}
+
+// PriceKey возвращает ключ цены: сумму в минимальных единицах, умноженную на
+// множитель ее валюты из PriceFactors. Ключи пропорциональны ценам в money.Base,
+// ключи больше math.MaxInt64 считаются равными ему. ok == false, если цена не
+// указана или у ее валюты нет множителя.
+func (f AdFilter) PriceKey(price money.Money) (key int64, ok bool) {
+	factor, ok := f.PriceFactors[price.Currency]
+	if price.IsZero() || !ok {
+		return 0, false
+	}
+
+	return clampInt64(new(big.Int).Mul(big.NewInt(price.Amount), big.NewInt(factor))), true
+}
+
+// KeyOf возвращает позицию объявления в порядке f.Sort. Ключ даты - время в
+// наносекундах Unix, у нулевого времени ключа нет.
+func (f AdFilter) KeyOf(ad ads.Ad) AdKey {
+	var (
+		key int64
+		ok  bool
+	)
+	switch f.Sort {
+	case SortByPrice:
+		key, ok = f.PriceKey(ad.Price)
+	case SortByCreated:
+		key, ok = ad.CreatedAt.UnixNano(), !ad.CreatedAt.IsZero()
+	case SortByUpdated:
+		key, ok = ad.UpdatedAt.UnixNano(), !ad.UpdatedAt.IsZero()
+	}
+	if !ok {
+		return AdKey{ID: ad.ID}
+	}
+
+	return AdKey{Key: &key, ID: ad.ID}
+}
+
+// Before сообщает, идет ли x раньше y в порядке f.
+func (f AdFilter) Before(x, y AdKey) bool {
+	switch {
+	case x.Key == nil && y.Key == nil:
+	case x.Key == nil:
+		return false
+	case y.Key == nil:
+		return true
+	case *x.Key != *y.Key:
+		return (*x.Key < *y.Key) != f.Desc
+	}
+
+	if x.ID == y.ID {
+		return false
+	}
+
+	return (x.ID < y.ID) != f.Desc
+}
+
+// Match сообщает, проходит ли объявление границы цены и After.
+func (f AdFilter) Match(ad ads.Ad) bool {
+	if f.MinPriceKey != nil || f.MaxPriceKey != nil {
+		key, ok := f.PriceKey(ad.Price)
+		if !ok || f.MinPriceKey != nil && key < *f.MinPriceKey || f.MaxPriceKey != nil && key > *f.MaxPriceKey {
+			return false
+		}
+	}
+
+	return f.After == nil || f.Before(*f.After, f.KeyOf(ad))
+}
+
+func clampInt64(x *big.Int) int64 {
+	switch {
+	case x.IsInt64():
+		return x.Int64()
+	case x.Sign() > 0:
+		return math.MaxInt64
+	}
+
+	return math.MinInt64
+}
+
+// TrashFilter - отбор объявлений по нахождению в корзине.
+type TrashFilter int
+
+const (
+	// TrashExcluded - только объявления не из корзины.
+	TrashExcluded TrashFilter = iota
+	// TrashOnly - только объявления из корзины.
+	TrashOnly
+	// TrashIncluded - все объявления.
+	TrashIncluded
+)
+
+// AdsQuery - параметры App.ListAds.
+type AdsQuery struct {
+	// CategoryID, если не 0, оставляет объявления категории и всех ее подкатегорий.
+	CategoryID int64
+	// MinPrice и MaxPrice, если не нулевые, оставляют объявления с ценой в этих
+	// границах после пересчета по курсам. Объявления без цены при этом не попадают в выдачу.
+	MinPrice money.Money
+	MaxPrice money.Money
+	// Sort и Desc задают порядок, по умолчанию - по возрастанию ID.
+	Sort AdSort
+	Desc bool
+	// Currency, если не пуста, - валюта, в которой возвращаются AdsPage.DisplayPrices.
+	Currency money.Currency
+	// PageSize 0 - размер по умолчанию, отрицательный отклоняется.
+	// PageToken - NextPageToken предыдущей страницы.
+	PageSize  int
+	PageToken string
+}
+
+// AdSort - поле, по которому сортируется список объявлений.
+type AdSort string
+
+const (
+	SortByID      AdSort = ""
+	SortByCreated AdSort = "created"
+	SortByUpdated AdSort = "updated"
+	// SortByPrice сравнивает цены в разных валютах по курсам, объявления без
+	// цены или с валютой без курса идут в конце при любом направлении.
+	SortByPrice AdSort = "price"
+)
+
+// Valid сообщает, является ли s известным порядком сортировки.
+func (s AdSort) Valid() bool {
+	switch s {
+	case SortByID, SortByCreated, SortByUpdated, SortByPrice:
+		return true
+	}
+
+	return false
+}
+
+// AdsPage - страница списка объявлений. NextPageToken пуст на последней странице.
+type AdsPage struct {
+	Ads           []ads.Ad
+	NextPageToken string
+	// DisplayPrices - цены объявлений страницы в AdsQuery.Currency по ID
+	// объявления. Объявлений без цены или с валютой без курса здесь нет.
+	DisplayPrices map[int64]money.Money
+}
+
+// pageCursor - содержимое непрозрачного курсора. ID объявлений только растут,
+// поэтому курсор по ID не пропускает и не повторяет объявления, даже если
+// между запросами страниц были созданы новые.
+type pageCursor struct {
+	FromID int64 `json:"from_id"`
+
+	// Для списков, отсортированных не по ID, - порядок и ключ сортировки с ID
+	// последнего объявления предыдущей страницы. Key пуст у объявлений без ключа.
+	Sort    AdSort `json:"sort,omitempty"`
+	Desc    bool   `json:"desc,omitempty"`
+	Key     string `json:"key,omitempty"`
+	AfterID int64  `json:"after_id,omitempty"`
+}
+
+func encodePageToken(t pageCursor) string {
+	data, _ := json.Marshal(t)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodePageToken(s string) (pageCursor, error) {
+	if s == "" {
+		return pageCursor{}, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return pageCursor{}, fmt.Errorf("%w: invalid page token", ErrValidation)
+	}
+
+	var t pageCursor
+	if err := json.Unmarshal(data, &t); err != nil || t.FromID < 0 {
+		return pageCursor{}, fmt.Errorf("%w: invalid page token", ErrValidation)
+	}
+
+	return t, nil
+}
+
+func normalizePageSize(size int) (int, error) {
+	switch {
+	case size < 0:
+		return 0, fmt.Errorf("%w: negative page size", ErrValidation)
+	case size == 0:
+		return defaultPageSize, nil
+	case size > maxPageSize:
+		return maxPageSize, nil
+	}
+
+	return size, nil
+}
@@ -0,0 +1,259 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+
+	"homework9/internal/ads"
+	"homework9/internal/photos"
+)
+
+const defaultMaxPhotos = 10
+
+// ErrNoBlobStore - сервис запущен без хранилища фотографий.
+var ErrNoBlobStore = errors.New("photo storage is not configured")
+
+// BlobStore хранит содержимое фотографий по ключу. Get для отсутствующего
+// ключа должен возвращать ошибку, оборачивающую ErrNotFound, а Delete -
+// ничего не делать.
+type BlobStore interface {
+	Put(ctx context.Context, key string, r io.Reader) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// WithBlobStore задает хранилище фотографий. Без него загрузка фотографий
+// возвращает ErrNoBlobStore.
+func WithBlobStore(store BlobStore) Option {
+	return func(o *options) {
+		o.blobs = store
+	}
+}
+
+// WithMaxPhotos задает наибольшее число фотографий у объявления, по умолчанию 10.
+func WithMaxPhotos(n int) Option {
+	return func(o *options) {
+		o.maxPhotos = n
+	}
+}
+
+// AddPhotos добавляет фотографии в конец списка фотографий объявления.
+// Фотографии либо добавляются все, либо ни одной.
+func (a *app) AddPhotos(ctx context.Context, adID int64, files [][]byte) (*ads.Ad, error) {
+	if a.blobs == nil {
+		return nil, ErrNoBlobStore
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("%w: no photos", ErrValidation)
+	}
+
+	ad, err := a.getOwnAd(ctx, adID)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.checkPhotoCount(ad, len(files)); err != nil {
+		return nil, err
+	}
+
+	images := make([]*photos.Image, 0, len(files))
+	for i, data := range files {
+		img, err := photos.Process(data)
+		if err != nil {
+			return nil, fmt.Errorf("%w: photo %d: %s", ErrValidation, i+1, err)
+		}
+		images = append(images, img)
+	}
+
+	added := make([]ads.Photo, 0, len(files))
+	for i, img := range images {
+		photo := ads.Photo{
+			ID:          newPhotoID(),
+			ContentType: img.ContentType,
+			Width:       img.Width,
+			Height:      img.Height,
+			Size:        int64(len(files[i])),
+		}
+		if err := a.putPhoto(ctx, photo.ID, files[i], img); err != nil {
+			a.deletePhotoBlobs(ctx, append(added, photo))
+			return nil, err
+		}
+		added = append(added, photo)
+	}
+
+	var countErr error
+	updated, _, err := a.modifyAd(ctx, adID, 0, func(ad *ads.Ad) bool {
+		if countErr = a.checkPhotoCount(*ad, len(added)); countErr != nil {
+			return false
+		}
+		ad.Photos = append(ad.Photos[:len(ad.Photos):len(ad.Photos)], added...)
+		return true
+	})
+	if err == nil {
+		err = countErr
+	}
+	if err != nil {
+		a.deletePhotoBlobs(ctx, added)
+		return nil, err
+	}
+
+	a.publishAdChange(*updated, updated.Published)
+
+	return updated, nil
+}
+
+// ReorderPhotos задает порядок фотографий. photoIDs должен содержать ID всех
+// фотографий объявления, каждый по одному разу.
+func (a *app) ReorderPhotos(ctx context.Context, adID int64, photoIDs []string, expectedVersion int64) (*ads.Ad, error) {
+	var orderErr error
+	ad, changed, err := a.modifyAd(ctx, adID, expectedVersion, func(ad *ads.Ad) bool {
+		byID := make(map[string]ads.Photo, len(ad.Photos))
+		for _, p := range ad.Photos {
+			byID[p.ID] = p
+		}
+
+		orderErr = nil
+		if len(photoIDs) != len(ad.Photos) {
+			orderErr = fmt.Errorf("%w: expected %d photo IDs, got %d", ErrValidation, len(ad.Photos), len(photoIDs))
+			return false
+		}
+
+		reordered := make([]ads.Photo, 0, len(photoIDs))
+		for _, id := range photoIDs {
+			p, ok := byID[id]
+			if !ok {
+				orderErr = fmt.Errorf("%w: unknown or repeated photo %q", ErrValidation, id)
+				return false
+			}
+			delete(byID, id)
+			reordered = append(reordered, p)
+		}
+		ad.Photos = reordered
+		return true
+	})
+	if err == nil {
+		err = orderErr
+	}
+	if err != nil || !changed {
+		return ad, err
+	}
+
+	a.publishAdChange(*ad, ad.Published)
+
+	return ad, nil
+}
+
+// DeletePhoto удаляет фотографию из объявления и из хранилища.
+func (a *app) DeletePhoto(ctx context.Context, adID int64, photoID string) (*ads.Ad, error) {
+	var deleted *ads.Photo
+	ad, _, err := a.modifyAd(ctx, adID, 0, func(ad *ads.Ad) bool {
+		deleted = nil
+		for i, p := range ad.Photos {
+			if p.ID == photoID {
+				deleted = &p
+				ad.Photos = append(ad.Photos[:i:i], ad.Photos[i+1:]...)
+				return true
+			}
+		}
+		return false
+	})
+	if err != nil {
+		return nil, err
+	}
+	if deleted == nil {
+		return nil, fmt.Errorf("photo %s of ad %d: %w", photoID, adID, ErrNotFound)
+	}
+
+	a.deletePhotoBlobs(ctx, []ads.Photo{*deleted})
+	a.publishAdChange(*ad, ad.Published)
+
+	return ad, nil
+}
+
+// GetPhoto возвращает содержимое варианта фотографии и его тип. Фотографии
+// неопубликованного объявления доступны только автору и модераторам.
+func (a *app) GetPhoto(ctx context.Context, adID int64, photoID string, variant photos.Variant) (io.ReadCloser, string, error) {
+	if a.blobs == nil {
+		return nil, "", ErrNoBlobStore
+	}
+
+	ad, err := a.getVisibleAd(ctx, adID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	for _, p := range ad.Photos {
+		if p.ID != photoID {
+			continue
+		}
+
+		contentType := photos.ThumbnailContentType
+		if variant == photos.Original {
+			contentType = p.ContentType
+		} else if _, ok := photos.ThumbnailSizes[variant]; !ok {
+			return nil, "", fmt.Errorf("%w: unknown photo variant %q", ErrValidation, variant)
+		}
+
+		r, err := a.blobs.Get(ctx, photoKey(photoID, variant))
+		if err != nil {
+			return nil, "", err
+		}
+		return r, contentType, nil
+	}
+
+	return nil, "", fmt.Errorf("photo %s of ad %d: %w", photoID, adID, ErrNotFound)
+}
+
+func (a *app) checkPhotoCount(ad ads.Ad, adding int) error {
+	if len(ad.Photos)+adding > a.maxPhotos {
+		return fmt.Errorf("%w: ad %d would have %d photos, max %d",
+			ErrValidation, ad.ID, len(ad.Photos)+adding, a.maxPhotos)
+	}
+
+	return nil
+}
+
+func (a *app) putPhoto(ctx context.Context, photoID string, original []byte, img *photos.Image) error {
+	if err := a.blobs.Put(ctx, photoKey(photoID, photos.Original), bytes.NewReader(original)); err != nil {
+		return fmt.Errorf("store photo: %w", err)
+	}
+	for variant, data := range img.Thumbnails {
+		if err := a.blobs.Put(ctx, photoKey(photoID, variant), bytes.NewReader(data)); err != nil {
+			return fmt.Errorf("store photo: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// deletePhotoBlobs удаляет содержимое фотографий. Ошибки игнорируются:
+// объявление уже не ссылается на эти фотографии.
+func (a *app) deletePhotoBlobs(ctx context.Context, list []ads.Photo) {
+	if a.blobs == nil {
+		return
+	}
+
+	for _, p := range list {
+		_ = a.blobs.Delete(ctx, photoKey(p.ID, photos.Original))
+		for variant := range photos.ThumbnailSizes {
+			_ = a.blobs.Delete(ctx, photoKey(p.ID, variant))
+		}
+	}
+}
+
+func photoKey(photoID string, variant photos.Variant) string {
+	return photoID + "." + string(variant)
+}
+
+func newPhotoID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("crypto/rand: %s", err))
+	}
+
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,226 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/big"
+
+	"homework9/internal/ads"
+	"homework9/internal/money"
+	"homework9/internal/users"
+)
+
+func (a *app) ExchangeRates(ctx context.Context) ([]money.Rate, error) {
+	return a.repo.ListRates(ctx)
+}
+
+// SetExchangeRate задает стоимость одной единицы currency в money.Base.
+func (a *app) SetExchangeRate(ctx context.Context, currency money.Currency, value string) (*money.Rate, error) {
+	if _, err := a.requireRole(ctx, users.RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case !currency.Valid():
+		return nil, fmt.Errorf("%w: unknown currency %q", ErrValidation, currency)
+	case currency == money.Base:
+		return nil, fmt.Errorf("%w: rate of %s is always 1", ErrValidation, money.Base)
+	}
+	if _, err := money.ParseRate(value); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrValidation, err)
+	}
+
+	// Курс должен позволять сравнивать цены в хранилище, см. listSorted.
+	rates, err := a.exchangeRates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rates[currency], _ = money.ParseRate(value)
+	if _, _, err := priceFactors(rates); err != nil {
+		return nil, err
+	}
+
+	rate := money.Rate{Currency: currency, Value: value}
+	if err := a.repo.SetRate(ctx, rate); err != nil {
+		return nil, err
+	}
+
+	return &rate, nil
+}
+
+func (a *app) exchangeRates(ctx context.Context) (money.Rates, error) {
+	list, err := a.repo.ListRates(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return money.NewRates(list)
+}
+
+// listSorted - listPage для сортировки не по возрастанию ID и фильтров по цене.
+// Цены в разных валютах сравниваются по целым ключам из priceFactors, поэтому
+// отбор, сортировку и курсор страницы выполняет хранилище.
+func (a *app) listSorted(ctx context.Context, filter AdFilter, query AdsQuery, rates money.Rates) (*AdsPage, error) {
+	size, err := normalizePageSize(query.PageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := decodePageToken(query.PageToken)
+	if err != nil {
+		return nil, err
+	}
+	if query.PageToken != "" && (cursor.Sort != query.Sort || cursor.Desc != query.Desc) {
+		return nil, fmt.Errorf("%w: page token was issued for another sort order", ErrValidation)
+	}
+
+	factors, scale, err := priceFactors(rates)
+	if err != nil {
+		return nil, err
+	}
+	filter.Sort, filter.Desc, filter.PriceFactors = query.Sort, query.Desc, factors
+
+	if filter.MinPriceKey, err = priceBound(filter, query.MinPrice); err != nil {
+		return nil, err
+	}
+	if filter.MaxPriceKey, err = priceBound(filter, query.MaxPrice); err != nil {
+		return nil, err
+	}
+
+	if query.PageToken != "" {
+		after, err := cursorKey(cursor, query, scale)
+		if err != nil {
+			return nil, err
+		}
+		filter.After = &after
+	}
+
+	// Берем на одно объявление больше, чтобы понять, есть ли следующая страница.
+	filter.Limit = size + 1
+	list, err := a.repo.ListAds(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &AdsPage{Ads: list}
+	if len(list) > size {
+		page.Ads = list[:size]
+		last := filter.KeyOf(list[size-1])
+		next := pageCursor{Sort: query.Sort, Desc: query.Desc, AfterID: last.ID}
+		if last.Key != nil {
+			key := big.NewRat(*last.Key, 1)
+			if query.Sort == SortByPrice {
+				key.Quo(key, new(big.Rat).SetInt(scale))
+			}
+			next.Key = key.RatString()
+		}
+		page.NextPageToken = encodePageToken(next)
+	}
+
+	return page, nil
+}
+
+// cursorKey возвращает позицию последнего объявления предыдущей страницы. Ключ
+// цены в курсоре хранится в единицах money.Base, чтобы курсор пережил смену
+// курсов: если в новом масштабе ключ не целый, позиция сдвигается на ближайший
+// целый ключ так, чтобы объявления с ним не пропустить и не повторить.
+func cursorKey(cursor pageCursor, query AdsQuery, scale *big.Int) (AdKey, error) {
+	after := AdKey{ID: cursor.AfterID}
+	if cursor.Key == "" {
+		return after, nil
+	}
+
+	key, ok := new(big.Rat).SetString(cursor.Key)
+	if !ok {
+		return AdKey{}, fmt.Errorf("%w: invalid page token", ErrValidation)
+	}
+	if query.Sort == SortByPrice {
+		key.Mul(key, new(big.Rat).SetInt(scale))
+	}
+
+	rounded := new(big.Int).Div(key.Num(), key.Denom())
+	if !key.IsInt() {
+		// Ключ лежит между rounded и rounded+1.
+		if query.Desc {
+			rounded.Add(rounded, big.NewInt(1))
+			after.ID = -1
+		} else {
+			after.ID = math.MaxInt64
+		}
+	}
+	k := clampInt64(rounded)
+	after.Key = &k
+
+	return after, nil
+}
+
+// priceFactors возвращает множители, переводящие цену в минимальных единицах
+// валюты в целый ключ, пропорциональный цене в money.Base, и их общий масштаб:
+// ключ / scale - цена в единицах money.Base.
+func priceFactors(rates money.Rates) (map[money.Currency]int64, *big.Int, error) {
+	units := make(map[money.Currency]*big.Rat, len(rates))
+	scale := big.NewInt(1)
+	for currency := range rates {
+		unit, _ := rates.InBase(money.Money{Amount: 1, Currency: currency})
+		units[currency] = unit
+
+		gcd := new(big.Int).GCD(nil, nil, scale, unit.Denom())
+		scale.Mul(scale, new(big.Int).Quo(unit.Denom(), gcd))
+	}
+
+	factors := make(map[money.Currency]int64, len(units))
+	for currency, unit := range units {
+		factor := new(big.Int).Mul(unit.Num(), new(big.Int).Quo(scale, unit.Denom()))
+		if !factor.IsInt64() {
+			return nil, nil, fmt.Errorf("%w: exchange rates are too precise to compare prices", ErrValidation)
+		}
+		factors[currency] = factor.Int64()
+	}
+
+	return factors, scale, nil
+}
+
+// displayPrices пересчитывает цены объявлений в currency.
+func displayPrices(list []ads.Ad, currency money.Currency, rates money.Rates) map[int64]money.Money {
+	prices := make(map[int64]money.Money, len(list))
+	for _, ad := range list {
+		if price, ok := rates.Convert(ad.Price, currency); ok {
+			prices[ad.ID] = price
+		}
+	}
+
+	return prices
+}
+
+// priceBound возвращает ключ границы фильтра по цене, nil - границы нет.
+func priceBound(filter AdFilter, bound money.Money) (*int64, error) {
+	if bound.IsZero() {
+		return nil, nil
+	}
+	if err := validatePrice(bound); err != nil {
+		return nil, err
+	}
+
+	key, ok := filter.PriceKey(bound)
+	if !ok {
+		return nil, fmt.Errorf("%w: no exchange rate for %s", ErrValidation, bound.Currency)
+	}
+
+	return &key, nil
+}
+
+func validatePrice(price money.Money) error {
+	switch {
+	case price.IsZero() && price.Amount != 0:
+		return fmt.Errorf("%w: price without currency", ErrValidation)
+	case price.IsZero():
+		return nil
+	case !price.Currency.Valid():
+		return fmt.Errorf("%w: unknown currency %q", ErrValidation, price.Currency)
+	case price.Amount < 0:
+		return fmt.Errorf("%w: negative price", ErrValidation)
+	}
+
+	return nil
+}
@@ -0,0 +1,168 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"homework9/internal/ads"
+)
+
+// schedulerRetryDelay - через сколько повторить проход планировщика после ошибки.
+const schedulerRetryDelay = time.Minute
+
+func (a *app) ScheduleAd(ctx context.Context, adID int64, publishAt time.Time, unpublishAt time.Time,
+	expectedVersion int64) (*ads.Ad, error) {
+	publishAt, unpublishAt = publishAt.UTC(), unpublishAt.UTC()
+
+	now := a.now()
+	switch {
+	case !publishAt.IsZero() && !publishAt.After(now):
+		return nil, fmt.Errorf("%w: publish time %s is in the past", ErrValidation, publishAt.Format(time.RFC3339))
+	case !unpublishAt.IsZero() && !unpublishAt.After(now):
+		return nil, fmt.Errorf("%w: unpublish time %s is in the past", ErrValidation, unpublishAt.Format(time.RFC3339))
+	case !publishAt.IsZero() && !unpublishAt.IsZero() && !unpublishAt.After(publishAt):
+		return nil, fmt.Errorf("%w: unpublish time must be after publish time", ErrValidation)
+	}
+
+	ad, changed, err := a.modifyAd(ctx, adID, expectedVersion, func(ad *ads.Ad) bool {
+		if ad.PublishAt.Equal(publishAt) && ad.UnpublishAt.Equal(unpublishAt) {
+			return false
+		}
+		ad.PublishAt = publishAt
+		ad.UnpublishAt = unpublishAt
+		return true
+	})
+	if err != nil || !changed {
+		return ad, err
+	}
+
+	a.wakeScheduler()
+	a.events.publish(AdUpdated, *ad)
+
+	return ad, nil
+}
+
+func (a *app) RunScheduler(ctx context.Context) {
+	for {
+		next, err := a.applySchedules(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			a.logger.ErrorCtx(ctx, "apply ad schedules", "error", err)
+			next = a.now().Add(schedulerRetryDelay)
+		}
+
+		var timer <-chan time.Time
+		if !next.IsZero() {
+			timer = a.clock.After(next.Sub(a.now()))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-a.scheduleWake:
+		case <-timer:
+		}
+	}
+}
+
+// applySchedules выполняет наступившие изменения статуса и возвращает время
+// следующего запланированного изменения, нулевое - если ничего не запланировано.
+// Ошибка одного объявления не мешает остальным: она пишется в лог, а изменение
+// повторяется через schedulerRetryDelay.
+func (a *app) applySchedules(ctx context.Context) (time.Time, error) {
+	list, err := a.repo.ListAds(ctx, AdFilter{Scheduled: true})
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var next time.Time
+	now := a.now()
+	for _, ad := range list {
+		times := []time.Time{ad.PublishAt, ad.UnpublishAt}
+		if due(ad.PublishAt, now) || due(ad.UnpublishAt, now) {
+			if ad, err = a.applySchedule(ctx, ad, now); err != nil {
+				if ctx.Err() != nil {
+					return time.Time{}, ctx.Err()
+				}
+				a.logger.ErrorCtx(ctx, "apply ad schedule", "ad_id", ad.ID, "error", err)
+				times = []time.Time{now.Add(schedulerRetryDelay)}
+			} else {
+				times = []time.Time{ad.PublishAt, ad.UnpublishAt}
+			}
+		}
+
+		for _, t := range times {
+			if !t.IsZero() && (next.IsZero() || t.Before(next)) {
+				next = t
+			}
+		}
+	}
+
+	return next, nil
+}
+
+// applySchedule выполняет наступившие к now изменения статуса объявления от
+// имени его автора и возвращает объявление после них.
+func (a *app) applySchedule(ctx context.Context, ad ads.Ad, now time.Time) (ads.Ad, error) {
+	ctx = WithUserID(ctx, ad.AuthorID)
+
+	var wasPublished bool
+	updated, changed, err := a.modifyAdWith(ctx, ad.ID, 0, a.getAd, func(ad *ads.Ad) bool {
+		wasPublished = ad.Published
+
+		// Если наступили оба времени (например, сервис был остановлен),
+		// изменения выполняются в том порядке, в котором были запланированы.
+		steps := []struct {
+			at        time.Time
+			published bool
+		}{{ad.PublishAt, true}, {ad.UnpublishAt, false}}
+		if steps[1].at.Before(steps[0].at) {
+			steps[0], steps[1] = steps[1], steps[0]
+		}
+
+		changed := false
+		for _, step := range steps {
+			if due(step.at, now) {
+				a.setPublished(ad, step.published)
+				changed = true
+			}
+		}
+		if due(ad.PublishAt, now) {
+			ad.PublishAt = time.Time{}
+		}
+		if due(ad.UnpublishAt, now) {
+			ad.UnpublishAt = time.Time{}
+		}
+		return changed
+	})
+	if errors.Is(err, ErrNotFound) {
+		// Объявление удалили или переместили в корзину после выборки.
+		return ads.Ad{ID: ad.ID}, nil
+	}
+	if err != nil {
+		return ad, err
+	}
+
+	if changed {
+		a.publishAdChange(*updated, wasPublished)
+	}
+
+	return *updated, nil
+}
+
+// wakeScheduler сообщает RunScheduler, что расписание изменилось.
+func (a *app) wakeScheduler() {
+	select {
+	case a.scheduleWake <- struct{}{}:
+	default:
+	}
+}
+
+// due сообщает, что запланированное на t изменение должно быть выполнено к now.
+func due(t time.Time, now time.Time) bool {
+	return !t.IsZero() && !t.After(now)
+}
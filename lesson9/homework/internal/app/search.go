@@ -0,0 +1,123 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"homework9/internal/ads"
+	"homework9/internal/search"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+	snippetWords       = 30
+)
+
+// AdSearchResult - объявление, найденное полнотекстовым поиском.
+type AdSearchResult struct {
+	Ad    ads.Ad
+	Score float64
+	// Title - заголовок с подсвеченными совпадениями.
+	Title string
+	// Snippet - фрагмент текста вокруг первого совпадения с подсветкой.
+	Snippet string
+}
+
+// SearchAds ищет опубликованные объявления по заголовку и тексту и
+// возвращает их по убыванию релевантности (BM25). limit <= 0 - значение по умолчанию.
+func (a *app) SearchAds(ctx context.Context, query string, limit int) ([]AdSearchResult, error) {
+	if strings.TrimSpace(query) == "" {
+		return nil, fmt.Errorf("%w: empty search query", ErrValidation)
+	}
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+	if limit > maxSearchLimit {
+		limit = maxSearchLimit
+	}
+
+	index, err := a.searchIndex(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	hits := index.Search(query, limit)
+	results := make([]AdSearchResult, 0, len(hits))
+	for _, hit := range hits {
+		// Индекс обновляется после записи в репозиторий, поэтому объявление
+		// могли уже снять с публикации или удалить - такие пропускаем.
+		ad, err := a.repo.GetAd(ctx, hit.ID)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !ad.Published || ad.Trashed() {
+			continue
+		}
+
+		results = append(results, AdSearchResult{
+			Ad:      ad,
+			Score:   hit.Score,
+			Title:   search.Highlight(ad.Title, query, 0),
+			Snippet: search.Highlight(ad.Text, query, snippetWords),
+		})
+	}
+
+	return results, nil
+}
+
+// searchIndex строит индекс по содержимому репозитория при первом обращении.
+// Изменения, сделанные до этого, в индекс не пишутся - они попадут в него из репозитория.
+func (a *app) searchIndex(ctx context.Context) (*search.Index, error) {
+	a.indexMu.Lock()
+	defer a.indexMu.Unlock()
+
+	if a.index != nil {
+		return a.index, nil
+	}
+
+	list, err := a.repo.ListAds(ctx, AdFilter{Trash: TrashIncluded})
+	if err != nil {
+		return nil, fmt.Errorf("build search index: %w", err)
+	}
+
+	index := search.NewIndex()
+	a.indexedVersions = make(map[int64]int64, len(list))
+	for _, ad := range list {
+		if ad.Published && !ad.Trashed() {
+			index.Put(searchDocument(ad))
+		}
+		a.indexedVersions[ad.ID] = ad.Version
+	}
+	a.index = index
+
+	return index, nil
+}
+
+// indexAd синхронизирует индекс с объявлением: в поиске участвуют только
+// опубликованные и не перемещенные в корзину. Параллельные изменения могут
+// прийти не по порядку, поэтому версия старше уже проиндексированной пропускается.
+func (a *app) indexAd(ad ads.Ad) {
+	a.indexMu.Lock()
+	defer a.indexMu.Unlock()
+
+	if a.index == nil || ad.Version < a.indexedVersions[ad.ID] {
+		return
+	}
+	a.indexedVersions[ad.ID] = ad.Version
+
+	if ad.Published && !ad.Trashed() {
+		a.index.Put(searchDocument(ad))
+	} else {
+		a.index.Delete(ad.ID)
+	}
+}
+
+func searchDocument(ad ads.Ad) search.Document {
+	return search.Document{ID: ad.ID, Title: ad.Title, Text: ad.Text}
+}
@@ -0,0 +1,21 @@
+package app
+
+import "context"
+
+// Stats - сводные показатели сервиса для мониторинга. Ads и Users не включают
+// объявления и пользователей из корзины, они считаются в TrashedAds и TrashedUsers.
+type Stats struct {
+	Ads          int
+	PublishedAds int
+	TrashedAds   int
+	Users        int
+	TrashedUsers int
+}
+
+func (a *app) Stats(ctx context.Context) (Stats, error) {
+	return a.repo.Stats(ctx)
+}
+
+func (a *app) Ping(ctx context.Context) error {
+	return a.repo.Ping(ctx)
+}
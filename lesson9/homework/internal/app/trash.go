@@ -0,0 +1,230 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"golang.org/x/exp/slog"
+
+	"homework9/internal/ads"
+	"homework9/internal/users"
+)
+
+// DefaultTrashRetention - сколько объявления и пользователи хранятся в корзине по умолчанию.
+const DefaultTrashRetention = 30 * 24 * time.Hour
+
+// purgeBatchSize - сколько объявлений из корзины читается за раз при очистке.
+const purgeBatchSize = 100
+
+// WithTrashRetention задает срок хранения в корзине, после которого объявления
+// и пользователи удаляются окончательно и их нельзя восстановить.
+func WithTrashRetention(d time.Duration) Option {
+	return func(o *options) {
+		o.trashRetention = d
+	}
+}
+
+func (a *app) RestoreAd(ctx context.Context, adID int64) (*ads.Ad, error) {
+	a.trashMu.Lock()
+	defer a.trashMu.Unlock()
+
+	ad, _, err := a.modifyAdWith(ctx, adID, 0, a.getOwnTrashedAd, func(ad *ads.Ad) bool {
+		ad.DeletedAt = time.Time{}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	a.indexAd(*ad)
+	a.events.publish(AdRestored, *ad)
+	if ad.Scheduled() {
+		a.wakeScheduler()
+	}
+
+	return ad, nil
+}
+
+func (a *app) TrashedAds(ctx context.Context, pageSize int, pageToken string) (*AdsPage, error) {
+	userID, err := currentUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.listPage(ctx, AdFilter{Trash: TrashOnly, AuthorID: &userID}, pageSize, pageToken)
+}
+
+func (a *app) RestoreUser(ctx context.Context, userID int64) (*users.User, error) {
+	if _, err := a.requireRole(ctx, users.RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	a.trashMu.Lock()
+	defer a.trashMu.Unlock()
+
+	user, err := a.repo.GetUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !a.restorable(user.DeletedAt) {
+		return nil, fmt.Errorf("user %d is not in the trash: %w", userID, ErrNotFound)
+	}
+
+	user.DeletedAt = time.Time{}
+	if err := a.repo.UpdateUser(ctx, user); err != nil {
+		return nil, err
+	}
+	user.Role = a.roleOf(user)
+
+	return &user, nil
+}
+
+// PurgeTrash проверяет ctx между удалениями, поэтому отмена не прерывает
+// удаление объявления на середине.
+func (a *app) PurgeTrash(ctx context.Context) (int, error) {
+	cutoff := a.now().Add(-a.trashRetention)
+	purged := 0
+
+	filter := AdFilter{Trash: TrashOnly, DeletedBefore: cutoff, Limit: purgeBatchSize}
+	for {
+		list, err := a.repo.ListAds(ctx, filter)
+		if err != nil {
+			return purged, err
+		}
+
+		for _, ad := range list {
+			if err := ctx.Err(); err != nil {
+				return purged, err
+			}
+			ok, err := a.purgeAd(ctx, ad.ID)
+			if err != nil {
+				return purged, err
+			}
+			if ok {
+				purged++
+			}
+		}
+
+		if len(list) < purgeBatchSize {
+			break
+		}
+		filter.FromID = list[len(list)-1].ID + 1
+	}
+
+	trashed, err := a.repo.ListTrashedUsers(ctx, cutoff)
+	if err != nil {
+		return purged, err
+	}
+	for _, user := range trashed {
+		if err := ctx.Err(); err != nil {
+			return purged, err
+		}
+		ok, err := a.purgeUser(ctx, user.ID)
+		if err != nil {
+			return purged, err
+		}
+		if ok {
+			purged++
+		}
+	}
+
+	return purged, nil
+}
+
+// RunPurger очищает корзину сразу и затем каждые interval, пока не отменен
+// ctx. Ошибки пишутся в лог, недоудаленное удалит следующая очистка.
+func RunPurger(ctx context.Context, a App, interval time.Duration, logger *slog.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		n, err := a.PurgeTrash(ctx)
+		switch {
+		case err != nil && ctx.Err() == nil:
+			logger.ErrorCtx(ctx, "purge trash", "error", err)
+		case n > 0:
+			logger.InfoCtx(ctx, "purged trash", "count", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// purgeAd удаляет объявление, если оно все еще в корзине и срок его хранения
+// истек: после выборки его могли восстановить.
+func (a *app) purgeAd(ctx context.Context, adID int64) (bool, error) {
+	a.trashMu.Lock()
+	defer a.trashMu.Unlock()
+
+	ad, err := a.repo.GetAd(ctx, adID)
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if !ad.Trashed() || a.restorable(ad.DeletedAt) {
+		return false, nil
+	}
+
+	if err := a.repo.DeleteAd(ctx, adID); err != nil {
+		return false, err
+	}
+	a.deletePhotoBlobs(ctx, ad.Photos)
+
+	return true, nil
+}
+
+// purgeUser - purgeAd для пользователя.
+func (a *app) purgeUser(ctx context.Context, userID int64) (bool, error) {
+	a.trashMu.Lock()
+	defer a.trashMu.Unlock()
+
+	user, err := a.repo.GetUser(ctx, userID)
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if !user.Trashed() || a.restorable(user.DeletedAt) {
+		return false, nil
+	}
+
+	if err := a.repo.DeleteUser(ctx, userID); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// getOwnTrashedAd возвращает объявление текущего пользователя, которое можно
+// восстановить из корзины, иначе ErrNotFound или ErrForbidden.
+func (a *app) getOwnTrashedAd(ctx context.Context, adID int64) (ads.Ad, error) {
+	userID, err := currentUserID(ctx)
+	if err != nil {
+		return ads.Ad{}, err
+	}
+
+	ad, err := a.repo.GetAd(ctx, adID)
+	if err != nil {
+		return ads.Ad{}, err
+	}
+	if !a.restorable(ad.DeletedAt) {
+		return ads.Ad{}, fmt.Errorf("ad %d is not in the trash: %w", adID, ErrNotFound)
+	}
+
+	return ad, checkAuthor(ad, userID)
+}
+
+// restorable сообщает, что сущность, перемещенная в корзину в deletedAt, еще
+// не удалена окончательно. Для сущностей не из корзины возвращает false.
+func (a *app) restorable(deletedAt time.Time) bool {
+	return !deletedAt.IsZero() && !deletedAt.Before(a.now().Add(-a.trashRetention))
+}
@@ -0,0 +1,315 @@
+package app
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"homework9/internal/ads"
+)
+
+const (
+	// eventHistorySize - сколько последних событий хранится для возобновления подписки.
+	eventHistorySize = 1024
+	// subscriberBuffer - сколько событий может накопить подписчик, прежде чем его отключат.
+	subscriberBuffer = 64
+)
+
+var (
+	// ErrSlowSubscriber - подписчик не успевал читать события и был отключен.
+	// Переподключиться можно с ResumeToken последнего полученного события.
+	ErrSlowSubscriber = errors.New("subscriber is too slow")
+	// ErrResumeTokenExpired - событий после ResumeToken уже нет в истории.
+	ErrResumeTokenExpired = errors.New("resume token expired")
+)
+
+type AdEventType int
+
+const (
+	AdCreated AdEventType = iota + 1
+	AdUpdated
+	AdPublished
+	AdUnpublished
+	AdDeleted
+	AdRestored
+)
+
+// AdEvent - изменение объявления. Ad - состояние после изменения
+// (для AdDeleted - состояние после перемещения в корзину). Если объявление не
+// было опубликовано ни до, ни после изменения, в Ad заполнен только ID.
+type AdEvent struct {
+	Type AdEventType
+	Ad   ads.Ad
+	// ResumeToken позволяет продолжить подписку сразу после этого события.
+	ResumeToken string
+
+	seq uint64
+}
+
+// WatchFilter отбирает события подписки. nil-поля не фильтруют.
+type WatchFilter struct {
+	// AuthorID отбирает события объявлений автора, кроме событий скрытых черновиков.
+	AuthorID *int64
+	// Published отбирает события объявлений, которые были или стали
+	// опубликованы (true) или не опубликованы (false) в результате события.
+	Published *bool
+	// ResumeToken - токен последнего полученного события, пустой - только новые события.
+	ResumeToken string
+}
+
+// match проверяет событие таким, каким его увидит подписчик: автор скрытого
+// события неизвестен, поэтому по автору отбираются только видимые события.
+// Иначе анонимный подписчик узнал бы идентификаторы черновиков автора.
+func (f WatchFilter) match(e AdEvent) bool {
+	if f.AuthorID != nil && (e.hidden() || e.Ad.AuthorID != *f.AuthorID) {
+		return false
+	}
+
+	if f.Published != nil && e.Ad.Published != *f.Published && e.publishedBefore() != *f.Published {
+		return false
+	}
+
+	return true
+}
+
+// publishedBefore сообщает, было ли объявление опубликовано до события.
+func (e AdEvent) publishedBefore() bool {
+	switch e.Type {
+	case AdPublished:
+		return false
+	case AdUnpublished:
+		return true
+	}
+
+	return e.Ad.Published
+}
+
+// hidden сообщает, что объявление не было опубликовано ни до, ни после события.
+func (e AdEvent) hidden() bool {
+	return !e.Ad.Published && !e.publishedBefore()
+}
+
+// redacted возвращает событие без содержимого объявления, если оно скрыто:
+// подписка доступна без токена, а черновики и объявления на проверке видят
+// только автор и модераторы.
+func (e AdEvent) redacted() AdEvent {
+	if !e.hidden() {
+		return e
+	}
+
+	e.Ad = ads.Ad{ID: e.Ad.ID}
+	return e
+}
+
+// Subscription - подписка на события объявлений. Должна быть закрыта через Close.
+type Subscription struct {
+	broker  *broker
+	filter  WatchFilter
+	backlog []AdEvent
+	events  chan AdEvent
+
+	err error // выставляется брокером под broker.mu перед закрытием events
+}
+
+// Next возвращает следующее событие, блокируясь до его появления или отмены ctx.
+func (s *Subscription) Next(ctx context.Context) (AdEvent, error) {
+	if len(s.backlog) > 0 {
+		e := s.backlog[0]
+		s.backlog = s.backlog[1:]
+		return e.redacted(), nil
+	}
+
+	select {
+	case e, ok := <-s.events:
+		if !ok {
+			s.broker.mu.Lock()
+			defer s.broker.mu.Unlock()
+			return AdEvent{}, s.err
+		}
+		return e.redacted(), nil
+	case <-ctx.Done():
+		return AdEvent{}, ctx.Err()
+	}
+}
+
+func (s *Subscription) Close() {
+	s.broker.unsubscribe(s)
+}
+
+// broker рассылает события подписчикам. Публикация никогда не блокируется:
+// подписчик с заполненным буфером отключается с ErrSlowSubscriber.
+type broker struct {
+	mu sync.Mutex
+
+	// epoch отличает токены разных запусков сервиса, seq после рестарта начинается заново.
+	epoch   int64
+	seq     uint64
+	history []AdEvent // кольцевой буфер последних событий
+	subs    map[*Subscription]struct{}
+	// versions - версии объявлений, события которых еще есть в истории.
+	// Записи удаляются вместе с последним событием объявления, поэтому
+	// размер словаря ограничен размером истории.
+	versions map[int64]adVersion
+}
+
+// adVersion - версия объявления в последнем разосланном событии и число
+// событий этого объявления в истории.
+type adVersion struct {
+	version int64
+	events  int
+}
+
+func newBroker() *broker {
+	return &broker{
+		epoch:    time.Now().UnixNano(),
+		subs:     make(map[*Subscription]struct{}),
+		versions: make(map[int64]adVersion),
+	}
+}
+
+// publish рассылает событие об объявлении ad. Параллельные изменения могут
+// прийти не по порядку: событие с версией старше уже разосланной пропускается,
+// чтобы подписчики не увидели, как объявление возвращается в прошлое.
+// Порядок отслеживается, пока события объявления есть в истории: изменения,
+// опоздавшие на eventHistorySize событий, не ожидаются.
+func (b *broker) publish(t AdEventType, ad ads.Ad) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if v, ok := b.versions[ad.ID]; ok && ad.Version < v.version {
+		return
+	}
+
+	b.seq++
+	e := AdEvent{
+		Type:        t,
+		Ad:          ad,
+		ResumeToken: encodeResumeToken(resumeCursor{Epoch: b.epoch, Seq: b.seq}),
+		seq:         b.seq,
+	}
+
+	if len(b.history) < eventHistorySize {
+		b.history = append(b.history, e)
+	} else {
+		i := (b.seq - 1) % eventHistorySize
+		b.forget(b.history[i].Ad.ID)
+		b.history[i] = e
+	}
+
+	v := b.versions[ad.ID]
+	b.versions[ad.ID] = adVersion{version: ad.Version, events: v.events + 1}
+
+	for s := range b.subs {
+		if !s.filter.match(e) {
+			continue
+		}
+
+		select {
+		case s.events <- e:
+		default:
+			s.err = ErrSlowSubscriber
+			close(s.events)
+			delete(b.subs, s)
+		}
+	}
+}
+
+// forget учитывает, что событие объявления adID вытеснено из истории.
+// Вызывается под b.mu.
+func (b *broker) forget(adID int64) {
+	v := b.versions[adID]
+	if v.events <= 1 {
+		delete(b.versions, adID)
+		return
+	}
+
+	v.events--
+	b.versions[adID] = v
+}
+
+func (b *broker) subscribe(filter WatchFilter) (*Subscription, error) {
+	cursor, err := decodeResumeToken(filter.ResumeToken)
+	if err != nil {
+		return nil, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	s := &Subscription{
+		broker: b,
+		filter: filter,
+		events: make(chan AdEvent, subscriberBuffer),
+	}
+
+	if filter.ResumeToken != "" {
+		if cursor.Epoch != b.epoch || cursor.Seq > b.seq {
+			return nil, fmt.Errorf("%w: token from another server run", ErrResumeTokenExpired)
+		}
+
+		oldest := b.seq - uint64(len(b.history)) + 1
+		if cursor.Seq+1 < oldest {
+			return nil, ErrResumeTokenExpired
+		}
+
+		for seq := cursor.Seq + 1; seq <= b.seq; seq++ {
+			e := b.history[(seq-1)%eventHistorySize]
+			if filter.match(e) {
+				s.backlog = append(s.backlog, e)
+			}
+		}
+	}
+
+	b.subs[s] = struct{}{}
+
+	return s, nil
+}
+
+func (b *broker) unsubscribe(s *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.subs[s]; ok {
+		delete(b.subs, s)
+		close(s.events)
+		s.err = context.Canceled
+	}
+}
+
+type resumeCursor struct {
+	Epoch int64  `json:"epoch"`
+	Seq   uint64 `json:"seq"`
+}
+
+func encodeResumeToken(c resumeCursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeResumeToken(s string) (resumeCursor, error) {
+	if s == "" {
+		return resumeCursor{}, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return resumeCursor{}, fmt.Errorf("%w: invalid resume token", ErrValidation)
+	}
+
+	var c resumeCursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return resumeCursor{}, fmt.Errorf("%w: invalid resume token", ErrValidation)
+	}
+
+	return c, nil
+}
+
+// WatchAds подписывает на изменения объявлений. Если в фильтре указан
+// ResumeToken, сначала будут доставлены пропущенные после него события.
+func (a *app) WatchAds(_ context.Context, filter WatchFilter) (*Subscription, error) {
+	return a.events.subscribe(filter)
+}
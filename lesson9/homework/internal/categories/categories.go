@@ -0,0 +1,16 @@
+package categories
+
+// Category - узел дерева категорий. ID категорий начинаются с 1, а ParentID 0
+// означает категорию верхнего уровня.
+type Category struct {
+	ID       int64
+	ParentID int64
+	Name     string
+}
+
+// OtherID - категория "Прочее". Хранилища создают ее сразу, в нее же попадают
+// объявления, созданные до появления категорий. Удалить ее нельзя.
+const OtherID int64 = 1
+
+// OtherName - название категории OtherID.
+const OtherName = "Прочее"
@@ -0,0 +1,435 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/exp/slog"
+	"gopkg.in/yaml.v3"
+
+	"homework9/internal/adapters/adrepo"
+	"homework9/internal/app"
+	"homework9/internal/idempotency"
+	"homework9/internal/ratelimit"
+)
+
+// envPrefix - префикс переменных окружения: ключ storage.sqlite_path с флагом
+// -sqlite-path задается переменной AD_SQLITE_PATH.
+const envPrefix = "AD_"
+
+// configEnv - переменная окружения с путем к файлу конфигурации, если не
+// указан флаг -config.
+const configEnv = envPrefix + "CONFIG"
+
+// Config - настройки сервиса объявлений. Значения собираются слоями: значения
+// по умолчанию, файл YAML, переменные окружения, флаги командной строки;
+// каждый следующий слой переопределяет предыдущий.
+type Config struct {
+	HTTP Server `yaml:"http"`
+	GRPC Server `yaml:"grpc"`
+	// Listen - общий адрес REST (HTTP/1.1) и gRPC, если задан, HTTP.Addr и GRPC.Addr не используются.
+	Listen string `yaml:"listen"`
+
+	Storage     Storage     `yaml:"storage"`
+	Ads         Ads         `yaml:"ads"`
+	Auth        Auth        `yaml:"auth"`
+	RateLimit   RateLimit   `yaml:"rate_limit"`
+	Trash       Trash       `yaml:"trash"`
+	Idempotency Idempotency `yaml:"idempotency"`
+	Shutdown    Shutdown    `yaml:"shutdown"`
+	Log         Log         `yaml:"log"`
+}
+
+type Server struct {
+	Addr string `yaml:"addr"`
+}
+
+type Storage struct {
+	// Backend - memory или sqlite.
+	Backend    string `yaml:"backend"`
+	SQLitePath string `yaml:"sqlite_path"`
+	// WALDir - каталог журнала memory хранилища, пустой - не сохранять на диск.
+	WALDir       string `yaml:"wal_dir"`
+	CompactEvery int    `yaml:"compact_every"`
+}
+
+// Ads - ограничения длины объявлений в символах.
+type Ads struct {
+	MaxTitleLen int `yaml:"max_title_len"`
+	MaxTextLen  int `yaml:"max_text_len"`
+}
+
+type Auth struct {
+	// TokenSecret подписывает токены доступа, пустой - случайный, и токены не
+	// переживают перезапуск.
+	TokenSecret string        `yaml:"token_secret"`
+	TokenTTL    time.Duration `yaml:"token_ttl"`
+}
+
+// RateLimit - лимиты запросов на пользователя или IP вида "100/1m", "0" - без ограничений.
+type RateLimit struct {
+	Read   ratelimit.Limit `yaml:"read"`
+	Write  ratelimit.Limit `yaml:"write"`
+	Create ratelimit.Limit `yaml:"create"`
+}
+
+// Limits возвращает лимиты для ratelimit.New.
+func (r RateLimit) Limits() ratelimit.Limits {
+	return ratelimit.Limits{Read: r.Read, Write: r.Write, Create: r.Create}
+}
+
+type Trash struct {
+	// Retention - сколько удаленные объявления и пользователи можно восстановить.
+	Retention time.Duration `yaml:"retention"`
+	// PurgeInterval - как часто удалять окончательно то, что хранится дольше Retention.
+	PurgeInterval time.Duration `yaml:"purge_interval"`
+}
+
+type Idempotency struct {
+	// TTL - сколько повторяются ответы на запросы с Idempotency-Key.
+	TTL time.Duration `yaml:"ttl"`
+}
+
+type Shutdown struct {
+	// Timeout - сколько ждать завершения текущих запросов.
+	Timeout time.Duration `yaml:"timeout"`
+	// Delay - сколько принимать запросы после того, как /readyz начал отвечать 503.
+	Delay time.Duration `yaml:"delay"`
+}
+
+type Log struct {
+	// Level - debug, info, warn или error.
+	Level string `yaml:"level"`
+}
+
+// Default возвращает конфигурацию, с которой сервис запускается без файла,
+// переменных окружения и флагов.
+func Default() Config {
+	var c Config
+	c.HTTP.Addr = ":18080"
+	c.GRPC.Addr = ":50054"
+	c.Storage.Backend = "memory"
+	c.Storage.SQLitePath = "ads.db"
+	c.Storage.CompactEvery = adrepo.DefaultCompactEvery
+	c.Ads.MaxTitleLen = app.DefaultMaxTitleLen
+	c.Ads.MaxTextLen = app.DefaultMaxTextLen
+	c.Auth.TokenTTL = 24 * time.Hour
+	c.RateLimit.Read = ratelimit.Limit{Requests: 600, Per: time.Minute}
+	c.RateLimit.Write = ratelimit.Limit{Requests: 120, Per: time.Minute}
+	c.RateLimit.Create = ratelimit.Limit{Requests: 10, Per: time.Minute}
+	c.Trash.Retention = app.DefaultTrashRetention
+	c.Trash.PurgeInterval = time.Hour
+	c.Idempotency.TTL = idempotency.DefaultTTL
+	c.Shutdown.Timeout = 10 * time.Second
+	c.Log.Level = "info"
+
+	return c
+}
+
+// logLevels - допустимые значения log.level.
+var logLevels = map[string]slog.Level{
+	"debug": slog.LevelDebug,
+	"info":  slog.LevelInfo,
+	"warn":  slog.LevelWarn,
+	"error": slog.LevelError,
+}
+
+// LogLevel возвращает уровень log.level. Конфигурация должна пройти Validate.
+func (c Config) LogLevel() slog.Level {
+	return logLevels[c.Log.Level]
+}
+
+// key - ключ конфигурации, который можно переопределить переменной окружения
+// и флагом.
+type key struct {
+	name  string // путь в YAML
+	flag  string
+	usage string
+	value any // указатель на поле Config
+}
+
+func (k key) env() string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(k.flag, "-", "_"))
+}
+
+// keys перечисляет ключи с указателями на поля c.
+func (c *Config) keys() []key {
+	return []key{
+		{"http.addr", "http-addr", "REST API address", &c.HTTP.Addr},
+		{"grpc.addr", "grpc-addr", "gRPC API address", &c.GRPC.Addr},
+		{"listen", "listen", "serve REST and gRPC on this single address instead of http.addr and grpc.addr", &c.Listen},
+		{"storage.backend", "storage", "ads storage backend (memory, sqlite)", &c.Storage.Backend},
+		{"storage.sqlite_path", "sqlite-path", "path to the sqlite database file", &c.Storage.SQLitePath},
+		{"storage.wal_dir", "wal-dir", "directory for the memory storage journal and snapshots (empty - do not persist)", &c.Storage.WALDir},
+		{"storage.compact_every", "compact-every", "compact the memory storage journal into a snapshot every N records (0 - never)", &c.Storage.CompactEvery},
+		{"ads.max_title_len", "max-title-len", "maximum ad title length in characters", &c.Ads.MaxTitleLen},
+		{"ads.max_text_len", "max-text-len", "maximum ad text length in characters", &c.Ads.MaxTextLen},
+		{"auth.token_secret", "token-secret", "secret for signing access tokens (empty - random, tokens do not survive restarts)", &c.Auth.TokenSecret},
+		{"auth.token_ttl", "token-ttl", "access token lifetime", &c.Auth.TokenTTL},
+		{"rate_limit.read", "rate-read", "read requests allowed per user or IP, as <requests>/<duration> (0 - unlimited)", &c.RateLimit.Read},
+		{"rate_limit.write", "rate-write", "write requests allowed per user or IP, as <requests>/<duration> (0 - unlimited)", &c.RateLimit.Write},
+		{"rate_limit.create", "rate-create", "ad and user creations allowed per user or IP, as <requests>/<duration> (0 - unlimited)", &c.RateLimit.Create},
+		{"trash.retention", "trash-retention", "how long deleted ads and users can be restored before they are purged", &c.Trash.Retention},
+		{"trash.purge_interval", "purge-interval", "how often to purge expired ads and users from the trash", &c.Trash.PurgeInterval},
+		{"idempotency.ttl", "idempotency-ttl", "how long responses to requests with an Idempotency-Key are replayed", &c.Idempotency.TTL},
+		{"shutdown.timeout", "shutdown-timeout", "how long to wait for in-flight requests on shutdown", &c.Shutdown.Timeout},
+		{"shutdown.delay", "shutdown-delay", "how long to keep serving after /readyz starts failing on shutdown, so load balancers stop sending new requests", &c.Shutdown.Delay},
+		{"log.level", "log-level", "minimum log level (debug, info, warn, error)", &c.Log.Level},
+	}
+}
+
+// set разбирает строковое значение ключа из переменной окружения или флага.
+func (k key) set(s string) error {
+	switch v := k.value.(type) {
+	case *string:
+		*v = s
+	case *int:
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return fmt.Errorf("%q is not an integer", s)
+		}
+		*v = n
+	case *time.Duration:
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return fmt.Errorf("%q is not a duration", s)
+		}
+		*v = d
+	case *ratelimit.Limit:
+		if err := v.Set(s); err != nil {
+			return err
+		}
+	default:
+		panic(fmt.Sprintf("config: unsupported type %T of key %s", k.value, k.name))
+	}
+
+	return nil
+}
+
+// Flags - флаг -config и флаги ключей конфигурации. Флаги применяются поверх
+// файла и переменных окружения, только если указаны в командной строке.
+type Flags struct {
+	path   string
+	values map[string]string // ключ -> значение флага
+}
+
+// BindFlags регистрирует флаги в fs. Конфигурацию нужно загружать через Load
+// после fs.Parse.
+func BindFlags(fs *flag.FlagSet) *Flags {
+	f := &Flags{values: make(map[string]string)}
+	fs.StringVar(&f.path, "config", "", "YAML configuration file (env "+configEnv+")")
+
+	defaults := Default()
+	for _, k := range defaults.keys() {
+		k := k
+		usage := fmt.Sprintf("%s (env %s, config %s)", k.usage, k.env(), k.name)
+		if def := fmt.Sprint(deref(k.value)); def != "" && def != "0" && def != "0s" {
+			usage += fmt.Sprintf(" (default %s)", def)
+		}
+		fs.Func(k.flag, usage, func(s string) error {
+			f.values[k.name] = s
+			return nil
+		})
+	}
+
+	return f
+}
+
+// Load собирает конфигурацию из значений по умолчанию, файла, переменных
+// окружения (lookupEnv, обычно os.LookupEnv) и флагов и проверяет ее. Ошибка
+// перечисляет все неверные ключи.
+func (f *Flags) Load(lookupEnv func(string) (string, bool)) (Config, error) {
+	c := Default()
+
+	path := f.path
+	if path == "" {
+		path, _ = lookupEnv(configEnv)
+	}
+	if path != "" {
+		if err := c.readFile(path, lookupEnv); err != nil {
+			return Config{}, err
+		}
+	}
+
+	var errs Errors
+	keys := c.keys()
+	for _, k := range keys {
+		if s, ok := lookupEnv(k.env()); ok {
+			if err := k.set(s); err != nil {
+				errs = append(errs, KeyError{Key: k.name, Reason: fmt.Sprintf("%s: %s", k.env(), err)})
+			}
+		}
+	}
+	for _, k := range keys {
+		if s, ok := f.values[k.name]; ok {
+			if err := k.set(s); err != nil {
+				errs = append(errs, KeyError{Key: k.name, Reason: fmt.Sprintf("-%s: %s", k.flag, err)})
+			}
+		}
+	}
+
+	if err := c.Validate(); err != nil {
+		var invalid Errors
+		errors.As(err, &invalid)
+		errs = append(errs, invalid...)
+	}
+	if len(errs) > 0 {
+		return Config{}, errs
+	}
+
+	return c, nil
+}
+
+// readFile читает файл YAML поверх c. Ссылки ${VAR} в файле заменяются
+// значениями переменных окружения, неизвестные ключи считаются ошибкой.
+func (c *Config) readFile(path string, lookupEnv func(string) (string, bool)) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read config file: %w", err)
+	}
+
+	expanded := os.Expand(string(data), func(name string) string {
+		v, _ := lookupEnv(name)
+		return v
+	})
+	dec := yaml.NewDecoder(strings.NewReader(expanded))
+	dec.KnownFields(true)
+	if err := dec.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("broken config file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// Validate проверяет все ключи и возвращает Errors со всеми нарушениями.
+func (c Config) Validate() error {
+	var errs Errors
+	invalid := func(key string, format string, args ...any) {
+		errs = append(errs, KeyError{Key: key, Reason: fmt.Sprintf(format, args...)})
+	}
+
+	if c.Listen == "" {
+		if c.HTTP.Addr == "" {
+			invalid("http.addr", "required unless listen is set")
+		}
+		if c.GRPC.Addr == "" {
+			invalid("grpc.addr", "required unless listen is set")
+		}
+	}
+	for _, addr := range []struct{ key, value string }{
+		{"http.addr", c.HTTP.Addr},
+		{"grpc.addr", c.GRPC.Addr},
+		{"listen", c.Listen},
+	} {
+		if addr.value == "" {
+			continue
+		}
+		if err := validateAddr(addr.value); err != nil {
+			invalid(addr.key, "%q: %s", addr.value, err)
+		}
+	}
+
+	switch c.Storage.Backend {
+	case "memory":
+	case "sqlite":
+		if c.Storage.SQLitePath == "" {
+			invalid("storage.sqlite_path", "required for the sqlite backend")
+		}
+	default:
+		invalid("storage.backend", "unknown backend %q, expected memory or sqlite", c.Storage.Backend)
+	}
+	if c.Storage.CompactEvery < 0 {
+		invalid("storage.compact_every", "must not be negative, got %d", c.Storage.CompactEvery)
+	}
+
+	if c.Ads.MaxTitleLen <= 0 {
+		invalid("ads.max_title_len", "must be positive, got %d", c.Ads.MaxTitleLen)
+	}
+	if c.Ads.MaxTextLen <= 0 {
+		invalid("ads.max_text_len", "must be positive, got %d", c.Ads.MaxTextLen)
+	}
+
+	if c.Auth.TokenTTL <= 0 {
+		invalid("auth.token_ttl", "must be positive, got %s", c.Auth.TokenTTL)
+	}
+
+	if c.Trash.Retention <= 0 {
+		invalid("trash.retention", "must be positive, got %s", c.Trash.Retention)
+	}
+	if c.Trash.PurgeInterval <= 0 {
+		invalid("trash.purge_interval", "must be positive, got %s", c.Trash.PurgeInterval)
+	}
+
+	if c.Idempotency.TTL <= 0 {
+		invalid("idempotency.ttl", "must be positive, got %s", c.Idempotency.TTL)
+	}
+
+	if c.Shutdown.Timeout <= 0 {
+		invalid("shutdown.timeout", "must be positive, got %s", c.Shutdown.Timeout)
+	}
+	if c.Shutdown.Delay < 0 {
+		invalid("shutdown.delay", "must not be negative, got %s", c.Shutdown.Delay)
+	}
+
+	if _, ok := logLevels[c.Log.Level]; !ok {
+		invalid("log.level", "unknown level %q, expected debug, info, warn or error", c.Log.Level)
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func validateAddr(addr string) error {
+	_, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return errors.New("expected host:port")
+	}
+	if n, err := strconv.Atoi(port); err != nil || n < 0 || n > 65535 {
+		return errors.New("port must be a number from 0 to 65535")
+	}
+
+	return nil
+}
+
+// KeyError - неверное значение ключа конфигурации.
+type KeyError struct {
+	Key    string
+	Reason string
+}
+
+// Errors - все неверные ключи конфигурации.
+type Errors []KeyError
+
+func (e Errors) Error() string {
+	var b strings.Builder
+	b.WriteString("invalid config:")
+	for _, err := range e {
+		fmt.Fprintf(&b, "\n  %s: %s", err.Key, err.Reason)
+	}
+
+	return b.String()
+}
+
+func deref(p any) any {
+	switch v := p.(type) {
+	case *string:
+		return *v
+	case *int:
+		return *v
+	case *time.Duration:
+		return *v
+	case *ratelimit.Limit:
+		return *v
+	}
+
+	return nil
+}
@@ -0,0 +1,29 @@
+package favorites
+
+import (
+	"time"
+
+	"homework9/internal/ads"
+)
+
+// Favorite - объявление в избранном пользователя.
+type Favorite struct {
+	UserID    int64
+	AdID      int64
+	CreatedAt time.Time
+}
+
+// Notification - уведомление пользователя об изменении объявления из его
+// избранного: версия объявления после изменения и что изменилось.
+type Notification struct {
+	ID        int64
+	UserID    int64
+	AdID      int64
+	AdVersion int64
+	Changes   []ads.FieldChange
+	CreatedAt time.Time
+}
+
+// WatchedFields - поля объявления, об изменении которых уведомляются
+// пользователи, добавившие его в избранное.
+var WatchedFields = []string{"price", "text"}
@@ -0,0 +1,60 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"homework9/internal/app"
+)
+
+// checkTimeout ограничивает проверку хранилища, чтобы зависшая база не
+// задерживала ответ на проверку готовности дольше таймаута балансировщика.
+const checkTimeout = 2 * time.Second
+
+// ErrShuttingDown - сервис останавливается и не принимает новые запросы.
+var ErrShuttingDown = errors.New("shutting down")
+
+// Checker определяет готовность сервиса принимать запросы. Сервис готов, пока
+// доступно хранилище и не вызван Shutdown.
+type Checker struct {
+	app  app.App
+	once sync.Once
+	done chan struct{}
+}
+
+func New(a app.App) *Checker {
+	return &Checker{app: a, done: make(chan struct{})}
+}
+
+// Shutdown переводит сервис в неготовое состояние в начале graceful shutdown:
+// балансировщики перестают присылать новые запросы, а текущие дорабатывают.
+func (c *Checker) Shutdown() {
+	c.once.Do(func() {
+		close(c.done)
+	})
+}
+
+// ShuttingDown закрывается при вызове Shutdown.
+func (c *Checker) ShuttingDown() <-chan struct{} {
+	return c.done
+}
+
+// Ready возвращает nil, если сервис готов принимать запросы, иначе причину.
+func (c *Checker) Ready(ctx context.Context) error {
+	select {
+	case <-c.done:
+		return ErrShuttingDown
+	default:
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+	if err := c.app.Ping(ctx); err != nil {
+		return fmt.Errorf("repository: %w", err)
+	}
+
+	return nil
+}
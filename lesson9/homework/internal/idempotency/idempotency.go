@@ -0,0 +1,180 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultTTL - сколько по умолчанию хранится ответ на запрос с ключом идемпотентности.
+const DefaultTTL = 24 * time.Hour
+
+// MaxKeyLength - максимальная длина ключа идемпотентности.
+const MaxKeyLength = 255
+
+// sweepInterval - как часто из памяти удаляются истекшие ответы.
+const sweepInterval = time.Minute
+
+var (
+	ErrInvalidKey = fmt.Errorf("idempotency key must be 1 to %d characters long", MaxKeyLength)
+	ErrKeyReused  = errors.New("idempotency key was already used with a different request")
+)
+
+type options struct {
+	now func() time.Time
+}
+
+type Option func(o *options)
+
+// WithNow подменяет источник текущего времени.
+func WithNow(now func() time.Time) Option {
+	return func(o *options) {
+		o.now = now
+	}
+}
+
+// Store хранит в памяти первые ответы на запросы с ключами идемпотентности,
+// чтобы повторы запроса не выполнялись заново, а получали тот же ответ.
+type Store struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu        sync.Mutex
+	entries   map[string]*entry
+	lastSweep time.Time
+}
+
+type entry struct {
+	fingerprint string
+	// done закрывается, когда первый запрос с ключом завершился.
+	done    chan struct{}
+	value   any
+	saved   bool
+	expires time.Time
+}
+
+func New(ttl time.Duration, opts ...Option) *Store {
+	o := options{now: time.Now}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return &Store{
+		ttl:       ttl,
+		now:       o.now,
+		entries:   make(map[string]*entry),
+		lastSweep: o.now(),
+	}
+}
+
+// ValidateKey проверяет ключ, переданный клиентом.
+func ValidateKey(key string) error {
+	if key == "" || len(key) > MaxKeyLength {
+		return ErrInvalidKey
+	}
+
+	return nil
+}
+
+// Do выполняет fn для первого запроса с ключом key и, если fn вернула keep,
+// сохраняет ее результат на TTL. Повторы с тем же fingerprint (отпечатком
+// тела запроса) получают сохраненный результат и replayed, а одновременные
+// повторы ждут завершения первого запроса. Если результат не сохранен,
+// следующий повтор выполняет fn заново. Повтор с другим fingerprint
+// получает ErrKeyReused.
+func (s *Store) Do(ctx context.Context, key string, fingerprint string,
+	fn func() (value any, keep bool)) (value any, replayed bool, err error) {
+	for {
+		e, owner, err := s.begin(key, fingerprint)
+		if err != nil {
+			return nil, false, err
+		}
+
+		if owner {
+			return s.run(key, e, fn), false, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, false, ctx.Err()
+		case <-e.done:
+		}
+		if e.saved {
+			return e.value, true, nil
+		}
+	}
+}
+
+// begin возвращает запись для key, создавая ее, если первый запрос с этим
+// ключом еще не выполнялся; owner означает, что выполнять его должен вызывающий.
+func (s *Store) begin(key string, fingerprint string) (*entry, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	e, ok := s.entries[key]
+	if ok && e.saved && !e.expires.After(now) {
+		delete(s.entries, key)
+		ok = false
+	}
+	if !ok {
+		e = &entry{fingerprint: fingerprint, done: make(chan struct{})}
+		s.entries[key] = e
+		return e, true, nil
+	}
+
+	if e.fingerprint != fingerprint {
+		return nil, false, ErrKeyReused
+	}
+
+	return e, false, nil
+}
+
+// run выполняет fn и сохраняет результат. Если fn паникует, ожидающие повторы
+// не зависают: запись удаляется, и один из них выполнит запрос сам.
+func (s *Store) run(key string, e *entry, fn func() (any, bool)) any {
+	finished := false
+	defer func() {
+		if !finished {
+			s.finish(key, e, nil, false)
+		}
+	}()
+
+	value, keep := fn()
+	finished = true
+	s.finish(key, e, value, keep)
+
+	return value
+}
+
+func (s *Store) finish(key string, e *entry, value any, keep bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if keep {
+		e.value = value
+		e.saved = true
+		e.expires = s.now().Add(s.ttl)
+	} else {
+		delete(s.entries, key)
+	}
+	close(e.done)
+}
+
+// sweep удаляет истекшие ответы, чтобы память не росла с числом запросов.
+func (s *Store) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+
+	for key, e := range s.entries {
+		if e.saved && !e.expires.After(now) {
+			delete(s.entries, key)
+		}
+	}
+}
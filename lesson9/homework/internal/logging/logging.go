@@ -0,0 +1,90 @@
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+
+	"golang.org/x/exp/slog"
+)
+
+// RequestIDKey - атрибут, в котором в каждую строку лога попадает
+// идентификатор запроса.
+const RequestIDKey = "request_id"
+
+// maxRequestIDLen ограничивает идентификатор, присланный клиентом: длинные
+// и непечатные значения заменяются новыми, чтобы не засорять логи.
+const maxRequestIDLen = 128
+
+type requestIDKey struct{}
+
+// WithRequestID возвращает контекст с идентификатором запроса. Логгеры из New
+// добавляют его во все строки, записанные с этим контекстом.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext возвращает идентификатор запроса или "", если его нет.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// NewRequestID генерирует случайный идентификатор запроса.
+func NewRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// RequestID возвращает идентификатор, присланный клиентом, если он допустим,
+// иначе новый.
+func RequestID(fromClient string) string {
+	if fromClient == "" || len(fromClient) > maxRequestIDLen {
+		return NewRequestID()
+	}
+	for _, r := range fromClient {
+		if r < 0x21 || r > 0x7e {
+			return NewRequestID()
+		}
+	}
+
+	return fromClient
+}
+
+// New создает логгер, который пишет в w строки JSON и добавляет в них
+// идентификатор запроса из контекста (методы InfoCtx, ErrorCtx и т.д.).
+func New(w io.Writer) *slog.Logger {
+	return NewWithLevel(w, slog.LevelInfo)
+}
+
+// NewWithLevel - New, пропускающий строки ниже level.
+func NewWithLevel(w io.Writer, level slog.Leveler) *slog.Logger {
+	return slog.New(NewHandler(slog.HandlerOptions{Level: level}.NewJSONHandler(w)))
+}
+
+// NewHandler оборачивает h, добавляя в записи идентификатор запроса из контекста.
+func NewHandler(h slog.Handler) slog.Handler {
+	return requestIDHandler{Handler: h}
+}
+
+type requestIDHandler struct {
+	slog.Handler
+}
+
+func (h requestIDHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestIDFromContext(ctx); id != "" {
+		r.AddAttrs(slog.String(RequestIDKey, id))
+	}
+
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h requestIDHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return requestIDHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h requestIDHandler) WithGroup(name string) slog.Handler {
+	return requestIDHandler{Handler: h.Handler.WithGroup(name)}
+}
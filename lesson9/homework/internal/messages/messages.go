@@ -0,0 +1,63 @@
+package messages
+
+import "time"
+
+// Thread - переписка покупателя с автором объявления. У покупателя одна
+// переписка по каждому объявлению. ID сообщений начинаются с 1, поэтому
+// нулевые ID прочитанного и последнего сообщения означают "ни одного".
+type Thread struct {
+	ID        int64
+	AdID      int64
+	BuyerID   int64
+	SellerID  int64
+	CreatedAt time.Time
+	// BuyerReadID и SellerReadID - ID последнего прочитанного участником сообщения.
+	BuyerReadID  int64
+	SellerReadID int64
+
+	// LastMessageID, BuyerUnread и SellerUnread заполняет хранилище при чтении.
+	LastMessageID int64
+	BuyerUnread   int
+	SellerUnread  int
+}
+
+// Participant сообщает, участвует ли пользователь в переписке.
+func (t Thread) Participant(userID int64) bool {
+	return userID == t.BuyerID || userID == t.SellerID
+}
+
+// Peer возвращает собеседника участника переписки.
+func (t Thread) Peer(userID int64) int64 {
+	if userID == t.BuyerID {
+		return t.SellerID
+	}
+
+	return t.BuyerID
+}
+
+// ReadID возвращает ID последнего прочитанного участником сообщения.
+func (t Thread) ReadID(userID int64) int64 {
+	if userID == t.BuyerID {
+		return t.BuyerReadID
+	}
+
+	return t.SellerReadID
+}
+
+// Unread возвращает число непрочитанных участником сообщений собеседника.
+func (t Thread) Unread(userID int64) int {
+	if userID == t.BuyerID {
+		return t.BuyerUnread
+	}
+
+	return t.SellerUnread
+}
+
+// Message - сообщение в переписке.
+type Message struct {
+	ID        int64
+	ThreadID  int64
+	SenderID  int64
+	Text      string
+	CreatedAt time.Time
+}
@@ -0,0 +1,160 @@
+package metrics
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"homework9/internal/app"
+)
+
+// statsTimeout ограничивает чтение показателей из хранилища при сборе метрик.
+const statsTimeout = 5 * time.Second
+
+// Metrics собирает метрики REST и gRPC запросов и показатели сервиса.
+// Метрики запросов заполняют middleware и интерсепторы портов, показатели
+// сервиса читаются из app.App при каждом запросе /metrics.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests *prometheus.CounterVec
+	httpDuration *prometheus.HistogramVec
+	httpInFlight *prometheus.GaugeVec
+
+	grpcRequests *prometheus.CounterVec
+	grpcDuration *prometheus.HistogramVec
+	grpcInFlight *prometheus.GaugeVec
+}
+
+func New(a app.App) *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "HTTP requests by route template, method and status code.",
+		}, []string{"method", "route", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "HTTP request latency by route template, method and status code.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		httpInFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "http_requests_in_flight",
+			Help: "HTTP requests being served by route template and method.",
+		}, []string{"method", "route"}),
+
+		grpcRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "grpc_requests_total",
+			Help: "gRPC calls by full method and status code.",
+		}, []string{"method", "code"}),
+		grpcDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "grpc_request_duration_seconds",
+			Help:    "gRPC call latency by full method and status code, streams are measured until they end.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "code"}),
+		grpcInFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "grpc_requests_in_flight",
+			Help: "gRPC calls and open streams by full method.",
+		}, []string{"method"}),
+	}
+
+	m.registry.MustRegister(
+		m.httpRequests, m.httpDuration, m.httpInFlight,
+		m.grpcRequests, m.grpcDuration, m.grpcInFlight,
+		newStatsCollector(a),
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+
+	return m
+}
+
+// Handler отдает метрики в текстовом формате Prometheus.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// TrackHTTP учитывает начало HTTP запроса, возвращаемую функцию нужно вызвать
+// с кодом ответа после его обработки.
+func (m *Metrics) TrackHTTP(method string, route string) func(status int) {
+	start := time.Now()
+	inFlight := m.httpInFlight.WithLabelValues(method, route)
+	inFlight.Inc()
+
+	return func(status int) {
+		inFlight.Dec()
+		code := strconv.Itoa(status)
+		m.httpRequests.WithLabelValues(method, route, code).Inc()
+		m.httpDuration.WithLabelValues(method, route, code).Observe(time.Since(start).Seconds())
+	}
+}
+
+// TrackGRPC учитывает начало gRPC вызова, возвращаемую функцию нужно вызвать
+// с названием кода ответа после его завершения.
+func (m *Metrics) TrackGRPC(fullMethod string) func(code string) {
+	start := time.Now()
+	inFlight := m.grpcInFlight.WithLabelValues(fullMethod)
+	inFlight.Inc()
+
+	return func(code string) {
+		inFlight.Dec()
+		m.grpcRequests.WithLabelValues(fullMethod, code).Inc()
+		m.grpcDuration.WithLabelValues(fullMethod, code).Observe(time.Since(start).Seconds())
+	}
+}
+
+// statsCollector читает показатели сервиса при каждом сборе метрик, поэтому
+// они не расходятся с хранилищем после перезапуска и очистки корзины.
+type statsCollector struct {
+	app app.App
+
+	ads          *prometheus.Desc
+	publishedAds *prometheus.Desc
+	trashedAds   *prometheus.Desc
+	users        *prometheus.Desc
+	trashedUsers *prometheus.Desc
+}
+
+func newStatsCollector(a app.App) *statsCollector {
+	return &statsCollector{
+		app:          a,
+		ads:          prometheus.NewDesc("ads", "Ads not in the trash.", nil, nil),
+		publishedAds: prometheus.NewDesc("ads_published", "Published ads not in the trash.", nil, nil),
+		trashedAds:   prometheus.NewDesc("ads_trashed", "Ads in the trash.", nil, nil),
+		users:        prometheus.NewDesc("users", "Users not in the trash.", nil, nil),
+		trashedUsers: prometheus.NewDesc("users_trashed", "Users in the trash.", nil, nil),
+	}
+}
+
+func (c *statsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.ads
+	ch <- c.publishedAds
+	ch <- c.trashedAds
+	ch <- c.users
+	ch <- c.trashedUsers
+}
+
+func (c *statsCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), statsTimeout)
+	defer cancel()
+
+	stats, err := c.app.Stats(ctx)
+	if err != nil {
+		log.Printf("metrics: read stats: %s", err)
+		ch <- prometheus.NewInvalidMetric(c.ads, err)
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.ads, prometheus.GaugeValue, float64(stats.Ads))
+	ch <- prometheus.MustNewConstMetric(c.publishedAds, prometheus.GaugeValue, float64(stats.PublishedAds))
+	ch <- prometheus.MustNewConstMetric(c.trashedAds, prometheus.GaugeValue, float64(stats.TrashedAds))
+	ch <- prometheus.MustNewConstMetric(c.users, prometheus.GaugeValue, float64(stats.Users))
+	ch <- prometheus.MustNewConstMetric(c.trashedUsers, prometheus.GaugeValue, float64(stats.TrashedUsers))
+}
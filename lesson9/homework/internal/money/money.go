@@ -0,0 +1,122 @@
+package money
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Currency - код валюты по ISO 4217.
+type Currency string
+
+// Base - валюта, относительно которой задаются курсы. Ее курс всегда 1.
+const Base Currency = "RUB"
+
+// exponents - число знаков минимальной единицы (копеек, центов) у поддерживаемых валют.
+var exponents = map[Currency]int{
+	"RUB": 2,
+	"USD": 2,
+	"EUR": 2,
+	"GBP": 2,
+	"CNY": 2,
+	"KZT": 2,
+	"BYN": 2,
+	"TRY": 2,
+	"AED": 2,
+	"JPY": 0,
+	"KRW": 0,
+}
+
+var (
+	ErrUnknownCurrency = errors.New("unknown currency")
+	ErrInvalidAmount   = errors.New("invalid amount")
+)
+
+// ParseCurrency проверяет код валюты, регистр не важен.
+func ParseCurrency(s string) (Currency, error) {
+	c := Currency(strings.ToUpper(s))
+	if !c.Valid() {
+		return "", fmt.Errorf("%w %q", ErrUnknownCurrency, s)
+	}
+
+	return c, nil
+}
+
+// Valid сообщает, поддерживается ли валюта.
+func (c Currency) Valid() bool {
+	_, ok := exponents[c]
+	return ok
+}
+
+// Exponent - число знаков после запятой в минимальной единице валюты.
+func (c Currency) Exponent() int {
+	return exponents[c]
+}
+
+// Money - сумма в минимальных единицах валюты (копейках, центах), что
+// позволяет хранить и сравнивать цены без ошибок округления. Нулевое
+// значение (пустая валюта) означает, что цена не указана.
+type Money struct {
+	Amount   int64
+	Currency Currency
+}
+
+// IsZero сообщает, что цена не указана.
+func (m Money) IsZero() bool {
+	return m.Currency == ""
+}
+
+// Parse разбирает неотрицательную десятичную сумму вида "1234.5" в валюте currency.
+func Parse(amount string, currency string) (Money, error) {
+	c, err := ParseCurrency(currency)
+	if err != nil {
+		return Money{}, err
+	}
+
+	whole, frac, _ := strings.Cut(amount, ".")
+	if whole == "" || !isDigits(whole) || !isDigits(frac) || len(frac) > c.Exponent() {
+		return Money{}, fmt.Errorf("%w %q for %s", ErrInvalidAmount, amount, c)
+	}
+
+	digits := whole + frac + strings.Repeat("0", c.Exponent()-len(frac))
+	minor, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("%w %q for %s", ErrInvalidAmount, amount, c)
+	}
+
+	return Money{Amount: minor, Currency: c}, nil
+}
+
+// FormatAmount возвращает сумму в виде десятичной строки, например "1234.50".
+func (m Money) FormatAmount() string {
+	exp := m.Currency.Exponent()
+	s := strconv.FormatInt(m.Amount, 10)
+	if exp == 0 {
+		return s
+	}
+
+	if len(s) <= exp {
+		s = strings.Repeat("0", exp-len(s)+1) + s
+	}
+
+	return s[:len(s)-exp] + "." + s[len(s)-exp:]
+}
+
+func (m Money) String() string {
+	if m.IsZero() {
+		return ""
+	}
+
+	return m.FormatAmount() + " " + string(m.Currency)
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return true
+}
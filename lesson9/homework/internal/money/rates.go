@@ -0,0 +1,81 @@
+package money
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// Rate - курс валюты: стоимость одной единицы Currency в Base, десятичная строка.
+type Rate struct {
+	Currency Currency
+	Value    string
+}
+
+// ParseRate проверяет, что value - положительное десятичное число.
+func ParseRate(value string) (*big.Rat, error) {
+	r, ok := new(big.Rat).SetString(value)
+	if !ok || r.Sign() <= 0 {
+		return nil, fmt.Errorf("%w: invalid rate %q", ErrInvalidAmount, value)
+	}
+
+	return r, nil
+}
+
+// Rates - таблица курсов для пересчета сумм между валютами.
+type Rates map[Currency]*big.Rat
+
+// NewRates строит таблицу из сохраненных курсов, курс Base добавляется всегда.
+func NewRates(list []Rate) (Rates, error) {
+	rates := Rates{Base: big.NewRat(1, 1)}
+	for _, rate := range list {
+		r, err := ParseRate(rate.Value)
+		if err != nil {
+			return nil, fmt.Errorf("rate for %s: %w", rate.Currency, err)
+		}
+		rates[rate.Currency] = r
+	}
+
+	return rates, nil
+}
+
+// InBase возвращает сумму m в единицах (не копейках) Base. ok == false, если
+// цена не указана или курс ее валюты неизвестен.
+func (r Rates) InBase(m Money) (value *big.Rat, ok bool) {
+	rate, ok := r[m.Currency]
+	if m.IsZero() || !ok {
+		return nil, false
+	}
+
+	value = new(big.Rat).SetFrac(big.NewInt(m.Amount), pow10(m.Currency.Exponent()))
+	return value.Mul(value, rate), true
+}
+
+// Convert пересчитывает m в валюту to с округлением до минимальной единицы
+// (половина округляется вверх).
+func (r Rates) Convert(m Money, to Currency) (Money, bool) {
+	if m.Currency == to {
+		return m, !m.IsZero()
+	}
+
+	value, ok := r.InBase(m)
+	rate, known := r[to]
+	if !ok || !known {
+		return Money{}, false
+	}
+
+	value.Quo(value, rate)
+	value.Mul(value, new(big.Rat).SetInt(pow10(to.Exponent())))
+
+	// Суммы неотрицательны, поэтому округление - это floor(value + 1/2).
+	value.Add(value, big.NewRat(1, 2))
+	amount := new(big.Int).Quo(value.Num(), value.Denom())
+	if !amount.IsInt64() {
+		return Money{}, false
+	}
+
+	return Money{Amount: amount.Int64(), Currency: to}, true
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
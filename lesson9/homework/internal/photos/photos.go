@@ -0,0 +1,144 @@
+// Package photos проверяет загруженные изображения и строит их миниатюры.
+package photos
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif" // регистрирует декодер GIF
+	"image/jpeg"
+	_ "image/png" // регистрирует декодер PNG
+	"net/http"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp" // регистрирует декодер WebP
+)
+
+// Variant - вариант фотографии: оригинал или миниатюра.
+type Variant string
+
+const (
+	Original Variant = "original"
+	Small    Variant = "small"
+	Medium   Variant = "medium"
+)
+
+// ThumbnailSizes - наибольшая сторона миниатюр в пикселях.
+var ThumbnailSizes = map[Variant]int{
+	Small:  200,
+	Medium: 800,
+}
+
+const (
+	// MaxSize - наибольший размер загружаемого файла.
+	MaxSize = 10 << 20
+	// maxPixels защищает от изображений, которые малы в сжатом виде, но огромны
+	// после декодирования.
+	maxPixels = 40_000_000
+
+	// ThumbnailContentType - формат, в котором хранятся миниатюры.
+	ThumbnailContentType = "image/jpeg"
+	thumbnailQuality     = 85
+)
+
+var (
+	ErrUnsupportedType = errors.New("unsupported image type")
+	ErrTooLarge        = errors.New("image is too large")
+)
+
+// contentTypes - поддерживаемые типы в том виде, как их определяет http.DetectContentType.
+var contentTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// Image - проверенное изображение и его миниатюры в формате JPEG.
+type Image struct {
+	ContentType string
+	Width       int
+	Height      int
+	Thumbnails  map[Variant][]byte
+}
+
+// Process определяет тип изображения по содержимому (расширение файла не
+// учитывается), проверяет размеры и строит миниатюры всех размеров из ThumbnailSizes.
+func Process(data []byte) (*Image, error) {
+	if len(data) > MaxSize {
+		return nil, fmt.Errorf("%w: %d bytes, max %d", ErrTooLarge, len(data), MaxSize)
+	}
+
+	contentType := http.DetectContentType(data)
+	if !contentTypes[contentType] {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedType, contentType)
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedType, err)
+	}
+	if cfg.Width*cfg.Height > maxPixels {
+		return nil, fmt.Errorf("%w: %dx%d pixels", ErrTooLarge, cfg.Width, cfg.Height)
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedType, err)
+	}
+
+	img := &Image{
+		ContentType: contentType,
+		Width:       cfg.Width,
+		Height:      cfg.Height,
+		Thumbnails:  make(map[Variant][]byte, len(ThumbnailSizes)),
+	}
+	for variant, size := range ThumbnailSizes {
+		thumb, err := thumbnail(src, size)
+		if err != nil {
+			return nil, fmt.Errorf("thumbnail %s: %w", variant, err)
+		}
+		img.Thumbnails[variant] = thumb
+	}
+
+	return img, nil
+}
+
+// thumbnail вписывает src в квадрат size x size с сохранением пропорций.
+// Изображения меньше квадрата не увеличиваются.
+func thumbnail(src image.Image, size int) ([]byte, error) {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w > size || h > size {
+		if w >= h {
+			w, h = size, h*size/w
+		} else {
+			w, h = w*size/h, size
+		}
+	}
+	if w == 0 {
+		w = 1
+	}
+	if h == 0 {
+		h = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	// У JPEG нет прозрачности, поэтому прозрачные области становятся белыми.
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, b, draw.Over, nil)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: thumbnailQuality}); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// URL возвращает путь, по которому HTTP сервер отдает вариант фотографии.
+func URL(adID int64, photoID string, variant Variant) string {
+	return fmt.Sprintf("/api/v1/ads/%d/photos/%s/%s", adID, photoID, variant)
+}
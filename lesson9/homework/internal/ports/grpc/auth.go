@@ -0,0 +1,116 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"homework9/internal/app"
+)
+
+// authenticate проверяет токен из метаданных authorization и кладет
+// пользователя в контекст. Вызовы без токена пропускаются как есть.
+func authenticate(ctx context.Context, a app.App) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return ctx, nil
+	}
+
+	if !strings.HasPrefix(values[0], "Bearer ") {
+		return nil, status.Error(codes.Unauthenticated, "unsupported authorization scheme")
+	}
+
+	token := strings.TrimPrefix(values[0], "Bearer ")
+	userID, err := a.Authenticate(ctx, token)
+	if err != nil {
+		return nil, errorStatus(err)
+	}
+
+	return app.WithUserID(ctx, userID), nil
+}
+
+// authInterceptor аутентифицирует вызов по метаданным authorization.
+func authInterceptor(a app.App) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := authenticate(ctx, a)
+		if err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// streamAuthInterceptor - authInterceptor для потоковых методов.
+func streamAuthInterceptor(a app.App) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authenticate(ss.Context(), a)
+		if err != nil {
+			return err
+		}
+
+		return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
+
+// clientKey возвращает ключ клиента для лимитов и ключей идемпотентности:
+// пользователя из токена, а для анонимных вызовов - IP-адрес.
+func clientKey(ctx context.Context) string {
+	if userID, ok := app.UserIDFromContext(ctx); ok {
+		return "user:" + strconv.FormatInt(userID, 10)
+	}
+
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return "ip:"
+	}
+	addr := p.Addr.String()
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		addr = host
+	}
+
+	return "ip:" + addr
+}
+
+// actorContext возвращает контекст с пользователем из токена, а если токена
+// не было - с устаревшим user_id из запроса (если это разрешено WithLegacyUserID).
+func (s *service) actorContext(ctx context.Context, legacyUserID int64) (context.Context, error) {
+	if _, ok := app.UserIDFromContext(ctx); ok {
+		return ctx, nil
+	}
+	if !s.legacyUserID {
+		return nil, status.Error(codes.Unauthenticated, "authorization metadata required")
+	}
+
+	return app.WithUserID(ctx, legacyUserID), nil
+}
+
+func (s *service) Login(ctx context.Context, req *LoginRequest) (*LoginResponse, error) {
+	token, err := s.app.Login(ctx, req.GetUserId(), req.GetPassword())
+	if err != nil {
+		return nil, errorStatus(err)
+	}
+
+	return &LoginResponse{
+		Token:     token.Value,
+		ExpiresAt: timestamppb.New(token.ExpiresAt),
+	}, nil
+}
@@ -0,0 +1,67 @@
+package grpc
+
+import (
+	"context"
+
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"homework9/internal/app"
+	"homework9/internal/categories"
+)
+
+func (s *service) GetCategoryTree(ctx context.Context, _ *emptypb.Empty) (*CategoryTreeResponse, error) {
+	tree, err := s.app.CategoryTree(ctx)
+	if err != nil {
+		return nil, errorStatus(err)
+	}
+
+	return &CategoryTreeResponse{Roots: newCategoryNodes(tree)}, nil
+}
+
+func (s *service) CreateCategory(ctx context.Context, req *CreateCategoryRequest) (*CategoryResponse, error) {
+	category, err := s.app.CreateCategory(ctx, req.GetName(), req.GetParentId())
+	if err != nil {
+		return nil, errorStatus(err)
+	}
+
+	return newCategoryResponse(category), nil
+}
+
+func (s *service) UpdateCategory(ctx context.Context, req *UpdateCategoryRequest) (*CategoryResponse, error) {
+	category, err := s.app.UpdateCategory(ctx, req.GetId(), req.GetName(), req.GetParentId())
+	if err != nil {
+		return nil, errorStatus(err)
+	}
+
+	return newCategoryResponse(category), nil
+}
+
+func (s *service) DeleteCategory(ctx context.Context, req *DeleteCategoryRequest) (*emptypb.Empty, error) {
+	if err := s.app.DeleteCategory(ctx, req.GetId()); err != nil {
+		return nil, errorStatus(err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func newCategoryResponse(category *categories.Category) *CategoryResponse {
+	return &CategoryResponse{
+		Id:       category.ID,
+		ParentId: category.ParentID,
+		Name:     category.Name,
+	}
+}
+
+func newCategoryNodes(nodes []app.CategoryNode) []*CategoryNode {
+	list := make([]*CategoryNode, 0, len(nodes))
+	for _, node := range nodes {
+		list = append(list, &CategoryNode{
+			Id:           node.ID,
+			Name:         node.Name,
+			PublishedAds: int32(node.PublishedAds),
+			Children:     newCategoryNodes(node.Children),
+		})
+	}
+
+	return list
+}
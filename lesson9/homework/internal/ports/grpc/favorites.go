@@ -0,0 +1,67 @@
+package grpc
+
+import (
+	"context"
+
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"homework9/internal/app"
+)
+
+func (s *service) AddFavorite(ctx context.Context, req *FavoriteRequest) (*AdResponse, error) {
+	ad, err := s.app.AddFavorite(ctx, req.GetAdId())
+	if err != nil {
+		return nil, errorStatus(err)
+	}
+
+	return newAdResponse(ad), nil
+}
+
+func (s *service) RemoveFavorite(ctx context.Context, req *FavoriteRequest) (*emptypb.Empty, error) {
+	if err := s.app.RemoveFavorite(ctx, req.GetAdId()); err != nil {
+		return nil, errorStatus(err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *service) ListFavorites(ctx context.Context, req *ListAdsRequest) (*ListAdResponse, error) {
+	page, err := s.app.Favorites(ctx, int(req.GetPageSize()), req.GetPageToken())
+	if err != nil {
+		return nil, errorStatus(err)
+	}
+
+	return newListAdResponse(page), nil
+}
+
+func (s *service) ListNotifications(ctx context.Context, req *ListNotificationsRequest) (*ListNotificationsResponse, error) {
+	page, err := s.app.Notifications(ctx, int(req.GetPageSize()), req.GetPageToken())
+	if err != nil {
+		return nil, errorStatus(err)
+	}
+
+	return newListNotificationsResponse(page), nil
+}
+
+func newListNotificationsResponse(page *app.NotificationsPage) *ListNotificationsResponse {
+	resp := &ListNotificationsResponse{
+		Notifications: make([]*Notification, 0, len(page.Notifications)),
+		NextPageToken: page.NextPageToken,
+	}
+	for _, n := range page.Notifications {
+		changes := make([]*FieldChange, 0, len(n.Changes))
+		for _, change := range n.Changes {
+			changes = append(changes, &FieldChange{Field: change.Field, Old: change.Old, New: change.New})
+		}
+
+		resp.Notifications = append(resp.Notifications, &Notification{
+			Id:        n.ID,
+			AdId:      n.AdID,
+			AdVersion: n.AdVersion,
+			CreatedAt: newTimestamp(n.CreatedAt),
+			Changes:   changes,
+		})
+	}
+
+	return resp
+}
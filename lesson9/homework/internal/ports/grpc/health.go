@@ -0,0 +1,110 @@
+package grpc
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+
+	"homework9/internal/health"
+)
+
+// healthWatchInterval - как часто Watch перепроверяет хранилище. О начале
+// остановки Watch сообщает сразу.
+const healthWatchInterval = 5 * time.Second
+
+// healthService реализует grpc.health.v1.Health. Сервер целиком ("") и
+// ad.AdService готовы, пока доступно хранилище и не началась остановка.
+type healthService struct {
+	healthpb.UnimplementedHealthServer
+	checker *health.Checker
+}
+
+func (s *healthService) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	if !knownService(req.GetService()) {
+		return nil, status.Errorf(codes.NotFound, "unknown service %q", req.GetService())
+	}
+
+	return &healthpb.HealthCheckResponse{Status: s.status(ctx)}, nil
+}
+
+// Watch присылает статус сразу и затем при каждом изменении. После начала
+// остановки присылает NOT_SERVING и завершает поток, чтобы не задерживать
+// GracefulStop.
+func (s *healthService) Watch(req *healthpb.HealthCheckRequest, stream healthpb.Health_WatchServer) error {
+	ctx := stream.Context()
+	ticker := time.NewTicker(healthWatchInterval)
+	defer ticker.Stop()
+
+	last := healthpb.HealthCheckResponse_ServingStatus(-1)
+	for {
+		current := healthpb.HealthCheckResponse_SERVICE_UNKNOWN
+		if knownService(req.GetService()) {
+			current = s.status(ctx)
+		}
+		if current != last {
+			if err := stream.Send(&healthpb.HealthCheckResponse{Status: current}); err != nil {
+				return err
+			}
+			last = current
+		}
+
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case <-s.checker.ShuttingDown():
+			if last != healthpb.HealthCheckResponse_NOT_SERVING && last != healthpb.HealthCheckResponse_SERVICE_UNKNOWN {
+				if err := stream.Send(&healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_NOT_SERVING}); err != nil {
+					return err
+				}
+			}
+			return status.Error(codes.Unavailable, health.ErrShuttingDown.Error())
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *healthService) status(ctx context.Context) healthpb.HealthCheckResponse_ServingStatus {
+	if err := s.checker.Ready(ctx); err != nil {
+		return healthpb.HealthCheckResponse_NOT_SERVING
+	}
+
+	return healthpb.HealthCheckResponse_SERVING
+}
+
+func knownService(service string) bool {
+	return service == "" || service == AdService_ServiceDesc.ServiceName
+}
+
+// streamShutdownInterceptor завершает потоки AdService (WatchAds, Chat) в
+// начале остановки, чтобы они не задерживали GracefulStop до таймаута. Клиент
+// получает codes.Unavailable и переподключается к другому экземпляру, WatchAds -
+// с последним resume_token. Health.Watch завершается сам, сообщив NOT_SERVING.
+func streamShutdownInterceptor(checker *health.Checker) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if !strings.HasPrefix(info.FullMethod, "/"+AdService_ServiceDesc.ServiceName+"/") {
+			return handler(srv, ss)
+		}
+
+		ctx, cancel := context.WithCancel(ss.Context())
+		defer cancel()
+		go func() {
+			select {
+			case <-checker.ShuttingDown():
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+
+		err := handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+		if ss.Context().Err() == nil && ctx.Err() != nil {
+			return status.Error(codes.Unavailable, health.ErrShuttingDown.Error())
+		}
+
+		return err
+	}
+}
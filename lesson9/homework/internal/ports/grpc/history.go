@@ -0,0 +1,51 @@
+package grpc
+
+import (
+	"context"
+
+	"homework9/internal/ads"
+)
+
+func (s *service) GetAdHistory(ctx context.Context, req *GetAdHistoryRequest) (*AdHistoryResponse, error) {
+	revisions, err := s.app.AdHistory(ctx, req.GetAdId())
+	if err != nil {
+		return nil, errorStatus(err)
+	}
+
+	resp := &AdHistoryResponse{Revisions: make([]*AdRevision, 0, len(revisions))}
+	for i := range revisions {
+		resp.Revisions = append(resp.Revisions, newAdRevision(&revisions[i]))
+	}
+
+	return resp, nil
+}
+
+func (s *service) RevertAd(ctx context.Context, req *RevertAdRequest) (*AdResponse, error) {
+	ad, err := s.app.RevertAd(ctx, req.GetAdId(), req.GetVersion(), req.GetExpectedVersion())
+	if err != nil {
+		return nil, errorStatus(err)
+	}
+
+	return newAdResponse(ad), nil
+}
+
+func newAdRevision(rev *ads.Revision) *AdRevision {
+	changes := make([]*FieldChange, 0, len(rev.Changes))
+	for _, change := range rev.Changes {
+		changes = append(changes, &FieldChange{Field: change.Field, Old: change.Old, New: change.New})
+	}
+
+	return &AdRevision{
+		Version:      rev.Version,
+		UserId:       rev.UserID,
+		CreatedAt:    newTimestamp(rev.CreatedAt),
+		RevertedFrom: rev.RevertedFrom,
+		Title:        rev.Content.Title,
+		Text:         rev.Content.Text,
+		CategoryId:   rev.Content.CategoryID,
+		Price:        newMoney(rev.Content.Price),
+		Published:    rev.Content.Published,
+		Moderation:   moderationStatuses[rev.Content.Moderation],
+		Changes:      changes,
+	}
+}
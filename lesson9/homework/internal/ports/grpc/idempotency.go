@@ -0,0 +1,84 @@
+package grpc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"path"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"homework9/internal/idempotency"
+)
+
+// idempotentMethods - методы, которые клиенты повторяют при сбоях сети и для
+// которых поддерживаются метаданные idempotency-key.
+var idempotentMethods = map[string]bool{
+	"CreateAd":       true,
+	"ChangeAdStatus": true,
+}
+
+// transientCodes - коды временных ошибок: их не сохраняем, повтор выполнит вызов заново.
+var transientCodes = map[codes.Code]bool{
+	codes.Unknown:           true,
+	codes.Internal:          true,
+	codes.Unavailable:       true,
+	codes.Canceled:          true,
+	codes.DeadlineExceeded:  true,
+	codes.ResourceExhausted: true,
+}
+
+// storedResult - результат первого вызова с ключом, который получат повторы.
+type storedResult struct {
+	resp any
+	err  error
+}
+
+// idempotencyInterceptor выполняет вызов с метаданными idempotency-key один
+// раз, а повторам с тем же ключом и запросом отдает сохраненный результат.
+// Ключи у каждого клиента свои, поэтому должен идти после authInterceptor.
+func idempotencyInterceptor(store *idempotency.Store) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		keys := md.Get("idempotency-key")
+		if len(keys) == 0 || !idempotentMethods[path.Base(info.FullMethod)] {
+			return handler(ctx, req)
+		}
+		if err := idempotency.ValidateKey(keys[0]); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+
+		msg, ok := req.(proto.Message)
+		if !ok {
+			return handler(ctx, req)
+		}
+		data, err := proto.MarshalOptions{Deterministic: true}.Marshal(msg)
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		sum := sha256.Sum256(data)
+		fingerprint := info.FullMethod + " " + hex.EncodeToString(sum[:])
+
+		value, replayed, err := store.Do(ctx, "grpc "+clientKey(ctx)+" "+keys[0], fingerprint, func() (any, bool) {
+			resp, err := handler(ctx, req)
+			return storedResult{resp: resp, err: err}, !transientCodes[status.Code(err)]
+		})
+		switch {
+		case errors.Is(err, idempotency.ErrKeyReused):
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		case err != nil:
+			return nil, errorStatus(err)
+		}
+
+		if replayed {
+			_ = grpc.SetHeader(ctx, metadata.Pairs("idempotent-replayed", "true"))
+		}
+		result := value.(storedResult)
+		return result.resp, result.err
+	}
+}
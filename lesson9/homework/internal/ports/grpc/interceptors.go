@@ -0,0 +1,112 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"golang.org/x/exp/slog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"homework9/internal/logging"
+)
+
+// requestIDMetadata - метаданные с идентификатором вызова: присланный клиентом
+// сохраняется, иначе генерируется новый и возвращается в заголовках ответа.
+const requestIDMetadata = "x-request-id"
+
+// requestIDInterceptor кладет идентификатор вызова в контекст, откуда его
+// берут логгеры обработчиков, app и хранилища.
+func requestIDInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		return handler(withRequestID(ctx), req)
+	}
+}
+
+// streamRequestIDInterceptor - requestIDInterceptor для потоковых методов.
+func streamRequestIDInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &contextStream{ServerStream: ss, ctx: withRequestID(ss.Context())})
+	}
+}
+
+func withRequestID(ctx context.Context) context.Context {
+	var fromClient string
+	if values := metadata.ValueFromIncomingContext(ctx, requestIDMetadata); len(values) > 0 {
+		fromClient = values[0]
+	}
+	id := logging.RequestID(fromClient)
+	_ = grpc.SetHeader(ctx, metadata.Pairs(requestIDMetadata, id))
+
+	return logging.WithRequestID(ctx, id)
+}
+
+// loggerInterceptor пишет в лог вызванный метод, код ответа и время обработки.
+func loggerInterceptor(logger *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+
+		resp, err := handler(ctx, req)
+
+		logCall(ctx, logger, info.FullMethod, err, start)
+		return resp, err
+	}
+}
+
+// recoveryInterceptor перехватывает панику в обработчике и возвращает codes.Internal.
+func recoveryInterceptor(logger *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				logPanic(ctx, logger, info.FullMethod, r)
+				err = status.Error(codes.Internal, "internal server error")
+			}
+		}()
+
+		return handler(ctx, req)
+	}
+}
+
+// streamLoggerInterceptor - loggerInterceptor для потоковых методов.
+func streamLoggerInterceptor(logger *slog.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+
+		err := handler(srv, ss)
+
+		logCall(ss.Context(), logger, info.FullMethod, err, start)
+		return err
+	}
+}
+
+// streamRecoveryInterceptor - recoveryInterceptor для потоковых методов.
+func streamRecoveryInterceptor(logger *slog.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				logPanic(ss.Context(), logger, info.FullMethod, r)
+				err = status.Error(codes.Internal, "internal server error")
+			}
+		}()
+
+		return handler(srv, ss)
+	}
+}
+
+func logCall(ctx context.Context, logger *slog.Logger, fullMethod string, err error, start time.Time) {
+	logger.InfoCtx(ctx, "call",
+		"method", fullMethod,
+		"code", status.Code(err).String(),
+		"duration", time.Since(start))
+}
+
+func logPanic(ctx context.Context, logger *slog.Logger, fullMethod string, r any) {
+	logger.ErrorCtx(ctx, "panic recovered",
+		"method", fullMethod,
+		"panic", fmt.Sprint(r),
+		"stack", string(debug.Stack()))
+}
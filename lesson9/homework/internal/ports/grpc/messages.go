@@ -0,0 +1,179 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"homework9/internal/app"
+	"homework9/internal/messages"
+	"homework9/internal/ratelimit"
+)
+
+func (s *service) OpenThread(ctx context.Context, req *OpenThreadRequest) (*Thread, error) {
+	thread, err := s.app.OpenThread(ctx, req.GetAdId())
+	if err != nil {
+		return nil, errorStatus(err)
+	}
+
+	userID, _ := app.UserIDFromContext(ctx)
+	return newThread(*thread, userID), nil
+}
+
+func (s *service) ListThreads(ctx context.Context, req *ListThreadsRequest) (*ListThreadsResponse, error) {
+	page, err := s.app.Threads(ctx, int(req.GetPageSize()), req.GetPageToken())
+	if err != nil {
+		return nil, errorStatus(err)
+	}
+	unread, err := s.app.UnreadMessages(ctx)
+	if err != nil {
+		return nil, errorStatus(err)
+	}
+
+	userID, _ := app.UserIDFromContext(ctx)
+	resp := &ListThreadsResponse{
+		Threads:       make([]*Thread, 0, len(page.Threads)),
+		NextPageToken: page.NextPageToken,
+		Unread:        int32(unread),
+	}
+	for _, thread := range page.Threads {
+		resp.Threads = append(resp.Threads, newThread(thread, userID))
+	}
+
+	return resp, nil
+}
+
+func (s *service) ListMessages(ctx context.Context, req *ListMessagesRequest) (*ListMessagesResponse, error) {
+	page, err := s.app.Messages(ctx, req.GetThreadId(), int(req.GetPageSize()), req.GetPageToken())
+	if err != nil {
+		return nil, errorStatus(err)
+	}
+
+	resp := &ListMessagesResponse{
+		Messages:      make([]*Message, 0, len(page.Messages)),
+		NextPageToken: page.NextPageToken,
+	}
+	for _, message := range page.Messages {
+		resp.Messages = append(resp.Messages, newMessage(message))
+	}
+
+	return resp, nil
+}
+
+// Chat читает запросы клиента в отдельной горутине и отправляет события
+// подписки, пока клиент не отменит вызов или не придет ошибка. Закрытие
+// клиентом своей стороны потока не завершает доставку событий. Заголовки
+// ответа отправляются сразу после подписки: дождавшись их, клиент не пропустит
+// события.
+func (s *service) Chat(stream AdService_ChatServer) error {
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+
+	sub, err := s.app.Chat(ctx)
+	if err != nil {
+		return errorStatus(err)
+	}
+	defer sub.Close()
+	if err := stream.SendHeader(metadata.MD{}); err != nil {
+		return err
+	}
+
+	failed := make(chan error, 1)
+	go func() {
+		if err := s.receiveChat(ctx, stream); err != nil {
+			failed <- err
+			cancel()
+		}
+	}()
+
+	for {
+		e, err := sub.Next(ctx)
+		if err != nil {
+			select {
+			case err := <-failed:
+				return err
+			default:
+				return errorStatus(err)
+			}
+		}
+
+		err = stream.Send(&ChatEvent{
+			Type:    chatEventTypes[e.Type],
+			Thread:  newThread(e.Thread, sub.UserID()),
+			Message: newChatMessage(e),
+		})
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// receiveChat выполняет запросы клиента. Возвращает nil, когда клиент закрыл
+// свою сторону потока.
+func (s *service) receiveChat(ctx context.Context, stream AdService_ChatServer) error {
+	for {
+		req, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := allow(s.limiter, ratelimit.Write, clientKey(ctx)); err != nil {
+			return err
+		}
+
+		switch action := req.GetAction().(type) {
+		case *ChatRequest_Send:
+			_, err = s.app.SendMessage(ctx, action.Send.GetThreadId(), action.Send.GetText())
+		case *ChatRequest_MarkRead:
+			_, err = s.app.MarkThreadRead(ctx, action.MarkRead.GetThreadId(), action.MarkRead.GetReadId())
+		default:
+			return status.Error(codes.InvalidArgument, "chat request has no action")
+		}
+		if err != nil {
+			return errorStatus(err)
+		}
+	}
+}
+
+var chatEventTypes = map[app.ChatEventType]ChatEventType{
+	app.ChatMessage: ChatEventType_CHAT_EVENT_TYPE_MESSAGE,
+	app.ChatRead:    ChatEventType_CHAT_EVENT_TYPE_READ,
+}
+
+func newChatMessage(e app.ChatEvent) *Message {
+	if e.Type != app.ChatMessage {
+		return nil
+	}
+
+	return newMessage(e.Message)
+}
+
+func newThread(thread messages.Thread, userID int64) *Thread {
+	return &Thread{
+		Id:            thread.ID,
+		AdId:          thread.AdID,
+		BuyerId:       thread.BuyerID,
+		SellerId:      thread.SellerID,
+		CreatedAt:     newTimestamp(thread.CreatedAt),
+		LastMessageId: thread.LastMessageID,
+		ReadId:        thread.ReadID(userID),
+		PeerReadId:    thread.ReadID(thread.Peer(userID)),
+		Unread:        int32(thread.Unread(userID)),
+	}
+}
+
+func newMessage(message messages.Message) *Message {
+	return &Message{
+		Id:        message.ID,
+		ThreadId:  message.ThreadID,
+		SenderId:  message.SenderID,
+		Text:      message.Text,
+		CreatedAt: newTimestamp(message.CreatedAt),
+	}
+}
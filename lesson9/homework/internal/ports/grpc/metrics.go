@@ -0,0 +1,35 @@
+package grpc
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+
+	"homework9/internal/metrics"
+)
+
+// metricsInterceptor считает вызовы, их длительность и число выполняющихся вызовов по методу.
+func metricsInterceptor(m *metrics.Metrics) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		done := m.TrackGRPC(info.FullMethod)
+
+		resp, err := handler(ctx, req)
+
+		done(status.Code(err).String())
+		return resp, err
+	}
+}
+
+// streamMetricsInterceptor - metricsInterceptor для потоковых методов, поток
+// учитывается до его завершения.
+func streamMetricsInterceptor(m *metrics.Metrics) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		done := m.TrackGRPC(info.FullMethod)
+
+		err := handler(srv, ss)
+
+		done(status.Code(err).String())
+		return err
+	}
+}
@@ -0,0 +1,69 @@
+package grpc
+
+import (
+	"context"
+
+	"homework9/internal/ads"
+	"homework9/internal/users"
+)
+
+func (s *service) ListModerationQueue(ctx context.Context, req *ListAdsRequest) (*ListAdResponse, error) {
+	page, err := s.app.ModerationQueue(ctx, int(req.GetPageSize()), req.GetPageToken())
+	if err != nil {
+		return nil, errorStatus(err)
+	}
+
+	return newListAdResponse(page), nil
+}
+
+func (s *service) ApproveAd(ctx context.Context, req *ApproveAdRequest) (*AdResponse, error) {
+	ad, err := s.app.ApproveAd(ctx, req.GetAdId())
+	if err != nil {
+		return nil, errorStatus(err)
+	}
+
+	return newAdResponse(ad), nil
+}
+
+func (s *service) RejectAd(ctx context.Context, req *RejectAdRequest) (*AdResponse, error) {
+	ad, err := s.app.RejectAd(ctx, req.GetAdId(), req.GetReason())
+	if err != nil {
+		return nil, errorStatus(err)
+	}
+
+	return newAdResponse(ad), nil
+}
+
+func (s *service) SetUserRole(ctx context.Context, req *SetUserRoleRequest) (*UserResponse, error) {
+	user, err := s.app.SetUserRole(ctx, req.GetUserId(), roles[req.GetRole()])
+	if err != nil {
+		return nil, errorStatus(err)
+	}
+
+	return newUserResponse(user), nil
+}
+
+var moderationStatuses = map[ads.Moderation]ModerationStatus{
+	ads.ModerationNone:     ModerationStatus_MODERATION_STATUS_UNSPECIFIED,
+	ads.ModerationPending:  ModerationStatus_MODERATION_STATUS_PENDING,
+	ads.ModerationApproved: ModerationStatus_MODERATION_STATUS_APPROVED,
+	ads.ModerationRejected: ModerationStatus_MODERATION_STATUS_REJECTED,
+}
+
+// roles сопоставляет роли из запроса ролям пользователей. ROLE_UNSPECIFIED
+// отображается в пустую роль, которую отклоняет валидация.
+var roles = map[Role]users.Role{
+	Role_ROLE_USER:      users.RoleUser,
+	Role_ROLE_MODERATOR: users.RoleModerator,
+	Role_ROLE_ADMIN:     users.RoleAdmin,
+}
+
+func newRole(role users.Role) Role {
+	for r, ur := range roles {
+		if ur == role {
+			return r
+		}
+	}
+
+	return Role_ROLE_UNSPECIFIED
+}
@@ -0,0 +1,114 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"homework9/internal/ads"
+	"homework9/internal/app"
+	"homework9/internal/photos"
+	"homework9/internal/ratelimit"
+)
+
+// maxUploadSize ограничивает все фотографии одного вызова UploadPhotos, как
+// и тело запроса на загрузку фотографий в REST.
+const maxUploadSize = 64 << 20
+
+func (s *service) AddPhotos(ctx context.Context, req *AddPhotosRequest) (*AdResponse, error) {
+	ad, err := s.app.AddPhotos(ctx, req.GetAdId(), req.GetPhotos())
+	if err != nil {
+		return nil, errorStatus(err)
+	}
+
+	return newAdResponse(ad), nil
+}
+
+// UploadPhotos собирает фотографии из частей потока и добавляет их к
+// объявлению одним вызовом, как AddPhotos. Размер сообщения остается по
+// умолчанию, поэтому большие фотографии передаются несколькими частями.
+func (s *service) UploadPhotos(stream AdService_UploadPhotosServer) error {
+	ctx := stream.Context()
+	// Потоковые методы не проходят rateLimitInterceptor.
+	if err := allow(s.limiter, ratelimit.Write, clientKey(ctx)); err != nil {
+		return err
+	}
+
+	var adID int64
+	var files [][]byte
+	var photo []byte
+	total := 0
+	for first := true; ; first = false {
+		req, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if first {
+			adID = req.GetAdId()
+		}
+
+		photo = append(photo, req.GetChunk()...)
+		total += len(req.GetChunk())
+		switch {
+		case len(photo) > photos.MaxSize:
+			return errorStatus(fmt.Errorf("%w: photo %d: %s", app.ErrValidation, len(files)+1, photos.ErrTooLarge))
+		case total > maxUploadSize:
+			return errorStatus(fmt.Errorf("%w: photos exceed %d bytes", app.ErrValidation, maxUploadSize))
+		}
+
+		if req.GetPhotoEnd() {
+			files = append(files, photo)
+			photo = nil
+		}
+	}
+	if len(photo) > 0 {
+		files = append(files, photo)
+	}
+
+	ad, err := s.app.AddPhotos(ctx, adID, files)
+	if err != nil {
+		return errorStatus(err)
+	}
+
+	return stream.SendAndClose(newAdResponse(ad))
+}
+
+func (s *service) ReorderPhotos(ctx context.Context, req *ReorderPhotosRequest) (*AdResponse, error) {
+	ad, err := s.app.ReorderPhotos(ctx, req.GetAdId(), req.GetPhotoIds(), req.GetExpectedVersion())
+	if err != nil {
+		return nil, errorStatus(err)
+	}
+
+	return newAdResponse(ad), nil
+}
+
+func (s *service) DeletePhoto(ctx context.Context, req *DeletePhotoRequest) (*AdResponse, error) {
+	ad, err := s.app.DeletePhoto(ctx, req.GetAdId(), req.GetPhotoId())
+	if err != nil {
+		return nil, errorStatus(err)
+	}
+
+	return newAdResponse(ad), nil
+}
+
+func newPhotos(ad *ads.Ad) []*Photo {
+	list := make([]*Photo, 0, len(ad.Photos))
+	for _, p := range ad.Photos {
+		list = append(list, &Photo{
+			Id:          p.ID,
+			Url:         photos.URL(ad.ID, p.ID, photos.Original),
+			SmallUrl:    photos.URL(ad.ID, p.ID, photos.Small),
+			MediumUrl:   photos.URL(ad.ID, p.ID, photos.Medium),
+			ContentType: p.ContentType,
+			Width:       int32(p.Width),
+			Height:      int32(p.Height),
+			Size:        p.Size,
+		})
+	}
+
+	return list
+}
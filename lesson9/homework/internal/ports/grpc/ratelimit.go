@@ -0,0 +1,72 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"homework9/internal/ratelimit"
+)
+
+// createMethods - методы, создающие сущности, у них отдельный, обычно самый строгий лимит.
+var createMethods = map[string]bool{
+	"CreateAd":   true,
+	"CreateUser": true,
+}
+
+// rateLimitInterceptor ограничивает частоту вызовов пользователя из токена,
+// а для анонимных вызовов - IP-адреса клиента. Должен идти после authInterceptor.
+func rateLimitInterceptor(l *ratelimit.Limiter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		// Проверки готовности частые и дешевые, лимит на них не тратится.
+		if strings.HasPrefix(info.FullMethod, "/"+healthpb.Health_ServiceDesc.ServiceName+"/") {
+			return handler(ctx, req)
+		}
+		if err := allow(l, methodClass(info.FullMethod), clientKey(ctx)); err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// allow забирает токен из ведра class для key, а если он закончился, возвращает
+// codes.ResourceExhausted с временем ожидания. nil-лимитер не ограничивает вызовы.
+func allow(l *ratelimit.Limiter, class ratelimit.Class, key string) error {
+	if l == nil {
+		return nil
+	}
+
+	ok, wait := l.Allow(class, key)
+	if ok {
+		return nil
+	}
+
+	st := status.New(codes.ResourceExhausted,
+		fmt.Sprintf("%s: too many %s requests", ratelimit.ErrLimitExceeded, class))
+	if detailed, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(wait)}); err == nil {
+		st = detailed
+	}
+
+	return st.Err()
+}
+
+func methodClass(fullMethod string) ratelimit.Class {
+	method := path.Base(fullMethod)
+	switch {
+	case createMethods[method]:
+		return ratelimit.Create
+	case strings.HasPrefix(method, "Get"), strings.HasPrefix(method, "List"), strings.HasPrefix(method, "Search"):
+		return ratelimit.Read
+	}
+
+	return ratelimit.Write
+}
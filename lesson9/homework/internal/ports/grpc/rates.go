@@ -0,0 +1,64 @@
+package grpc
+
+import (
+	"context"
+
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"homework9/internal/app"
+	"homework9/internal/money"
+)
+
+func (s *service) ListExchangeRates(ctx context.Context, _ *emptypb.Empty) (*ExchangeRatesResponse, error) {
+	rates, err := s.app.ExchangeRates(ctx)
+	if err != nil {
+		return nil, errorStatus(err)
+	}
+
+	resp := &ExchangeRatesResponse{Rates: make([]*ExchangeRate, 0, len(rates))}
+	for i := range rates {
+		resp.Rates = append(resp.Rates, newExchangeRate(&rates[i]))
+	}
+
+	return resp, nil
+}
+
+func (s *service) SetExchangeRate(ctx context.Context, req *ExchangeRate) (*ExchangeRate, error) {
+	rate, err := s.app.SetExchangeRate(ctx, money.Currency(req.GetCurrency()), req.GetRate())
+	if err != nil {
+		return nil, errorStatus(err)
+	}
+
+	return newExchangeRate(rate), nil
+}
+
+func newExchangeRate(rate *money.Rate) *ExchangeRate {
+	return &ExchangeRate{
+		Currency: string(rate.Currency),
+		Rate:     rate.Value,
+	}
+}
+
+// newMoney возвращает nil, если цена не указана.
+func newMoney(m money.Money) *Money {
+	if m.IsZero() {
+		return nil
+	}
+
+	return &Money{Amount: m.Amount, Currency: string(m.Currency)}
+}
+
+func toMoney(m *Money) money.Money {
+	if m == nil {
+		return money.Money{}
+	}
+
+	return money.Money{Amount: m.GetAmount(), Currency: money.Currency(m.GetCurrency())}
+}
+
+var adSorts = map[AdSort]app.AdSort{
+	AdSort_AD_SORT_ID:      app.SortByID,
+	AdSort_AD_SORT_CREATED: app.SortByCreated,
+	AdSort_AD_SORT_UPDATED: app.SortByUpdated,
+	AdSort_AD_SORT_PRICE:   app.SortByPrice,
+}
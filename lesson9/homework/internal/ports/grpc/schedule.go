@@ -0,0 +1,27 @@
+package grpc
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (s *service) ScheduleAd(ctx context.Context, req *ScheduleAdRequest) (*AdResponse, error) {
+	ad, err := s.app.ScheduleAd(ctx, req.GetAdId(), toTime(req.GetPublishAt()), toTime(req.GetUnpublishAt()),
+		req.GetExpectedVersion())
+	if err != nil {
+		return nil, errorStatus(err)
+	}
+
+	return newAdResponse(ad), nil
+}
+
+// toTime возвращает нулевое время для незаданного ts.
+func toTime(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+
+	return ts.AsTime()
+}
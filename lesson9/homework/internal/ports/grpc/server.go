@@ -0,0 +1,142 @@
+package grpc
+
+import (
+	"os"
+
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/exp/slog"
+	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"homework9/internal/app"
+	"homework9/internal/health"
+	"homework9/internal/idempotency"
+	"homework9/internal/logging"
+	"homework9/internal/metrics"
+	"homework9/internal/ratelimit"
+)
+
+type options struct {
+	legacyUserID bool
+	limiter      *ratelimit.Limiter
+	idempotency  *idempotency.Store
+	metrics      *metrics.Metrics
+	tracer       trace.TracerProvider
+	logger       *slog.Logger
+	health       *health.Checker
+}
+
+type Option func(o *options)
+
+// WithLegacyUserID разрешает устаревшую передачу user_id в запросах вместо
+// токена. По умолчанию изменять объявления можно только с токеном в метаданных
+// authorization.
+func WithLegacyUserID() Option {
+	return func(o *options) {
+		o.legacyUserID = true
+	}
+}
+
+// WithRateLimiter ограничивает частоту вызовов: при превышении лимита клиент
+// получает codes.ResourceExhausted с errdetails.RetryInfo.
+func WithRateLimiter(l *ratelimit.Limiter) Option {
+	return func(o *options) {
+		o.limiter = l
+	}
+}
+
+// WithIdempotency включает метаданные idempotency-key для CreateAd и
+// ChangeAdStatus: повторы вызова с тем же ключом получают первый результат.
+func WithIdempotency(store *idempotency.Store) Option {
+	return func(o *options) {
+		o.idempotency = store
+	}
+}
+
+// WithMetrics включает метрики вызовов: число, длительность и выполняющиеся
+// вызовы по методу и коду ответа.
+func WithMetrics(m *metrics.Metrics) Option {
+	return func(o *options) {
+		o.metrics = m
+	}
+}
+
+// WithTracing создает спан на каждый вызов, продолжая трассировку клиента из
+// метаданных traceparent.
+func WithTracing(tp trace.TracerProvider) Option {
+	return func(o *options) {
+		o.tracer = tp
+	}
+}
+
+// WithLogger задает логгер запросов и паник, по умолчанию строки JSON пишутся
+// в stdout. Строки получают идентификатор вызова из контекста, если логгер
+// создан logging.New.
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// WithHealth регистрирует стандартный сервис grpc.health.v1.Health, который
+// отвечает SERVING, пока сервис готов принимать вызовы. После checker.Shutdown
+// потоки WatchAds и Chat завершаются с codes.Unavailable.
+func WithHealth(checker *health.Checker) Option {
+	return func(o *options) {
+		o.health = checker
+	}
+}
+
+func NewGRPCServer(a app.App, opts ...Option) *grpc.Server {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	logger := o.logger
+	if logger == nil {
+		logger = logging.New(os.Stdout)
+	}
+	logger = logger.With("component", "grpc")
+	unary := []grpc.UnaryServerInterceptor{requestIDInterceptor()}
+	stream := []grpc.StreamServerInterceptor{streamRequestIDInterceptor()}
+	if o.tracer != nil {
+		unary = append(unary, tracingInterceptor(o.tracer))
+		stream = append(stream, streamTracingInterceptor(o.tracer))
+	}
+	if o.metrics != nil {
+		unary = append(unary, metricsInterceptor(o.metrics))
+		stream = append(stream, streamMetricsInterceptor(o.metrics))
+	}
+	unary = append(unary,
+		loggerInterceptor(logger),
+		recoveryInterceptor(logger),
+		authInterceptor(a),
+	)
+	if o.limiter != nil {
+		unary = append(unary, rateLimitInterceptor(o.limiter))
+	}
+	if o.idempotency != nil {
+		unary = append(unary, idempotencyInterceptor(o.idempotency))
+	}
+
+	stream = append(stream,
+		streamLoggerInterceptor(logger),
+		streamRecoveryInterceptor(logger),
+		streamAuthInterceptor(a),
+	)
+	if o.health != nil {
+		stream = append(stream, streamShutdownInterceptor(o.health))
+	}
+
+	s := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(unary...),
+		grpc.ChainStreamInterceptor(stream...),
+	)
+	RegisterAdServiceServer(s, NewService(a, opts...))
+	if o.health != nil {
+		healthpb.RegisterHealthServer(s, &healthService{checker: o.health})
+	}
+
+	return s
+}
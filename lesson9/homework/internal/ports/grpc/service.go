@@ -0,0 +1,276 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"homework9/internal/ads"
+	"homework9/internal/app"
+	"homework9/internal/money"
+	"homework9/internal/ratelimit"
+	"homework9/internal/users"
+)
+
+type service struct {
+	UnimplementedAdServiceServer
+	app          app.App
+	legacyUserID bool
+	// limiter ограничивает запросы внутри потока Chat, которые не проходят
+	// через rateLimitInterceptor.
+	limiter *ratelimit.Limiter
+}
+
+func NewService(a app.App, opts ...Option) AdServiceServer {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return &service{app: a, legacyUserID: o.legacyUserID, limiter: o.limiter}
+}
+
+func (s *service) CreateAd(ctx context.Context, req *CreateAdRequest) (*AdResponse, error) {
+	ctx, err := s.actorContext(ctx, req.GetUserId())
+	if err != nil {
+		return nil, err
+	}
+
+	ad, err := s.app.CreateAd(ctx, req.GetTitle(), req.GetText(), req.GetCategoryId(), toMoney(req.GetPrice()))
+	if err != nil {
+		return nil, errorStatus(err)
+	}
+
+	return newAdResponse(ad), nil
+}
+
+func (s *service) ChangeAdStatus(ctx context.Context, req *ChangeAdStatusRequest) (*AdResponse, error) {
+	ctx, err := s.actorContext(ctx, req.GetUserId())
+	if err != nil {
+		return nil, err
+	}
+
+	ad, err := s.app.ChangeAdStatus(ctx, req.GetAdId(), req.GetPublished(), req.GetExpectedVersion())
+	if err != nil {
+		return nil, errorStatus(err)
+	}
+
+	return newAdResponse(ad), nil
+}
+
+func (s *service) UpdateAd(ctx context.Context, req *UpdateAdRequest) (*AdResponse, error) {
+	ctx, err := s.actorContext(ctx, req.GetUserId())
+	if err != nil {
+		return nil, err
+	}
+
+	ad, err := s.app.UpdateAd(ctx, req.GetAdId(), req.GetTitle(), req.GetText(), req.GetCategoryId(),
+		toMoney(req.GetPrice()), req.GetExpectedVersion())
+	if err != nil {
+		return nil, errorStatus(err)
+	}
+
+	return newAdResponse(ad), nil
+}
+
+func (s *service) ListAds(ctx context.Context, req *ListAdsRequest) (*ListAdResponse, error) {
+	page, err := s.app.ListAds(ctx, app.AdsQuery{
+		CategoryID: req.GetCategoryId(),
+		MinPrice:   toMoney(req.GetMinPrice()),
+		MaxPrice:   toMoney(req.GetMaxPrice()),
+		Sort:       adSorts[req.GetSort()],
+		Desc:       req.GetDescending(),
+		Currency:   money.Currency(req.GetCurrency()),
+		PageSize:   int(req.GetPageSize()),
+		PageToken:  req.GetPageToken(),
+	})
+	if err != nil {
+		return nil, errorStatus(err)
+	}
+
+	return newListAdResponse(page), nil
+}
+
+func (s *service) SearchAds(ctx context.Context, req *SearchAdsRequest) (*SearchAdsResponse, error) {
+	results, err := s.app.SearchAds(ctx, req.GetQuery(), int(req.GetLimit()))
+	if err != nil {
+		return nil, errorStatus(err)
+	}
+
+	resp := &SearchAdsResponse{Results: make([]*SearchAdResult, 0, len(results))}
+	for i := range results {
+		resp.Results = append(resp.Results, &SearchAdResult{
+			Ad:             newAdResponse(&results[i].Ad),
+			Score:          results[i].Score,
+			TitleHighlight: results[i].Title,
+			TextSnippet:    results[i].Snippet,
+		})
+	}
+
+	return resp, nil
+}
+
+func (s *service) WatchAds(req *WatchAdsRequest, stream AdService_WatchAdsServer) error {
+	sub, err := s.app.WatchAds(stream.Context(), app.WatchFilter{
+		AuthorID:    req.AuthorId,
+		Published:   req.Published,
+		ResumeToken: req.GetResumeToken(),
+	})
+	if err != nil {
+		return errorStatus(err)
+	}
+	defer sub.Close()
+
+	for {
+		e, err := sub.Next(stream.Context())
+		if err != nil {
+			return errorStatus(err)
+		}
+
+		err = stream.Send(&AdEvent{
+			Type:        adEventTypes[e.Type],
+			Ad:          newAdResponse(&e.Ad),
+			ResumeToken: e.ResumeToken,
+		})
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (s *service) DeleteAd(ctx context.Context, req *DeleteAdRequest) (*emptypb.Empty, error) {
+	ctx, err := s.actorContext(ctx, req.GetAuthorId())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.app.DeleteAd(ctx, req.GetAdId()); err != nil {
+		return nil, errorStatus(err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *service) CreateUser(ctx context.Context, req *CreateUserRequest) (*UserResponse, error) {
+	user, err := s.app.CreateUser(ctx, req.GetName(), req.GetPassword())
+	if err != nil {
+		return nil, errorStatus(err)
+	}
+
+	return newUserResponse(user), nil
+}
+
+func (s *service) GetUser(ctx context.Context, req *GetUserRequest) (*UserResponse, error) {
+	user, err := s.app.GetUser(ctx, req.GetId())
+	if err != nil {
+		return nil, errorStatus(err)
+	}
+
+	return newUserResponse(user), nil
+}
+
+func (s *service) DeleteUser(ctx context.Context, req *DeleteUserRequest) (*emptypb.Empty, error) {
+	if err := s.app.DeleteUser(ctx, req.GetId()); err != nil {
+		return nil, errorStatus(err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func newAdResponse(ad *ads.Ad) *AdResponse {
+	return &AdResponse{
+		Id:         ad.ID,
+		Title:      ad.Title,
+		Text:       ad.Text,
+		AuthorId:   ad.AuthorID,
+		CategoryId: ad.CategoryID,
+		Published:  ad.Published,
+		Version:    ad.Version,
+
+		Moderation:       moderationStatuses[ad.Moderation],
+		ModerationReason: ad.ModerationReason,
+		Photos:           newPhotos(ad),
+		Price:            newMoney(ad.Price),
+		CreatedAt:        newTimestamp(ad.CreatedAt),
+		UpdatedAt:        newTimestamp(ad.UpdatedAt),
+		PublishAt:        newTimestamp(ad.PublishAt),
+		UnpublishAt:      newTimestamp(ad.UnpublishAt),
+		DeletedAt:        newTimestamp(ad.DeletedAt),
+		Favorites:        int32(ad.Favorites),
+	}
+}
+
+func newListAdResponse(page *app.AdsPage) *ListAdResponse {
+	resp := &ListAdResponse{
+		List:          make([]*AdResponse, 0, len(page.Ads)),
+		NextPageToken: page.NextPageToken,
+	}
+	for i := range page.Ads {
+		ad := newAdResponse(&page.Ads[i])
+		if price, ok := page.DisplayPrices[page.Ads[i].ID]; ok {
+			ad.DisplayPrice = newMoney(price)
+		}
+		resp.List = append(resp.List, ad)
+	}
+
+	return resp
+}
+
+func newUserResponse(user *users.User) *UserResponse {
+	return &UserResponse{
+		Id:   user.ID,
+		Name: user.Name,
+		Role: newRole(user.Role),
+	}
+}
+
+// newTimestamp возвращает nil для нулевого времени.
+func newTimestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+
+	return timestamppb.New(t)
+}
+
+var adEventTypes = map[app.AdEventType]AdEventType{
+	app.AdCreated:     AdEventType_AD_EVENT_TYPE_CREATED,
+	app.AdUpdated:     AdEventType_AD_EVENT_TYPE_UPDATED,
+	app.AdPublished:   AdEventType_AD_EVENT_TYPE_PUBLISHED,
+	app.AdUnpublished: AdEventType_AD_EVENT_TYPE_UNPUBLISHED,
+	app.AdDeleted:     AdEventType_AD_EVENT_TYPE_DELETED,
+	app.AdRestored:    AdEventType_AD_EVENT_TYPE_RESTORED,
+}
+
+// errorStatus сопоставляет ошибку бизнес-логики с gRPC статусом.
+func errorStatus(err error) error {
+	switch {
+	case errors.Is(err, app.ErrValidation):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, app.ErrUnauthenticated):
+		return status.Error(codes.Unauthenticated, err.Error())
+	case errors.Is(err, app.ErrForbidden):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, app.ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, app.ErrVersionConflict):
+		return status.Error(codes.Aborted, err.Error())
+	case errors.Is(err, app.ErrNoBlobStore):
+		return status.Error(codes.Unavailable, err.Error())
+	case errors.Is(err, app.ErrSlowSubscriber):
+		return status.Error(codes.ResourceExhausted, err.Error())
+	case errors.Is(err, app.ErrResumeTokenExpired):
+		return status.Error(codes.OutOfRange, err.Error())
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
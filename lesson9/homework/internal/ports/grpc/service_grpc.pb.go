@@ -0,0 +1,1668 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             v3.21.12
+// source: service.proto
+
+package grpc
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	AdService_CreateAd_FullMethodName            = "/ad.AdService/CreateAd"
+	AdService_ChangeAdStatus_FullMethodName      = "/ad.AdService/ChangeAdStatus"
+	AdService_UpdateAd_FullMethodName            = "/ad.AdService/UpdateAd"
+	AdService_ScheduleAd_FullMethodName          = "/ad.AdService/ScheduleAd"
+	AdService_ListAds_FullMethodName             = "/ad.AdService/ListAds"
+	AdService_SearchAds_FullMethodName           = "/ad.AdService/SearchAds"
+	AdService_WatchAds_FullMethodName            = "/ad.AdService/WatchAds"
+	AdService_CreateUser_FullMethodName          = "/ad.AdService/CreateUser"
+	AdService_GetUser_FullMethodName             = "/ad.AdService/GetUser"
+	AdService_DeleteUser_FullMethodName          = "/ad.AdService/DeleteUser"
+	AdService_DeleteAd_FullMethodName            = "/ad.AdService/DeleteAd"
+	AdService_Login_FullMethodName               = "/ad.AdService/Login"
+	AdService_ListModerationQueue_FullMethodName = "/ad.AdService/ListModerationQueue"
+	AdService_ApproveAd_FullMethodName           = "/ad.AdService/ApproveAd"
+	AdService_RejectAd_FullMethodName            = "/ad.AdService/RejectAd"
+	AdService_SetUserRole_FullMethodName         = "/ad.AdService/SetUserRole"
+	AdService_AddPhotos_FullMethodName           = "/ad.AdService/AddPhotos"
+	AdService_UploadPhotos_FullMethodName        = "/ad.AdService/UploadPhotos"
+	AdService_ReorderPhotos_FullMethodName       = "/ad.AdService/ReorderPhotos"
+	AdService_DeletePhoto_FullMethodName         = "/ad.AdService/DeletePhoto"
+	AdService_GetCategoryTree_FullMethodName     = "/ad.AdService/GetCategoryTree"
+	AdService_CreateCategory_FullMethodName      = "/ad.AdService/CreateCategory"
+	AdService_UpdateCategory_FullMethodName      = "/ad.AdService/UpdateCategory"
+	AdService_DeleteCategory_FullMethodName      = "/ad.AdService/DeleteCategory"
+	AdService_ListExchangeRates_FullMethodName   = "/ad.AdService/ListExchangeRates"
+	AdService_SetExchangeRate_FullMethodName     = "/ad.AdService/SetExchangeRate"
+	AdService_GetAdHistory_FullMethodName        = "/ad.AdService/GetAdHistory"
+	AdService_RevertAd_FullMethodName            = "/ad.AdService/RevertAd"
+	AdService_RestoreAd_FullMethodName           = "/ad.AdService/RestoreAd"
+	AdService_RestoreUser_FullMethodName         = "/ad.AdService/RestoreUser"
+	AdService_ListTrashedAds_FullMethodName      = "/ad.AdService/ListTrashedAds"
+	AdService_AddFavorite_FullMethodName         = "/ad.AdService/AddFavorite"
+	AdService_RemoveFavorite_FullMethodName      = "/ad.AdService/RemoveFavorite"
+	AdService_ListFavorites_FullMethodName       = "/ad.AdService/ListFavorites"
+	AdService_ListNotifications_FullMethodName   = "/ad.AdService/ListNotifications"
+	AdService_OpenThread_FullMethodName          = "/ad.AdService/OpenThread"
+	AdService_ListThreads_FullMethodName         = "/ad.AdService/ListThreads"
+	AdService_ListMessages_FullMethodName        = "/ad.AdService/ListMessages"
+	AdService_Chat_FullMethodName                = "/ad.AdService/Chat"
+)
+
+// AdServiceClient is the client API for AdService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type AdServiceClient interface {
+	CreateAd(ctx context.Context, in *CreateAdRequest, opts ...grpc.CallOption) (*AdResponse, error)
+	ChangeAdStatus(ctx context.Context, in *ChangeAdStatusRequest, opts ...grpc.CallOption) (*AdResponse, error)
+	UpdateAd(ctx context.Context, in *UpdateAdRequest, opts ...grpc.CallOption) (*AdResponse, error)
+	// Планирует публикацию и снятие с публикации, только для автора.
+	ScheduleAd(ctx context.Context, in *ScheduleAdRequest, opts ...grpc.CallOption) (*AdResponse, error)
+	ListAds(ctx context.Context, in *ListAdsRequest, opts ...grpc.CallOption) (*ListAdResponse, error)
+	SearchAds(ctx context.Context, in *SearchAdsRequest, opts ...grpc.CallOption) (*SearchAdsResponse, error)
+	WatchAds(ctx context.Context, in *WatchAdsRequest, opts ...grpc.CallOption) (AdService_WatchAdsClient, error)
+	CreateUser(ctx context.Context, in *CreateUserRequest, opts ...grpc.CallOption) (*UserResponse, error)
+	GetUser(ctx context.Context, in *GetUserRequest, opts ...grpc.CallOption) (*UserResponse, error)
+	// DeleteUser и DeleteAd перемещают пользователя и объявление в корзину.
+	DeleteUser(ctx context.Context, in *DeleteUserRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	DeleteAd(ctx context.Context, in *DeleteAdRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// Возвращает токен для метаданных "authorization: Bearer <token>".
+	Login(ctx context.Context, in *LoginRequest, opts ...grpc.CallOption) (*LoginResponse, error)
+	// Методы модерации, доступны модераторам и администраторам.
+	ListModerationQueue(ctx context.Context, in *ListAdsRequest, opts ...grpc.CallOption) (*ListAdResponse, error)
+	ApproveAd(ctx context.Context, in *ApproveAdRequest, opts ...grpc.CallOption) (*AdResponse, error)
+	// Отклоняет объявление из очереди или снимает с публикации опубликованное.
+	RejectAd(ctx context.Context, in *RejectAdRequest, opts ...grpc.CallOption) (*AdResponse, error)
+	// Доступен только администраторам.
+	SetUserRole(ctx context.Context, in *SetUserRoleRequest, opts ...grpc.CallOption) (*UserResponse, error)
+	// Методы для фотографий доступны только автору объявления. Содержимое
+	// фотографий отдает HTTP сервер по адресам из Photo. Запрос AddPhotos
+	// ограничен размером сообщения gRPC по умолчанию (4 MiB), фотографии
+	// больше загружаются потоком UploadPhotos.
+	AddPhotos(ctx context.Context, in *AddPhotosRequest, opts ...grpc.CallOption) (*AdResponse, error)
+	UploadPhotos(ctx context.Context, opts ...grpc.CallOption) (AdService_UploadPhotosClient, error)
+	ReorderPhotos(ctx context.Context, in *ReorderPhotosRequest, opts ...grpc.CallOption) (*AdResponse, error)
+	DeletePhoto(ctx context.Context, in *DeletePhotoRequest, opts ...grpc.CallOption) (*AdResponse, error)
+	// Дерево категорий с числом опубликованных объявлений.
+	GetCategoryTree(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*CategoryTreeResponse, error)
+	// Методы изменения категорий доступны только администраторам.
+	CreateCategory(ctx context.Context, in *CreateCategoryRequest, opts ...grpc.CallOption) (*CategoryResponse, error)
+	UpdateCategory(ctx context.Context, in *UpdateCategoryRequest, opts ...grpc.CallOption) (*CategoryResponse, error)
+	DeleteCategory(ctx context.Context, in *DeleteCategoryRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// Курсы валют к базовой валюте (RUB), по ним пересчитываются цены.
+	ListExchangeRates(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ExchangeRatesResponse, error)
+	// Доступен только администраторам.
+	SetExchangeRate(ctx context.Context, in *ExchangeRate, opts ...grpc.CallOption) (*ExchangeRate, error)
+	// История изменений доступна автору объявления и модераторам.
+	GetAdHistory(ctx context.Context, in *GetAdHistoryRequest, opts ...grpc.CallOption) (*AdHistoryResponse, error)
+	// Возвращает заголовок, текст, категорию и цену к версии из истории, только для автора.
+	RevertAd(ctx context.Context, in *RevertAdRequest, opts ...grpc.CallOption) (*AdResponse, error)
+	// Восстанавливают из корзины, пока не истек срок хранения. RestoreAd доступен
+	// автору объявления, RestoreUser - администраторам.
+	RestoreAd(ctx context.Context, in *RestoreAdRequest, opts ...grpc.CallOption) (*AdResponse, error)
+	RestoreUser(ctx context.Context, in *RestoreUserRequest, opts ...grpc.CallOption) (*UserResponse, error)
+	// Свои объявления из корзины, учитываются только page_size и page_token.
+	ListTrashedAds(ctx context.Context, in *ListAdsRequest, opts ...grpc.CallOption) (*ListAdResponse, error)
+	// Избранное текущего пользователя. Повторное добавление ничего не меняет.
+	AddFavorite(ctx context.Context, in *FavoriteRequest, opts ...grpc.CallOption) (*AdResponse, error)
+	RemoveFavorite(ctx context.Context, in *FavoriteRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// Учитываются только page_size и page_token.
+	ListFavorites(ctx context.Context, in *ListAdsRequest, opts ...grpc.CallOption) (*ListAdResponse, error)
+	// Уведомления об изменении цены и текста объявлений из избранного.
+	ListNotifications(ctx context.Context, in *ListNotificationsRequest, opts ...grpc.CallOption) (*ListNotificationsResponse, error)
+	// Переписки покупателей с авторами объявлений доступны только участникам.
+	// Возвращает переписку текущего пользователя с автором объявления, создавая ее.
+	OpenThread(ctx context.Context, in *OpenThreadRequest, opts ...grpc.CallOption) (*Thread, error)
+	ListThreads(ctx context.Context, in *ListThreadsRequest, opts ...grpc.CallOption) (*ListThreadsResponse, error)
+	ListMessages(ctx context.Context, in *ListMessagesRequest, opts ...grpc.CallOption) (*ListMessagesResponse, error)
+	// Отправляет сообщения и отметки о прочтении и доставляет новые сообщения и
+	// отметки из всех переписок пользователя, включая его собственные. Ошибка в
+	// запросе завершает поток, пропущенное можно перечитать через ListMessages.
+	// Закрытие клиентом своей стороны потока не прекращает доставку событий.
+	// Заголовки ответа приходят, когда подписка на события уже оформлена.
+	Chat(ctx context.Context, opts ...grpc.CallOption) (AdService_ChatClient, error)
+}
+
+type adServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAdServiceClient(cc grpc.ClientConnInterface) AdServiceClient {
+	return &adServiceClient{cc}
+}
+
+func (c *adServiceClient) CreateAd(ctx context.Context, in *CreateAdRequest, opts ...grpc.CallOption) (*AdResponse, error) {
+	out := new(AdResponse)
+	err := c.cc.Invoke(ctx, AdService_CreateAd_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) ChangeAdStatus(ctx context.Context, in *ChangeAdStatusRequest, opts ...grpc.CallOption) (*AdResponse, error) {
+	out := new(AdResponse)
+	err := c.cc.Invoke(ctx, AdService_ChangeAdStatus_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) UpdateAd(ctx context.Context, in *UpdateAdRequest, opts ...grpc.CallOption) (*AdResponse, error) {
+	out := new(AdResponse)
+	err := c.cc.Invoke(ctx, AdService_UpdateAd_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) ScheduleAd(ctx context.Context, in *ScheduleAdRequest, opts ...grpc.CallOption) (*AdResponse, error) {
+	out := new(AdResponse)
+	err := c.cc.Invoke(ctx, AdService_ScheduleAd_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) ListAds(ctx context.Context, in *ListAdsRequest, opts ...grpc.CallOption) (*ListAdResponse, error) {
+	out := new(ListAdResponse)
+	err := c.cc.Invoke(ctx, AdService_ListAds_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) SearchAds(ctx context.Context, in *SearchAdsRequest, opts ...grpc.CallOption) (*SearchAdsResponse, error) {
+	out := new(SearchAdsResponse)
+	err := c.cc.Invoke(ctx, AdService_SearchAds_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) WatchAds(ctx context.Context, in *WatchAdsRequest, opts ...grpc.CallOption) (AdService_WatchAdsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &AdService_ServiceDesc.Streams[0], AdService_WatchAds_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &adServiceWatchAdsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type AdService_WatchAdsClient interface {
+	Recv() (*AdEvent, error)
+	grpc.ClientStream
+}
+
+type adServiceWatchAdsClient struct {
+	grpc.ClientStream
+}
+
+func (x *adServiceWatchAdsClient) Recv() (*AdEvent, error) {
+	m := new(AdEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *adServiceClient) CreateUser(ctx context.Context, in *CreateUserRequest, opts ...grpc.CallOption) (*UserResponse, error) {
+	out := new(UserResponse)
+	err := c.cc.Invoke(ctx, AdService_CreateUser_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) GetUser(ctx context.Context, in *GetUserRequest, opts ...grpc.CallOption) (*UserResponse, error) {
+	out := new(UserResponse)
+	err := c.cc.Invoke(ctx, AdService_GetUser_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) DeleteUser(ctx context.Context, in *DeleteUserRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, AdService_DeleteUser_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) DeleteAd(ctx context.Context, in *DeleteAdRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, AdService_DeleteAd_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) Login(ctx context.Context, in *LoginRequest, opts ...grpc.CallOption) (*LoginResponse, error) {
+	out := new(LoginResponse)
+	err := c.cc.Invoke(ctx, AdService_Login_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) ListModerationQueue(ctx context.Context, in *ListAdsRequest, opts ...grpc.CallOption) (*ListAdResponse, error) {
+	out := new(ListAdResponse)
+	err := c.cc.Invoke(ctx, AdService_ListModerationQueue_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) ApproveAd(ctx context.Context, in *ApproveAdRequest, opts ...grpc.CallOption) (*AdResponse, error) {
+	out := new(AdResponse)
+	err := c.cc.Invoke(ctx, AdService_ApproveAd_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) RejectAd(ctx context.Context, in *RejectAdRequest, opts ...grpc.CallOption) (*AdResponse, error) {
+	out := new(AdResponse)
+	err := c.cc.Invoke(ctx, AdService_RejectAd_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) SetUserRole(ctx context.Context, in *SetUserRoleRequest, opts ...grpc.CallOption) (*UserResponse, error) {
+	out := new(UserResponse)
+	err := c.cc.Invoke(ctx, AdService_SetUserRole_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) AddPhotos(ctx context.Context, in *AddPhotosRequest, opts ...grpc.CallOption) (*AdResponse, error) {
+	out := new(AdResponse)
+	err := c.cc.Invoke(ctx, AdService_AddPhotos_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) UploadPhotos(ctx context.Context, opts ...grpc.CallOption) (AdService_UploadPhotosClient, error) {
+	stream, err := c.cc.NewStream(ctx, &AdService_ServiceDesc.Streams[1], AdService_UploadPhotos_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &adServiceUploadPhotosClient{stream}
+	return x, nil
+}
+
+type AdService_UploadPhotosClient interface {
+	Send(*UploadPhotosRequest) error
+	CloseAndRecv() (*AdResponse, error)
+	grpc.ClientStream
+}
+
+type adServiceUploadPhotosClient struct {
+	grpc.ClientStream
+}
+
+func (x *adServiceUploadPhotosClient) Send(m *UploadPhotosRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *adServiceUploadPhotosClient) CloseAndRecv() (*AdResponse, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(AdResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *adServiceClient) ReorderPhotos(ctx context.Context, in *ReorderPhotosRequest, opts ...grpc.CallOption) (*AdResponse, error) {
+	out := new(AdResponse)
+	err := c.cc.Invoke(ctx, AdService_ReorderPhotos_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) DeletePhoto(ctx context.Context, in *DeletePhotoRequest, opts ...grpc.CallOption) (*AdResponse, error) {
+	out := new(AdResponse)
+	err := c.cc.Invoke(ctx, AdService_DeletePhoto_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) GetCategoryTree(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*CategoryTreeResponse, error) {
+	out := new(CategoryTreeResponse)
+	err := c.cc.Invoke(ctx, AdService_GetCategoryTree_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) CreateCategory(ctx context.Context, in *CreateCategoryRequest, opts ...grpc.CallOption) (*CategoryResponse, error) {
+	out := new(CategoryResponse)
+	err := c.cc.Invoke(ctx, AdService_CreateCategory_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) UpdateCategory(ctx context.Context, in *UpdateCategoryRequest, opts ...grpc.CallOption) (*CategoryResponse, error) {
+	out := new(CategoryResponse)
+	err := c.cc.Invoke(ctx, AdService_UpdateCategory_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) DeleteCategory(ctx context.Context, in *DeleteCategoryRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, AdService_DeleteCategory_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) ListExchangeRates(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ExchangeRatesResponse, error) {
+	out := new(ExchangeRatesResponse)
+	err := c.cc.Invoke(ctx, AdService_ListExchangeRates_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) SetExchangeRate(ctx context.Context, in *ExchangeRate, opts ...grpc.CallOption) (*ExchangeRate, error) {
+	out := new(ExchangeRate)
+	err := c.cc.Invoke(ctx, AdService_SetExchangeRate_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) GetAdHistory(ctx context.Context, in *GetAdHistoryRequest, opts ...grpc.CallOption) (*AdHistoryResponse, error) {
+	out := new(AdHistoryResponse)
+	err := c.cc.Invoke(ctx, AdService_GetAdHistory_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) RevertAd(ctx context.Context, in *RevertAdRequest, opts ...grpc.CallOption) (*AdResponse, error) {
+	out := new(AdResponse)
+	err := c.cc.Invoke(ctx, AdService_RevertAd_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) RestoreAd(ctx context.Context, in *RestoreAdRequest, opts ...grpc.CallOption) (*AdResponse, error) {
+	out := new(AdResponse)
+	err := c.cc.Invoke(ctx, AdService_RestoreAd_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) RestoreUser(ctx context.Context, in *RestoreUserRequest, opts ...grpc.CallOption) (*UserResponse, error) {
+	out := new(UserResponse)
+	err := c.cc.Invoke(ctx, AdService_RestoreUser_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) ListTrashedAds(ctx context.Context, in *ListAdsRequest, opts ...grpc.CallOption) (*ListAdResponse, error) {
+	out := new(ListAdResponse)
+	err := c.cc.Invoke(ctx, AdService_ListTrashedAds_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) AddFavorite(ctx context.Context, in *FavoriteRequest, opts ...grpc.CallOption) (*AdResponse, error) {
+	out := new(AdResponse)
+	err := c.cc.Invoke(ctx, AdService_AddFavorite_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) RemoveFavorite(ctx context.Context, in *FavoriteRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, AdService_RemoveFavorite_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) ListFavorites(ctx context.Context, in *ListAdsRequest, opts ...grpc.CallOption) (*ListAdResponse, error) {
+	out := new(ListAdResponse)
+	err := c.cc.Invoke(ctx, AdService_ListFavorites_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) ListNotifications(ctx context.Context, in *ListNotificationsRequest, opts ...grpc.CallOption) (*ListNotificationsResponse, error) {
+	out := new(ListNotificationsResponse)
+	err := c.cc.Invoke(ctx, AdService_ListNotifications_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) OpenThread(ctx context.Context, in *OpenThreadRequest, opts ...grpc.CallOption) (*Thread, error) {
+	out := new(Thread)
+	err := c.cc.Invoke(ctx, AdService_OpenThread_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) ListThreads(ctx context.Context, in *ListThreadsRequest, opts ...grpc.CallOption) (*ListThreadsResponse, error) {
+	out := new(ListThreadsResponse)
+	err := c.cc.Invoke(ctx, AdService_ListThreads_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) ListMessages(ctx context.Context, in *ListMessagesRequest, opts ...grpc.CallOption) (*ListMessagesResponse, error) {
+	out := new(ListMessagesResponse)
+	err := c.cc.Invoke(ctx, AdService_ListMessages_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) Chat(ctx context.Context, opts ...grpc.CallOption) (AdService_ChatClient, error) {
+	stream, err := c.cc.NewStream(ctx, &AdService_ServiceDesc.Streams[2], AdService_Chat_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &adServiceChatClient{stream}
+	return x, nil
+}
+
+type AdService_ChatClient interface {
+	Send(*ChatRequest) error
+	Recv() (*ChatEvent, error)
+	grpc.ClientStream
+}
+
+type adServiceChatClient struct {
+	grpc.ClientStream
+}
+
+func (x *adServiceChatClient) Send(m *ChatRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *adServiceChatClient) Recv() (*ChatEvent, error) {
+	m := new(ChatEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// AdServiceServer is the server API for AdService service.
+// All implementations must embed UnimplementedAdServiceServer
+// for forward compatibility
+type AdServiceServer interface {
+	CreateAd(context.Context, *CreateAdRequest) (*AdResponse, error)
+	ChangeAdStatus(context.Context, *ChangeAdStatusRequest) (*AdResponse, error)
+	UpdateAd(context.Context, *UpdateAdRequest) (*AdResponse, error)
+	// Планирует публикацию и снятие с публикации, только для автора.
+	ScheduleAd(context.Context, *ScheduleAdRequest) (*AdResponse, error)
+	ListAds(context.Context, *ListAdsRequest) (*ListAdResponse, error)
+	SearchAds(context.Context, *SearchAdsRequest) (*SearchAdsResponse, error)
+	WatchAds(*WatchAdsRequest, AdService_WatchAdsServer) error
+	CreateUser(context.Context, *CreateUserRequest) (*UserResponse, error)
+	GetUser(context.Context, *GetUserRequest) (*UserResponse, error)
+	// DeleteUser и DeleteAd перемещают пользователя и объявление в корзину.
+	DeleteUser(context.Context, *DeleteUserRequest) (*emptypb.Empty, error)
+	DeleteAd(context.Context, *DeleteAdRequest) (*emptypb.Empty, error)
+	// Возвращает токен для метаданных "authorization: Bearer <token>".
+	Login(context.Context, *LoginRequest) (*LoginResponse, error)
+	// Методы модерации, доступны модераторам и администраторам.
+	ListModerationQueue(context.Context, *ListAdsRequest) (*ListAdResponse, error)
+	ApproveAd(context.Context, *ApproveAdRequest) (*AdResponse, error)
+	// Отклоняет объявление из очереди или снимает с публикации опубликованное.
+	RejectAd(context.Context, *RejectAdRequest) (*AdResponse, error)
+	// Доступен только администраторам.
+	SetUserRole(context.Context, *SetUserRoleRequest) (*UserResponse, error)
+	// Методы для фотографий доступны только автору объявления. Содержимое
+	// фотографий отдает HTTP сервер по адресам из Photo. Запрос AddPhotos
+	// ограничен размером сообщения gRPC по умолчанию (4 MiB), фотографии
+	// больше загружаются потоком UploadPhotos.
+	AddPhotos(context.Context, *AddPhotosRequest) (*AdResponse, error)
+	UploadPhotos(AdService_UploadPhotosServer) error
+	ReorderPhotos(context.Context, *ReorderPhotosRequest) (*AdResponse, error)
+	DeletePhoto(context.Context, *DeletePhotoRequest) (*AdResponse, error)
+	// Дерево категорий с числом опубликованных объявлений.
+	GetCategoryTree(context.Context, *emptypb.Empty) (*CategoryTreeResponse, error)
+	// Методы изменения категорий доступны только администраторам.
+	CreateCategory(context.Context, *CreateCategoryRequest) (*CategoryResponse, error)
+	UpdateCategory(context.Context, *UpdateCategoryRequest) (*CategoryResponse, error)
+	DeleteCategory(context.Context, *DeleteCategoryRequest) (*emptypb.Empty, error)
+	// Курсы валют к базовой валюте (RUB), по ним пересчитываются цены.
+	ListExchangeRates(context.Context, *emptypb.Empty) (*ExchangeRatesResponse, error)
+	// Доступен только администраторам.
+	SetExchangeRate(context.Context, *ExchangeRate) (*ExchangeRate, error)
+	// История изменений доступна автору объявления и модераторам.
+	GetAdHistory(context.Context, *GetAdHistoryRequest) (*AdHistoryResponse, error)
+	// Возвращает заголовок, текст, категорию и цену к версии из истории, только для автора.
+	RevertAd(context.Context, *RevertAdRequest) (*AdResponse, error)
+	// Восстанавливают из корзины, пока не истек срок хранения. RestoreAd доступен
+	// автору объявления, RestoreUser - администраторам.
+	RestoreAd(context.Context, *RestoreAdRequest) (*AdResponse, error)
+	RestoreUser(context.Context, *RestoreUserRequest) (*UserResponse, error)
+	// Свои объявления из корзины, учитываются только page_size и page_token.
+	ListTrashedAds(context.Context, *ListAdsRequest) (*ListAdResponse, error)
+	// Избранное текущего пользователя. Повторное добавление ничего не меняет.
+	AddFavorite(context.Context, *FavoriteRequest) (*AdResponse, error)
+	RemoveFavorite(context.Context, *FavoriteRequest) (*emptypb.Empty, error)
+	// Учитываются только page_size и page_token.
+	ListFavorites(context.Context, *ListAdsRequest) (*ListAdResponse, error)
+	// Уведомления об изменении цены и текста объявлений из избранного.
+	ListNotifications(context.Context, *ListNotificationsRequest) (*ListNotificationsResponse, error)
+	// Переписки покупателей с авторами объявлений доступны только участникам.
+	// Возвращает переписку текущего пользователя с автором объявления, создавая ее.
+	OpenThread(context.Context, *OpenThreadRequest) (*Thread, error)
+	ListThreads(context.Context, *ListThreadsRequest) (*ListThreadsResponse, error)
+	ListMessages(context.Context, *ListMessagesRequest) (*ListMessagesResponse, error)
+	// Отправляет сообщения и отметки о прочтении и доставляет новые сообщения и
+	// отметки из всех переписок пользователя, включая его собственные. Ошибка в
+	// запросе завершает поток, пропущенное можно перечитать через ListMessages.
+	// Закрытие клиентом своей стороны потока не прекращает доставку событий.
+	// Заголовки ответа приходят, когда подписка на события уже оформлена.
+	Chat(AdService_ChatServer) error
+	mustEmbedUnimplementedAdServiceServer()
+}
+
+// UnimplementedAdServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedAdServiceServer struct {
+}
+
+func (UnimplementedAdServiceServer) CreateAd(context.Context, *CreateAdRequest) (*AdResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateAd not implemented")
+}
+func (UnimplementedAdServiceServer) ChangeAdStatus(context.Context, *ChangeAdStatusRequest) (*AdResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ChangeAdStatus not implemented")
+}
+func (UnimplementedAdServiceServer) UpdateAd(context.Context, *UpdateAdRequest) (*AdResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateAd not implemented")
+}
+func (UnimplementedAdServiceServer) ScheduleAd(context.Context, *ScheduleAdRequest) (*AdResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ScheduleAd not implemented")
+}
+func (UnimplementedAdServiceServer) ListAds(context.Context, *ListAdsRequest) (*ListAdResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListAds not implemented")
+}
+func (UnimplementedAdServiceServer) SearchAds(context.Context, *SearchAdsRequest) (*SearchAdsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SearchAds not implemented")
+}
+func (UnimplementedAdServiceServer) WatchAds(*WatchAdsRequest, AdService_WatchAdsServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchAds not implemented")
+}
+func (UnimplementedAdServiceServer) CreateUser(context.Context, *CreateUserRequest) (*UserResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateUser not implemented")
+}
+func (UnimplementedAdServiceServer) GetUser(context.Context, *GetUserRequest) (*UserResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetUser not implemented")
+}
+func (UnimplementedAdServiceServer) DeleteUser(context.Context, *DeleteUserRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteUser not implemented")
+}
+func (UnimplementedAdServiceServer) DeleteAd(context.Context, *DeleteAdRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteAd not implemented")
+}
+func (UnimplementedAdServiceServer) Login(context.Context, *LoginRequest) (*LoginResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Login not implemented")
+}
+func (UnimplementedAdServiceServer) ListModerationQueue(context.Context, *ListAdsRequest) (*ListAdResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListModerationQueue not implemented")
+}
+func (UnimplementedAdServiceServer) ApproveAd(context.Context, *ApproveAdRequest) (*AdResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ApproveAd not implemented")
+}
+func (UnimplementedAdServiceServer) RejectAd(context.Context, *RejectAdRequest) (*AdResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RejectAd not implemented")
+}
+func (UnimplementedAdServiceServer) SetUserRole(context.Context, *SetUserRoleRequest) (*UserResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetUserRole not implemented")
+}
+func (UnimplementedAdServiceServer) AddPhotos(context.Context, *AddPhotosRequest) (*AdResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddPhotos not implemented")
+}
+func (UnimplementedAdServiceServer) UploadPhotos(AdService_UploadPhotosServer) error {
+	return status.Errorf(codes.Unimplemented, "method UploadPhotos not implemented")
+}
+func (UnimplementedAdServiceServer) ReorderPhotos(context.Context, *ReorderPhotosRequest) (*AdResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReorderPhotos not implemented")
+}
+func (UnimplementedAdServiceServer) DeletePhoto(context.Context, *DeletePhotoRequest) (*AdResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeletePhoto not implemented")
+}
+func (UnimplementedAdServiceServer) GetCategoryTree(context.Context, *emptypb.Empty) (*CategoryTreeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCategoryTree not implemented")
+}
+func (UnimplementedAdServiceServer) CreateCategory(context.Context, *CreateCategoryRequest) (*CategoryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateCategory not implemented")
+}
+func (UnimplementedAdServiceServer) UpdateCategory(context.Context, *UpdateCategoryRequest) (*CategoryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateCategory not implemented")
+}
+func (UnimplementedAdServiceServer) DeleteCategory(context.Context, *DeleteCategoryRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteCategory not implemented")
+}
+func (UnimplementedAdServiceServer) ListExchangeRates(context.Context, *emptypb.Empty) (*ExchangeRatesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListExchangeRates not implemented")
+}
+func (UnimplementedAdServiceServer) SetExchangeRate(context.Context, *ExchangeRate) (*ExchangeRate, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetExchangeRate not implemented")
+}
+func (UnimplementedAdServiceServer) GetAdHistory(context.Context, *GetAdHistoryRequest) (*AdHistoryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAdHistory not implemented")
+}
+func (UnimplementedAdServiceServer) RevertAd(context.Context, *RevertAdRequest) (*AdResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RevertAd not implemented")
+}
+func (UnimplementedAdServiceServer) RestoreAd(context.Context, *RestoreAdRequest) (*AdResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RestoreAd not implemented")
+}
+func (UnimplementedAdServiceServer) RestoreUser(context.Context, *RestoreUserRequest) (*UserResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RestoreUser not implemented")
+}
+func (UnimplementedAdServiceServer) ListTrashedAds(context.Context, *ListAdsRequest) (*ListAdResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListTrashedAds not implemented")
+}
+func (UnimplementedAdServiceServer) AddFavorite(context.Context, *FavoriteRequest) (*AdResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddFavorite not implemented")
+}
+func (UnimplementedAdServiceServer) RemoveFavorite(context.Context, *FavoriteRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemoveFavorite not implemented")
+}
+func (UnimplementedAdServiceServer) ListFavorites(context.Context, *ListAdsRequest) (*ListAdResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListFavorites not implemented")
+}
+func (UnimplementedAdServiceServer) ListNotifications(context.Context, *ListNotificationsRequest) (*ListNotificationsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListNotifications not implemented")
+}
+func (UnimplementedAdServiceServer) OpenThread(context.Context, *OpenThreadRequest) (*Thread, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method OpenThread not implemented")
+}
+func (UnimplementedAdServiceServer) ListThreads(context.Context, *ListThreadsRequest) (*ListThreadsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListThreads not implemented")
+}
+func (UnimplementedAdServiceServer) ListMessages(context.Context, *ListMessagesRequest) (*ListMessagesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListMessages not implemented")
+}
+func (UnimplementedAdServiceServer) Chat(AdService_ChatServer) error {
+	return status.Errorf(codes.Unimplemented, "method Chat not implemented")
+}
+func (UnimplementedAdServiceServer) mustEmbedUnimplementedAdServiceServer() {}
+
+// UnsafeAdServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AdServiceServer will
+// result in compilation errors.
+type UnsafeAdServiceServer interface {
+	mustEmbedUnimplementedAdServiceServer()
+}
+
+func RegisterAdServiceServer(s grpc.ServiceRegistrar, srv AdServiceServer) {
+	s.RegisterService(&AdService_ServiceDesc, srv)
+}
+
+func _AdService_CreateAd_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateAdRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).CreateAd(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdService_CreateAd_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).CreateAd(ctx, req.(*CreateAdRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_ChangeAdStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ChangeAdStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).ChangeAdStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdService_ChangeAdStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).ChangeAdStatus(ctx, req.(*ChangeAdStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_UpdateAd_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateAdRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).UpdateAd(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdService_UpdateAd_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).UpdateAd(ctx, req.(*UpdateAdRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_ScheduleAd_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ScheduleAdRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).ScheduleAd(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdService_ScheduleAd_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).ScheduleAd(ctx, req.(*ScheduleAdRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_ListAds_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListAdsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).ListAds(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdService_ListAds_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).ListAds(ctx, req.(*ListAdsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_SearchAds_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchAdsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).SearchAds(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdService_SearchAds_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).SearchAds(ctx, req.(*SearchAdsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_WatchAds_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchAdsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AdServiceServer).WatchAds(m, &adServiceWatchAdsServer{stream})
+}
+
+type AdService_WatchAdsServer interface {
+	Send(*AdEvent) error
+	grpc.ServerStream
+}
+
+type adServiceWatchAdsServer struct {
+	grpc.ServerStream
+}
+
+func (x *adServiceWatchAdsServer) Send(m *AdEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _AdService_CreateUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).CreateUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdService_CreateUser_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).CreateUser(ctx, req.(*CreateUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_GetUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).GetUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdService_GetUser_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).GetUser(ctx, req.(*GetUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_DeleteUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).DeleteUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdService_DeleteUser_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).DeleteUser(ctx, req.(*DeleteUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_DeleteAd_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteAdRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).DeleteAd(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdService_DeleteAd_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).DeleteAd(ctx, req.(*DeleteAdRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_Login_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LoginRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).Login(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdService_Login_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).Login(ctx, req.(*LoginRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_ListModerationQueue_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListAdsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).ListModerationQueue(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdService_ListModerationQueue_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).ListModerationQueue(ctx, req.(*ListAdsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_ApproveAd_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ApproveAdRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).ApproveAd(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdService_ApproveAd_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).ApproveAd(ctx, req.(*ApproveAdRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_RejectAd_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RejectAdRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).RejectAd(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdService_RejectAd_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).RejectAd(ctx, req.(*RejectAdRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_SetUserRole_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetUserRoleRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).SetUserRole(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdService_SetUserRole_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).SetUserRole(ctx, req.(*SetUserRoleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_AddPhotos_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddPhotosRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).AddPhotos(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdService_AddPhotos_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).AddPhotos(ctx, req.(*AddPhotosRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_UploadPhotos_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(AdServiceServer).UploadPhotos(&adServiceUploadPhotosServer{stream})
+}
+
+type AdService_UploadPhotosServer interface {
+	SendAndClose(*AdResponse) error
+	Recv() (*UploadPhotosRequest, error)
+	grpc.ServerStream
+}
+
+type adServiceUploadPhotosServer struct {
+	grpc.ServerStream
+}
+
+func (x *adServiceUploadPhotosServer) SendAndClose(m *AdResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *adServiceUploadPhotosServer) Recv() (*UploadPhotosRequest, error) {
+	m := new(UploadPhotosRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _AdService_ReorderPhotos_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReorderPhotosRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).ReorderPhotos(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdService_ReorderPhotos_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).ReorderPhotos(ctx, req.(*ReorderPhotosRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_DeletePhoto_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeletePhotoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).DeletePhoto(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdService_DeletePhoto_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).DeletePhoto(ctx, req.(*DeletePhotoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_GetCategoryTree_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).GetCategoryTree(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdService_GetCategoryTree_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).GetCategoryTree(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_CreateCategory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateCategoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).CreateCategory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdService_CreateCategory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).CreateCategory(ctx, req.(*CreateCategoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_UpdateCategory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateCategoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).UpdateCategory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdService_UpdateCategory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).UpdateCategory(ctx, req.(*UpdateCategoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_DeleteCategory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteCategoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).DeleteCategory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdService_DeleteCategory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).DeleteCategory(ctx, req.(*DeleteCategoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_ListExchangeRates_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).ListExchangeRates(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdService_ListExchangeRates_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).ListExchangeRates(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_SetExchangeRate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExchangeRate)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).SetExchangeRate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdService_SetExchangeRate_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).SetExchangeRate(ctx, req.(*ExchangeRate))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_GetAdHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetAdHistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).GetAdHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdService_GetAdHistory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).GetAdHistory(ctx, req.(*GetAdHistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_RevertAd_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RevertAdRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).RevertAd(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdService_RevertAd_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).RevertAd(ctx, req.(*RevertAdRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_RestoreAd_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RestoreAdRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).RestoreAd(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdService_RestoreAd_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).RestoreAd(ctx, req.(*RestoreAdRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_RestoreUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RestoreUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).RestoreUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdService_RestoreUser_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).RestoreUser(ctx, req.(*RestoreUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_ListTrashedAds_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListAdsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).ListTrashedAds(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdService_ListTrashedAds_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).ListTrashedAds(ctx, req.(*ListAdsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_AddFavorite_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FavoriteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).AddFavorite(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdService_AddFavorite_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).AddFavorite(ctx, req.(*FavoriteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_RemoveFavorite_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FavoriteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).RemoveFavorite(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdService_RemoveFavorite_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).RemoveFavorite(ctx, req.(*FavoriteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_ListFavorites_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListAdsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).ListFavorites(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdService_ListFavorites_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).ListFavorites(ctx, req.(*ListAdsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_ListNotifications_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListNotificationsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).ListNotifications(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdService_ListNotifications_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).ListNotifications(ctx, req.(*ListNotificationsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_OpenThread_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(OpenThreadRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).OpenThread(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdService_OpenThread_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).OpenThread(ctx, req.(*OpenThreadRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_ListThreads_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListThreadsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).ListThreads(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdService_ListThreads_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).ListThreads(ctx, req.(*ListThreadsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_ListMessages_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListMessagesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).ListMessages(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdService_ListMessages_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).ListMessages(ctx, req.(*ListMessagesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_Chat_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(AdServiceServer).Chat(&adServiceChatServer{stream})
+}
+
+type AdService_ChatServer interface {
+	Send(*ChatEvent) error
+	Recv() (*ChatRequest, error)
+	grpc.ServerStream
+}
+
+type adServiceChatServer struct {
+	grpc.ServerStream
+}
+
+func (x *adServiceChatServer) Send(m *ChatEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *adServiceChatServer) Recv() (*ChatRequest, error) {
+	m := new(ChatRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// AdService_ServiceDesc is the grpc.ServiceDesc for AdService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var AdService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "ad.AdService",
+	HandlerType: (*AdServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateAd",
+			Handler:    _AdService_CreateAd_Handler,
+		},
+		{
+			MethodName: "ChangeAdStatus",
+			Handler:    _AdService_ChangeAdStatus_Handler,
+		},
+		{
+			MethodName: "UpdateAd",
+			Handler:    _AdService_UpdateAd_Handler,
+		},
+		{
+			MethodName: "ScheduleAd",
+			Handler:    _AdService_ScheduleAd_Handler,
+		},
+		{
+			MethodName: "ListAds",
+			Handler:    _AdService_ListAds_Handler,
+		},
+		{
+			MethodName: "SearchAds",
+			Handler:    _AdService_SearchAds_Handler,
+		},
+		{
+			MethodName: "CreateUser",
+			Handler:    _AdService_CreateUser_Handler,
+		},
+		{
+			MethodName: "GetUser",
+			Handler:    _AdService_GetUser_Handler,
+		},
+		{
+			MethodName: "DeleteUser",
+			Handler:    _AdService_DeleteUser_Handler,
+		},
+		{
+			MethodName: "DeleteAd",
+			Handler:    _AdService_DeleteAd_Handler,
+		},
+		{
+			MethodName: "Login",
+			Handler:    _AdService_Login_Handler,
+		},
+		{
+			MethodName: "ListModerationQueue",
+			Handler:    _AdService_ListModerationQueue_Handler,
+		},
+		{
+			MethodName: "ApproveAd",
+			Handler:    _AdService_ApproveAd_Handler,
+		},
+		{
+			MethodName: "RejectAd",
+			Handler:    _AdService_RejectAd_Handler,
+		},
+		{
+			MethodName: "SetUserRole",
+			Handler:    _AdService_SetUserRole_Handler,
+		},
+		{
+			MethodName: "AddPhotos",
+			Handler:    _AdService_AddPhotos_Handler,
+		},
+		{
+			MethodName: "ReorderPhotos",
+			Handler:    _AdService_ReorderPhotos_Handler,
+		},
+		{
+			MethodName: "DeletePhoto",
+			Handler:    _AdService_DeletePhoto_Handler,
+		},
+		{
+			MethodName: "GetCategoryTree",
+			Handler:    _AdService_GetCategoryTree_Handler,
+		},
+		{
+			MethodName: "CreateCategory",
+			Handler:    _AdService_CreateCategory_Handler,
+		},
+		{
+			MethodName: "UpdateCategory",
+			Handler:    _AdService_UpdateCategory_Handler,
+		},
+		{
+			MethodName: "DeleteCategory",
+			Handler:    _AdService_DeleteCategory_Handler,
+		},
+		{
+			MethodName: "ListExchangeRates",
+			Handler:    _AdService_ListExchangeRates_Handler,
+		},
+		{
+			MethodName: "SetExchangeRate",
+			Handler:    _AdService_SetExchangeRate_Handler,
+		},
+		{
+			MethodName: "GetAdHistory",
+			Handler:    _AdService_GetAdHistory_Handler,
+		},
+		{
+			MethodName: "RevertAd",
+			Handler:    _AdService_RevertAd_Handler,
+		},
+		{
+			MethodName: "RestoreAd",
+			Handler:    _AdService_RestoreAd_Handler,
+		},
+		{
+			MethodName: "RestoreUser",
+			Handler:    _AdService_RestoreUser_Handler,
+		},
+		{
+			MethodName: "ListTrashedAds",
+			Handler:    _AdService_ListTrashedAds_Handler,
+		},
+		{
+			MethodName: "AddFavorite",
+			Handler:    _AdService_AddFavorite_Handler,
+		},
+		{
+			MethodName: "RemoveFavorite",
+			Handler:    _AdService_RemoveFavorite_Handler,
+		},
+		{
+			MethodName: "ListFavorites",
+			Handler:    _AdService_ListFavorites_Handler,
+		},
+		{
+			MethodName: "ListNotifications",
+			Handler:    _AdService_ListNotifications_Handler,
+		},
+		{
+			MethodName: "OpenThread",
+			Handler:    _AdService_OpenThread_Handler,
+		},
+		{
+			MethodName: "ListThreads",
+			Handler:    _AdService_ListThreads_Handler,
+		},
+		{
+			MethodName: "ListMessages",
+			Handler:    _AdService_ListMessages_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchAds",
+			Handler:       _AdService_WatchAds_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "UploadPhotos",
+			Handler:       _AdService_UploadPhotos_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "Chat",
+			Handler:       _AdService_Chat_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "service.proto",
+}
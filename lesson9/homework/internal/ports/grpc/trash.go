@@ -0,0 +1,30 @@
+package grpc
+
+import "context"
+
+func (s *service) RestoreAd(ctx context.Context, req *RestoreAdRequest) (*AdResponse, error) {
+	ad, err := s.app.RestoreAd(ctx, req.GetAdId())
+	if err != nil {
+		return nil, errorStatus(err)
+	}
+
+	return newAdResponse(ad), nil
+}
+
+func (s *service) RestoreUser(ctx context.Context, req *RestoreUserRequest) (*UserResponse, error) {
+	user, err := s.app.RestoreUser(ctx, req.GetId())
+	if err != nil {
+		return nil, errorStatus(err)
+	}
+
+	return newUserResponse(user), nil
+}
+
+func (s *service) ListTrashedAds(ctx context.Context, req *ListAdsRequest) (*ListAdResponse, error) {
+	page, err := s.app.TrashedAds(ctx, int(req.GetPageSize()), req.GetPageToken())
+	if err != nil {
+		return nil, errorStatus(err)
+	}
+
+	return newListAdResponse(page), nil
+}
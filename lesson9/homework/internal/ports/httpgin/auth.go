@@ -0,0 +1,97 @@
+package httpgin
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"homework9/internal/app"
+)
+
+var errNoCredentials = errors.New("authorization required: pass Authorization: Bearer <token>")
+
+// authMiddleware проверяет токен из заголовка Authorization и кладет
+// пользователя в контекст запроса. Запросы без заголовка пропускаются.
+func authMiddleware(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		if header == "" {
+			c.Next()
+			return
+		}
+
+		if !strings.HasPrefix(header, "Bearer ") {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, ErrorResponse(errors.New("unsupported authorization scheme")))
+			return
+		}
+
+		token := strings.TrimPrefix(header, "Bearer ")
+		userID, err := a.Authenticate(c.Request.Context(), token)
+		if err != nil {
+			c.AbortWithStatusJSON(errorStatus(err), ErrorResponse(err))
+			return
+		}
+
+		c.Request = c.Request.WithContext(app.WithUserID(c.Request.Context(), userID))
+		c.Next()
+	}
+}
+
+// requireUserMiddleware отклоняет запросы без токена.
+func requireUserMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := app.UserIDFromContext(c.Request.Context()); !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, ErrorResponse(errNoCredentials))
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// clientKey возвращает ключ клиента для лимитов и ключей идемпотентности:
+// пользователя из токена, а для анонимных запросов - IP-адрес.
+func clientKey(c *gin.Context) string {
+	if userID, ok := app.UserIDFromContext(c.Request.Context()); ok {
+		return "user:" + strconv.FormatInt(userID, 10)
+	}
+
+	return "ip:" + c.ClientIP()
+}
+
+// actorContext возвращает контекст с пользователем из токена, а если токена
+// не было - с устаревшим user_id из тела запроса.
+//
+// Deprecated-путь с user_id включается опцией WithLegacyUserID, без нее
+// запросы без токена отклоняет requireUserMiddleware.
+func actorContext(c *gin.Context, legacyUserID int64) context.Context {
+	ctx := c.Request.Context()
+	if _, ok := app.UserIDFromContext(ctx); ok {
+		return ctx
+	}
+
+	return app.WithUserID(ctx, legacyUserID)
+}
+
+// Метод для входа по ID пользователя и паролю, возвращает токен для заголовка Authorization
+func login(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody loginRequest
+		if err := c.ShouldBindJSON(&reqBody); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		token, err := a.Login(c.Request.Context(), reqBody.UserID, reqBody.Password)
+		if err != nil {
+			c.JSON(errorStatus(err), ErrorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, TokenSuccessResponse(token))
+	}
+}
@@ -0,0 +1,84 @@
+package httpgin
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"homework9/internal/app"
+)
+
+// Метод для получения дерева категорий
+func getCategoryTree(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tree, err := a.CategoryTree(c.Request.Context())
+		if err != nil {
+			c.JSON(errorStatus(err), ErrorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, CategoryTreeSuccessResponse(tree))
+	}
+}
+
+// Метод для создания категории, parent_id 0 - категория верхнего уровня
+func createCategory(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody categoryRequest
+		if err := c.ShouldBindJSON(&reqBody); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		category, err := a.CreateCategory(c.Request.Context(), reqBody.Name, reqBody.ParentID)
+		if err != nil {
+			c.JSON(errorStatus(err), ErrorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, CategorySuccessResponse(category))
+	}
+}
+
+// Метод для переименования категории и ее переноса под другого родителя
+func updateCategory(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody categoryRequest
+		if err := c.ShouldBindJSON(&reqBody); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		categoryID, err := paramInt64(c, "category_id")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		category, err := a.UpdateCategory(c.Request.Context(), categoryID, reqBody.Name, reqBody.ParentID)
+		if err != nil {
+			c.JSON(errorStatus(err), ErrorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, CategorySuccessResponse(category))
+	}
+}
+
+// Метод для удаления категории без подкатегорий и объявлений
+func deleteCategory(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		categoryID, err := paramInt64(c, "category_id")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		if err := a.DeleteCategory(c.Request.Context(), categoryID); err != nil {
+			c.JSON(errorStatus(err), ErrorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, EmptySuccessResponse())
+	}
+}
@@ -0,0 +1,84 @@
+package httpgin
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"homework9/internal/app"
+)
+
+// Метод для получения страницы своего избранного, query параметры page_size и page_token
+func listFavorites(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		pageSize, err := queryInt(c, "page_size")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		page, err := a.Favorites(c.Request.Context(), pageSize, c.Query("page_token"))
+		if err != nil {
+			c.JSON(errorStatus(err), ErrorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, AdsPageSuccessResponse(page))
+	}
+}
+
+// Метод для добавления объявления в избранное, возвращает объявление
+func addFavorite(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		adID, err := paramInt64(c, "ad_id")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		ad, err := a.AddFavorite(c.Request.Context(), adID)
+		if err != nil {
+			c.JSON(errorStatus(err), ErrorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, AdSuccessResponse(ad))
+	}
+}
+
+// Метод для удаления объявления из избранного
+func removeFavorite(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		adID, err := paramInt64(c, "ad_id")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		if err := a.RemoveFavorite(c.Request.Context(), adID); err != nil {
+			c.JSON(errorStatus(err), ErrorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, EmptySuccessResponse())
+	}
+}
+
+// Метод для получения уведомлений об изменении объявлений из избранного, query параметры page_size и page_token
+func listNotifications(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		pageSize, err := queryInt(c, "page_size")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		page, err := a.Notifications(c.Request.Context(), pageSize, c.Query("page_token"))
+		if err != nil {
+			c.JSON(errorStatus(err), ErrorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, NotificationsPageSuccessResponse(page))
+	}
+}
@@ -0,0 +1,425 @@
+package httpgin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"homework9/internal/ads"
+	"homework9/internal/app"
+	"homework9/internal/money"
+)
+
+// Метод для создания объявления (ad)
+func createAd(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody createAdRequest
+		if err := c.ShouldBindJSON(&reqBody); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		price, err := parsePrice(reqBody.Price, reqBody.Currency)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		ad, err := a.CreateAd(actorContext(c, reqBody.UserID), reqBody.Title, reqBody.Text, reqBody.CategoryID, price)
+		if err != nil {
+			c.JSON(errorStatus(err), ErrorResponse(err))
+			return
+		}
+
+		setETag(c, ad)
+		c.JSON(http.StatusOK, AdSuccessResponse(ad))
+	}
+}
+
+// Метод для изменения статуса объявления (опубликовано - Published = true или снято с публикации Published = false)
+func changeAdStatus(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody changeAdStatusRequest
+		if err := c.ShouldBindJSON(&reqBody); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		adID, err := paramInt64(c, "ad_id")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		ctx := actorContext(c, reqBody.UserID)
+		version, err := ifMatchVersion(c, currentVersion(ctx, a, adID))
+		if err != nil {
+			c.JSON(errorStatus(err), ErrorResponse(err))
+			return
+		}
+
+		ad, err := a.ChangeAdStatus(ctx, adID, reqBody.Published, version)
+		if err != nil {
+			c.JSON(errorStatus(err), ErrorResponse(err))
+			return
+		}
+
+		setETag(c, ad)
+		c.JSON(http.StatusOK, AdSuccessResponse(ad))
+	}
+}
+
+// Метод для обновления текста(Text) или заголовка(Title) объявления
+func updateAd(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody updateAdRequest
+		if err := c.ShouldBindJSON(&reqBody); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		adID, err := paramInt64(c, "ad_id")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		ctx := actorContext(c, reqBody.UserID)
+		version, err := ifMatchVersion(c, currentVersion(ctx, a, adID))
+		if err != nil {
+			c.JSON(errorStatus(err), ErrorResponse(err))
+			return
+		}
+
+		price, err := parsePrice(reqBody.Price, reqBody.Currency)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		ad, err := a.UpdateAd(ctx, adID, reqBody.Title, reqBody.Text, reqBody.CategoryID,
+			price, version)
+		if err != nil {
+			c.JSON(errorStatus(err), ErrorResponse(err))
+			return
+		}
+
+		setETag(c, ad)
+		c.JSON(http.StatusOK, AdSuccessResponse(ad))
+	}
+}
+
+// Метод для получения страницы опубликованных объявлений, query параметры page_size, page_token,
+// category_id (объявления категории вместе с подкатегориями), min_price и max_price (в валюте
+// currency или в базовой), sort (created, updated, price), order (asc, desc) и currency (валюта
+// для display_price)
+func listAds(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		pageSize, err := queryInt(c, "page_size")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		categoryID, err := queryInt(c, "category_id")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		query, err := adsQuery(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+		query.CategoryID = int64(categoryID)
+		query.PageSize = pageSize
+		query.PageToken = c.Query("page_token")
+
+		page, err := a.ListAds(c.Request.Context(), query)
+		if err != nil {
+			c.JSON(errorStatus(err), ErrorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, AdsPageSuccessResponse(page))
+	}
+}
+
+// Метод для полнотекстового поиска, запрос в query параметре q, опционально limit
+func searchAds(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit, err := queryInt(c, "limit")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		results, err := a.SearchAds(c.Request.Context(), c.Query("q"), limit)
+		if err != nil {
+			c.JSON(errorStatus(err), ErrorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, SearchSuccessResponse(results))
+	}
+}
+
+// Метод для получения объявления по ID
+func getAd(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		adID, err := paramInt64(c, "ad_id")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		ad, err := a.GetAd(c.Request.Context(), adID)
+		if err != nil {
+			c.JSON(errorStatus(err), ErrorResponse(err))
+			return
+		}
+
+		setETag(c, ad)
+		c.JSON(http.StatusOK, AdSuccessResponse(ad))
+	}
+}
+
+// Метод для перемещения объявления в корзину (устаревший способ - ID автора в query параметре user_id)
+func deleteAd(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		adID, err := paramInt64(c, "ad_id")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		ctx := c.Request.Context()
+		if _, ok := app.UserIDFromContext(ctx); !ok {
+			userID, err := strconv.ParseInt(c.Query("user_id"), 10, 64)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, ErrorResponse(err))
+				return
+			}
+			ctx = actorContext(c, userID)
+		}
+
+		if err := a.DeleteAd(ctx, adID); err != nil {
+			c.JSON(errorStatus(err), ErrorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, EmptySuccessResponse())
+	}
+}
+
+// Метод для создания пользователя
+func createUser(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody createUserRequest
+		if err := c.ShouldBindJSON(&reqBody); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		user, err := a.CreateUser(c.Request.Context(), reqBody.Name, reqBody.Password)
+		if err != nil {
+			c.JSON(errorStatus(err), ErrorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, UserSuccessResponse(user))
+	}
+}
+
+// Метод для получения пользователя по ID
+func getUser(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := paramInt64(c, "user_id")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		user, err := a.GetUser(c.Request.Context(), userID)
+		if err != nil {
+			c.JSON(errorStatus(err), ErrorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, UserSuccessResponse(user))
+	}
+}
+
+// Метод для перемещения пользователя в корзину
+func deleteUser(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := paramInt64(c, "user_id")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		if err := a.DeleteUser(c.Request.Context(), userID); err != nil {
+			c.JSON(errorStatus(err), ErrorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, EmptySuccessResponse())
+	}
+}
+
+func paramInt64(c *gin.Context, name string) (int64, error) {
+	return strconv.ParseInt(c.Param(name), 10, 64)
+}
+
+// queryInt возвращает необязательный целочисленный query параметр, 0 если его нет.
+func queryInt(c *gin.Context, name string) (int, error) {
+	raw := c.Query(name)
+	if raw == "" {
+		return 0, nil
+	}
+
+	return strconv.Atoi(raw)
+}
+
+// parsePrice разбирает цену из тела запроса, пустые price и currency - цена не указана.
+func parsePrice(price string, currency string) (money.Money, error) {
+	if price == "" && currency == "" {
+		return money.Money{}, nil
+	}
+
+	return money.Parse(price, currency)
+}
+
+// adsQuery разбирает query параметры сортировки и фильтрации по цене.
+func adsQuery(c *gin.Context) (app.AdsQuery, error) {
+	query := app.AdsQuery{Sort: app.AdSort(c.Query("sort"))}
+
+	switch c.Query("order") {
+	case "", "asc":
+	case "desc":
+		query.Desc = true
+	default:
+		return app.AdsQuery{}, fmt.Errorf("invalid order %q", c.Query("order"))
+	}
+
+	boundCurrency := string(money.Base)
+	if raw := c.Query("currency"); raw != "" {
+		currency, err := money.ParseCurrency(raw)
+		if err != nil {
+			return app.AdsQuery{}, err
+		}
+		query.Currency = currency
+		boundCurrency = raw
+	}
+
+	var err error
+	if raw := c.Query("min_price"); raw != "" {
+		if query.MinPrice, err = money.Parse(raw, boundCurrency); err != nil {
+			return app.AdsQuery{}, err
+		}
+	}
+	if raw := c.Query("max_price"); raw != "" {
+		if query.MaxPrice, err = money.Parse(raw, boundCurrency); err != nil {
+			return app.AdsQuery{}, err
+		}
+	}
+
+	return query, nil
+}
+
+// setETag выставляет ETag ответа по версии объявления.
+func setETag(c *gin.Context, ad *ads.Ad) {
+	c.Header("ETag", strconv.Quote(strconv.FormatInt(ad.Version, 10)))
+}
+
+// ifMatchVersion возвращает версию из заголовка If-Match со списком ETag из
+// предыдущих ответов или 0, если заголовка нет или он равен "*". Теги
+// сравниваются строго (RFC 7232), поэтому слабые W/"3" и теги, которые не
+// выдавал сервер, не совпадают ни с одной версией. Если в списке несколько
+// версий, current возвращает текущую, чтобы выбрать совпадающую. Если не
+// совпал ни один тег, возвращается ErrVersionConflict (412).
+func ifMatchVersion(c *gin.Context, current func() (int64, error)) (int64, error) {
+	raw := strings.TrimSpace(strings.Join(c.Request.Header.Values("If-Match"), ","))
+	if raw == "" || raw == "*" {
+		return 0, nil
+	}
+
+	var versions []int64
+	for _, tag := range strings.Split(raw, ",") {
+		if version, ok := strongVersionTag(strings.TrimSpace(tag)); ok {
+			versions = append(versions, version)
+		}
+	}
+
+	switch len(versions) {
+	case 0:
+		return 0, fmt.Errorf("%w: If-Match %s matches no version", app.ErrVersionConflict, raw)
+	case 1:
+		return versions[0], nil
+	}
+
+	version, err := current()
+	if err != nil {
+		return 0, err
+	}
+	for _, v := range versions {
+		if v == version {
+			return version, nil
+		}
+	}
+
+	return 0, fmt.Errorf("%w: If-Match %s does not match version %d", app.ErrVersionConflict, raw, version)
+}
+
+// strongVersionTag разбирает сильный ETag "3", выданный setETag.
+func strongVersionTag(tag string) (int64, bool) {
+	unquoted, err := strconv.Unquote(tag)
+	if err != nil || !strings.HasPrefix(tag, `"`) {
+		return 0, false
+	}
+
+	version, err := strconv.ParseInt(unquoted, 10, 64)
+	if err != nil || version <= 0 {
+		return 0, false
+	}
+
+	return version, true
+}
+
+// currentVersion возвращает функцию для ifMatchVersion, читающую версию объявления adID.
+func currentVersion(ctx context.Context, a app.App, adID int64) func() (int64, error) {
+	return func() (int64, error) {
+		ad, err := a.GetAd(ctx, adID)
+		if err != nil {
+			return 0, err
+		}
+		return ad.Version, nil
+	}
+}
+
+// errorStatus сопоставляет ошибку бизнес-логики с HTTP кодом ответа.
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, app.ErrValidation):
+		return http.StatusBadRequest
+	case errors.Is(err, app.ErrUnauthenticated):
+		return http.StatusUnauthorized
+	case errors.Is(err, app.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, app.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, app.ErrVersionConflict):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, app.ErrNoBlobStore):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
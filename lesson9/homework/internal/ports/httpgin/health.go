@@ -0,0 +1,33 @@
+package httpgin
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"homework9/internal/health"
+)
+
+// healthResponse - ответ проверок /healthz и /readyz.
+type healthResponse struct {
+	Status string `json:"status"`
+}
+
+// getLiveness отвечает 200, пока процесс способен обрабатывать запросы.
+func getLiveness(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"data": healthResponse{Status: "ok"}, "error": nil})
+}
+
+// getReadiness отвечает 200, если сервис готов принимать запросы, и 503, если
+// недоступно хранилище или началась остановка.
+func getReadiness(checker *health.Checker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := checker.Ready(c.Request.Context()); err != nil {
+			c.JSON(http.StatusServiceUnavailable, ErrorResponse(fmt.Errorf("not ready: %w", err)))
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"data": healthResponse{Status: "ready"}, "error": nil})
+	}
+}
@@ -0,0 +1,61 @@
+package httpgin
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"homework9/internal/app"
+)
+
+// Метод для получения истории изменений объявления
+func getAdHistory(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		adID, err := paramInt64(c, "ad_id")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		revisions, err := a.AdHistory(c.Request.Context(), adID)
+		if err != nil {
+			c.JSON(errorStatus(err), ErrorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, HistorySuccessResponse(revisions))
+	}
+}
+
+// Метод для возврата объявления к версии из истории, поддерживает If-Match
+func revertAd(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody revertAdRequest
+		if err := c.ShouldBindJSON(&reqBody); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		adID, err := paramInt64(c, "ad_id")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		ctx := c.Request.Context()
+		version, err := ifMatchVersion(c, currentVersion(ctx, a, adID))
+		if err != nil {
+			c.JSON(errorStatus(err), ErrorResponse(err))
+			return
+		}
+
+		ad, err := a.RevertAd(ctx, adID, reqBody.Version, version)
+		if err != nil {
+			c.JSON(errorStatus(err), ErrorResponse(err))
+			return
+		}
+
+		setETag(c, ad)
+		c.JSON(http.StatusOK, AdSuccessResponse(ad))
+	}
+}
@@ -0,0 +1,110 @@
+package httpgin
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"homework9/internal/idempotency"
+)
+
+// idempotentRoutes - методы, которые клиенты повторяют при сбоях сети и для
+// которых поддерживается заголовок Idempotency-Key.
+var idempotentRoutes = map[string]bool{
+	http.MethodPost + " /api/v1/ads":              true,
+	http.MethodPut + " /api/v1/ads/:ad_id/status": true,
+}
+
+// storedResponse - ответ на первый запрос с ключом, который получат повторы.
+type storedResponse struct {
+	status int
+	header http.Header
+	body   []byte
+}
+
+// replayedHeaders - заголовки ответа, которые сохраняются вместе с телом.
+var replayedHeaders = []string{"Content-Type", "ETag", "Location"}
+
+// idempotencyMiddleware выполняет запрос с заголовком Idempotency-Key один раз,
+// а повторам с тем же ключом и телом отдает сохраненный ответ. Ключи
+// у каждого клиента свои, поэтому middleware должен идти после authMiddleware.
+func idempotencyMiddleware(store *idempotency.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader("Idempotency-Key")
+		if key == "" || !idempotentRoutes[c.Request.Method+" "+c.FullPath()] {
+			c.Next()
+			return
+		}
+		if err := idempotency.ValidateKey(key); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		sum := sha256.Sum256(body)
+		fingerprint := c.Request.Method + " " + c.Request.URL.Path + " " + hex.EncodeToString(sum[:])
+
+		value, replayed, err := store.Do(c.Request.Context(), "http "+clientKey(c)+" "+key, fingerprint, func() (any, bool) {
+			recorder := &responseRecorder{ResponseWriter: c.Writer}
+			c.Writer = recorder
+			c.Next()
+			c.Writer = recorder.ResponseWriter
+
+			resp := storedResponse{status: recorder.Status(), header: http.Header{}, body: recorder.body.Bytes()}
+			for _, name := range replayedHeaders {
+				if v := recorder.Header().Get(name); v != "" {
+					resp.header.Set(name, v)
+				}
+			}
+			// Ошибки сервера временные, повтор запроса должен выполнить его заново.
+			return resp, resp.status < http.StatusInternalServerError
+		})
+		switch {
+		case errors.Is(err, idempotency.ErrKeyReused):
+			c.AbortWithStatusJSON(http.StatusUnprocessableEntity, ErrorResponse(err))
+			return
+		case err != nil:
+			// Клиент отключился, не дождавшись первого запроса с тем же ключом.
+			c.Abort()
+			return
+		case !replayed:
+			return
+		}
+
+		resp := value.(storedResponse)
+		for name, values := range resp.header {
+			c.Writer.Header()[name] = values
+		}
+		c.Header("Idempotent-Replayed", "true")
+		c.Writer.WriteHeader(resp.status)
+		_, _ = c.Writer.Write(resp.body)
+		c.Abort()
+	}
+}
+
+// responseRecorder копирует тело ответа, чтобы сохранить его для повторов.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	r.body.Write(data)
+	return r.ResponseWriter.Write(data)
+}
+
+func (r *responseRecorder) WriteString(s string) (int, error) {
+	r.body.WriteString(s)
+	return r.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,139 @@
+package httpgin
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"homework9/internal/app"
+)
+
+// Метод для открытия переписки с автором объявления, возвращает существующую переписку, если она уже есть
+func openThread(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		adID, err := paramInt64(c, "ad_id")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		thread, err := a.OpenThread(c.Request.Context(), adID)
+		if err != nil {
+			c.JSON(errorStatus(err), ErrorResponse(err))
+			return
+		}
+
+		userID, _ := app.UserIDFromContext(c.Request.Context())
+		c.JSON(http.StatusOK, ThreadSuccessResponse(thread, userID))
+	}
+}
+
+// Метод для получения страницы своих переписок, query параметры page_size и page_token
+func listThreads(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		pageSize, err := queryInt(c, "page_size")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		page, err := a.Threads(c.Request.Context(), pageSize, c.Query("page_token"))
+		if err != nil {
+			c.JSON(errorStatus(err), ErrorResponse(err))
+			return
+		}
+
+		userID, _ := app.UserIDFromContext(c.Request.Context())
+		c.JSON(http.StatusOK, ThreadsPageSuccessResponse(page, userID))
+	}
+}
+
+// Метод для получения числа непрочитанных сообщений во всех своих переписках
+func unreadMessages(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		unread, err := a.UnreadMessages(c.Request.Context())
+		if err != nil {
+			c.JSON(errorStatus(err), ErrorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, UnreadSuccessResponse(unread))
+	}
+}
+
+// Метод для получения страницы сообщений переписки, query параметры page_size и page_token
+func listMessages(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		threadID, err := paramInt64(c, "thread_id")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+		pageSize, err := queryInt(c, "page_size")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		page, err := a.Messages(c.Request.Context(), threadID, pageSize, c.Query("page_token"))
+		if err != nil {
+			c.JSON(errorStatus(err), ErrorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, MessagesPageSuccessResponse(page))
+	}
+}
+
+// Метод для отправки сообщения в переписку
+func sendMessage(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		threadID, err := paramInt64(c, "thread_id")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		var reqBody sendMessageRequest
+		if err := c.ShouldBindJSON(&reqBody); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		message, err := a.SendMessage(c.Request.Context(), threadID, reqBody.Text)
+		if err != nil {
+			c.JSON(errorStatus(err), ErrorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, MessageSuccessResponse(message))
+	}
+}
+
+// Метод для отметки сообщений переписки прочитанными до read_id включительно, без тела - всех сообщений
+func markThreadRead(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		threadID, err := paramInt64(c, "thread_id")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		var reqBody markThreadReadRequest
+		if err := c.ShouldBindJSON(&reqBody); err != nil && !errors.Is(err, io.EOF) {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		thread, err := a.MarkThreadRead(c.Request.Context(), threadID, reqBody.ReadID)
+		if err != nil {
+			c.JSON(errorStatus(err), ErrorResponse(err))
+			return
+		}
+
+		userID, _ := app.UserIDFromContext(c.Request.Context())
+		c.JSON(http.StatusOK, ThreadSuccessResponse(thread, userID))
+	}
+}
@@ -0,0 +1,26 @@
+package httpgin
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"homework9/internal/metrics"
+)
+
+// unmatchedRoute - метка запросов к несуществующим путям, чтобы их число меток не росло.
+const unmatchedRoute = "unmatched"
+
+// metricsMiddleware считает запросы, их длительность и число обрабатываемых
+// запросов по шаблону маршрута gin.
+func metricsMiddleware(m *metrics.Metrics) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		done := m.TrackHTTP(c.Request.Method, route)
+
+		c.Next()
+
+		done(c.Writer.Status())
+	}
+}
@@ -0,0 +1,65 @@
+package httpgin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/exp/slog"
+
+	"homework9/internal/logging"
+)
+
+// requestIDHeader - заголовок с идентификатором запроса: присланный клиентом
+// сохраняется, иначе генерируется новый и возвращается в ответе.
+const requestIDHeader = "X-Request-ID"
+
+var errPanic = errors.New("internal server error")
+
+// requestIDMiddleware кладет идентификатор запроса в контекст http.Request,
+// откуда его берут логгеры обработчиков, app и хранилища.
+func requestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := logging.RequestID(c.GetHeader(requestIDHeader))
+		c.Request = c.Request.WithContext(logging.WithRequestID(c.Request.Context(), id))
+		c.Header(requestIDHeader, id)
+
+		c.Next()
+	}
+}
+
+// loggerMiddleware пишет в лог метод, путь, код ответа и время обработки запроса.
+func loggerMiddleware(logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		c.Next()
+
+		logger.InfoCtx(c.Request.Context(), "request",
+			"method", c.Request.Method,
+			"path", c.Request.URL.Path,
+			"status", c.Writer.Status(),
+			"duration", time.Since(start))
+	}
+}
+
+// recoveryMiddleware перехватывает панику в обработчике и отвечает кодом 500.
+func recoveryMiddleware(logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if r := recover(); r != nil {
+				logger.ErrorCtx(c.Request.Context(), "panic recovered",
+					"method", c.Request.Method,
+					"path", c.Request.URL.Path,
+					"panic", fmt.Sprint(r),
+					"stack", string(debug.Stack()))
+				c.AbortWithStatusJSON(http.StatusInternalServerError, ErrorResponse(errPanic))
+			}
+		}()
+
+		c.Next()
+	}
+}
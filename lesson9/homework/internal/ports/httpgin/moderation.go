@@ -0,0 +1,100 @@
+package httpgin
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"homework9/internal/app"
+	"homework9/internal/users"
+)
+
+// Метод для получения страницы очереди модерации, query параметры page_size и page_token
+func moderationQueue(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		pageSize, err := queryInt(c, "page_size")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		page, err := a.ModerationQueue(c.Request.Context(), pageSize, c.Query("page_token"))
+		if err != nil {
+			c.JSON(errorStatus(err), ErrorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, AdsPageSuccessResponse(page))
+	}
+}
+
+// Метод для одобрения объявления из очереди модерации
+func approveAd(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		adID, err := paramInt64(c, "ad_id")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		ad, err := a.ApproveAd(c.Request.Context(), adID)
+		if err != nil {
+			c.JSON(errorStatus(err), ErrorResponse(err))
+			return
+		}
+
+		setETag(c, ad)
+		c.JSON(http.StatusOK, AdSuccessResponse(ad))
+	}
+}
+
+// Метод для отклонения объявления, причина в поле reason обязательна
+func rejectAd(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody rejectAdRequest
+		if err := c.ShouldBindJSON(&reqBody); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		adID, err := paramInt64(c, "ad_id")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		ad, err := a.RejectAd(c.Request.Context(), adID, reqBody.Reason)
+		if err != nil {
+			c.JSON(errorStatus(err), ErrorResponse(err))
+			return
+		}
+
+		setETag(c, ad)
+		c.JSON(http.StatusOK, AdSuccessResponse(ad))
+	}
+}
+
+// Метод для назначения роли пользователю: user, moderator или admin
+func setUserRole(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody setUserRoleRequest
+		if err := c.ShouldBindJSON(&reqBody); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		userID, err := paramInt64(c, "user_id")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		user, err := a.SetUserRole(c.Request.Context(), userID, users.Role(reqBody.Role))
+		if err != nil {
+			c.JSON(errorStatus(err), ErrorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, UserSuccessResponse(user))
+	}
+}
@@ -0,0 +1,447 @@
+package httpgin
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+	swaggerFiles "github.com/swaggo/files/v2"
+)
+
+// apiOperation описывает метод API в спецификации OpenAPI. Схемы тела запроса
+// и поля data ответа строятся по типам из presenters.go.
+type apiOperation struct {
+	method  string
+	path    string // в формате gin, относительно /api/v1
+	tag     string
+	summary string
+	// auth - метод доступен только с токеном в заголовке Authorization
+	// (если сервер не запущен с WithLegacyUserID).
+	auth  bool
+	query []apiParam
+	// ifMatch - метод принимает версию объявления в заголовке If-Match.
+	ifMatch bool
+	request any // значение типа тела запроса, nil - метод без тела
+	data    any // значение типа поля data, nil - data всегда null
+	page    bool
+	// upload и binary - тело запроса multipart и двоичный ответ вместо JSON.
+	upload bool
+	binary bool
+}
+
+type apiParam struct {
+	name        string
+	typ         string
+	description string
+	enum        []string
+	deprecated  bool
+}
+
+var pageParams = []apiParam{
+	{name: "page_size", typ: "integer", description: "Размер страницы"},
+	{name: "page_token", typ: "string", description: "Токен страницы из next_page_token предыдущего ответа"},
+}
+
+var apiOperations = []apiOperation{
+	{method: http.MethodGet, path: "/openapi.json", tag: "docs", summary: "Спецификация API в формате OpenAPI 3"},
+
+	{method: http.MethodGet, path: "/ads", tag: "ads", summary: "Список опубликованных объявлений", data: []adResponse{}, page: true,
+		query: append([]apiParam{
+			{name: "category_id", typ: "integer", description: "Категория вместе с подкатегориями"},
+			{name: "sort", typ: "string", description: "Поле сортировки, по умолчанию ID", enum: []string{"created", "updated", "price"}},
+			{name: "order", typ: "string", enum: []string{"asc", "desc"}},
+			{name: "currency", typ: "string", description: "Валюта для display_price и границ цены, по умолчанию RUB"},
+			{name: "min_price", typ: "string"},
+			{name: "max_price", typ: "string"},
+		}, pageParams...)},
+	{method: http.MethodGet, path: "/ads/search", tag: "ads", summary: "Полнотекстовый поиск по опубликованным объявлениям", data: []searchAdResponse{},
+		query: []apiParam{{name: "q", typ: "string"}, {name: "limit", typ: "integer"}}},
+	{method: http.MethodGet, path: "/ads/:ad_id", tag: "ads", summary: "Объявление по ID", data: adResponse{}},
+	{method: http.MethodPost, path: "/ads", tag: "ads", summary: "Создание объявления", auth: true, request: createAdRequest{}, data: adResponse{}},
+	{method: http.MethodPut, path: "/ads/:ad_id/status", tag: "ads", summary: "Изменение статуса объявления", auth: true, ifMatch: true, request: changeAdStatusRequest{}, data: adResponse{}},
+	{method: http.MethodPut, path: "/ads/:ad_id", tag: "ads", summary: "Изменение объявления", auth: true, ifMatch: true, request: updateAdRequest{}, data: adResponse{}},
+	{method: http.MethodPut, path: "/ads/:ad_id/schedule", tag: "ads", summary: "Планирование публикации и снятия с публикации", auth: true, ifMatch: true, request: scheduleAdRequest{}, data: adResponse{}},
+	{method: http.MethodDelete, path: "/ads/:ad_id", tag: "ads", summary: "Перемещение объявления в корзину", auth: true,
+		query: []apiParam{{name: "user_id", typ: "integer", description: "ID автора, если запрос без токена", deprecated: true}}},
+	{method: http.MethodPost, path: "/ads/:ad_id/restore", tag: "ads", summary: "Восстановление объявления из корзины", auth: true, data: adResponse{}},
+	{method: http.MethodGet, path: "/trash/ads", tag: "ads", summary: "Свои объявления из корзины", auth: true, query: pageParams, data: []adResponse{}, page: true},
+	{method: http.MethodGet, path: "/ads/:ad_id/history", tag: "ads", summary: "История изменений объявления", auth: true, data: []revisionResponse{}},
+	{method: http.MethodPost, path: "/ads/:ad_id/revert", tag: "ads", summary: "Возврат объявления к версии из истории", auth: true, ifMatch: true, request: revertAdRequest{}, data: adResponse{}},
+
+	{method: http.MethodPost, path: "/ads/:ad_id/photos", tag: "photos", summary: "Загрузка фотографий объявления", auth: true, upload: true, data: adResponse{}},
+	{method: http.MethodPut, path: "/ads/:ad_id/photos", tag: "photos", summary: "Изменение порядка фотографий", auth: true, ifMatch: true, request: reorderPhotosRequest{}, data: adResponse{}},
+	{method: http.MethodDelete, path: "/ads/:ad_id/photos/:photo_id", tag: "photos", summary: "Удаление фотографии", auth: true, data: adResponse{}},
+	{method: http.MethodGet, path: "/ads/:ad_id/photos/:photo_id/:variant", tag: "photos", summary: "Содержимое фотографии или миниатюры", binary: true},
+
+	{method: http.MethodGet, path: "/favorites", tag: "favorites", summary: "Своя страница избранного", auth: true, query: pageParams, data: []adResponse{}, page: true},
+	{method: http.MethodPut, path: "/favorites/:ad_id", tag: "favorites", summary: "Добавление объявления в избранное", auth: true, data: adResponse{}},
+	{method: http.MethodDelete, path: "/favorites/:ad_id", tag: "favorites", summary: "Удаление объявления из избранного", auth: true},
+	{method: http.MethodGet, path: "/notifications", tag: "favorites", summary: "Уведомления об изменении объявлений из избранного", auth: true, query: pageParams, data: []notificationResponse{}, page: true},
+
+	{method: http.MethodPost, path: "/ads/:ad_id/thread", tag: "messages", summary: "Открытие переписки с автором объявления", auth: true, data: threadResponse{}},
+	{method: http.MethodGet, path: "/threads", tag: "messages", summary: "Свои переписки", auth: true, query: pageParams, data: []threadResponse{}, page: true},
+	{method: http.MethodGet, path: "/threads/unread", tag: "messages", summary: "Число непрочитанных сообщений", auth: true, data: unreadResponse{}},
+	{method: http.MethodGet, path: "/threads/:thread_id/messages", tag: "messages", summary: "Сообщения переписки", auth: true, query: pageParams, data: []messageResponse{}, page: true},
+	{method: http.MethodPost, path: "/threads/:thread_id/messages", tag: "messages", summary: "Отправка сообщения", auth: true, request: sendMessageRequest{}, data: messageResponse{}},
+	{method: http.MethodPut, path: "/threads/:thread_id/read", tag: "messages", summary: "Отметка сообщений прочитанными", auth: true, request: markThreadReadRequest{}, data: threadResponse{}},
+
+	{method: http.MethodGet, path: "/moderation/queue", tag: "moderation", summary: "Объявления, ожидающие проверки", auth: true, query: pageParams, data: []adResponse{}, page: true},
+	{method: http.MethodPost, path: "/moderation/ads/:ad_id/approve", tag: "moderation", summary: "Одобрение и публикация объявления", auth: true, data: adResponse{}},
+	{method: http.MethodPost, path: "/moderation/ads/:ad_id/reject", tag: "moderation", summary: "Отклонение объявления с причиной", auth: true, request: rejectAdRequest{}, data: adResponse{}},
+
+	{method: http.MethodPost, path: "/users", tag: "users", summary: "Создание пользователя", request: createUserRequest{}, data: userResponse{}},
+	{method: http.MethodGet, path: "/users/:user_id", tag: "users", summary: "Пользователь по ID", data: userResponse{}},
+	{method: http.MethodDelete, path: "/users/:user_id", tag: "users", summary: "Перемещение пользователя в корзину", auth: true},
+	{method: http.MethodPost, path: "/users/:user_id/restore", tag: "users", summary: "Восстановление пользователя из корзины", auth: true, data: userResponse{}},
+	{method: http.MethodPut, path: "/users/:user_id/role", tag: "users", summary: "Назначение роли пользователю", auth: true, request: setUserRoleRequest{}, data: userResponse{}},
+	{method: http.MethodPost, path: "/login", tag: "users", summary: "Получение токена по ID пользователя и паролю", request: loginRequest{}, data: tokenResponse{}},
+
+	{method: http.MethodGet, path: "/categories", tag: "categories", summary: "Дерево категорий с числом опубликованных объявлений", data: []categoryNodeResponse{}},
+	{method: http.MethodPost, path: "/categories", tag: "categories", summary: "Создание категории", auth: true, request: categoryRequest{}, data: categoryResponse{}},
+	{method: http.MethodPut, path: "/categories/:category_id", tag: "categories", summary: "Переименование или перенос категории", auth: true, request: categoryRequest{}, data: categoryResponse{}},
+	{method: http.MethodDelete, path: "/categories/:category_id", tag: "categories", summary: "Удаление пустой категории", auth: true},
+
+	{method: http.MethodGet, path: "/rates", tag: "rates", summary: "Курсы валют к базовой валюте (RUB)", data: []exchangeRateResponse{}},
+	{method: http.MethodPut, path: "/rates/:currency", tag: "rates", summary: "Установка курса валюты", auth: true, request: exchangeRateRequest{}, data: exchangeRateResponse{}},
+}
+
+// stringPathParams - параметры пути, которые не являются числовыми ID.
+var stringPathParams = map[string][]string{
+	"photo_id": nil,
+	"variant":  {"original", "small", "medium"},
+	"currency": nil,
+}
+
+var pathParamRe = regexp.MustCompile(`:(\w+)`)
+
+var (
+	openAPIOnce sync.Once
+	openAPIJSON []byte
+)
+
+// Метод для получения спецификации API в формате OpenAPI 3
+func getOpenAPISpec(c *gin.Context) {
+	openAPIOnce.Do(func() {
+		var err error
+		if openAPIJSON, err = json.Marshal(openAPISpec()); err != nil {
+			panic(err)
+		}
+	})
+
+	c.Data(http.StatusOK, "application/json; charset=utf-8", openAPIJSON)
+}
+
+// openAPISpec строит спецификацию по apiOperations.
+func openAPISpec() map[string]any {
+	b := &schemaBuilder{components: map[string]any{
+		"Error": map[string]any{
+			"type":     "object",
+			"required": []string{"data", "error"},
+			"properties": map[string]any{
+				"data":  map[string]any{"type": "object", "nullable": true},
+				"error": map[string]any{"type": "string"},
+			},
+		},
+	}}
+
+	paths := make(map[string]map[string]any)
+	for _, op := range apiOperations {
+		path := pathParamRe.ReplaceAllString(op.path, "{$1}")
+		if paths[path] == nil {
+			paths[path] = make(map[string]any)
+		}
+		paths[path][strings.ToLower(op.method)] = b.operation(op)
+	}
+
+	return map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":   "Ads API",
+			"version": "1.0",
+			"description": "Ответы в формате {\"data\": ..., \"error\": null}, ошибки - {\"data\": null, \"error\": \"текст\"}. " +
+				"Методы с пометкой bearerAuth доступны только с токеном из /login, если сервер запущен с обязательной авторизацией.",
+		},
+		"servers": []any{map[string]any{"url": "/api/v1"}},
+		"paths":   paths,
+		"components": map[string]any{
+			"schemas": b.components,
+			"responses": map[string]any{
+				"Error": map[string]any{
+					"description": "Ошибка",
+					"content":     jsonContent(ref("Error")),
+				},
+			},
+			"securitySchemes": map[string]any{
+				"bearerAuth": map[string]any{"type": "http", "scheme": "bearer"},
+			},
+		},
+	}
+}
+
+func (b *schemaBuilder) operation(op apiOperation) map[string]any {
+	var params []any
+	for _, m := range pathParamRe.FindAllStringSubmatch(op.path, -1) {
+		schema := map[string]any{"type": "integer", "format": "int64"}
+		if enum, ok := stringPathParams[m[1]]; ok {
+			schema = map[string]any{"type": "string"}
+			if enum != nil {
+				schema["enum"] = enum
+			}
+		}
+		params = append(params, map[string]any{"name": m[1], "in": "path", "required": true, "schema": schema})
+	}
+	for _, p := range op.query {
+		schema := map[string]any{"type": p.typ}
+		if p.enum != nil {
+			schema["enum"] = p.enum
+		}
+		param := map[string]any{"name": p.name, "in": "query", "schema": schema}
+		if p.description != "" {
+			param["description"] = p.description
+		}
+		if p.deprecated {
+			param["deprecated"] = true
+		}
+		params = append(params, param)
+	}
+	if op.ifMatch {
+		params = append(params, map[string]any{
+			"name":        "If-Match",
+			"in":          "header",
+			"description": "ETag объявления или их список через запятую (или *), изменение применяется, только если текущая версия совпадает с одним из них. Слабые теги W/\"3\" не совпадают",
+			"schema":      map[string]any{"type": "string"},
+		})
+	}
+
+	responses := map[string]any{
+		"200":     map[string]any{"description": "OK", "content": b.successContent(op)},
+		"default": map[string]any{"$ref": "#/components/responses/Error"},
+	}
+	if op.auth {
+		responses["401"] = map[string]any{"$ref": "#/components/responses/Error"}
+	}
+	if strings.Contains(op.path, ":") {
+		responses["404"] = map[string]any{"$ref": "#/components/responses/Error"}
+	}
+	if op.ifMatch {
+		responses["412"] = map[string]any{"$ref": "#/components/responses/Error"}
+	}
+
+	result := map[string]any{
+		"tags":        []string{op.tag},
+		"summary":     op.summary,
+		"operationId": operationID(op),
+		"responses":   responses,
+	}
+	if params != nil {
+		result["parameters"] = params
+	}
+	if op.auth {
+		result["security"] = []any{map[string]any{"bearerAuth": []string{}}}
+	}
+	switch {
+	case op.upload:
+		result["requestBody"] = map[string]any{
+			"required": true,
+			"content": map[string]any{
+				"multipart/form-data": map[string]any{
+					"schema": map[string]any{
+						"type": "object",
+						"properties": map[string]any{
+							"photos": map[string]any{
+								"type":  "array",
+								"items": map[string]any{"type": "string", "format": "binary"},
+							},
+						},
+					},
+				},
+			},
+		}
+	case op.request != nil:
+		result["requestBody"] = map[string]any{
+			"required": true,
+			"content":  jsonContent(b.schema(reflect.TypeOf(op.request))),
+		}
+	}
+
+	return result
+}
+
+func (b *schemaBuilder) successContent(op apiOperation) map[string]any {
+	if op.binary {
+		return map[string]any{
+			"image/*": map[string]any{"schema": map[string]any{"type": "string", "format": "binary"}},
+		}
+	}
+	if op.path == "/openapi.json" {
+		return jsonContent(map[string]any{"type": "object"})
+	}
+
+	data := map[string]any{"type": "object", "nullable": true}
+	if op.data != nil {
+		data = b.schema(reflect.TypeOf(op.data))
+	}
+	properties := map[string]any{
+		"data":  data,
+		"error": map[string]any{"type": "string", "nullable": true},
+	}
+	if op.page {
+		properties["next_page_token"] = map[string]any{
+			"type":        "string",
+			"description": "Токен следующей страницы, пусто - страница последняя",
+		}
+	}
+
+	return jsonContent(map[string]any{
+		"type":       "object",
+		"required":   []string{"data", "error"},
+		"properties": properties,
+	})
+}
+
+// operationID строит ID метода из HTTP метода и пути: PUT /ads/:ad_id/status -
+// putAdsAdIdStatus.
+func operationID(op apiOperation) string {
+	id := strings.ToLower(op.method)
+	for _, part := range strings.FieldsFunc(op.path, func(r rune) bool {
+		return r == '/' || r == ':' || r == '_' || r == '.'
+	}) {
+		id += upperFirst(part)
+	}
+
+	return id
+}
+
+// schemaBuilder строит JSON Schema по типам Go и их json тегам, структуры
+// попадают в components.schemas.
+type schemaBuilder struct {
+	components map[string]any
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func (b *schemaBuilder) schema(t reflect.Type) map[string]any {
+	switch {
+	case t == timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case t.Kind() == reflect.Pointer:
+		schema := b.schema(t.Elem())
+		if _, isRef := schema["$ref"]; !isRef {
+			schema["nullable"] = true
+		}
+		return schema
+	case t.Kind() == reflect.Slice:
+		return map[string]any{"type": "array", "items": b.schema(t.Elem())}
+	case t.Kind() == reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": b.schema(t.Elem())}
+	case t.Kind() == reflect.Struct:
+		return b.structSchema(t)
+	case t.Kind() == reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case t.Kind() == reflect.String:
+		return map[string]any{"type": "string"}
+	case t.Kind() == reflect.Int64:
+		return map[string]any{"type": "integer", "format": "int64"}
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		return map[string]any{"type": "number"}
+	default:
+		panic("openapi: unsupported type " + t.String())
+	}
+}
+
+// structSchema добавляет схему структуры в components и возвращает ссылку на нее.
+// Поля ответов без omitempty обязательны, поля запросов - нет: отсутствующее
+// поле равно нулевому значению.
+func (b *schemaBuilder) structSchema(t reflect.Type) map[string]any {
+	name := upperFirst(t.Name())
+	if _, ok := b.components[name]; ok {
+		return ref(name)
+	}
+	// Заглушка до построения полей, чтобы рекурсивные типы ссылались на себя.
+	b.components[name] = nil
+
+	properties := make(map[string]any)
+	var required []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if !field.IsExported() || tag == "-" {
+			continue
+		}
+		fieldName, opts, _ := strings.Cut(tag, ",")
+		if fieldName == "" {
+			fieldName = field.Name
+		}
+		properties[fieldName] = b.schema(field.Type)
+		if !strings.Contains(opts, "omitempty") && !strings.HasSuffix(t.Name(), "Request") {
+			required = append(required, fieldName)
+		}
+	}
+
+	schema := map[string]any{"type": "object", "properties": properties}
+	if required != nil {
+		schema["required"] = required
+	}
+	b.components[name] = schema
+
+	return ref(name)
+}
+
+func ref(name string) map[string]any {
+	return map[string]any{"$ref": "#/components/schemas/" + name}
+}
+
+func jsonContent(schema map[string]any) map[string]any {
+	return map[string]any{"application/json": map[string]any{"schema": schema}}
+}
+
+func upperFirst(s string) string {
+	r := []rune(s)
+	if len(r) > 0 {
+		r[0] = unicode.ToUpper(r[0])
+	}
+
+	return string(r)
+}
+
+// swaggerUI - страница Swagger UI со спецификацией из /api/v1/openapi.json.
+// Скрипты и стили Swagger UI встроены в бинарник (github.com/swaggo/files/v2)
+// и отдаются сервисом, поэтому страница не зависит от CDN и работает без сети.
+const swaggerUI = `<!DOCTYPE html>
+<html lang="ru">
+<head>
+  <meta charset="utf-8">
+  <title>Ads API</title>
+  <link rel="stylesheet" href="/docs/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="/docs/swagger-ui-bundle.js"></script>
+  <script>
+    window.onload = () => {
+      window.ui = SwaggerUIBundle({url: "/api/v1/openapi.json", dom_id: "#swagger-ui"});
+    };
+  </script>
+</body>
+</html>
+`
+
+// Метод для получения страницы Swagger UI
+func getSwaggerUI(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(swaggerUI))
+}
+
+// swaggerAsset отдает встроенный файл Swagger UI name.
+func swaggerAsset(name string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.FileFromFS(name, http.FS(swaggerFiles.FS))
+	}
+}
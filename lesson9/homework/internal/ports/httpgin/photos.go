@@ -0,0 +1,154 @@
+package httpgin
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"homework9/internal/app"
+	"homework9/internal/photos"
+)
+
+// maxUploadSize ограничивает тело запроса на загрузку фотографий целиком.
+const maxUploadSize = 64 << 20
+
+// Метод для загрузки фотографий объявления, файлы в multipart поле photos
+func addPhotos(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		adID, err := paramInt64(c, "ad_id")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxUploadSize)
+		form, err := c.MultipartForm()
+		if err != nil {
+			c.JSON(uploadErrorStatus(err), ErrorResponse(err))
+			return
+		}
+
+		files := make([][]byte, 0, len(form.File["photos"]))
+		for _, fh := range form.File["photos"] {
+			if fh.Size > photos.MaxSize {
+				err := fmt.Errorf("%s: %w", fh.Filename, photos.ErrTooLarge)
+				c.JSON(http.StatusRequestEntityTooLarge, ErrorResponse(err))
+				return
+			}
+
+			data, err := readFormFile(fh)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, ErrorResponse(err))
+				return
+			}
+			files = append(files, data)
+		}
+
+		ad, err := a.AddPhotos(c.Request.Context(), adID, files)
+		if err != nil {
+			c.JSON(errorStatus(err), ErrorResponse(err))
+			return
+		}
+
+		setETag(c, ad)
+		c.JSON(http.StatusOK, AdSuccessResponse(ad))
+	}
+}
+
+// Метод для изменения порядка фотографий, в photo_ids перечисляются все фотографии объявления
+func reorderPhotos(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody reorderPhotosRequest
+		if err := c.ShouldBindJSON(&reqBody); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		adID, err := paramInt64(c, "ad_id")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		ctx := c.Request.Context()
+		version, err := ifMatchVersion(c, currentVersion(ctx, a, adID))
+		if err != nil {
+			c.JSON(errorStatus(err), ErrorResponse(err))
+			return
+		}
+
+		ad, err := a.ReorderPhotos(ctx, adID, reqBody.PhotoIDs, version)
+		if err != nil {
+			c.JSON(errorStatus(err), ErrorResponse(err))
+			return
+		}
+
+		setETag(c, ad)
+		c.JSON(http.StatusOK, AdSuccessResponse(ad))
+	}
+}
+
+// Метод для удаления фотографии объявления
+func deletePhoto(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		adID, err := paramInt64(c, "ad_id")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		ad, err := a.DeletePhoto(c.Request.Context(), adID, c.Param("photo_id"))
+		if err != nil {
+			c.JSON(errorStatus(err), ErrorResponse(err))
+			return
+		}
+
+		setETag(c, ad)
+		c.JSON(http.StatusOK, AdSuccessResponse(ad))
+	}
+}
+
+// Метод для получения содержимого фотографии или ее миниатюры
+func getPhoto(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		adID, err := paramInt64(c, "ad_id")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		r, contentType, err := a.GetPhoto(c.Request.Context(), adID, c.Param("photo_id"), photos.Variant(c.Param("variant")))
+		if err != nil {
+			c.JSON(errorStatus(err), ErrorResponse(err))
+			return
+		}
+		defer r.Close()
+
+		// Содержимое фотографии по ID никогда не меняется.
+		c.Header("Cache-Control", "public, max-age=31536000, immutable")
+		c.DataFromReader(http.StatusOK, -1, contentType, r, nil)
+	}
+}
+
+func readFormFile(fh *multipart.FileHeader) ([]byte, error) {
+	f, err := fh.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return io.ReadAll(io.LimitReader(f, photos.MaxSize+1))
+}
+
+func uploadErrorStatus(err error) int {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return http.StatusRequestEntityTooLarge
+	}
+
+	return http.StatusBadRequest
+}
@@ -0,0 +1,554 @@
+package httpgin
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"homework9/internal/ads"
+	"homework9/internal/app"
+	"homework9/internal/categories"
+	"homework9/internal/favorites"
+	"homework9/internal/messages"
+	"homework9/internal/money"
+	"homework9/internal/photos"
+	"homework9/internal/users"
+)
+
+type createAdRequest struct {
+	Title      string `json:"title"`
+	Text       string `json:"text"`
+	CategoryID int64  `json:"category_id"`
+	// Price - десятичная сумма, например "1500.50", в валюте Currency (ISO 4217).
+	// Оба поля пустые - цена не указана.
+	Price    string `json:"price"`
+	Currency string `json:"currency"`
+	// Deprecated: автор определяется по токену из заголовка Authorization.
+	UserID int64 `json:"user_id"`
+}
+
+type adResponse struct {
+	ID         int64  `json:"id"`
+	Title      string `json:"title"`
+	Text       string `json:"text"`
+	AuthorID   int64  `json:"author_id"`
+	CategoryID int64  `json:"category_id"`
+	Published  bool   `json:"published"`
+	Version    int64  `json:"version"`
+	// Moderation - pending, approved или rejected, пусто - объявление не проверялось.
+	Moderation       string          `json:"moderation,omitempty"`
+	ModerationReason string          `json:"moderation_reason,omitempty"`
+	Photos           []photoResponse `json:"photos"`
+	// Price и Currency пусты, если цена не указана.
+	Price     string     `json:"price,omitempty"`
+	Currency  string     `json:"currency,omitempty"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+	// PublishAt и UnpublishAt заданы, если публикация или снятие с публикации запланированы.
+	PublishAt   *time.Time `json:"publish_at,omitempty"`
+	UnpublishAt *time.Time `json:"unpublish_at,omitempty"`
+	// DeletedAt задано только у объявлений из корзины.
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	// Favorites - сколько пользователей добавили объявление в избранное.
+	Favorites int `json:"favorites"`
+	// DisplayPrice - цена в валюте из query параметра currency списка объявлений.
+	DisplayPrice    string `json:"display_price,omitempty"`
+	DisplayCurrency string `json:"display_currency,omitempty"`
+}
+
+type photoResponse struct {
+	ID          string            `json:"id"`
+	URL         string            `json:"url"`
+	Thumbnails  map[string]string `json:"thumbnails"`
+	ContentType string            `json:"content_type"`
+	Width       int               `json:"width"`
+	Height      int               `json:"height"`
+	Size        int64             `json:"size"`
+}
+
+type reorderPhotosRequest struct {
+	PhotoIDs []string `json:"photo_ids"`
+}
+
+type changeAdStatusRequest struct {
+	Published bool `json:"published"`
+	// Deprecated: автор определяется по токену из заголовка Authorization.
+	UserID int64 `json:"user_id"`
+}
+
+type updateAdRequest struct {
+	Title string `json:"title"`
+	Text  string `json:"text"`
+	// CategoryID 0 оставляет категорию прежней.
+	CategoryID int64 `json:"category_id"`
+	// Пустые Price и Currency оставляют цену прежней.
+	Price    string `json:"price"`
+	Currency string `json:"currency"`
+	// Deprecated: автор определяется по токену из заголовка Authorization.
+	UserID int64 `json:"user_id"`
+}
+
+type scheduleAdRequest struct {
+	// PublishAt и UnpublishAt в формате RFC 3339, null или отсутствие поля
+	// отменяет запланированное.
+	PublishAt   *time.Time `json:"publish_at"`
+	UnpublishAt *time.Time `json:"unpublish_at"`
+}
+
+type searchAdResponse struct {
+	Ad             adResponse `json:"ad"`
+	Score          float64    `json:"score"`
+	TitleHighlight string     `json:"title_highlight"`
+	TextSnippet    string     `json:"text_snippet"`
+}
+
+type createUserRequest struct {
+	Name     string `json:"name"`
+	Password string `json:"password"`
+}
+
+type loginRequest struct {
+	UserID   int64  `json:"user_id"`
+	Password string `json:"password"`
+}
+
+type tokenResponse struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type userResponse struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+	Role string `json:"role"`
+}
+
+type categoryRequest struct {
+	Name string `json:"name"`
+	// ParentID 0 - категория верхнего уровня.
+	ParentID int64 `json:"parent_id"`
+}
+
+type categoryResponse struct {
+	ID       int64  `json:"id"`
+	ParentID int64  `json:"parent_id"`
+	Name     string `json:"name"`
+}
+
+type categoryNodeResponse struct {
+	ID           int64                  `json:"id"`
+	Name         string                 `json:"name"`
+	PublishedAds int                    `json:"published_ads"`
+	Children     []categoryNodeResponse `json:"children"`
+}
+
+type exchangeRateRequest struct {
+	// Rate - стоимость одной единицы валюты в базовой валюте, десятичное число.
+	Rate string `json:"rate"`
+}
+
+type exchangeRateResponse struct {
+	Currency string `json:"currency"`
+	Rate     string `json:"rate"`
+}
+
+type revertAdRequest struct {
+	// Version - версия из истории, к содержимому которой нужно вернуться.
+	Version int64 `json:"version"`
+}
+
+type revisionResponse struct {
+	Version      int64                 `json:"version"`
+	UserID       int64                 `json:"user_id"`
+	CreatedAt    *time.Time            `json:"created_at,omitempty"`
+	RevertedFrom int64                 `json:"reverted_from,omitempty"`
+	Title        string                `json:"title"`
+	Text         string                `json:"text"`
+	CategoryID   int64                 `json:"category_id"`
+	Price        string                `json:"price,omitempty"`
+	Currency     string                `json:"currency,omitempty"`
+	Published    bool                  `json:"published"`
+	Moderation   string                `json:"moderation,omitempty"`
+	Changes      []fieldChangeResponse `json:"changes"`
+}
+
+type fieldChangeResponse struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+type notificationResponse struct {
+	ID        int64                 `json:"id"`
+	AdID      int64                 `json:"ad_id"`
+	AdVersion int64                 `json:"ad_version"`
+	CreatedAt *time.Time            `json:"created_at,omitempty"`
+	Changes   []fieldChangeResponse `json:"changes"`
+}
+
+// threadResponse показывает переписку с точки зрения текущего пользователя:
+// read_id и unread - его, peer_read_id - собеседника.
+type threadResponse struct {
+	ID            int64      `json:"id"`
+	AdID          int64      `json:"ad_id"`
+	BuyerID       int64      `json:"buyer_id"`
+	SellerID      int64      `json:"seller_id"`
+	CreatedAt     *time.Time `json:"created_at,omitempty"`
+	LastMessageID int64      `json:"last_message_id"`
+	ReadID        int64      `json:"read_id"`
+	PeerReadID    int64      `json:"peer_read_id"`
+	Unread        int        `json:"unread"`
+}
+
+type messageResponse struct {
+	ID        int64      `json:"id"`
+	ThreadID  int64      `json:"thread_id"`
+	SenderID  int64      `json:"sender_id"`
+	Text      string     `json:"text"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+}
+
+type unreadResponse struct {
+	Unread int `json:"unread"`
+}
+
+type sendMessageRequest struct {
+	Text string `json:"text"`
+}
+
+type markThreadReadRequest struct {
+	// 0 - все сообщения переписки.
+	ReadID int64 `json:"read_id"`
+}
+
+type rejectAdRequest struct {
+	Reason string `json:"reason"`
+}
+
+type setUserRoleRequest struct {
+	Role string `json:"role"`
+}
+
+func newAdResponse(ad *ads.Ad) adResponse {
+	resp := adResponse{
+		ID:         ad.ID,
+		Title:      ad.Title,
+		Text:       ad.Text,
+		AuthorID:   ad.AuthorID,
+		CategoryID: ad.CategoryID,
+		Published:  ad.Published,
+		Version:    ad.Version,
+
+		Moderation:       string(ad.Moderation),
+		ModerationReason: ad.ModerationReason,
+		Photos:           newPhotoResponses(ad),
+		CreatedAt:        optionalTime(ad.CreatedAt),
+		UpdatedAt:        optionalTime(ad.UpdatedAt),
+		PublishAt:        optionalTime(ad.PublishAt),
+		UnpublishAt:      optionalTime(ad.UnpublishAt),
+		DeletedAt:        optionalTime(ad.DeletedAt),
+		Favorites:        ad.Favorites,
+	}
+	if !ad.Price.IsZero() {
+		resp.Price = ad.Price.FormatAmount()
+		resp.Currency = string(ad.Price.Currency)
+	}
+
+	return resp
+}
+
+// optionalTime возвращает nil для нулевого времени, чтобы не выводить его в JSON.
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+
+	return &t
+}
+
+func newPhotoResponses(ad *ads.Ad) []photoResponse {
+	list := make([]photoResponse, 0, len(ad.Photos))
+	for _, p := range ad.Photos {
+		thumbnails := make(map[string]string, len(photos.ThumbnailSizes))
+		for variant := range photos.ThumbnailSizes {
+			thumbnails[string(variant)] = photos.URL(ad.ID, p.ID, variant)
+		}
+
+		list = append(list, photoResponse{
+			ID:          p.ID,
+			URL:         photos.URL(ad.ID, p.ID, photos.Original),
+			Thumbnails:  thumbnails,
+			ContentType: p.ContentType,
+			Width:       p.Width,
+			Height:      p.Height,
+			Size:        p.Size,
+		})
+	}
+
+	return list
+}
+
+func AdSuccessResponse(ad *ads.Ad) *gin.H {
+	return &gin.H{
+		"data":  newAdResponse(ad),
+		"error": nil,
+	}
+}
+
+func AdsPageSuccessResponse(page *app.AdsPage) *gin.H {
+	data := make([]adResponse, 0, len(page.Ads))
+	for i := range page.Ads {
+		ad := newAdResponse(&page.Ads[i])
+		if price, ok := page.DisplayPrices[page.Ads[i].ID]; ok {
+			ad.DisplayPrice = price.FormatAmount()
+			ad.DisplayCurrency = string(price.Currency)
+		}
+		data = append(data, ad)
+	}
+
+	return &gin.H{
+		"data":            data,
+		"next_page_token": page.NextPageToken,
+		"error":           nil,
+	}
+}
+
+func SearchSuccessResponse(results []app.AdSearchResult) *gin.H {
+	data := make([]searchAdResponse, 0, len(results))
+	for i := range results {
+		data = append(data, searchAdResponse{
+			Ad:             newAdResponse(&results[i].Ad),
+			Score:          results[i].Score,
+			TitleHighlight: results[i].Title,
+			TextSnippet:    results[i].Snippet,
+		})
+	}
+
+	return &gin.H{
+		"data":  data,
+		"error": nil,
+	}
+}
+
+func HistorySuccessResponse(revisions []ads.Revision) *gin.H {
+	data := make([]revisionResponse, 0, len(revisions))
+	for _, rev := range revisions {
+		resp := revisionResponse{
+			Version:      rev.Version,
+			UserID:       rev.UserID,
+			CreatedAt:    optionalTime(rev.CreatedAt),
+			RevertedFrom: rev.RevertedFrom,
+			Title:        rev.Content.Title,
+			Text:         rev.Content.Text,
+			CategoryID:   rev.Content.CategoryID,
+			Published:    rev.Content.Published,
+			Moderation:   string(rev.Content.Moderation),
+			Changes:      make([]fieldChangeResponse, 0, len(rev.Changes)),
+		}
+		if !rev.Content.Price.IsZero() {
+			resp.Price = rev.Content.Price.FormatAmount()
+			resp.Currency = string(rev.Content.Price.Currency)
+		}
+		for _, change := range rev.Changes {
+			resp.Changes = append(resp.Changes, fieldChangeResponse(change))
+		}
+		data = append(data, resp)
+	}
+
+	return &gin.H{
+		"data":  data,
+		"error": nil,
+	}
+}
+
+func NotificationsPageSuccessResponse(page *app.NotificationsPage) *gin.H {
+	data := make([]notificationResponse, 0, len(page.Notifications))
+	for _, n := range page.Notifications {
+		data = append(data, newNotificationResponse(n))
+	}
+
+	return &gin.H{
+		"data":            data,
+		"next_page_token": page.NextPageToken,
+		"error":           nil,
+	}
+}
+
+func newNotificationResponse(n favorites.Notification) notificationResponse {
+	resp := notificationResponse{
+		ID:        n.ID,
+		AdID:      n.AdID,
+		AdVersion: n.AdVersion,
+		CreatedAt: optionalTime(n.CreatedAt),
+		Changes:   make([]fieldChangeResponse, 0, len(n.Changes)),
+	}
+	for _, change := range n.Changes {
+		resp.Changes = append(resp.Changes, fieldChangeResponse(change))
+	}
+
+	return resp
+}
+
+func ThreadSuccessResponse(thread *messages.Thread, userID int64) *gin.H {
+	return &gin.H{
+		"data":  newThreadResponse(*thread, userID),
+		"error": nil,
+	}
+}
+
+func ThreadsPageSuccessResponse(page *app.ThreadsPage, userID int64) *gin.H {
+	data := make([]threadResponse, 0, len(page.Threads))
+	for _, thread := range page.Threads {
+		data = append(data, newThreadResponse(thread, userID))
+	}
+
+	return &gin.H{
+		"data":            data,
+		"next_page_token": page.NextPageToken,
+		"error":           nil,
+	}
+}
+
+func newThreadResponse(thread messages.Thread, userID int64) threadResponse {
+	return threadResponse{
+		ID:            thread.ID,
+		AdID:          thread.AdID,
+		BuyerID:       thread.BuyerID,
+		SellerID:      thread.SellerID,
+		CreatedAt:     optionalTime(thread.CreatedAt),
+		LastMessageID: thread.LastMessageID,
+		ReadID:        thread.ReadID(userID),
+		PeerReadID:    thread.ReadID(thread.Peer(userID)),
+		Unread:        thread.Unread(userID),
+	}
+}
+
+func UnreadSuccessResponse(unread int) *gin.H {
+	return &gin.H{
+		"data":  unreadResponse{Unread: unread},
+		"error": nil,
+	}
+}
+
+func MessageSuccessResponse(message *messages.Message) *gin.H {
+	return &gin.H{
+		"data":  newMessageResponse(*message),
+		"error": nil,
+	}
+}
+
+func MessagesPageSuccessResponse(page *app.MessagesPage) *gin.H {
+	data := make([]messageResponse, 0, len(page.Messages))
+	for _, message := range page.Messages {
+		data = append(data, newMessageResponse(message))
+	}
+
+	return &gin.H{
+		"data":            data,
+		"next_page_token": page.NextPageToken,
+		"error":           nil,
+	}
+}
+
+func newMessageResponse(message messages.Message) messageResponse {
+	return messageResponse{
+		ID:        message.ID,
+		ThreadID:  message.ThreadID,
+		SenderID:  message.SenderID,
+		Text:      message.Text,
+		CreatedAt: optionalTime(message.CreatedAt),
+	}
+}
+
+func UserSuccessResponse(user *users.User) *gin.H {
+	return &gin.H{
+		"data": userResponse{
+			ID:   user.ID,
+			Name: user.Name,
+			Role: string(user.Role),
+		},
+		"error": nil,
+	}
+}
+
+func CategorySuccessResponse(category *categories.Category) *gin.H {
+	return &gin.H{
+		"data": categoryResponse{
+			ID:       category.ID,
+			ParentID: category.ParentID,
+			Name:     category.Name,
+		},
+		"error": nil,
+	}
+}
+
+func CategoryTreeSuccessResponse(tree []app.CategoryNode) *gin.H {
+	return &gin.H{
+		"data":  newCategoryNodeResponses(tree),
+		"error": nil,
+	}
+}
+
+func newCategoryNodeResponses(nodes []app.CategoryNode) []categoryNodeResponse {
+	list := make([]categoryNodeResponse, 0, len(nodes))
+	for _, node := range nodes {
+		list = append(list, categoryNodeResponse{
+			ID:           node.ID,
+			Name:         node.Name,
+			PublishedAds: node.PublishedAds,
+			Children:     newCategoryNodeResponses(node.Children),
+		})
+	}
+
+	return list
+}
+
+func ExchangeRateSuccessResponse(rate *money.Rate) *gin.H {
+	return &gin.H{
+		"data":  newExchangeRateResponse(rate),
+		"error": nil,
+	}
+}
+
+func ExchangeRatesSuccessResponse(rates []money.Rate) *gin.H {
+	data := make([]exchangeRateResponse, 0, len(rates))
+	for i := range rates {
+		data = append(data, newExchangeRateResponse(&rates[i]))
+	}
+
+	return &gin.H{
+		"data":  data,
+		"error": nil,
+	}
+}
+
+func newExchangeRateResponse(rate *money.Rate) exchangeRateResponse {
+	return exchangeRateResponse{
+		Currency: string(rate.Currency),
+		Rate:     rate.Value,
+	}
+}
+
+func TokenSuccessResponse(token *app.Token) *gin.H {
+	return &gin.H{
+		"data": tokenResponse{
+			Token:     token.Value,
+			ExpiresAt: token.ExpiresAt,
+		},
+		"error": nil,
+	}
+}
+
+func EmptySuccessResponse() *gin.H {
+	return &gin.H{
+		"data":  nil,
+		"error": nil,
+	}
+}
+
+func ErrorResponse(err error) *gin.H {
+	return &gin.H{
+		"data":  nil,
+		"error": err.Error(),
+	}
+}
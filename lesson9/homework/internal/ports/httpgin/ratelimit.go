@@ -0,0 +1,44 @@
+package httpgin
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"homework9/internal/ratelimit"
+)
+
+// createRoutes - методы, создающие сущности, у них отдельный, обычно самый строгий лимит.
+var createRoutes = map[string]bool{
+	http.MethodPost + " /api/v1/ads":   true,
+	http.MethodPost + " /api/v1/users": true,
+}
+
+// rateLimitMiddleware ограничивает частоту запросов пользователя из токена,
+// а для анонимных запросов - IP-адреса клиента. Должен идти после authMiddleware.
+func rateLimitMiddleware(l *ratelimit.Limiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		class := requestClass(c)
+		if ok, wait := l.Allow(class, clientKey(c)); !ok {
+			c.Header("Retry-After", strconv.Itoa(ratelimit.RetryAfterSeconds(wait)))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests,
+				ErrorResponse(fmt.Errorf("%w: too many %s requests", ratelimit.ErrLimitExceeded, class)))
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func requestClass(c *gin.Context) ratelimit.Class {
+	switch {
+	case c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead:
+		return ratelimit.Read
+	case createRoutes[c.Request.Method+" "+c.FullPath()]:
+		return ratelimit.Create
+	}
+
+	return ratelimit.Write
+}
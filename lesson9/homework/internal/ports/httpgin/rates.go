@@ -0,0 +1,48 @@
+package httpgin
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"homework9/internal/app"
+	"homework9/internal/money"
+)
+
+// Метод для получения курсов валют к базовой валюте
+func listExchangeRates(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rates, err := a.ExchangeRates(c.Request.Context())
+		if err != nil {
+			c.JSON(errorStatus(err), ErrorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, ExchangeRatesSuccessResponse(rates))
+	}
+}
+
+// Метод для установки курса валюты
+func setExchangeRate(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody exchangeRateRequest
+		if err := c.ShouldBindJSON(&reqBody); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		currency, err := money.ParseCurrency(c.Param("currency"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		rate, err := a.SetExchangeRate(c.Request.Context(), currency, reqBody.Rate)
+		if err != nil {
+			c.JSON(errorStatus(err), ErrorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, ExchangeRateSuccessResponse(rate))
+	}
+}
@@ -0,0 +1,65 @@
+package httpgin
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"homework9/internal/app"
+)
+
+// AppRouter регистрирует методы API. Методы, изменяющие объявления, доступны
+// только с токеном в заголовке Authorization, если не legacyUserID.
+func AppRouter(r gin.IRouter, a app.App, legacyUserID bool) {
+	r.GET("/ads", listAds(a))          // Метод для получения списка опубликованных объявлений
+	r.GET("/ads/search", searchAds(a)) // Метод для полнотекстового поиска по опубликованным объявлениям
+	r.GET("/ads/:ad_id", getAd(a))     // Метод для получения объявления по ID (черновиков - только для автора и модераторов)
+
+	r.POST("/users", createUser(a))      // Метод для создания пользователя
+	r.GET("/users/:user_id", getUser(a)) // Метод для получения пользователя по ID
+	r.POST("/login", login(a))           // Метод для получения токена по ID пользователя и паролю
+
+	r.GET("/categories", getCategoryTree(a)) // Метод для получения дерева категорий с числом опубликованных объявлений
+	r.GET("/rates", listExchangeRates(a))    // Метод для получения курсов валют к базовой валюте (RUB)
+
+	authorized := r
+	if !legacyUserID {
+		authorized = r.Group("", requireUserMiddleware())
+	}
+	authorized.POST("/ads", createAd(a))                    // Метод для создания объявления (ad)
+	authorized.PUT("/ads/:ad_id/status", changeAdStatus(a)) // Метод для изменения статуса объявления (опубликовано - Published = true или снято с публикации Published = false)
+	authorized.PUT("/ads/:ad_id", updateAd(a))              // Метод для обновления текста(Text) или заголовка(Title) объявления
+	authorized.PUT("/ads/:ad_id/schedule", scheduleAd(a))   // Метод для планирования публикации и снятия с публикации объявления (только для автора)
+	authorized.DELETE("/ads/:ad_id", deleteAd(a))           // Метод для перемещения объявления в корзину (только для автора)
+	authorized.POST("/ads/:ad_id/restore", restoreAd(a))    // Метод для восстановления объявления из корзины (только для автора)
+	authorized.GET("/trash/ads", listTrashedAds(a))         // Метод для получения своих объявлений из корзины
+	authorized.GET("/ads/:ad_id/history", getAdHistory(a))  // Метод для получения истории изменений объявления (для автора и модераторов)
+	authorized.POST("/ads/:ad_id/revert", revertAd(a))      // Метод для возврата объявления к версии из истории (только для автора)
+
+	authorized.GET("/favorites", listFavorites(a))            // Метод для получения страницы своего избранного
+	authorized.PUT("/favorites/:ad_id", addFavorite(a))       // Метод для добавления объявления в избранное
+	authorized.DELETE("/favorites/:ad_id", removeFavorite(a)) // Метод для удаления объявления из избранного
+	authorized.GET("/notifications", listNotifications(a))    // Метод для получения уведомлений об изменении объявлений из избранного
+
+	authorized.POST("/ads/:ad_id/thread", openThread(a))            // Метод для открытия переписки с автором объявления
+	authorized.GET("/threads", listThreads(a))                      // Метод для получения страницы своих переписок
+	authorized.GET("/threads/unread", unreadMessages(a))            // Метод для получения числа непрочитанных сообщений
+	authorized.GET("/threads/:thread_id/messages", listMessages(a)) // Метод для получения сообщений переписки (только для участников)
+	authorized.POST("/threads/:thread_id/messages", sendMessage(a)) // Метод для отправки сообщения в переписку (только для участников)
+	authorized.PUT("/threads/:thread_id/read", markThreadRead(a))   // Метод для отметки сообщений переписки прочитанными
+
+	authorized.POST("/ads/:ad_id/photos", addPhotos(a))               // Метод для загрузки фотографий объявления (multipart, поле photos)
+	authorized.PUT("/ads/:ad_id/photos", reorderPhotos(a))            // Метод для изменения порядка фотографий объявления
+	authorized.DELETE("/ads/:ad_id/photos/:photo_id", deletePhoto(a)) // Метод для удаления фотографии объявления
+	r.GET("/ads/:ad_id/photos/:photo_id/:variant", getPhoto(a))       // Метод для получения фотографии: original или миниатюры small, medium (черновиков - только для автора и модераторов)
+
+	authorized.GET("/moderation/queue", moderationQueue(a))          // Метод для получения объявлений, ожидающих проверки (для модераторов)
+	authorized.POST("/moderation/ads/:ad_id/approve", approveAd(a))  // Метод для одобрения и публикации объявления (для модераторов)
+	authorized.POST("/moderation/ads/:ad_id/reject", rejectAd(a))    // Метод для отклонения или снятия с публикации объявления с причиной (для модераторов)
+	authorized.POST("/categories", createCategory(a))                // Метод для создания категории (для администраторов)
+	authorized.PUT("/categories/:category_id", updateCategory(a))    // Метод для переименования или переноса категории (для администраторов)
+	authorized.DELETE("/categories/:category_id", deleteCategory(a)) // Метод для удаления пустой категории (для администраторов)
+
+	authorized.DELETE("/users/:user_id", deleteUser(a))        // Метод для перемещения пользователя в корзину (для самого пользователя и администраторов)
+	authorized.PUT("/users/:user_id/role", setUserRole(a))     // Метод для назначения роли пользователю (для администраторов)
+	authorized.POST("/users/:user_id/restore", restoreUser(a)) // Метод для восстановления пользователя из корзины (для администраторов)
+	authorized.PUT("/rates/:currency", setExchangeRate(a))     // Метод для установки курса валюты к базовой валюте (для администраторов)
+}
@@ -0,0 +1,51 @@
+package httpgin
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"homework9/internal/app"
+)
+
+// Метод для планирования публикации и снятия с публикации объявления, поддерживает If-Match
+func scheduleAd(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody scheduleAdRequest
+		if err := c.ShouldBindJSON(&reqBody); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		adID, err := paramInt64(c, "ad_id")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		ctx := c.Request.Context()
+		version, err := ifMatchVersion(c, currentVersion(ctx, a, adID))
+		if err != nil {
+			c.JSON(errorStatus(err), ErrorResponse(err))
+			return
+		}
+
+		ad, err := a.ScheduleAd(ctx, adID, timeOrZero(reqBody.PublishAt), timeOrZero(reqBody.UnpublishAt), version)
+		if err != nil {
+			c.JSON(errorStatus(err), ErrorResponse(err))
+			return
+		}
+
+		setETag(c, ad)
+		c.JSON(http.StatusOK, AdSuccessResponse(ad))
+	}
+}
+
+func timeOrZero(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+
+	return *t
+}
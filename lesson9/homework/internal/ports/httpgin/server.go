@@ -2,17 +2,135 @@ package httpgin
 
 import (
 	"net/http"
+	"os"
 
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/exp/slog"
+
 	"homework9/internal/app"
+	"homework9/internal/health"
+	"homework9/internal/idempotency"
+	"homework9/internal/logging"
+	"homework9/internal/metrics"
+	"homework9/internal/ratelimit"
 )
 
-func NewHTTPServer(port string, a app.App) *http.Server {
+type options struct {
+	legacyUserID bool
+	limiter      *ratelimit.Limiter
+	idempotency  *idempotency.Store
+	metrics      *metrics.Metrics
+	tracer       trace.TracerProvider
+	logger       *slog.Logger
+	health       *health.Checker
+}
+
+type Option func(o *options)
+
+// WithLegacyUserID разрешает устаревшую передачу user_id в теле запроса вместо
+// токена. По умолчанию изменять объявления можно только с токеном в заголовке
+// Authorization.
+func WithLegacyUserID() Option {
+	return func(o *options) {
+		o.legacyUserID = true
+	}
+}
+
+// WithRateLimiter ограничивает частоту запросов к API: при превышении лимита
+// клиент получает 429 с заголовком Retry-After.
+func WithRateLimiter(l *ratelimit.Limiter) Option {
+	return func(o *options) {
+		o.limiter = l
+	}
+}
+
+// WithIdempotency включает заголовок Idempotency-Key для создания объявлений
+// и изменения их статуса: повторы запроса с тем же ключом получают первый ответ.
+func WithIdempotency(store *idempotency.Store) Option {
+	return func(o *options) {
+		o.idempotency = store
+	}
+}
+
+// WithMetrics включает метрики запросов и отдает их вместе с показателями
+// сервиса по GET /metrics в формате Prometheus.
+func WithMetrics(m *metrics.Metrics) Option {
+	return func(o *options) {
+		o.metrics = m
+	}
+}
+
+// WithTracing создает спан на каждый запрос, продолжая трассировку клиента из
+// заголовка traceparent.
+func WithTracing(tp trace.TracerProvider) Option {
+	return func(o *options) {
+		o.tracer = tp
+	}
+}
+
+// WithLogger задает логгер запросов и паник, по умолчанию строки JSON пишутся
+// в stdout. Строки получают идентификатор запроса из контекста, если логгер
+// создан logging.New.
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// WithHealth включает проверки GET /healthz (процесс жив) и GET /readyz
+// (готов принимать запросы) для оркестраторов и балансировщиков.
+func WithHealth(checker *health.Checker) Option {
+	return func(o *options) {
+		o.health = checker
+	}
+}
+
+func NewHTTPServer(port string, a app.App, opts ...Option) *http.Server {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	gin.SetMode(gin.ReleaseMode)
 	handler := gin.New()
+	// Заголовкам X-Forwarded-For не доверяем: по IP клиента ограничивается частота запросов.
+	_ = handler.SetTrustedProxies(nil)
 	s := &http.Server{Addr: port, Handler: handler}
 
-	// todo: add your own logic
+	logger := o.logger
+	if logger == nil {
+		logger = logging.New(os.Stdout)
+	}
+	logger = logger.With("component", "http")
+	handler.Use(requestIDMiddleware())
+	if o.tracer != nil {
+		handler.Use(tracingMiddleware(o.tracer))
+	}
+	if o.metrics != nil {
+		handler.Use(metricsMiddleware(o.metrics))
+	}
+	handler.Use(loggerMiddleware(logger), recoveryMiddleware(logger))
+
+	api := handler.Group("/api/v1", authMiddleware(a))
+	if o.limiter != nil {
+		api.Use(rateLimitMiddleware(o.limiter))
+	}
+	if o.idempotency != nil {
+		api.Use(idempotencyMiddleware(o.idempotency))
+	}
+	AppRouter(api, a, o.legacyUserID)
+	api.GET("/openapi.json", getOpenAPISpec) // Метод для получения спецификации API в формате OpenAPI 3
+	handler.GET("/docs/", getSwaggerUI)      // Страница Swagger UI, /docs перенаправляется сюда
+	handler.GET("/docs/swagger-ui.css", swaggerAsset("swagger-ui.css"))
+	handler.GET("/docs/swagger-ui-bundle.js", swaggerAsset("swagger-ui-bundle.js"))
+	if o.metrics != nil {
+		handler.GET("/metrics", gin.WrapH(o.metrics.Handler())) // Метрики в формате Prometheus
+	}
+	if o.health != nil {
+		handler.GET("/healthz", getLiveness)           // Проверка, что процесс жив
+		handler.GET("/readyz", getReadiness(o.health)) // Проверка готовности принимать запросы
+	}
 
 	return s
 }
@@ -0,0 +1,48 @@
+package httpgin
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"homework9/internal/tracing"
+)
+
+// tracingMiddleware продолжает трассировку из заголовка traceparent или
+// начинает новую и создает серверный спан запроса с шаблоном маршрута gin в
+// названии. Спан попадает в контекст http.Request, из которого его получают
+// app и хранилище.
+func tracingMiddleware(tp trace.TracerProvider) gin.HandlerFunc {
+	tracer := tp.Tracer(tracing.InstrumentationName)
+
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+
+		ctx := tracing.Propagator.Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+		ctx, span := tracer.Start(ctx, fmt.Sprintf("%s %s", c.Request.Method, route),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.method", c.Request.Method),
+				attribute.String("http.route", route),
+			))
+		defer span.End()
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(attribute.Int("http.status_code", status))
+		// Ошибки клиента - не ошибки сервера, спан отмечается только при 5xx.
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	}
+}
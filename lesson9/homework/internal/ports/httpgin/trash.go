@@ -0,0 +1,67 @@
+package httpgin
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"homework9/internal/app"
+)
+
+// Метод для получения страницы своих объявлений из корзины, query параметры page_size и page_token
+func listTrashedAds(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		pageSize, err := queryInt(c, "page_size")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		page, err := a.TrashedAds(c.Request.Context(), pageSize, c.Query("page_token"))
+		if err != nil {
+			c.JSON(errorStatus(err), ErrorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, AdsPageSuccessResponse(page))
+	}
+}
+
+// Метод для восстановления объявления из корзины
+func restoreAd(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		adID, err := paramInt64(c, "ad_id")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		ad, err := a.RestoreAd(c.Request.Context(), adID)
+		if err != nil {
+			c.JSON(errorStatus(err), ErrorResponse(err))
+			return
+		}
+
+		setETag(c, ad)
+		c.JSON(http.StatusOK, AdSuccessResponse(ad))
+	}
+}
+
+// Метод для восстановления пользователя из корзины
+func restoreUser(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := paramInt64(c, "user_id")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		user, err := a.RestoreUser(c.Request.Context(), userID)
+		if err != nil {
+			c.JSON(errorStatus(err), ErrorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, UserSuccessResponse(user))
+	}
+}
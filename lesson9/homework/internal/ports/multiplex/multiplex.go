@@ -0,0 +1,199 @@
+package multiplex
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"golang.org/x/net/http2"
+)
+
+// defaultSniffTimeout ограничивает время, за которое клиент должен прислать
+// начало соединения, чтобы молчащие соединения не копились.
+const defaultSniffTimeout = 10 * time.Second
+
+// Mux разделяет соединения одного слушателя между gRPC и HTTP серверами по
+// преамбуле: соединения HTTP/2 без TLS (prior knowledge), которые открывают
+// клиенты gRPC, получает GRPC(), все остальные - HTTP(). REST на общем порту
+// доступен по HTTP/1.1.
+//
+// Серверы закрывают свои слушатели сами при остановке, поэтому при graceful
+// shutdown сначала вызывается Close, чтобы не принимать новые соединения, а
+// затем останавливаются серверы, дожидаясь завершения текущих запросов.
+type Mux struct {
+	root         net.Listener
+	grpc         *listener
+	http         *listener
+	sniffTimeout time.Duration
+}
+
+type options struct {
+	sniffTimeout time.Duration
+}
+
+type Option func(o *options)
+
+// WithSniffTimeout задает время, за которое клиент должен прислать преамбулу
+// или начало запроса, по умолчанию 10 секунд. Дальше соединение не ограничено:
+// простаивающие клиенты gRPC не отключаются.
+func WithSniffTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.sniffTimeout = d
+	}
+}
+
+func New(root net.Listener, opts ...Option) *Mux {
+	o := options{sniffTimeout: defaultSniffTimeout}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return &Mux{
+		root:         root,
+		grpc:         newListener(root.Addr()),
+		http:         newListener(root.Addr()),
+		sniffTimeout: o.sniffTimeout,
+	}
+}
+
+// GRPC возвращает слушатель для grpc.Server.Serve.
+func (m *Mux) GRPC() net.Listener {
+	return m.grpc
+}
+
+// HTTP возвращает слушатель для http.Server.Serve.
+func (m *Mux) HTTP() net.Listener {
+	return m.http
+}
+
+// Serve принимает соединения до Close. После Close возвращает nil.
+func (m *Mux) Serve() error {
+	for {
+		conn, err := m.root.Accept()
+		if errors.Is(err, net.ErrClosed) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		go m.dispatch(conn)
+	}
+}
+
+// Close перестает принимать соединения. Уже принятые соединения обслуживаются,
+// пока их не закроют серверы.
+func (m *Mux) Close() error {
+	return m.root.Close()
+}
+
+func (m *Mux) dispatch(conn net.Conn) {
+	sc := &sniffConn{Conn: conn}
+	_ = conn.SetReadDeadline(time.Now().Add(m.sniffTimeout))
+	isHTTP2, err := readPreface(sc)
+	_ = conn.SetReadDeadline(time.Time{})
+	if err != nil {
+		_ = conn.Close()
+		return
+	}
+
+	sc.replay()
+	target := m.http
+	if isHTTP2 {
+		target = m.grpc
+	}
+	target.push(sc)
+}
+
+// readPreface читает начало соединения, пока оно совпадает с преамбулой
+// HTTP/2. Прочитанное остается в буфере sniffConn и будет отдано серверу.
+func readPreface(sc *sniffConn) (bool, error) {
+	preface := []byte(http2.ClientPreface)
+	buf := make([]byte, len(preface))
+	n := 0
+	for n < len(preface) {
+		m, err := sc.Read(buf[n:])
+		n += m
+		if !bytes.Equal(buf[:n], preface[:n]) {
+			return false, nil
+		}
+		if err != nil {
+			// Соединение закрылось, не прислав даже начала запроса.
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// sniffConn запоминает прочитанное при определении протокола и после replay
+// отдает его повторно.
+type sniffConn struct {
+	net.Conn
+	buf       bytes.Buffer
+	replaying bool
+}
+
+func (c *sniffConn) Read(p []byte) (int, error) {
+	if c.replaying {
+		if c.buf.Len() > 0 {
+			return c.buf.Read(p)
+		}
+		return c.Conn.Read(p)
+	}
+
+	n, err := c.Conn.Read(p)
+	c.buf.Write(p[:n])
+	return n, err
+}
+
+func (c *sniffConn) replay() {
+	c.replaying = true
+}
+
+// listener отдает серверу соединения, которые ему направил Mux.
+type listener struct {
+	addr   net.Addr
+	conns  chan net.Conn
+	done   chan struct{}
+	closed sync.Once
+}
+
+func newListener(addr net.Addr) *listener {
+	return &listener{
+		addr:  addr,
+		conns: make(chan net.Conn),
+		done:  make(chan struct{}),
+	}
+}
+
+func (l *listener) push(conn net.Conn) {
+	select {
+	case l.conns <- conn:
+	case <-l.done:
+		_ = conn.Close()
+	}
+}
+
+func (l *listener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.done:
+		return nil, net.ErrClosed
+	}
+}
+
+func (l *listener) Close() error {
+	l.closed.Do(func() {
+		close(l.done)
+	})
+
+	return nil
+}
+
+func (l *listener) Addr() net.Addr {
+	return l.addr
+}
@@ -0,0 +1,214 @@
+package ratelimit
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Class - класс запросов, для каждого из которых задается свой лимит.
+type Class int
+
+const (
+	Read Class = iota
+	Write
+	Create
+)
+
+func (c Class) String() string {
+	switch c {
+	case Read:
+		return "read"
+	case Write:
+		return "write"
+	case Create:
+		return "create"
+	}
+
+	return fmt.Sprintf("Class(%d)", int(c))
+}
+
+// idleSweepInterval - как часто из памяти удаляются заполнившиеся ведра.
+const idleSweepInterval = time.Minute
+
+// Limit разрешает Requests запросов за Per. Ведро вмещает Requests токенов и
+// пополняется равномерно, поэтому после простоя можно сделать Requests
+// запросов подряд. Нулевой Limit не ограничивает запросы.
+type Limit struct {
+	Requests int
+	Per      time.Duration
+}
+
+// ParseLimit разбирает лимит вида "100/1m"; пустая строка и "0" - без ограничений.
+func ParseLimit(s string) (Limit, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "0" {
+		return Limit{}, nil
+	}
+
+	requests, per, ok := strings.Cut(s, "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("limit %q: expected <requests>/<duration>", s)
+	}
+	n, err := strconv.Atoi(requests)
+	if err != nil || n < 0 {
+		return Limit{}, fmt.Errorf("limit %q: invalid number of requests", s)
+	}
+	d, err := time.ParseDuration(per)
+	if err != nil || d <= 0 {
+		return Limit{}, fmt.Errorf("limit %q: invalid duration", s)
+	}
+
+	return Limit{Requests: n, Per: d}, nil
+}
+
+func (l Limit) String() string {
+	if l.Unlimited() {
+		return "0"
+	}
+
+	return fmt.Sprintf("%d/%s", l.Requests, l.Per)
+}
+
+// Set позволяет передавать Limit флагом командной строки.
+func (l *Limit) Set(s string) error {
+	parsed, err := ParseLimit(s)
+	if err != nil {
+		return err
+	}
+
+	*l = parsed
+	return nil
+}
+
+// UnmarshalText позволяет задавать Limit строкой в файле конфигурации.
+func (l *Limit) UnmarshalText(text []byte) error {
+	return l.Set(string(text))
+}
+
+// Unlimited сообщает, что лимит не ограничивает запросы.
+func (l Limit) Unlimited() bool {
+	return l.Requests == 0 || l.Per == 0
+}
+
+// interval - время, за которое в ведре появляется один токен.
+func (l Limit) interval() time.Duration {
+	return l.Per / time.Duration(l.Requests)
+}
+
+// Limits - лимиты для каждого класса запросов.
+type Limits struct {
+	Read   Limit
+	Write  Limit
+	Create Limit
+}
+
+func (l Limits) of(class Class) Limit {
+	switch class {
+	case Write:
+		return l.Write
+	case Create:
+		return l.Create
+	}
+
+	return l.Read
+}
+
+var ErrLimitExceeded = errors.New("rate limit exceeded")
+
+type options struct {
+	now func() time.Time
+}
+
+type Option func(o *options)
+
+// WithNow подменяет источник текущего времени.
+func WithNow(now func() time.Time) Option {
+	return func(o *options) {
+		o.now = now
+	}
+}
+
+// Limiter - token bucket с отдельным ведром на каждую пару класса и ключа
+// (пользователя или IP-адреса клиента).
+type Limiter struct {
+	limits Limits
+	now    func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[bucketKey]time.Time
+	lastSweep time.Time
+}
+
+type bucketKey struct {
+	class Class
+	key   string
+}
+
+func New(limits Limits, opts ...Option) *Limiter {
+	o := options{now: time.Now}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return &Limiter{
+		limits:    limits,
+		now:       o.now,
+		buckets:   make(map[bucketKey]time.Time),
+		lastSweep: o.now(),
+	}
+}
+
+// Allow забирает токен из ведра class для key. Если ведро пусто, возвращает
+// false и время, через которое появится следующий токен.
+func (l *Limiter) Allow(class Class, key string) (bool, time.Duration) {
+	limit := l.limits.of(class)
+	if limit.Unlimited() {
+		return true, 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	// Ведро хранится как момент, когда оно снова станет полным (GCRA):
+	// запрос проходит, если после него до этого момента не больше Per.
+	k := bucketKey{class: class, key: key}
+	full := l.buckets[k]
+	if full.Before(now) {
+		full = now
+	}
+	full = full.Add(limit.interval())
+	if wait := full.Sub(now) - limit.Per; wait > 0 {
+		return false, wait
+	}
+
+	l.buckets[k] = full
+	return true, 0
+}
+
+// sweep удаляет заполнившиеся ведра, чтобы память не росла с числом клиентов.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < idleSweepInterval {
+		return
+	}
+	l.lastSweep = now
+
+	for k, full := range l.buckets {
+		if !full.After(now) {
+			delete(l.buckets, k)
+		}
+	}
+}
+
+// RetryAfterSeconds округляет время ожидания вверх до целых секунд для
+// заголовка Retry-After.
+func RetryAfterSeconds(wait time.Duration) int {
+	return int(math.Ceil(wait.Seconds()))
+}
@@ -0,0 +1,81 @@
+package search
+
+import (
+	"html"
+	"strings"
+)
+
+// Маркеры подсветки совпадений. Остальной текст экранируется, так что
+// результат можно вставлять в HTML как есть.
+const (
+	HighlightStart = "<em>"
+	HighlightEnd   = "</em>"
+
+	ellipsis = "…"
+)
+
+// Highlight оборачивает слова text, совпадающие с термами query, в маркеры
+// подсветки. Если maxWords > 0, возвращается фрагмент не длиннее maxWords
+// слов вокруг первого совпадения.
+func Highlight(text string, query string, maxWords int) string {
+	tokens := tokenize(text)
+	if len(tokens) == 0 {
+		return html.EscapeString(text)
+	}
+
+	wanted := make(map[string]bool)
+	for _, term := range terms(query) {
+		wanted[term] = true
+	}
+
+	from, to := 0, len(tokens)
+	if maxWords > 0 && len(tokens) > maxWords {
+		first := 0
+		for i, t := range tokens {
+			if wanted[t.term] {
+				first = i
+				break
+			}
+		}
+		// Оставляем немного контекста перед первым совпадением.
+		from = first - maxWords/4
+		if from < 0 {
+			from = 0
+		}
+		to = from + maxWords
+		if to > len(tokens) {
+			to = len(tokens)
+			from = to - maxWords
+		}
+	}
+
+	var sb strings.Builder
+	start := 0
+	if from > 0 {
+		sb.WriteString(ellipsis)
+		start = tokens[from].start
+	}
+
+	pos := start
+	for _, t := range tokens[from:to] {
+		if !wanted[t.term] {
+			continue
+		}
+		sb.WriteString(html.EscapeString(text[pos:t.start]))
+		sb.WriteString(HighlightStart)
+		sb.WriteString(html.EscapeString(text[t.start:t.end]))
+		sb.WriteString(HighlightEnd)
+		pos = t.end
+	}
+
+	end := len(text)
+	if to < len(tokens) {
+		end = tokens[to-1].end
+	}
+	sb.WriteString(html.EscapeString(text[pos:end]))
+	if to < len(tokens) {
+		sb.WriteString(ellipsis)
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,155 @@
+package search
+
+import (
+	"math"
+	"sort"
+	"sync"
+)
+
+// Параметры BM25.
+const (
+	k1 = 1.2
+	b  = 0.75
+
+	// titleWeight - во сколько раз вхождение в заголовок весит больше вхождения в текст.
+	titleWeight = 2
+)
+
+// Document - индексируемый документ: заголовок и текст объявления.
+type Document struct {
+	ID    int64
+	Title string
+	Text  string
+}
+
+// Hit - найденный документ и его релевантность.
+type Hit struct {
+	ID    int64
+	Score float64
+}
+
+// Index - инвертированный индекс с ранжированием BM25. Безопасен для
+// конкурентного использования.
+type Index struct {
+	mu sync.RWMutex
+
+	postings map[string]map[int64]int // терм -> документ -> взвешенная частота
+	docs     map[int64]docStats
+	total    int // сумма длин всех документов
+}
+
+type docStats struct {
+	length int // взвешенное число слов
+	terms  []string
+}
+
+func NewIndex() *Index {
+	return &Index{
+		postings: make(map[string]map[int64]int),
+		docs:     make(map[int64]docStats),
+	}
+}
+
+// Put добавляет документ в индекс или заменяет ранее добавленный с тем же ID.
+func (ix *Index) Put(doc Document) {
+	freqs := make(map[string]int)
+	length := 0
+	for _, t := range tokenize(doc.Title) {
+		freqs[t.term] += titleWeight
+		length += titleWeight
+	}
+	for _, t := range tokenize(doc.Text) {
+		freqs[t.term]++
+		length++
+	}
+
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	ix.remove(doc.ID)
+
+	stats := docStats{length: length, terms: make([]string, 0, len(freqs))}
+	for term, freq := range freqs {
+		docs, ok := ix.postings[term]
+		if !ok {
+			docs = make(map[int64]int)
+			ix.postings[term] = docs
+		}
+		docs[doc.ID] = freq
+		stats.terms = append(stats.terms, term)
+	}
+	ix.docs[doc.ID] = stats
+	ix.total += length
+}
+
+// Delete убирает документ из индекса.
+func (ix *Index) Delete(id int64) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	ix.remove(id)
+}
+
+// Search возвращает не больше limit документов, содержащих хотя бы один
+// терм запроса, по убыванию релевантности.
+func (ix *Index) Search(query string, limit int) []Hit {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	n := float64(len(ix.docs))
+	if n == 0 {
+		return nil
+	}
+	avgLen := float64(ix.total) / n
+
+	scores := make(map[int64]float64)
+	for _, term := range terms(query) {
+		docs := ix.postings[term]
+		if len(docs) == 0 {
+			continue
+		}
+
+		df := float64(len(docs))
+		idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+		for id, freq := range docs {
+			tf := float64(freq)
+			norm := k1 * (1 - b + b*float64(ix.docs[id].length)/avgLen)
+			scores[id] += idf * tf * (k1 + 1) / (tf + norm)
+		}
+	}
+
+	hits := make([]Hit, 0, len(scores))
+	for id, score := range scores {
+		hits = append(hits, Hit{ID: id, Score: score})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].ID < hits[j].ID
+	})
+
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+
+	return hits
+}
+
+// remove вызывается под ix.mu.
+func (ix *Index) remove(id int64) {
+	stats, ok := ix.docs[id]
+	if !ok {
+		return
+	}
+
+	for _, term := range stats.terms {
+		docs := ix.postings[term]
+		delete(docs, id)
+		if len(docs) == 0 {
+			delete(ix.postings, term)
+		}
+	}
+	delete(ix.docs, id)
+	ix.total -= stats.length
+}
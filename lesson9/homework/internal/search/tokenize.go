@@ -0,0 +1,81 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/kljensen/snowball/english"
+	"github.com/kljensen/snowball/russian"
+)
+
+// token - слово исходного текста и его нормализованная форма (терм).
+type token struct {
+	term  string
+	start int // смещение в байтах от начала текста
+	end   int
+}
+
+// tokenize разбивает текст на слова из букв и цифр, приводит их к нижнему
+// регистру и к основе: кириллические слова - русским стеммером, остальные -
+// английским.
+func tokenize(text string) []token {
+	var tokens []token
+
+	start := -1
+	for i, r := range text {
+		if isWordRune(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			tokens = append(tokens, newToken(text, start, i))
+			start = -1
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, newToken(text, start, len(text)))
+	}
+
+	return tokens
+}
+
+// terms возвращает уникальные термы текста в порядке их появления.
+func terms(text string) []string {
+	seen := make(map[string]bool)
+
+	var result []string
+	for _, t := range tokenize(text) {
+		if !seen[t.term] {
+			seen[t.term] = true
+			result = append(result, t.term)
+		}
+	}
+
+	return result
+}
+
+func newToken(text string, start, end int) token {
+	return token{term: normalize(text[start:end]), start: start, end: end}
+}
+
+func normalize(word string) string {
+	word = strings.ToLower(word)
+	word = strings.ReplaceAll(word, "ё", "е")
+
+	if isCyrillic(word) {
+		return russian.Stem(word, false)
+	}
+	return english.Stem(word, false)
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func isCyrillic(word string) bool {
+	r, _ := utf8.DecodeRuneInString(word)
+	return unicode.Is(unicode.Cyrillic, r)
+}
@@ -0,0 +1,137 @@
+package tests
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"homework9/internal/adapters/adrepo"
+	"homework9/internal/ads"
+	"homework9/internal/app"
+	"homework9/internal/categories"
+	"homework9/internal/users"
+)
+
+func TestPersistentAdRepo_Reopen(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	repo, err := adrepo.NewPersistent(dir, 0)
+	assert.NoError(t, err)
+
+	user, err := repo.AddUser(ctx, users.User{Name: "Oleg"})
+	assert.NoError(t, err)
+	ad, err := repo.AddAd(ctx, ads.Ad{Title: "hello", Text: "world", AuthorID: user.ID, CategoryID: categories.OtherID}, nil)
+	assert.NoError(t, err)
+	deleted, err := repo.AddAd(ctx, ads.Ad{Title: "best cat", Text: "not for sale", AuthorID: user.ID, CategoryID: categories.OtherID}, nil)
+	assert.NoError(t, err)
+
+	ad.Published = true
+	ad.Title = "hello!"
+	assert.NoError(t, repo.UpdateAd(ctx, ad, &ads.Revision{UserID: user.ID, Content: ad.Content()}))
+	ad.Version++
+	assert.NoError(t, repo.DeleteAd(ctx, deleted.ID))
+	assert.NoError(t, repo.Close())
+
+	repo, err = adrepo.NewPersistent(dir, 0)
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		repo.Close()
+	})
+
+	got, err := repo.GetAd(ctx, ad.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, ad, got)
+
+	_, err = repo.GetAd(ctx, deleted.ID)
+	assert.ErrorIs(t, err, app.ErrNotFound)
+	history, err := repo.ListRevisions(ctx, ad.ID)
+	assert.NoError(t, err)
+	if assert.Len(t, history, 1) {
+		assert.Equal(t, ad.Version, history[0].Version)
+		assert.Equal(t, "hello!", history[0].Content.Title)
+	}
+
+	gotUser, err := repo.GetUser(ctx, user.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, user, gotUser)
+
+	next, err := repo.AddAd(ctx, ads.Ad{Title: "hello", Text: "again", AuthorID: user.ID, CategoryID: categories.OtherID}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), next.ID)
+}
+
+func TestPersistentAdRepo_Compaction(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	repo, err := adrepo.NewPersistent(dir, 2)
+	assert.NoError(t, err)
+
+	for i := 0; i < 5; i++ {
+		_, err := repo.AddAd(ctx, ads.Ad{Title: "hello", Text: "world", AuthorID: 123, CategoryID: categories.OtherID}, nil)
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, repo.DeleteAd(ctx, 4))
+	assert.NoError(t, repo.Close())
+
+	assert.FileExists(t, filepath.Join(dir, "snapshot.json"))
+
+	repo, err = adrepo.NewPersistent(dir, 2)
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		repo.Close()
+	})
+
+	list, err := repo.ListAds(ctx, app.AdFilter{})
+	assert.NoError(t, err)
+	assert.Len(t, list, 4)
+
+	next, err := repo.AddAd(ctx, ads.Ad{Title: "hello", Text: "world", AuthorID: 123, CategoryID: categories.OtherID}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), next.ID)
+}
+
+func TestPersistentAdRepo_CorruptedTail(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	repo, err := adrepo.NewPersistent(dir, 0)
+	assert.NoError(t, err)
+
+	ad, err := repo.AddAd(ctx, ads.Ad{Title: "hello", Text: "world", AuthorID: 123, CategoryID: categories.OtherID}, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, repo.Close())
+
+	walPath := filepath.Join(dir, "wal.log")
+	info, err := os.Stat(walPath)
+	assert.NoError(t, err)
+
+	// Оборванная запись: заголовок обещает 100 байт, а в файле только 3.
+	f, err := os.OpenFile(walPath, os.O_APPEND|os.O_WRONLY, 0o644)
+	assert.NoError(t, err)
+	_, err = f.Write([]byte{100, 0, 0, 0, 1, 2, 3, 4, 'a', 'b', 'c'})
+	assert.NoError(t, err)
+	assert.NoError(t, f.Close())
+
+	repo, err = adrepo.NewPersistent(dir, 0)
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		repo.Close()
+	})
+
+	truncated, err := os.Stat(walPath)
+	assert.NoError(t, err)
+	assert.Equal(t, info.Size(), truncated.Size())
+
+	got, err := repo.GetAd(ctx, ad.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, ad, got)
+
+	next, err := repo.AddAd(ctx, ads.Ad{Title: "best cat", Text: "not for sale", AuthorID: 123, CategoryID: categories.OtherID}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), next.ID)
+}
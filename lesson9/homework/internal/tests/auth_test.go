@@ -0,0 +1,104 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"homework9/internal/categories"
+	grpcPort "homework9/internal/ports/grpc"
+)
+
+func TestLogin_TokenIdentifiesAuthor(t *testing.T) {
+	client := getTestClient()
+
+	user, err := client.createUser("Oleg", "secret")
+	assert.NoError(t, err)
+
+	assert.NoError(t, client.login(user.Data.ID, "secret"))
+
+	// user_id в теле игнорируется, автор берется из токена
+	ad, err := client.createAd(user.Data.ID+100, "hello", "world")
+	assert.NoError(t, err)
+	assert.Equal(t, user.Data.ID, ad.Data.AuthorID)
+
+	_, err = client.changeAdStatus(user.Data.ID+100, ad.Data.ID, true)
+	assert.NoError(t, err)
+}
+
+func TestLogin_ForgedUserIDIsIgnored(t *testing.T) {
+	client := getTestClient()
+
+	author, err := client.createUser("Oleg", "secret")
+	assert.NoError(t, err)
+	intruder, err := client.createUser("Ivan", "qwerty")
+	assert.NoError(t, err)
+
+	assert.NoError(t, client.login(author.Data.ID, "secret"))
+	ad, err := client.createAd(author.Data.ID, "hello", "world")
+	assert.NoError(t, err)
+
+	assert.NoError(t, client.login(intruder.Data.ID, "qwerty"))
+	_, err = client.updateAd(author.Data.ID, ad.Data.ID, "hacked", "text")
+	assert.ErrorIs(t, err, ErrForbidden)
+}
+
+func TestLogin_WrongPassword(t *testing.T) {
+	client := getTestClient()
+
+	user, err := client.createUser("Oleg", "secret")
+	assert.NoError(t, err)
+
+	err = client.login(user.Data.ID, "wrong")
+	assert.ErrorIs(t, err, ErrUnauthorized)
+
+	err = client.login(user.Data.ID+100, "secret")
+	assert.ErrorIs(t, err, ErrUnauthorized)
+}
+
+func TestAuth_InvalidToken(t *testing.T) {
+	client := getTestClient()
+	client.token = "garbage"
+
+	_, err := client.createAd(123, "hello", "world")
+	assert.ErrorIs(t, err, ErrUnauthorized)
+}
+
+func TestAuth_RejectsLegacyUserIDByDefault(t *testing.T) {
+	client := newTestClient(newApp(newRepo()))
+
+	_, err := client.createAd(123, "hello", "world")
+	assert.ErrorIs(t, err, ErrUnauthorized)
+
+	// читать объявления можно без токена
+	_, err = client.listAds()
+	assert.NoError(t, err)
+}
+
+func TestGRPCLogin(t *testing.T) {
+	client, ctx := newGRPCClient(t, newApp(newRepo()))
+
+	_, err := client.CreateAd(ctx, &grpcPort.CreateAdRequest{Title: "hello", Text: "world", UserId: 123, CategoryId: categories.OtherID})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	user, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Name: "Oleg", Password: "secret"})
+	assert.NoError(t, err)
+
+	_, err = client.Login(ctx, &grpcPort.LoginRequest{UserId: user.Id, Password: "wrong"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	token, err := client.Login(ctx, &grpcPort.LoginRequest{UserId: user.Id, Password: "secret"})
+	assert.NoError(t, err)
+
+	authCtx := metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token.Token)
+	ad, err := client.CreateAd(authCtx, &grpcPort.CreateAdRequest{Title: "hello", Text: "world", CategoryId: categories.OtherID})
+	assert.NoError(t, err)
+	assert.Equal(t, user.Id, ad.AuthorId)
+
+	badCtx := metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer garbage")
+	_, err = client.ListAds(badCtx, &grpcPort.ListAdsRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
@@ -0,0 +1,276 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"homework9/internal/ads"
+	"homework9/internal/app"
+	"homework9/internal/categories"
+	"homework9/internal/money"
+	grpcPort "homework9/internal/ports/grpc"
+	"homework9/internal/ports/httpgin"
+)
+
+type categoryTree struct {
+	Electronics, Phones, Laptops int64
+}
+
+// newCategoryApp создает приложение с администратором (ID 0) и деревом
+// Электроника > {Телефоны, Ноутбуки}.
+func newCategoryApp(t *testing.T) (app.App, context.Context, categoryTree) {
+	a := newApp(newRepo(), app.WithAdmins(0))
+	_, err := a.CreateUser(context.Background(), "admin", "admin-password")
+	assert.NoError(t, err)
+	admin := app.WithUserID(context.Background(), 0)
+
+	electronics, err := a.CreateCategory(admin, "Электроника", 0)
+	assert.NoError(t, err)
+	phones, err := a.CreateCategory(admin, "Телефоны", electronics.ID)
+	assert.NoError(t, err)
+	laptops, err := a.CreateCategory(admin, "Ноутбуки", electronics.ID)
+	assert.NoError(t, err)
+
+	return a, admin, categoryTree{Electronics: electronics.ID, Phones: phones.ID, Laptops: laptops.ID}
+}
+
+func createPublishedAdIn(t *testing.T, a app.App, ctx context.Context, categoryID int64) int64 {
+	ad, err := a.CreateAd(ctx, "hello", "world", categoryID, money.Money{})
+	assert.NoError(t, err)
+	_, err = a.ChangeAdStatus(ctx, ad.ID, true, 0)
+	assert.NoError(t, err)
+
+	return ad.ID
+}
+
+func TestCategories_FilterIncludesDescendants(t *testing.T) {
+	a, _, tree := newCategoryApp(t)
+	ctx := app.WithUserID(context.Background(), 123)
+
+	phone := createPublishedAdIn(t, a, ctx, tree.Phones)
+	laptop := createPublishedAdIn(t, a, ctx, tree.Laptops)
+	createPublishedAdIn(t, a, ctx, categories.OtherID)
+	_, err := a.CreateAd(ctx, "draft", "not published", tree.Phones, money.Money{})
+	assert.NoError(t, err)
+
+	page, err := a.ListAds(ctx, app.AdsQuery{CategoryID: tree.Electronics})
+	assert.NoError(t, err)
+	assert.Len(t, page.Ads, 2)
+	assert.Equal(t, phone, page.Ads[0].ID)
+	assert.Equal(t, laptop, page.Ads[1].ID)
+
+	page, err = a.ListAds(ctx, app.AdsQuery{CategoryID: tree.Phones})
+	assert.NoError(t, err)
+	assert.Len(t, page.Ads, 1)
+
+	page, err = a.ListAds(ctx, app.AdsQuery{})
+	assert.NoError(t, err)
+	assert.Len(t, page.Ads, 3)
+
+	_, err = a.ListAds(ctx, app.AdsQuery{CategoryID: 100})
+	assert.ErrorIs(t, err, app.ErrValidation)
+}
+
+func TestCategories_TreeCounts(t *testing.T) {
+	a, _, tree := newCategoryApp(t)
+	ctx := app.WithUserID(context.Background(), 123)
+
+	createPublishedAdIn(t, a, ctx, tree.Phones)
+	createPublishedAdIn(t, a, ctx, tree.Phones)
+	createPublishedAdIn(t, a, ctx, tree.Electronics)
+	_, err := a.CreateAd(ctx, "draft", "not published", tree.Laptops, money.Money{})
+	assert.NoError(t, err)
+
+	roots, err := a.CategoryTree(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, roots, 2)
+
+	// категории одного уровня упорядочены по названию
+	assert.Equal(t, categories.OtherName, roots[0].Name)
+	assert.Equal(t, 0, roots[0].PublishedAds)
+
+	electronics := roots[1]
+	assert.Equal(t, tree.Electronics, electronics.ID)
+	assert.Equal(t, 3, electronics.PublishedAds)
+	assert.Len(t, electronics.Children, 2)
+	assert.Equal(t, "Ноутбуки", electronics.Children[0].Name)
+	assert.Equal(t, 0, electronics.Children[0].PublishedAds)
+	assert.Equal(t, "Телефоны", electronics.Children[1].Name)
+	assert.Equal(t, 2, electronics.Children[1].PublishedAds)
+}
+
+func TestCategories_AdCategoryIsRequired(t *testing.T) {
+	a, _, tree := newCategoryApp(t)
+	ctx := app.WithUserID(context.Background(), 123)
+
+	_, err := a.CreateAd(ctx, "hello", "world", 0, money.Money{})
+	assert.ErrorIs(t, err, app.ErrValidation)
+
+	_, err = a.CreateAd(ctx, "hello", "world", 100, money.Money{})
+	assert.ErrorIs(t, err, app.ErrValidation)
+
+	ad, err := a.CreateAd(ctx, "hello", "world", tree.Phones, money.Money{})
+	assert.NoError(t, err)
+
+	ad, err = a.UpdateAd(ctx, ad.ID, "hello", "world", 0, money.Money{}, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, tree.Phones, ad.CategoryID)
+
+	ad, err = a.UpdateAd(ctx, ad.ID, "hello", "world", tree.Laptops, money.Money{}, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, tree.Laptops, ad.CategoryID)
+}
+
+func TestCategories_Management(t *testing.T) {
+	a, admin, tree := newCategoryApp(t)
+	user := app.WithUserID(context.Background(), 123)
+
+	_, err := a.CreateCategory(user, "Авто", 0)
+	assert.ErrorIs(t, err, app.ErrUnauthenticated, "user 123 does not exist")
+
+	bob, err := a.CreateUser(context.Background(), "bob", "bob-password")
+	assert.NoError(t, err)
+	_, err = a.CreateCategory(app.WithUserID(context.Background(), bob.ID), "Авто", 0)
+	assert.ErrorIs(t, err, app.ErrForbidden)
+
+	_, err = a.CreateCategory(admin, "телефоны", tree.Electronics)
+	assert.ErrorIs(t, err, app.ErrValidation, "duplicate name")
+
+	_, err = a.CreateCategory(admin, "Авто", 100)
+	assert.ErrorIs(t, err, app.ErrValidation, "unknown parent")
+
+	_, err = a.UpdateCategory(admin, tree.Electronics, "Электроника", tree.Phones)
+	assert.ErrorIs(t, err, app.ErrValidation, "cycle")
+
+	moved, err := a.UpdateCategory(admin, tree.Laptops, "Ноутбуки и планшеты", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), moved.ParentID)
+
+	err = a.DeleteCategory(admin, categories.OtherID)
+	assert.ErrorIs(t, err, app.ErrValidation)
+
+	err = a.DeleteCategory(admin, tree.Electronics)
+	assert.ErrorIs(t, err, app.ErrValidation, "has subcategories")
+
+	_, err = a.CreateAd(user, "hello", "world", tree.Phones, money.Money{})
+	assert.NoError(t, err)
+	err = a.DeleteCategory(admin, tree.Phones)
+	assert.ErrorIs(t, err, app.ErrValidation, "has ads")
+
+	err = a.DeleteCategory(admin, tree.Laptops)
+	assert.NoError(t, err)
+
+	err = a.DeleteCategory(admin, tree.Laptops)
+	assert.ErrorIs(t, err, app.ErrNotFound)
+}
+
+func TestCategories_RepositoryRejectsUnknownCategory(t *testing.T) {
+	ctx := context.Background()
+	repo := newRepo()
+
+	// Категорию могли удалить между проверкой в приложении и записью объявления.
+	_, err := repo.AddAd(ctx, ads.Ad{Title: "hello", Text: "world", AuthorID: 123, CategoryID: 100}, nil)
+	assert.ErrorIs(t, err, app.ErrValidation)
+
+	ad, err := repo.AddAd(ctx, ads.Ad{Title: "hello", Text: "world", AuthorID: 123, CategoryID: categories.OtherID}, nil)
+	assert.NoError(t, err)
+	ad.CategoryID = 100
+	assert.ErrorIs(t, repo.UpdateAd(ctx, ad, nil), app.ErrValidation)
+}
+
+func TestCategories_ConcurrentMovesDoNotCycle(t *testing.T) {
+	a, admin, tree := newCategoryApp(t)
+
+	for i := 0; i < 20; i++ {
+		_, err := a.UpdateCategory(admin, tree.Phones, "Телефоны", 0)
+		assert.NoError(t, err)
+		_, err = a.UpdateCategory(admin, tree.Laptops, "Ноутбуки", 0)
+		assert.NoError(t, err)
+
+		// Каждое перемещение по отдельности допустимо, но вместе они дали бы цикл.
+		var wg sync.WaitGroup
+		errs := make([]error, 2)
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_, errs[0] = a.UpdateCategory(admin, tree.Phones, "Телефоны", tree.Laptops)
+		}()
+		go func() {
+			defer wg.Done()
+			_, errs[1] = a.UpdateCategory(admin, tree.Laptops, "Ноутбуки", tree.Phones)
+		}()
+		wg.Wait()
+
+		if errs[0] == nil {
+			assert.ErrorIs(t, errs[1], app.ErrValidation)
+		} else {
+			assert.ErrorIs(t, errs[0], app.ErrValidation)
+			assert.NoError(t, errs[1])
+		}
+	}
+}
+
+func TestCategories_REST(t *testing.T) {
+	a, _, tree := newCategoryApp(t)
+	client := newTestClient(a, httpgin.WithLegacyUserID())
+
+	ad, err := client.createAdInCategory(123, "hello", "world", tree.Phones)
+	assert.NoError(t, err)
+	assert.Equal(t, tree.Phones, ad.Data.CategoryID)
+	_, err = client.changeAdStatus(123, ad.Data.ID, true)
+	assert.NoError(t, err)
+
+	_, err = client.createAdInCategory(123, "hello", "world", 0)
+	assert.ErrorIs(t, err, ErrBadRequest)
+
+	list, err := client.listAdsQuery(url.Values{"category_id": {"1"}})
+	assert.NoError(t, err)
+	assert.Len(t, list.Data, 0)
+
+	list, err = client.listAdsQuery(url.Values{"category_id": {strconv.FormatInt(tree.Electronics, 10)}})
+	assert.NoError(t, err)
+	assert.Len(t, list.Data, 1)
+
+	var response struct {
+		Data []struct {
+			ID           int64 `json:"id"`
+			PublishedAds int   `json:"published_ads"`
+			Children     []struct {
+				ID int64 `json:"id"`
+			} `json:"children"`
+		} `json:"data"`
+	}
+	req, err := http.NewRequest(http.MethodGet, client.baseURL+"/api/v1/categories", nil)
+	assert.NoError(t, err)
+	assert.NoError(t, client.getResponse(req, &response))
+	assert.Len(t, response.Data, 2)
+	assert.Equal(t, tree.Electronics, response.Data[1].ID)
+	assert.Equal(t, 1, response.Data[1].PublishedAds)
+	assert.Len(t, response.Data[1].Children, 2)
+}
+
+func TestGRPCGetCategoryTree(t *testing.T) {
+	a, _, tree := newCategoryApp(t)
+	client, ctx := newGRPCClient(t, a, grpcPort.WithLegacyUserID())
+
+	ad, err := client.CreateAd(ctx, &grpcPort.CreateAdRequest{Title: "hello", Text: "world", UserId: 123, CategoryId: tree.Laptops})
+	assert.NoError(t, err)
+	_, err = client.ChangeAdStatus(ctx, &grpcPort.ChangeAdStatusRequest{AdId: ad.Id, UserId: 123, Published: true})
+	assert.NoError(t, err)
+
+	res, err := client.GetCategoryTree(ctx, &emptypb.Empty{})
+	assert.NoError(t, err)
+	assert.Len(t, res.Roots, 2)
+	assert.Equal(t, int32(1), res.Roots[1].PublishedAds)
+
+	list, err := client.ListAds(ctx, &grpcPort.ListAdsRequest{CategoryId: tree.Phones})
+	assert.NoError(t, err)
+	assert.Len(t, list.List, 0)
+}
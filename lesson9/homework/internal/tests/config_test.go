@@ -0,0 +1,216 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/exp/slog"
+
+	"homework9/internal/app"
+	"homework9/internal/categories"
+	"homework9/internal/config"
+	"homework9/internal/money"
+	"homework9/internal/ratelimit"
+)
+
+// loadConfig загружает конфигурацию с аргументами командной строки args и
+// переменными окружения env.
+func loadConfig(t *testing.T, args []string, env map[string]string) (config.Config, error) {
+	t.Helper()
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags := config.BindFlags(fs)
+	assert.NoError(t, fs.Parse(args))
+
+	return flags.Load(func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	})
+}
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func TestConfig_Defaults(t *testing.T) {
+	cfg, err := loadConfig(t, nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, config.Default(), cfg)
+	assert.Equal(t, ":18080", cfg.HTTP.Addr)
+	assert.Equal(t, app.DefaultMaxTitleLen, cfg.Ads.MaxTitleLen)
+	assert.Equal(t, slog.LevelInfo, cfg.LogLevel())
+
+	// Пример конфигурации в корне модуля совпадает со значениями по умолчанию.
+	cfg, err = loadConfig(t, []string{"-config", "../../config.yaml"}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, config.Default(), cfg)
+}
+
+func TestConfig_Layers(t *testing.T) {
+	path := writeConfig(t, `
+http:
+  addr: ":1000"
+grpc:
+  addr: ":1001"
+storage:
+  backend: ${DB_BACKEND}
+  sqlite_path: /var/lib/ads.db
+ads:
+  max_title_len: 50
+shutdown:
+  timeout: 3s
+log:
+  level: debug
+`)
+	env := map[string]string{
+		"AD_CONFIG":         path,
+		"DB_BACKEND":        "sqlite",
+		"AD_HTTP_ADDR":      ":2000",
+		"AD_GRPC_ADDR":      ":2001",
+		"AD_MAX_TEXT_LEN":   "200",
+		"AD_SHUTDOWN_DELAY": "5s",
+	}
+
+	cfg, err := loadConfig(t, []string{"-http-addr", ":3000", "-log-level", "warn"}, env)
+	assert.NoError(t, err)
+	assert.Equal(t, ":3000", cfg.HTTP.Addr, "flags override env")
+	assert.Equal(t, ":2001", cfg.GRPC.Addr, "env overrides the file")
+	assert.Equal(t, "sqlite", cfg.Storage.Backend, "${VAR} is expanded in the file")
+	assert.Equal(t, "/var/lib/ads.db", cfg.Storage.SQLitePath)
+	assert.Equal(t, 50, cfg.Ads.MaxTitleLen)
+	assert.Equal(t, 200, cfg.Ads.MaxTextLen)
+	assert.Equal(t, 3*time.Second, cfg.Shutdown.Timeout)
+	assert.Equal(t, 5*time.Second, cfg.Shutdown.Delay)
+	assert.Equal(t, slog.LevelWarn, cfg.LogLevel())
+	assert.Equal(t, config.Default().Storage.CompactEvery, cfg.Storage.CompactEvery, "keys missing everywhere keep defaults")
+
+	// Флаг -config важнее AD_CONFIG.
+	other := writeConfig(t, "http:\n  addr: \":4000\"\n")
+	cfg, err = loadConfig(t, []string{"-config", other}, map[string]string{"AD_CONFIG": path})
+	assert.NoError(t, err)
+	assert.Equal(t, ":4000", cfg.HTTP.Addr)
+}
+
+func TestConfig_ServiceKeys(t *testing.T) {
+	path := writeConfig(t, `
+auth:
+  token_secret: ${TOKEN_SECRET}
+rate_limit:
+  read: 100/1s
+  create: 0
+trash:
+  retention: 48h
+`)
+	env := map[string]string{
+		"TOKEN_SECRET":       "s3cret",
+		"AD_RATE_WRITE":      "5/1m",
+		"AD_IDEMPOTENCY_TTL": "1h",
+	}
+
+	cfg, err := loadConfig(t, []string{"-config", path, "-token-ttl", "15m", "-purge-interval", "10m"}, env)
+	assert.NoError(t, err)
+	assert.Equal(t, "s3cret", cfg.Auth.TokenSecret)
+	assert.Equal(t, 15*time.Minute, cfg.Auth.TokenTTL)
+	assert.Equal(t, ratelimit.Limits{
+		Read:  ratelimit.Limit{Requests: 100, Per: time.Second},
+		Write: ratelimit.Limit{Requests: 5, Per: time.Minute},
+	}, cfg.RateLimit.Limits())
+	assert.True(t, cfg.RateLimit.Create.Unlimited())
+	assert.Equal(t, 48*time.Hour, cfg.Trash.Retention)
+	assert.Equal(t, 10*time.Minute, cfg.Trash.PurgeInterval)
+	assert.Equal(t, time.Hour, cfg.Idempotency.TTL)
+
+	env = map[string]string{
+		"AD_RATE_READ":       "100",
+		"AD_TOKEN_TTL":       "0s",
+		"AD_TRASH_RETENTION": "-1h",
+	}
+	_, err = loadConfig(t, []string{"-purge-interval", "0s", "-idempotency-ttl", "0s"}, env)
+	var errs config.Errors
+	if !assert.True(t, errors.As(err, &errs)) {
+		return
+	}
+	invalid := make(map[string]string)
+	for _, e := range errs {
+		invalid[e.Key] = e.Reason
+	}
+	assert.Len(t, invalid, 5)
+	assert.Contains(t, invalid["rate_limit.read"], "expected <requests>/<duration>")
+	assert.Contains(t, invalid["auth.token_ttl"], "must be positive")
+	assert.Contains(t, invalid["trash.retention"], "must be positive")
+	assert.Contains(t, invalid["trash.purge_interval"], "must be positive")
+	assert.Contains(t, invalid["idempotency.ttl"], "must be positive")
+}
+
+func TestConfig_InvalidKeys(t *testing.T) {
+	path := writeConfig(t, `
+http:
+  addr: localhost
+storage:
+  backend: postgres
+ads:
+  max_title_len: 0
+`)
+	env := map[string]string{
+		"AD_LOG_LEVEL":        "loud",
+		"AD_SHUTDOWN_TIMEOUT": "soon",
+	}
+
+	_, err := loadConfig(t, []string{"-config", path, "-max-text-len", "long", "-listen", ":99999"}, env)
+	var errs config.Errors
+	if !assert.True(t, errors.As(err, &errs)) {
+		return
+	}
+
+	invalid := make(map[string]string)
+	for _, e := range errs {
+		invalid[e.Key] = e.Reason
+	}
+	assert.Len(t, invalid, 7)
+	assert.Contains(t, invalid["http.addr"], "expected host:port")
+	assert.Contains(t, invalid["listen"], "port must be a number")
+	assert.Contains(t, invalid["storage.backend"], `"postgres"`)
+	assert.Contains(t, invalid["ads.max_title_len"], "must be positive")
+	assert.Contains(t, invalid["ads.max_text_len"], `-max-text-len: "long" is not an integer`)
+	assert.Contains(t, invalid["shutdown.timeout"], `AD_SHUTDOWN_TIMEOUT: "soon" is not a duration`)
+	assert.Contains(t, invalid["log.level"], `"loud"`)
+
+	// Сообщение перечисляет все ключи.
+	for key := range invalid {
+		assert.Contains(t, err.Error(), "\n  "+key+": ")
+	}
+	assert.True(t, strings.HasPrefix(err.Error(), "invalid config:"))
+}
+
+func TestConfig_BrokenFile(t *testing.T) {
+	_, err := loadConfig(t, []string{"-config", writeConfig(t, "http:\n  adr: \":1\"\n")}, nil)
+	assert.ErrorContains(t, err, "field adr not found")
+
+	_, err = loadConfig(t, []string{"-config", filepath.Join(t.TempDir(), "missing.yaml")}, nil)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestConfig_AdLimits(t *testing.T) {
+	a := newApp(newRepo(), app.WithMaxTitleLen(5), app.WithMaxTextLen(10))
+	user, err := a.CreateUser(context.Background(), "Oleg", "oleg-password")
+	assert.NoError(t, err)
+	ctx := app.WithUserID(context.Background(), user.ID)
+
+	_, err = a.CreateAd(ctx, "hello", "0123456789", categories.OtherID, money.Money{})
+	assert.NoError(t, err)
+	_, err = a.CreateAd(ctx, "hello!", "world", categories.OtherID, money.Money{})
+	assert.ErrorIs(t, err, app.ErrValidation)
+	_, err = a.CreateAd(ctx, "hello", "0123456789!", categories.OtherID, money.Money{})
+	assert.ErrorIs(t, err, app.ErrValidation)
+}
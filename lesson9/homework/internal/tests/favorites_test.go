@@ -0,0 +1,343 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"homework9/internal/adapters/adrepo"
+	"homework9/internal/ads"
+	"homework9/internal/app"
+	"homework9/internal/categories"
+	"homework9/internal/favorites"
+	"homework9/internal/money"
+	grpcPort "homework9/internal/ports/grpc"
+)
+
+// newBuyer создает пользователя, который добавляет объявления в избранное.
+func newBuyer(t *testing.T, a app.App) context.Context {
+	buyer, err := a.CreateUser(context.Background(), "buyer", "buyer-password")
+	assert.NoError(t, err)
+
+	return app.WithUserID(context.Background(), buyer.ID)
+}
+
+func TestFavorites_AddRemoveList(t *testing.T) {
+	a, _, _, author := newModerationApp(t)
+	buyer := newBuyer(t, a)
+
+	ad, err := a.CreateAd(author, "hello", "world", categories.OtherID, money.Money{})
+	assert.NoError(t, err)
+	_, err = a.ChangeAdStatus(author, ad.ID, true, 0)
+	assert.NoError(t, err)
+
+	_, err = a.AddFavorite(context.Background(), ad.ID)
+	assert.ErrorIs(t, err, app.ErrUnauthenticated)
+	_, err = a.AddFavorite(buyer, 100)
+	assert.ErrorIs(t, err, app.ErrNotFound)
+
+	favorite, err := a.AddFavorite(buyer, ad.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, favorite.Favorites)
+	favorite, err = a.AddFavorite(buyer, ad.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, favorite.Favorites, "adding twice changes nothing")
+	_, err = a.AddFavorite(author, ad.ID)
+	assert.NoError(t, err)
+
+	got, err := a.GetAd(buyer, ad.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, got.Favorites)
+	assert.Equal(t, ad.Version+1, got.Version, "favorites do not change the ad version")
+
+	list, err := a.ListAds(buyer, app.AdsQuery{})
+	assert.NoError(t, err)
+	if assert.Len(t, list.Ads, 1) {
+		assert.Equal(t, 2, list.Ads[0].Favorites)
+	}
+
+	page, err := a.Favorites(buyer, 0, "")
+	assert.NoError(t, err)
+	if assert.Len(t, page.Ads, 1) {
+		assert.Equal(t, ad.ID, page.Ads[0].ID)
+	}
+
+	assert.NoError(t, a.RemoveFavorite(buyer, ad.ID))
+	assert.ErrorIs(t, a.RemoveFavorite(buyer, ad.ID), app.ErrNotFound)
+	page, err = a.Favorites(buyer, 0, "")
+	assert.NoError(t, err)
+	assert.Len(t, page.Ads, 0)
+
+	got, err = a.GetAd(buyer, ad.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, got.Favorites)
+}
+
+func TestFavorites_UnpublishedAndDeletedAds(t *testing.T) {
+	a, _, _, author := newModerationApp(t, app.WithTrashRetention(50*time.Millisecond))
+	buyer := newBuyer(t, a)
+
+	ad, err := a.CreateAd(author, "hello", "world", categories.OtherID, money.Money{})
+	assert.NoError(t, err)
+	_, err = a.ChangeAdStatus(author, ad.ID, true, 0)
+	assert.NoError(t, err)
+	_, err = a.AddFavorite(buyer, ad.ID)
+	assert.NoError(t, err)
+
+	_, err = a.ChangeAdStatus(author, ad.ID, false, 0)
+	assert.NoError(t, err)
+	page, err := a.Favorites(buyer, 0, "")
+	assert.NoError(t, err)
+	if assert.Len(t, page.Ads, 1, "unpublished ads stay in favorites") {
+		assert.False(t, page.Ads[0].Published)
+	}
+
+	assert.NoError(t, a.DeleteAd(author, ad.ID))
+	page, err = a.Favorites(buyer, 0, "")
+	assert.NoError(t, err)
+	assert.Len(t, page.Ads, 0, "trashed ads are hidden")
+	_, err = a.AddFavorite(buyer, ad.ID)
+	assert.ErrorIs(t, err, app.ErrNotFound)
+
+	_, err = a.RestoreAd(author, ad.ID)
+	assert.NoError(t, err)
+	page, err = a.Favorites(buyer, 0, "")
+	assert.NoError(t, err)
+	assert.Len(t, page.Ads, 1, "restored ads are back in favorites")
+
+	assert.NoError(t, a.DeleteAd(author, ad.ID))
+	time.Sleep(60 * time.Millisecond)
+	_, err = a.PurgeTrash(context.Background())
+	assert.NoError(t, err)
+
+	assert.ErrorIs(t, a.RemoveFavorite(buyer, ad.ID), app.ErrNotFound, "purged ads leave favorites")
+	page, err = a.Favorites(buyer, 0, "")
+	assert.NoError(t, err)
+	assert.Len(t, page.Ads, 0)
+}
+
+func TestFavorites_Notifications(t *testing.T) {
+	a, _, moderator, author := newModerationApp(t)
+	buyer := newBuyer(t, a)
+
+	price := money.Money{Amount: 100000, Currency: money.Base}
+	ad, err := a.CreateAd(author, "hello", "world", categories.OtherID, price)
+	assert.NoError(t, err)
+	_, err = a.ChangeAdStatus(author, ad.ID, true, 0)
+	assert.NoError(t, err)
+	_, err = a.AddFavorite(buyer, ad.ID)
+	assert.NoError(t, err)
+	_, err = a.AddFavorite(author, ad.ID)
+	assert.NoError(t, err)
+
+	_, err = a.UpdateAd(author, ad.ID, "new title", "world", 0, money.Money{}, 0)
+	assert.NoError(t, err)
+	page, err := a.Notifications(buyer, 0, "")
+	assert.NoError(t, err)
+	assert.Len(t, page.Notifications, 0, "only price and text are watched")
+
+	updated, err := a.UpdateAd(author, ad.ID, "new title", "new text", 0,
+		money.Money{Amount: 90000, Currency: money.Base}, 0)
+	assert.NoError(t, err)
+	_, err = a.UpdateAd(author, ad.ID, "new title", "newer text", 0, money.Money{}, 0)
+	assert.NoError(t, err)
+
+	page, err = a.Notifications(buyer, 1, "")
+	assert.NoError(t, err)
+	if assert.Len(t, page.Notifications, 1) {
+		n := page.Notifications[0]
+		assert.Equal(t, ad.ID, n.AdID)
+		assert.Equal(t, updated.Version, n.AdVersion)
+		assert.Equal(t, []ads.FieldChange{
+			{Field: "text", Old: "world", New: "new text"},
+			{Field: "price", Old: "1000.00 RUB", New: "900.00 RUB"},
+		}, n.Changes)
+	}
+	assert.NotEmpty(t, page.NextPageToken)
+
+	page, err = a.Notifications(buyer, 1, page.NextPageToken)
+	assert.NoError(t, err)
+	if assert.Len(t, page.Notifications, 1) {
+		assert.Equal(t, []ads.FieldChange{{Field: "text", Old: "new text", New: "newer text"}}, page.Notifications[0].Changes)
+	}
+	assert.Empty(t, page.NextPageToken)
+
+	page, err = a.Notifications(author, 0, "")
+	assert.NoError(t, err)
+	assert.Len(t, page.Notifications, 0, "the editor is not notified about own changes")
+
+	// Изменения снятого с публикации объявления видят только автор и модераторы.
+	_, err = a.RejectAd(moderator, ad.ID, "spam")
+	assert.NoError(t, err)
+	_, err = a.UpdateAd(author, ad.ID, "new title", "hidden text", 0, money.Money{}, 0)
+	assert.NoError(t, err)
+	page, err = a.Notifications(buyer, 0, "")
+	assert.NoError(t, err)
+	assert.Len(t, page.Notifications, 2)
+}
+
+func TestFavorites_UnpublishedAdsCannotBeAdded(t *testing.T) {
+	a, _, moderator, author := newModerationApp(t)
+	buyer := newBuyer(t, a)
+
+	ad, err := a.CreateAd(author, "hello", "world", categories.OtherID, money.Money{})
+	assert.NoError(t, err)
+
+	_, err = a.AddFavorite(buyer, ad.ID)
+	assert.ErrorIs(t, err, app.ErrNotFound, "drafts are visible only to the author and moderators")
+	_, err = a.AddFavorite(author, ad.ID)
+	assert.NoError(t, err)
+	_, err = a.AddFavorite(moderator, ad.ID)
+	assert.NoError(t, err)
+
+	_, err = a.ChangeAdStatus(author, ad.ID, true, 0)
+	assert.NoError(t, err)
+	_, err = a.AddFavorite(buyer, ad.ID)
+	assert.NoError(t, err)
+}
+
+func TestFavorites_PersistentRepoReopen(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	repo, err := adrepo.NewPersistent(dir, 0)
+	assert.NoError(t, err)
+	ad, err := repo.AddAd(ctx, ads.Ad{Title: "hello", Text: "world", CategoryID: categories.OtherID}, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, repo.AddFavorite(ctx, favorites.Favorite{UserID: 1, AdID: ad.ID}))
+	assert.NoError(t, repo.AddFavorite(ctx, favorites.Favorite{UserID: 2, AdID: ad.ID}))
+	assert.NoError(t, repo.RemoveFavorite(ctx, 2, ad.ID))
+	_, err = repo.AddNotification(ctx, favorites.Notification{UserID: 1, AdID: ad.ID, AdVersion: 2})
+	assert.NoError(t, err)
+	assert.NoError(t, repo.Close())
+
+	repo, err = adrepo.NewPersistent(dir, 0)
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		repo.Close()
+	})
+
+	watchers, err := repo.ListWatchers(ctx, ad.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{1}, watchers)
+	got, err := repo.GetAd(ctx, ad.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, got.Favorites)
+
+	next, err := repo.AddNotification(ctx, favorites.Notification{UserID: 1, AdID: ad.ID, AdVersion: 3})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), next.ID)
+	list, err := repo.ListNotifications(ctx, 1, 0, 0)
+	assert.NoError(t, err)
+	assert.Len(t, list, 2)
+}
+
+type notificationsResponse struct {
+	Data []struct {
+		AdID    int64 `json:"ad_id"`
+		Changes []struct {
+			Field string `json:"field"`
+			Old   string `json:"old"`
+			New   string `json:"new"`
+		} `json:"changes"`
+	} `json:"data"`
+	NextPageToken string `json:"next_page_token"`
+}
+
+func TestFavorites_REST(t *testing.T) {
+	client := getTestClient()
+
+	author, err := client.createUser("Oleg", "secret")
+	assert.NoError(t, err)
+	assert.NoError(t, client.login(author.Data.ID, "secret"))
+	ad, err := client.createAd(author.Data.ID, "hello", "world")
+	assert.NoError(t, err)
+	_, err = client.changeAdStatus(author.Data.ID, ad.Data.ID, true)
+	assert.NoError(t, err)
+
+	buyer, err := client.createUser("Ivan", "secret")
+	assert.NoError(t, err)
+	assert.NoError(t, client.login(buyer.Data.ID, "secret"))
+
+	path := fmt.Sprintf("/api/v1/favorites/%d", ad.Data.ID)
+	var favorite adResponse
+	assert.NoError(t, client.putJSON(path, map[string]any{}, &favorite))
+	assert.Equal(t, 1, favorite.Data.Favorites)
+
+	req, err := http.NewRequest(http.MethodGet, client.baseURL+"/api/v1/favorites", nil)
+	assert.NoError(t, err)
+	var list adsResponse
+	assert.NoError(t, client.getResponse(req, &list))
+	if assert.Len(t, list.Data, 1) {
+		assert.Equal(t, ad.Data.ID, list.Data[0].ID)
+	}
+
+	assert.NoError(t, client.login(author.Data.ID, "secret"))
+	_, err = client.updateAd(author.Data.ID, ad.Data.ID, "hello", "new text")
+	assert.NoError(t, err)
+
+	assert.NoError(t, client.login(buyer.Data.ID, "secret"))
+	req, err = http.NewRequest(http.MethodGet, client.baseURL+"/api/v1/notifications", nil)
+	assert.NoError(t, err)
+	var notifications notificationsResponse
+	assert.NoError(t, client.getResponse(req, &notifications))
+	if assert.Len(t, notifications.Data, 1) && assert.Len(t, notifications.Data[0].Changes, 1) {
+		assert.Equal(t, ad.Data.ID, notifications.Data[0].AdID)
+		assert.Equal(t, "new text", notifications.Data[0].Changes[0].New)
+	}
+
+	req, err = http.NewRequest(http.MethodDelete, client.baseURL+path, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, client.getResponse(req, &struct{}{}))
+	req, err = http.NewRequest(http.MethodDelete, client.baseURL+path, nil)
+	assert.NoError(t, err)
+	assert.Error(t, client.getResponse(req, &struct{}{}))
+}
+
+func TestFavorites_GRPC(t *testing.T) {
+	a, _, _, _ := newModerationApp(t)
+	client, ctx := newGRPCClient(t, a)
+
+	token, err := client.Login(ctx, &grpcPort.LoginRequest{UserId: 2, Password: "author-password"})
+	assert.NoError(t, err)
+	authorCtx := metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token.GetToken())
+	token, err = client.Login(ctx, &grpcPort.LoginRequest{UserId: 1, Password: "moderator-password"})
+	assert.NoError(t, err)
+	buyerCtx := metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token.GetToken())
+
+	ad, err := client.CreateAd(authorCtx, &grpcPort.CreateAdRequest{Title: "hello", Text: "world", CategoryId: categories.OtherID})
+	assert.NoError(t, err)
+	_, err = client.ChangeAdStatus(authorCtx, &grpcPort.ChangeAdStatusRequest{AdId: ad.Id, Published: true})
+	assert.NoError(t, err)
+
+	favorite, err := client.AddFavorite(buyerCtx, &grpcPort.FavoriteRequest{AdId: ad.Id})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), favorite.Favorites)
+
+	list, err := client.ListFavorites(buyerCtx, &grpcPort.ListAdsRequest{})
+	assert.NoError(t, err)
+	assert.Len(t, list.List, 1)
+
+	_, err = client.UpdateAd(authorCtx, &grpcPort.UpdateAdRequest{AdId: ad.Id, Title: "hello", Text: "new text"})
+	assert.NoError(t, err)
+	notifications, err := client.ListNotifications(buyerCtx, &grpcPort.ListNotificationsRequest{})
+	assert.NoError(t, err)
+	if assert.Len(t, notifications.Notifications, 1) {
+		assert.Equal(t, ad.Id, notifications.Notifications[0].AdId)
+		assert.Equal(t, "text", notifications.Notifications[0].Changes[0].Field)
+	}
+
+	_, err = client.RemoveFavorite(buyerCtx, &grpcPort.FavoriteRequest{AdId: ad.Id})
+	assert.NoError(t, err)
+	_, err = client.RemoveFavorite(buyerCtx, &grpcPort.FavoriteRequest{AdId: ad.Id})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	_, err = client.ListFavorites(ctx, &grpcPort.ListAdsRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
@@ -9,25 +9,34 @@ import (
 	"github.com/stretchr/testify/assert"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/test/bufconn"
-	"homework9/internal/adapters/adrepo"
 	"homework9/internal/app"
+	"homework9/internal/categories"
 	grpcPort "homework9/internal/ports/grpc"
 )
 
-func TestGRRPCCreateUser(t *testing.T) {
+// getGRPCClient - getTestClient для gRPC: сервер принимает устаревший user_id.
+func getGRPCClient(t *testing.T, opts ...grpcPort.Option) (grpcPort.AdServiceClient, context.Context) {
+	return newGRPCClient(t, newApp(newRepo()), append([]grpcPort.Option{grpcPort.WithLegacyUserID()}, opts...)...)
+}
+
+func newGRPCClient(t *testing.T, a app.App, opts ...grpcPort.Option) (grpcPort.AdServiceClient, context.Context) {
+	conn, ctx := newGRPCConn(t, a, opts...)
+	return grpcPort.NewAdServiceClient(conn), ctx
+}
+
+// newGRPCConn запускает gRPC сервер в памяти и возвращает соединение с ним,
+// чтобы вызывать не только AdService.
+func newGRPCConn(t *testing.T, a app.App, opts ...grpcPort.Option) (*grpc.ClientConn, context.Context) {
 	lis := bufconn.Listen(1024 * 1024)
 	t.Cleanup(func() {
 		lis.Close()
 	})
 
-	srv := grpc.NewServer()
+	srv := grpcPort.NewGRPCServer(a, opts...)
 	t.Cleanup(func() {
 		srv.Stop()
 	})
 
-	svc := grpcPort.NewService(app.NewApp(adrepo.New()))
-	grpcPort.RegisterAdServiceServer(srv, svc)
-
 	go func() {
 		assert.NoError(t, srv.Serve(lis), "srv.Serve")
 	}()
@@ -48,9 +57,29 @@ func TestGRRPCCreateUser(t *testing.T) {
 		conn.Close()
 	})
 
-	client := grpcPort.NewAdServiceClient(conn)
+	return conn, ctx
+}
+
+func TestGRRPCCreateUser(t *testing.T) {
+	client, ctx := getGRPCClient(t)
+
 	res, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Name: "Oleg"})
 	assert.NoError(t, err, "client.GetUser")
 
 	assert.Equal(t, "Oleg", res.Name)
 }
+
+func TestGRPCSearchAds(t *testing.T) {
+	client, ctx := getGRPCClient(t)
+
+	ad, err := client.CreateAd(ctx, &grpcPort.CreateAdRequest{Title: "Продаю кошку", Text: "Пушистая", UserId: 123, CategoryId: categories.OtherID})
+	assert.NoError(t, err, "client.CreateAd")
+	_, err = client.ChangeAdStatus(ctx, &grpcPort.ChangeAdStatusRequest{AdId: ad.Id, UserId: 123, Published: true})
+	assert.NoError(t, err, "client.ChangeAdStatus")
+
+	res, err := client.SearchAds(ctx, &grpcPort.SearchAdsRequest{Query: "кошки"})
+	assert.NoError(t, err, "client.SearchAds")
+	assert.Len(t, res.Results, 1)
+	assert.Equal(t, ad.Id, res.Results[0].Ad.Id)
+	assert.Equal(t, "Продаю <em>кошку</em>", res.Results[0].TitleHighlight)
+}
@@ -0,0 +1,182 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"homework9/internal/adapters/adrepo"
+	"homework9/internal/app"
+	"homework9/internal/health"
+	grpcPort "homework9/internal/ports/grpc"
+	"homework9/internal/ports/httpgin"
+	"homework9/internal/ratelimit"
+)
+
+// unavailableRepo - хранилище, которое не отвечает на Ping.
+type unavailableRepo struct {
+	app.Repository
+}
+
+func (unavailableRepo) Ping(context.Context) error {
+	return errors.New("connection refused")
+}
+
+// probe запрашивает проверку и возвращает код ответа и статус или ошибку из тела.
+func (tc *testClient) probe(t *testing.T, path string) (int, string) {
+	t.Helper()
+
+	resp, err := tc.client.Get(tc.baseURL + path)
+	if !assert.NoError(t, err) {
+		return 0, ""
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		Data  *struct{ Status string } `json:"data"`
+		Error string                   `json:"error"`
+	}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	if body.Data == nil {
+		return resp.StatusCode, body.Error
+	}
+
+	return resp.StatusCode, body.Data.Status
+}
+
+func TestHealth_HTTP(t *testing.T) {
+	a := newApp(newRepo())
+	checker := health.New(a)
+	client := newTestClient(a, httpgin.WithHealth(checker))
+
+	code, body := client.probe(t, "/healthz")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ok", body)
+	code, body = client.probe(t, "/readyz")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ready", body)
+
+	// С началом остановки процесс жив, но новые запросы не принимает.
+	checker.Shutdown()
+	code, body = client.probe(t, "/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "not ready: shutting down", body)
+	code, _ = client.probe(t, "/healthz")
+	assert.Equal(t, http.StatusOK, code)
+}
+
+func TestHealth_HTTPRepositoryUnavailable(t *testing.T) {
+	a := newApp(unavailableRepo{Repository: newRepo()})
+	client := newTestClient(a, httpgin.WithHealth(health.New(a)))
+
+	code, body := client.probe(t, "/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "not ready: repository: connection refused", body)
+	code, _ = client.probe(t, "/healthz")
+	assert.Equal(t, http.StatusOK, code)
+}
+
+func TestHealth_GRPCCheck(t *testing.T) {
+	a := newApp(newRepo())
+	checker := health.New(a)
+	// Проверки готовности не расходуют лимит вызовов.
+	limiter := ratelimit.New(ratelimit.Limits{Write: ratelimit.Limit{Requests: 1, Per: time.Minute}})
+	conn, ctx := newGRPCConn(t, a, grpcPort.WithHealth(checker), grpcPort.WithRateLimiter(limiter))
+	client := healthpb.NewHealthClient(conn)
+
+	for _, service := range []string{"", "ad.AdService", ""} {
+		resp, err := client.Check(ctx, &healthpb.HealthCheckRequest{Service: service})
+		assert.NoError(t, err)
+		assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.GetStatus(), "service %q", service)
+	}
+
+	_, err := client.Check(ctx, &healthpb.HealthCheckRequest{Service: "no.such.Service"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	checker.Shutdown()
+	resp, err := client.Check(ctx, &healthpb.HealthCheckRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, resp.GetStatus())
+}
+
+func TestHealth_GRPCRepositoryUnavailable(t *testing.T) {
+	a := newApp(unavailableRepo{Repository: newRepo()})
+	conn, ctx := newGRPCConn(t, a, grpcPort.WithHealth(health.New(a)))
+
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, resp.GetStatus())
+}
+
+func TestHealth_GRPCWatch(t *testing.T) {
+	a := newApp(newRepo())
+	checker := health.New(a)
+	conn, ctx := newGRPCConn(t, a, grpcPort.WithHealth(checker))
+
+	stream, err := healthpb.NewHealthClient(conn).Watch(ctx, &healthpb.HealthCheckRequest{})
+	assert.NoError(t, err)
+	resp, err := stream.Recv()
+	assert.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.GetStatus())
+
+	// Поток сообщает об остановке и закрывается, не задерживая GracefulStop.
+	checker.Shutdown()
+	resp, err = stream.Recv()
+	assert.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, resp.GetStatus())
+	_, err = stream.Recv()
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+}
+
+func TestHealth_ShutdownEndsStreams(t *testing.T) {
+	a := newApp(newRepo())
+	checker := health.New(a)
+	client, ctx := newGRPCClient(t, a, grpcPort.WithHealth(checker))
+
+	user, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Name: "Oleg", Password: "oleg-password"})
+	assert.NoError(t, err)
+	token, err := client.Login(ctx, &grpcPort.LoginRequest{UserId: user.Id, Password: "oleg-password"})
+	assert.NoError(t, err)
+	authCtx := metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token.Token)
+
+	watch, err := client.WatchAds(ctx, &grpcPort.WatchAdsRequest{})
+	assert.NoError(t, err)
+	chat, err := client.Chat(authCtx)
+	assert.NoError(t, err)
+	_, err = chat.Header()
+	assert.NoError(t, err)
+
+	// Подписки завершаются в начале остановки, а не по таймауту GracefulStop.
+	checker.Shutdown()
+	_, err = watch.Recv()
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	_, err = chat.Recv()
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+
+	watch, err = client.WatchAds(ctx, &grpcPort.WatchAdsRequest{})
+	assert.NoError(t, err)
+	_, err = watch.Recv()
+	assert.Equal(t, codes.Unavailable, status.Code(err), "new streams are refused")
+
+	// Обычные вызовы дорабатывают до GracefulStop.
+	_, err = client.GetUser(ctx, &grpcPort.GetUserRequest{Id: user.Id})
+	assert.NoError(t, err)
+}
+
+func TestHealth_PersistentRepoPing(t *testing.T) {
+	repo, err := adrepo.NewPersistent(t.TempDir(), 0)
+	assert.NoError(t, err)
+	assert.NoError(t, repo.Ping(context.Background()))
+
+	assert.NoError(t, repo.Close())
+	assert.Error(t, repo.Ping(context.Background()))
+}
@@ -0,0 +1,239 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"homework9/internal/ads"
+	"homework9/internal/app"
+	"homework9/internal/categories"
+	"homework9/internal/money"
+	grpcPort "homework9/internal/ports/grpc"
+)
+
+type revisionData struct {
+	Version      int64  `json:"version"`
+	UserID       int64  `json:"user_id"`
+	RevertedFrom int64  `json:"reverted_from"`
+	Title        string `json:"title"`
+	Text         string `json:"text"`
+	Changes      []struct {
+		Field string `json:"field"`
+		Old   string `json:"old"`
+		New   string `json:"new"`
+	} `json:"changes"`
+}
+
+type historyResponse struct {
+	Data []revisionData `json:"data"`
+}
+
+func TestHistory_RecordsChanges(t *testing.T) {
+	a, _, moderator, author := newModerationApp(t)
+
+	ad, err := a.CreateAd(author, "hello", "world", categories.OtherID, money.Money{})
+	assert.NoError(t, err)
+	_, err = a.UpdateAd(author, ad.ID, "hello", "new world", 0, money.Money{Amount: 10000, Currency: "RUB"}, 0)
+	assert.NoError(t, err)
+	_, err = a.ChangeAdStatus(author, ad.ID, true, 0)
+	assert.NoError(t, err)
+	// без изменений новая версия не записывается
+	_, err = a.UpdateAd(author, ad.ID, "hello", "new world", 0, money.Money{}, 0)
+	assert.NoError(t, err)
+	_, err = a.RejectAd(moderator, ad.ID, "spam")
+	assert.NoError(t, err)
+
+	history, err := a.AdHistory(author, ad.ID)
+	assert.NoError(t, err)
+	if !assert.Len(t, history, 4) {
+		return
+	}
+
+	assert.Equal(t, int64(1), history[0].Version)
+	assert.Equal(t, int64(2), history[0].UserID)
+	assert.False(t, history[0].CreatedAt.IsZero())
+	assert.Equal(t, []ads.FieldChange{
+		{Field: "title", Old: "", New: "hello"},
+		{Field: "text", Old: "", New: "world"},
+		{Field: "category_id", Old: "", New: fmt.Sprint(categories.OtherID)},
+	}, history[0].Changes)
+
+	assert.Equal(t, []ads.FieldChange{
+		{Field: "text", Old: "world", New: "new world"},
+		{Field: "price", Old: "", New: "100.00 RUB"},
+	}, history[1].Changes)
+	assert.Equal(t, []ads.FieldChange{{Field: "published", Old: "false", New: "true"}}, history[2].Changes)
+
+	assert.Equal(t, int64(1), history[3].UserID, "moderator")
+	assert.False(t, history[3].Content.Published)
+	assert.Equal(t, ads.ModerationRejected, history[3].Content.Moderation)
+
+	// модератор видит историю чужого объявления
+	_, err = a.AdHistory(moderator, ad.ID)
+	assert.NoError(t, err)
+}
+
+func TestHistory_Revert(t *testing.T) {
+	a, _, _, author := newModerationApp(t)
+
+	ad, err := a.CreateAd(author, "hello", "world", categories.OtherID, money.Money{})
+	assert.NoError(t, err)
+	_, err = a.ChangeAdStatus(author, ad.ID, true, 0)
+	assert.NoError(t, err)
+	ad, err = a.UpdateAd(author, ad.ID, "bye", "everyone", 0, money.Money{Amount: 500, Currency: "USD"}, 0)
+	assert.NoError(t, err)
+
+	_, err = a.RevertAd(author, ad.ID, 1, ad.Version-1)
+	assert.ErrorIs(t, err, app.ErrVersionConflict)
+
+	reverted, err := a.RevertAd(author, ad.ID, 1, ad.Version)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", reverted.Title)
+	assert.Equal(t, "world", reverted.Text)
+	assert.True(t, reverted.Price.IsZero())
+	assert.True(t, reverted.Published, "status is not reverted")
+	assert.Equal(t, ad.Version+1, reverted.Version)
+
+	history, err := a.AdHistory(author, ad.ID)
+	assert.NoError(t, err)
+	last := history[len(history)-1]
+	assert.Equal(t, reverted.Version, last.Version)
+	assert.Equal(t, int64(1), last.RevertedFrom)
+	assert.Equal(t, []ads.FieldChange{
+		{Field: "title", Old: "bye", New: "hello"},
+		{Field: "text", Old: "everyone", New: "world"},
+		{Field: "price", Old: "5.00 USD", New: ""},
+	}, last.Changes)
+
+	// возврат к текущему содержимому ничего не меняет
+	same, err := a.RevertAd(author, ad.ID, 1, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, reverted.Version, same.Version)
+}
+
+func TestHistory_Errors(t *testing.T) {
+	a, admin, moderator, author := newModerationApp(t)
+
+	ad, err := a.CreateAd(author, "hello", "world", categories.OtherID, money.Money{})
+	assert.NoError(t, err)
+
+	stranger, err := a.CreateUser(context.Background(), "stranger", "stranger-password")
+	assert.NoError(t, err)
+	_, err = a.AdHistory(app.WithUserID(context.Background(), stranger.ID), ad.ID)
+	assert.ErrorIs(t, err, app.ErrForbidden)
+	_, err = a.RevertAd(moderator, ad.ID, 1, 0)
+	assert.ErrorIs(t, err, app.ErrForbidden)
+	_, err = a.RevertAd(admin, ad.ID, 1, 0)
+	assert.ErrorIs(t, err, app.ErrForbidden)
+
+	_, err = a.RevertAd(author, ad.ID, 5, 0)
+	assert.ErrorIs(t, err, app.ErrNotFound)
+	_, err = a.AdHistory(author, ad.ID+1)
+	assert.ErrorIs(t, err, app.ErrNotFound)
+
+	assert.NoError(t, a.DeleteAd(author, ad.ID))
+	_, err = a.AdHistory(moderator, ad.ID)
+	assert.ErrorIs(t, err, app.ErrNotFound)
+}
+
+func TestHistory_SavedWithAd(t *testing.T) {
+	repo := newRepo()
+	ctx := context.Background()
+
+	revision := &ads.Revision{UserID: 123, Content: ads.Content{Title: "hello", Text: "world"}}
+	ad, err := repo.AddAd(ctx, ads.Ad{Title: "hello", Text: "world", AuthorID: 123, CategoryID: categories.OtherID, Version: 1}, revision)
+	assert.NoError(t, err)
+
+	ad.Text = "new world"
+	assert.NoError(t, repo.UpdateAd(ctx, ad, &ads.Revision{UserID: 123, Content: ads.Content{Title: "hello", Text: "new world"}}))
+	// при конфликте версий не сохраняется ни объявление, ни запись истории
+	ad.Text = "stale"
+	assert.ErrorIs(t, repo.UpdateAd(ctx, ad, &ads.Revision{UserID: 123, Content: ads.Content{Title: "hello", Text: "stale"}}), app.ErrVersionConflict)
+
+	history, err := repo.ListRevisions(ctx, ad.ID)
+	assert.NoError(t, err)
+	if assert.Len(t, history, 2) {
+		assert.Equal(t, ad.ID, history[0].AdID)
+		assert.Equal(t, int64(1), history[0].Version)
+		assert.Equal(t, int64(2), history[1].Version)
+		assert.Equal(t, "new world", history[1].Content.Text)
+	}
+}
+
+func TestHistory_REST(t *testing.T) {
+	client := getTestClient()
+
+	user, err := client.createUser("Oleg", "secret")
+	assert.NoError(t, err)
+	assert.NoError(t, client.login(user.Data.ID, "secret"))
+
+	ad, err := client.createAd(user.Data.ID, "hello", "world")
+	assert.NoError(t, err)
+	_, err = client.updateAd(user.Data.ID, ad.Data.ID, "bye", "world")
+	assert.NoError(t, err)
+
+	var reverted adResponse
+	err = client.postJSON(fmt.Sprintf("/api/v1/ads/%d/revert", ad.Data.ID), map[string]any{"version": 1}, &reverted)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", reverted.Data.Title)
+	assert.Equal(t, int64(3), reverted.Data.Version)
+
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/api/v1/ads/%d/history", client.baseURL, ad.Data.ID), nil)
+	assert.NoError(t, err)
+	var history historyResponse
+	assert.NoError(t, client.getResponse(req, &history))
+	if !assert.Len(t, history.Data, 3) {
+		return
+	}
+	assert.Equal(t, user.Data.ID, history.Data[1].UserID)
+	assert.Equal(t, "bye", history.Data[1].Title)
+	assert.Equal(t, "title", history.Data[1].Changes[0].Field)
+	assert.Equal(t, int64(1), history.Data[2].RevertedFrom)
+
+	other, err := client.createUser("Ivan", "qwerty")
+	assert.NoError(t, err)
+	assert.NoError(t, client.login(other.Data.ID, "qwerty"))
+	err = client.postJSON(fmt.Sprintf("/api/v1/ads/%d/revert", ad.Data.ID), map[string]any{"version": 2}, &reverted)
+	assert.ErrorIs(t, err, ErrForbidden)
+}
+
+func TestHistory_GRPC(t *testing.T) {
+	a, _, _, _ := newModerationApp(t)
+	client, ctx := newGRPCClient(t, a)
+
+	token, err := client.Login(ctx, &grpcPort.LoginRequest{UserId: 2, Password: "author-password"})
+	assert.NoError(t, err)
+	authorCtx := metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token.GetToken())
+
+	ad, err := client.CreateAd(authorCtx, &grpcPort.CreateAdRequest{Title: "hello", Text: "world", CategoryId: categories.OtherID})
+	assert.NoError(t, err)
+	ad, err = client.UpdateAd(authorCtx, &grpcPort.UpdateAdRequest{AdId: ad.Id, Title: "bye", Text: "world",
+		Price: &grpcPort.Money{Amount: 1000, Currency: "RUB"}})
+	assert.NoError(t, err)
+
+	ad, err = client.RevertAd(authorCtx, &grpcPort.RevertAdRequest{AdId: ad.Id, Version: 1, ExpectedVersion: ad.Version})
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", ad.Title)
+	assert.Nil(t, ad.Price)
+
+	history, err := client.GetAdHistory(authorCtx, &grpcPort.GetAdHistoryRequest{AdId: ad.Id})
+	assert.NoError(t, err)
+	if !assert.Len(t, history.Revisions, 3) {
+		return
+	}
+	assert.Equal(t, int64(1000), history.Revisions[1].Price.GetAmount())
+	assert.Equal(t, int64(1), history.Revisions[2].RevertedFrom)
+	assert.NotNil(t, history.Revisions[2].CreatedAt)
+
+	_, err = client.RevertAd(authorCtx, &grpcPort.RevertAdRequest{AdId: ad.Id, Version: 10})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	_, err = client.GetAdHistory(ctx, &grpcPort.GetAdHistoryRequest{AdId: ad.Id})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
@@ -0,0 +1,232 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"homework9/internal/app"
+	"homework9/internal/categories"
+	"homework9/internal/idempotency"
+	grpcPort "homework9/internal/ports/grpc"
+	"homework9/internal/ports/httpgin"
+)
+
+func TestIdempotency_Store(t *testing.T) {
+	clock := newFakeClock()
+	store := idempotency.New(time.Hour, idempotency.WithNow(clock.Now))
+	ctx := context.Background()
+
+	calls := 0
+	fn := func() (any, bool) {
+		calls++
+		return calls, true
+	}
+
+	value, replayed, err := store.Do(ctx, "key", "body", fn)
+	assert.NoError(t, err)
+	assert.False(t, replayed)
+	assert.Equal(t, 1, value)
+
+	value, replayed, err = store.Do(ctx, "key", "body", fn)
+	assert.NoError(t, err)
+	assert.True(t, replayed)
+	assert.Equal(t, 1, value)
+
+	_, _, err = store.Do(ctx, "key", "other body", fn)
+	assert.ErrorIs(t, err, idempotency.ErrKeyReused)
+
+	clock.Advance(time.Hour)
+	value, replayed, err = store.Do(ctx, "key", "other body", fn)
+	assert.NoError(t, err)
+	assert.False(t, replayed, "the response expires after TTL")
+	assert.Equal(t, 2, value)
+
+	discard := func() (any, bool) {
+		calls++
+		return calls, false
+	}
+	_, _, err = store.Do(ctx, "failed", "body", discard)
+	assert.NoError(t, err)
+	value, replayed, err = store.Do(ctx, "failed", "body", fn)
+	assert.NoError(t, err)
+	assert.False(t, replayed, "unsaved results are not replayed")
+	assert.Equal(t, 4, value)
+}
+
+func TestIdempotency_StoreConcurrent(t *testing.T) {
+	store := idempotency.New(time.Hour)
+
+	var calls int32
+	release := make(chan struct{})
+	fn := func() (any, bool) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return "response", true
+	}
+
+	const n = 10
+	var wg sync.WaitGroup
+	var replays int32
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			value, replayed, err := store.Do(context.Background(), "key", "body", fn)
+			assert.NoError(t, err)
+			assert.Equal(t, "response", value)
+			if replayed {
+				atomic.AddInt32(&replays, 1)
+			}
+		}()
+	}
+
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&calls) == 1 }, time.Second, time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	assert.Equal(t, int32(n-1), atomic.LoadInt32(&replays))
+}
+
+// sendIdempotent отправляет JSON с заголовком Idempotency-Key и возвращает код ответа, заголовки и тело.
+func (tc *testClient) sendIdempotent(method string, path string, key string, body map[string]any) (int, http.Header, adResponse) {
+	data, _ := json.Marshal(body)
+	req, _ := http.NewRequest(method, tc.baseURL+path, bytes.NewReader(data))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", key)
+	if tc.token != "" {
+		req.Header.Set("Authorization", "Bearer "+tc.token)
+	}
+
+	resp, err := tc.client.Do(req)
+	if err != nil {
+		return 0, nil, adResponse{}
+	}
+	defer resp.Body.Close()
+
+	var out adResponse
+	_ = json.NewDecoder(resp.Body).Decode(&out)
+	return resp.StatusCode, resp.Header, out
+}
+
+func TestIdempotency_REST(t *testing.T) {
+	client := newTestClient(newApp(newRepo()), httpgin.WithIdempotency(idempotency.New(time.Hour)))
+
+	user, err := client.createUser("Oleg", "secret")
+	assert.NoError(t, err)
+	assert.NoError(t, client.login(user.Data.ID, "secret"))
+
+	body := map[string]any{"title": "hello", "text": "world", "category_id": categories.OtherID}
+	code, header, first := client.sendIdempotent(http.MethodPost, "/api/v1/ads", "create-1", body)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Empty(t, header.Get("Idempotent-Replayed"))
+
+	code, header, retry := client.sendIdempotent(http.MethodPost, "/api/v1/ads", "create-1", body)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "true", header.Get("Idempotent-Replayed"))
+	assert.Equal(t, first.Data.ID, retry.Data.ID)
+	assert.Equal(t, "application/json; charset=utf-8", header.Get("Content-Type"))
+
+	code, _, _ = client.sendIdempotent(http.MethodPost, "/api/v1/ads", "create-1",
+		map[string]any{"title": "hello", "text": "other", "category_id": categories.OtherID})
+	assert.Equal(t, http.StatusUnprocessableEntity, code)
+
+	list, err := client.listAdsPage(0, "")
+	assert.NoError(t, err)
+	assert.Len(t, list.Data, 0, "unpublished ads are not listed")
+
+	path := fmt.Sprintf("/api/v1/ads/%d/status", first.Data.ID)
+	status := map[string]any{"published": true}
+	code, _, published := client.sendIdempotent(http.MethodPut, path, "publish-1", status)
+	assert.Equal(t, http.StatusOK, code)
+	code, header, _ = client.sendIdempotent(http.MethodPut, path, "publish-1", status)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "true", header.Get("Idempotent-Replayed"))
+
+	got, _, err := client.getAd(first.Data.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, published.Data.Version, got.Data.Version, "the retry did not change the ad again")
+
+	code, _, _ = client.sendIdempotent(http.MethodPost, "/api/v1/ads", strings.Repeat("k", idempotency.MaxKeyLength+1), body)
+	assert.Equal(t, http.StatusBadRequest, code)
+}
+
+func TestIdempotency_RESTConcurrent(t *testing.T) {
+	client := newTestClient(newApp(newRepo()), httpgin.WithIdempotency(idempotency.New(time.Hour)))
+
+	user, err := client.createUser("Oleg", "secret")
+	assert.NoError(t, err)
+	assert.NoError(t, client.login(user.Data.ID, "secret"))
+
+	body := map[string]any{"title": "hello", "text": "world", "category_id": categories.OtherID}
+	const n = 10
+	ids := make([]int64, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			code, _, resp := client.sendIdempotent(http.MethodPost, "/api/v1/ads", "create-1", body)
+			assert.Equal(t, http.StatusOK, code)
+			ids[i] = resp.Data.ID
+		}(i)
+	}
+	wg.Wait()
+
+	for _, id := range ids {
+		assert.Equal(t, ids[0], id)
+	}
+	_, _, err = client.getAd(ids[0] + 1)
+	assert.Error(t, err, "only one ad is created")
+}
+
+func TestIdempotency_GRPC(t *testing.T) {
+	a, _, _, _ := newModerationApp(t)
+	client, ctx := newGRPCClient(t, a, grpcPort.WithIdempotency(idempotency.New(time.Hour)))
+
+	token, err := client.Login(ctx, &grpcPort.LoginRequest{UserId: 2, Password: "author-password"})
+	assert.NoError(t, err)
+	authorCtx := metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token.GetToken())
+
+	keyCtx := metadata.AppendToOutgoingContext(authorCtx, "idempotency-key", "create-1")
+	req := &grpcPort.CreateAdRequest{Title: "hello", Text: "world", CategoryId: categories.OtherID}
+	first, err := client.CreateAd(keyCtx, req)
+	assert.NoError(t, err)
+
+	var header metadata.MD
+	retry, err := client.CreateAd(keyCtx, req, grpc.Header(&header))
+	assert.NoError(t, err)
+	assert.Equal(t, first.Id, retry.Id)
+	assert.Equal(t, []string{"true"}, header.Get("idempotent-replayed"))
+
+	_, err = client.CreateAd(keyCtx, &grpcPort.CreateAdRequest{Title: "hello", Text: "other", CategoryId: categories.OtherID})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	other, err := client.CreateAd(authorCtx, req)
+	assert.NoError(t, err)
+	assert.NotEqual(t, first.Id, other.Id, "requests without a key are not deduplicated")
+
+	keyCtx = metadata.AppendToOutgoingContext(authorCtx, "idempotency-key", "publish-1")
+	published, err := client.ChangeAdStatus(keyCtx, &grpcPort.ChangeAdStatusRequest{AdId: first.Id, Published: true})
+	assert.NoError(t, err)
+	_, err = client.ChangeAdStatus(keyCtx, &grpcPort.ChangeAdStatusRequest{AdId: first.Id, Published: true})
+	assert.NoError(t, err)
+
+	got, err := a.GetAd(app.WithUserID(context.Background(), 2), first.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, published.Version, got.Version)
+}
@@ -0,0 +1,205 @@
+package tests
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/exp/slog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"homework9/internal/app"
+	"homework9/internal/categories"
+	"homework9/internal/logging"
+	"homework9/internal/money"
+	grpcPort "homework9/internal/ports/grpc"
+	"homework9/internal/ports/httpgin"
+	"homework9/internal/users"
+)
+
+// panicUserID - пользователь, при чтении которого faultyRepo паникует.
+const panicUserID = 1000
+
+// faultyRepo паникует при чтении пользователя panicUserID и не может прочитать
+// подписчиков объявлений, чтобы app писал ошибки в лог.
+type faultyRepo struct {
+	app.Repository
+}
+
+func (r faultyRepo) GetUser(ctx context.Context, userID int64) (users.User, error) {
+	if userID == panicUserID {
+		panic("user storage is broken")
+	}
+
+	return r.Repository.GetUser(ctx, userID)
+}
+
+func (r faultyRepo) ListWatchers(context.Context, int64) ([]int64, error) {
+	return nil, errors.New("watchers are unavailable")
+}
+
+// logBuffer собирает строки лога, которые серверы пишут из своих горутин.
+type logBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *logBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.Write(p)
+}
+
+// find возвращает первую строку с сообщением msg и идентификатором запроса id.
+func (b *logBuffer) find(msg string, id string) map[string]any {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	scanner := bufio.NewScanner(bytes.NewReader(b.buf.Bytes()))
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		var line map[string]any
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			continue
+		}
+		if line["msg"] == msg && line[logging.RequestIDKey] == id {
+			return line
+		}
+	}
+
+	return nil
+}
+
+// waitLine ждет строку лога: серверы пишут ее после отправки ответа.
+func (b *logBuffer) waitLine(t *testing.T, msg string, id string) map[string]any {
+	t.Helper()
+
+	var line map[string]any
+	assert.Eventually(t, func() bool {
+		line = b.find(msg, id)
+		return line != nil
+	}, time.Second, time.Millisecond, "log line %q with request id %q", msg, id)
+
+	return line
+}
+
+func newLoggedApp(t *testing.T) (app.App, *slog.Logger, *logBuffer) {
+	var buf logBuffer
+	logger := logging.New(&buf)
+
+	return newApp(faultyRepo{Repository: newRepo()}, app.WithLogger(logger)), logger, &buf
+}
+
+func TestLogging_HTTPRequestID(t *testing.T) {
+	a, logger, logs := newLoggedApp(t)
+	user, err := a.CreateUser(context.Background(), "Oleg", "oleg-password")
+	assert.NoError(t, err)
+	ad, err := a.CreateAd(app.WithUserID(context.Background(), user.ID), "hello", "world", categories.OtherID, money.Money{})
+	assert.NoError(t, err)
+	_, err = a.ChangeAdStatus(app.WithUserID(context.Background(), user.ID), ad.ID, true, 0)
+	assert.NoError(t, err)
+
+	server := httpgin.NewHTTPServer(":18080", a, httpgin.WithLogger(logger), httpgin.WithLegacyUserID())
+	testServer := httptest.NewServer(server.Handler)
+	defer testServer.Close()
+
+	// Идентификатор клиента возвращается в ответе и попадает в строки лога
+	// middleware и app.
+	body := fmt.Sprintf(`{"user_id": %d, "title": "hello", "text": "new text"}`, user.ID)
+	req, err := http.NewRequest(http.MethodPut, fmt.Sprintf("%s/api/v1/ads/%d", testServer.URL, ad.ID), strings.NewReader(body))
+	assert.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Request-ID", "client-request-1")
+	resp, err := testServer.Client().Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "client-request-1", resp.Header.Get("X-Request-ID"))
+
+	line := logs.waitLine(t, "request", "client-request-1")
+	assert.Equal(t, "http", line["component"])
+	assert.Equal(t, float64(http.StatusOK), line["status"])
+	line = logs.waitLine(t, "notify watchers", "client-request-1")
+	assert.Equal(t, "ERROR", line["level"])
+	assert.Equal(t, float64(ad.ID), line["ad_id"])
+
+	// Без заголовка идентификатор генерируется.
+	resp, err = testServer.Client().Get(fmt.Sprintf("%s/api/v1/ads/%d", testServer.URL, ad.ID))
+	assert.NoError(t, err)
+	resp.Body.Close()
+	id := resp.Header.Get("X-Request-ID")
+	assert.Len(t, id, 32)
+	logs.waitLine(t, "request", id)
+
+	// Недопустимый идентификатор заменяется новым.
+	req, err = http.NewRequest(http.MethodGet, fmt.Sprintf("%s/api/v1/ads/%d", testServer.URL, ad.ID), nil)
+	assert.NoError(t, err)
+	req.Header.Set("X-Request-ID", strings.Repeat("x", 200))
+	resp, err = testServer.Client().Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Len(t, resp.Header.Get("X-Request-ID"), 32)
+}
+
+func TestLogging_HTTPPanic(t *testing.T) {
+	a, logger, logs := newLoggedApp(t)
+	server := httpgin.NewHTTPServer(":18080", a, httpgin.WithLogger(logger), httpgin.WithLegacyUserID())
+	testServer := httptest.NewServer(server.Handler)
+	defer testServer.Close()
+
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/api/v1/users/%d", testServer.URL, panicUserID), nil)
+	assert.NoError(t, err)
+	req.Header.Set("X-Request-ID", "panic-request")
+	resp, err := testServer.Client().Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	assert.Equal(t, "panic-request", resp.Header.Get("X-Request-ID"))
+
+	line := logs.waitLine(t, "panic recovered", "panic-request")
+	assert.Equal(t, "user storage is broken", line["panic"])
+	assert.Contains(t, line["stack"], "faultyRepo.GetUser")
+	logs.waitLine(t, "request", "panic-request")
+}
+
+func TestLogging_GRPC(t *testing.T) {
+	a, logger, logs := newLoggedApp(t)
+	client, ctx := newGRPCClient(t, a, grpcPort.WithLogger(logger))
+
+	var header metadata.MD
+	_, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Name: "Oleg", Password: "oleg-password"}, grpc.Header(&header))
+	assert.NoError(t, err)
+	ids := header.Get("x-request-id")
+	if assert.Len(t, ids, 1) {
+		assert.Len(t, ids[0], 32)
+		line := logs.waitLine(t, "call", ids[0])
+		assert.Equal(t, "grpc", line["component"])
+		assert.Equal(t, "OK", line["code"])
+	}
+
+	header = nil
+	withID := metadata.AppendToOutgoingContext(ctx, "x-request-id", "panic-call")
+	_, err = client.GetUser(withID, &grpcPort.GetUserRequest{Id: panicUserID}, grpc.Header(&header))
+	assert.Equal(t, codes.Internal, status.Code(err))
+	assert.Equal(t, []string{"panic-call"}, header.Get("x-request-id"))
+
+	line := logs.waitLine(t, "panic recovered", "panic-call")
+	assert.Equal(t, "/ad.AdService/GetUser", line["method"])
+	assert.Contains(t, line["stack"], "faultyRepo.GetUser")
+	line = logs.waitLine(t, "call", "panic-call")
+	assert.Equal(t, "Internal", line["code"])
+}
@@ -0,0 +1,70 @@
+package tests
+
+import (
+	"context"
+	"log"
+	"os"
+	"sync"
+	"testing"
+
+	"homework9/internal/adapters/adrepo"
+	"homework9/internal/adapters/sqliterepo"
+	"homework9/internal/app"
+)
+
+type backend struct {
+	name    string
+	newRepo func() app.Repository
+}
+
+// backends - реализации app.Repository, на каждой из которых прогоняются все тесты пакета.
+var backends = []backend{
+	{name: "memory", newRepo: adrepo.New},
+	{name: "sqlite", newRepo: newSQLiteRepo},
+}
+
+func TestMain(m *testing.M) {
+	code := 0
+	for _, b := range backends {
+		log.Printf("running tests with %s repository", b.name)
+		newRepo = b.newRepo
+		if c := m.Run(); c != 0 {
+			code = c
+		}
+		closeSQLiteRepos()
+	}
+
+	os.Exit(code)
+}
+
+var (
+	sqliteMu    sync.Mutex
+	sqliteRepos []*sqliterepo.Repo
+)
+
+// newSQLiteRepo создает репозиторий в памяти. newRepo вызывается без
+// *testing.T, поэтому базы закрываются в TestMain после прогона тестов.
+func newSQLiteRepo() app.Repository {
+	repo, err := sqliterepo.New(context.Background(), ":memory:")
+	if err != nil {
+		panic(err)
+	}
+
+	sqliteMu.Lock()
+	sqliteRepos = append(sqliteRepos, repo)
+	sqliteMu.Unlock()
+
+	return repo
+}
+
+func closeSQLiteRepos() {
+	sqliteMu.Lock()
+	defer sqliteMu.Unlock()
+
+	for _, repo := range sqliteRepos {
+		if err := repo.Close(); err != nil {
+			log.Printf("closing sqlite repository: %v", err)
+		}
+	}
+	sqliteRepos = nil
+}
@@ -0,0 +1,419 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"homework9/internal/adapters/adrepo"
+	"homework9/internal/ads"
+	"homework9/internal/app"
+	"homework9/internal/categories"
+	"homework9/internal/messages"
+	"homework9/internal/money"
+	grpcPort "homework9/internal/ports/grpc"
+)
+
+// newThreadApp создает приложение с объявлением автора (ID 2) и перепиской
+// покупателя по нему. Модератор (ID 1) в переписке не участвует.
+func newThreadApp(t *testing.T, opts ...app.Option) (a app.App, outsider, author, buyer context.Context,
+	thread *messages.Thread) {
+	a, _, outsider, author = newModerationApp(t, opts...)
+	buyer = newBuyer(t, a)
+
+	ad, err := a.CreateAd(author, "hello", "world", categories.OtherID, money.Money{})
+	assert.NoError(t, err)
+	thread, err = a.OpenThread(buyer, ad.ID)
+	assert.NoError(t, err)
+
+	return a, outsider, author, buyer, thread
+}
+
+func TestMessages_OpenThread(t *testing.T) {
+	a, outsider, author, buyer, thread := newThreadApp(t)
+
+	assert.Equal(t, int64(2), thread.SellerID)
+	assert.Equal(t, int64(0), thread.LastMessageID)
+
+	again, err := a.OpenThread(buyer, thread.AdID)
+	assert.NoError(t, err)
+	assert.Equal(t, thread.ID, again.ID, "a buyer has one thread per ad")
+
+	other, err := a.OpenThread(outsider, thread.AdID)
+	assert.NoError(t, err)
+	assert.NotEqual(t, thread.ID, other.ID)
+
+	_, err = a.OpenThread(author, thread.AdID)
+	assert.ErrorIs(t, err, app.ErrValidation)
+	_, err = a.OpenThread(buyer, 100)
+	assert.ErrorIs(t, err, app.ErrNotFound)
+	_, err = a.OpenThread(context.Background(), thread.AdID)
+	assert.ErrorIs(t, err, app.ErrUnauthenticated)
+
+	page, err := a.Threads(author, 0, "")
+	assert.NoError(t, err)
+	assert.Len(t, page.Threads, 2)
+	page, err = a.Threads(buyer, 0, "")
+	assert.NoError(t, err)
+	assert.Len(t, page.Threads, 1)
+}
+
+func TestMessages_OnlyParticipants(t *testing.T) {
+	a, outsider, _, buyer, thread := newThreadApp(t)
+
+	_, err := a.SendMessage(buyer, thread.ID, "is it available?")
+	assert.NoError(t, err)
+
+	_, err = a.Messages(outsider, thread.ID, 0, "")
+	assert.ErrorIs(t, err, app.ErrForbidden)
+	_, err = a.SendMessage(outsider, thread.ID, "hi")
+	assert.ErrorIs(t, err, app.ErrForbidden)
+	_, err = a.MarkThreadRead(outsider, thread.ID, 0)
+	assert.ErrorIs(t, err, app.ErrForbidden)
+	_, err = a.Messages(context.Background(), thread.ID, 0, "")
+	assert.ErrorIs(t, err, app.ErrUnauthenticated)
+	_, err = a.Messages(buyer, 100, 0, "")
+	assert.ErrorIs(t, err, app.ErrNotFound)
+
+	unread, err := a.UnreadMessages(outsider)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, unread)
+}
+
+func TestMessages_SendAndRead(t *testing.T) {
+	a, _, author, buyer, thread := newThreadApp(t)
+
+	_, err := a.SendMessage(buyer, thread.ID, "  ")
+	assert.ErrorIs(t, err, app.ErrValidation)
+	_, err = a.SendMessage(buyer, thread.ID, strings.Repeat("я", 1001))
+	assert.ErrorIs(t, err, app.ErrValidation)
+
+	first, err := a.SendMessage(buyer, thread.ID, "is it available?")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), first.ID, "message IDs start at 1")
+	second, err := a.SendMessage(buyer, thread.ID, "hello?")
+	assert.NoError(t, err)
+
+	unread, err := a.UnreadMessages(author)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, unread)
+	unread, err = a.UnreadMessages(buyer)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, unread, "own messages are never unread")
+
+	page, err := a.Threads(author, 0, "")
+	assert.NoError(t, err)
+	if assert.Len(t, page.Threads, 1) {
+		assert.Equal(t, 2, page.Threads[0].Unread(2))
+		assert.Equal(t, second.ID, page.Threads[0].LastMessageID)
+	}
+
+	read, err := a.MarkThreadRead(author, thread.ID, first.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, read.Unread(2))
+	assert.Equal(t, first.ID, read.SellerReadID)
+
+	_, err = a.MarkThreadRead(author, thread.ID, second.ID+1)
+	assert.ErrorIs(t, err, app.ErrValidation)
+
+	read, err = a.MarkThreadRead(author, thread.ID, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, read.Unread(2))
+	read, err = a.MarkThreadRead(author, thread.ID, first.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, second.ID, read.SellerReadID, "read marks never move back")
+
+	reply, err := a.SendMessage(author, thread.ID, "yes")
+	assert.NoError(t, err)
+	unread, err = a.UnreadMessages(buyer)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, unread)
+
+	list, err := a.Messages(author, thread.ID, 2, "")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"is it available?", "hello?"}, messageTexts(list))
+	list, err = a.Messages(buyer, thread.ID, 2, list.NextPageToken)
+	assert.NoError(t, err)
+	if assert.Len(t, list.Messages, 1) {
+		assert.Equal(t, reply.ID, list.Messages[0].ID)
+		assert.Equal(t, int64(2), list.Messages[0].SenderID)
+	}
+	assert.Empty(t, list.NextPageToken)
+}
+
+func TestMessages_DeletedAd(t *testing.T) {
+	a, _, author, buyer, thread := newThreadApp(t, app.WithTrashRetention(50*time.Millisecond))
+
+	assert.NoError(t, a.DeleteAd(author, thread.AdID))
+	_, err := a.OpenThread(newBuyer(t, a), thread.AdID)
+	assert.ErrorIs(t, err, app.ErrNotFound, "new threads are not opened on trashed ads")
+	_, err = a.SendMessage(buyer, thread.ID, "still there?")
+	assert.NoError(t, err, "existing threads keep working")
+
+	time.Sleep(60 * time.Millisecond)
+	_, err = a.PurgeTrash(context.Background())
+	assert.NoError(t, err)
+
+	_, err = a.Messages(buyer, thread.ID, 0, "")
+	assert.ErrorIs(t, err, app.ErrNotFound, "purging an ad deletes its threads")
+	unread, err := a.UnreadMessages(author)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, unread)
+}
+
+func TestMessages_Chat(t *testing.T) {
+	a, outsider, author, buyer, thread := newThreadApp(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	authorChat, err := a.Chat(author)
+	assert.NoError(t, err)
+	defer authorChat.Close()
+	buyerChat, err := a.Chat(buyer)
+	assert.NoError(t, err)
+	defer buyerChat.Close()
+	outsiderChat, err := a.Chat(outsider)
+	assert.NoError(t, err)
+	defer outsiderChat.Close()
+
+	_, err = a.Chat(context.Background())
+	assert.ErrorIs(t, err, app.ErrUnauthenticated)
+
+	message, err := a.SendMessage(buyer, thread.ID, "is it available?")
+	assert.NoError(t, err)
+
+	for _, sub := range []*app.ChatSubscription{authorChat, buyerChat} {
+		e, err := sub.Next(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, app.ChatMessage, e.Type)
+		assert.Equal(t, *message, e.Message)
+		assert.Equal(t, 1, e.Thread.Unread(2))
+	}
+
+	_, err = a.MarkThreadRead(author, thread.ID, 0)
+	assert.NoError(t, err)
+	e, err := buyerChat.Next(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, app.ChatRead, e.Type)
+	assert.Equal(t, message.ID, e.Thread.SellerReadID)
+
+	short, cancelShort := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancelShort()
+	_, err = outsiderChat.Next(short)
+	assert.ErrorIs(t, err, context.DeadlineExceeded, "outsiders get no events")
+}
+
+func TestMessages_PersistentRepoReopen(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	repo, err := adrepo.NewPersistent(dir, 0)
+	assert.NoError(t, err)
+	ad, err := repo.AddAd(ctx, ads.Ad{Title: "hello", Text: "world", AuthorID: 2, CategoryID: categories.OtherID}, nil)
+	assert.NoError(t, err)
+	thread, err := repo.AddThread(ctx, messages.Thread{AdID: ad.ID, BuyerID: 3, SellerID: 2})
+	assert.NoError(t, err)
+	first, err := repo.AddMessage(ctx, messages.Message{ThreadID: thread.ID, SenderID: 3, Text: "one"})
+	assert.NoError(t, err)
+	_, err = repo.AddMessage(ctx, messages.Message{ThreadID: thread.ID, SenderID: 3, Text: "two"})
+	assert.NoError(t, err)
+	assert.NoError(t, repo.MarkThreadRead(ctx, thread.ID, 2, first.ID))
+	assert.NoError(t, repo.Close())
+
+	repo, err = adrepo.NewPersistent(dir, 0)
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		repo.Close()
+	})
+
+	got, err := repo.GetThread(ctx, thread.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, first.ID, got.SellerReadID)
+	assert.Equal(t, 1, got.SellerUnread)
+
+	next, err := repo.AddMessage(ctx, messages.Message{ThreadID: thread.ID, SenderID: 2, Text: "three"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), next.ID)
+	second, err := repo.AddThread(ctx, messages.Thread{AdID: ad.ID, BuyerID: 4, SellerID: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, thread.ID+1, second.ID)
+}
+
+func messageTexts(page *app.MessagesPage) []string {
+	texts := make([]string, 0, len(page.Messages))
+	for _, message := range page.Messages {
+		texts = append(texts, message.Text)
+	}
+
+	return texts
+}
+
+type threadData struct {
+	ID            int64 `json:"id"`
+	AdID          int64 `json:"ad_id"`
+	SellerID      int64 `json:"seller_id"`
+	LastMessageID int64 `json:"last_message_id"`
+	ReadID        int64 `json:"read_id"`
+	PeerReadID    int64 `json:"peer_read_id"`
+	Unread        int   `json:"unread"`
+}
+
+type threadResponse struct {
+	Data threadData `json:"data"`
+}
+
+type messageData struct {
+	ID       int64  `json:"id"`
+	SenderID int64  `json:"sender_id"`
+	Text     string `json:"text"`
+}
+
+func TestMessages_REST(t *testing.T) {
+	client := getTestClient()
+
+	authorID := client.signIn(t, "Oleg")
+	ad, err := client.createAd(authorID, "hello", "world")
+	assert.NoError(t, err)
+	buyerID := client.signIn(t, "Ivan")
+
+	var thread threadResponse
+	assert.NoError(t, client.postJSON(fmt.Sprintf("/api/v1/ads/%d/thread", ad.Data.ID), nil, &thread))
+	assert.Equal(t, authorID, thread.Data.SellerID)
+
+	messagesPath := fmt.Sprintf("/api/v1/threads/%d/messages", thread.Data.ID)
+	var message struct {
+		Data messageData `json:"data"`
+	}
+	assert.NoError(t, client.postJSON(messagesPath, map[string]any{"text": "is it available?"}, &message))
+	assert.Equal(t, buyerID, message.Data.SenderID)
+	err = client.postJSON(messagesPath, map[string]any{"text": ""}, &message)
+	assert.ErrorIs(t, err, ErrBadRequest)
+
+	assert.NoError(t, client.login(authorID, "Oleg-password"))
+	var unread struct {
+		Data struct {
+			Unread int `json:"unread"`
+		} `json:"data"`
+	}
+	req, err := http.NewRequest(http.MethodGet, client.baseURL+"/api/v1/threads/unread", nil)
+	assert.NoError(t, err)
+	assert.NoError(t, client.getResponse(req, &unread))
+	assert.Equal(t, 1, unread.Data.Unread)
+
+	var threads struct {
+		Data []threadData `json:"data"`
+	}
+	req, err = http.NewRequest(http.MethodGet, client.baseURL+"/api/v1/threads", nil)
+	assert.NoError(t, err)
+	assert.NoError(t, client.getResponse(req, &threads))
+	if assert.Len(t, threads.Data, 1) {
+		assert.Equal(t, 1, threads.Data[0].Unread)
+	}
+
+	var list struct {
+		Data []messageData `json:"data"`
+	}
+	req, err = http.NewRequest(http.MethodGet, client.baseURL+messagesPath, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, client.getResponse(req, &list))
+	if assert.Len(t, list.Data, 1) {
+		assert.Equal(t, "is it available?", list.Data[0].Text)
+	}
+
+	readPath := fmt.Sprintf("/api/v1/threads/%d/read", thread.Data.ID)
+	req, err = http.NewRequest(http.MethodPut, client.baseURL+readPath, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, client.getResponse(req, &thread), "the body is optional")
+	assert.Equal(t, 0, thread.Data.Unread)
+	assert.Equal(t, message.Data.ID, thread.Data.ReadID)
+
+	client.signIn(t, "Petr")
+	req, err = http.NewRequest(http.MethodGet, client.baseURL+messagesPath, nil)
+	assert.NoError(t, err)
+	assert.ErrorIs(t, client.getResponse(req, &list), ErrForbidden)
+}
+
+func TestMessages_GRPCChat(t *testing.T) {
+	a, _, _, _ := newModerationApp(t)
+	_, err := a.CreateUser(context.Background(), "buyer", "buyer-password")
+	assert.NoError(t, err)
+	client, ctx := newGRPCClient(t, a)
+
+	login := func(userID int64, password string) context.Context {
+		token, err := client.Login(ctx, &grpcPort.LoginRequest{UserId: userID, Password: password})
+		assert.NoError(t, err)
+		return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token.GetToken())
+	}
+	authorCtx := login(2, "author-password")
+	buyerCtx := login(3, "buyer-password")
+
+	ad, err := client.CreateAd(authorCtx, &grpcPort.CreateAdRequest{Title: "hello", Text: "world", CategoryId: categories.OtherID})
+	assert.NoError(t, err)
+	thread, err := client.OpenThread(buyerCtx, &grpcPort.OpenThreadRequest{AdId: ad.Id})
+	assert.NoError(t, err)
+
+	authorChat, err := client.Chat(authorCtx)
+	assert.NoError(t, err)
+	buyerChat, err := client.Chat(buyerCtx)
+	assert.NoError(t, err)
+	// Заголовки приходят после подписки, после них события не теряются.
+	_, err = authorChat.Header()
+	assert.NoError(t, err)
+	_, err = buyerChat.Header()
+	assert.NoError(t, err)
+
+	err = buyerChat.Send(&grpcPort.ChatRequest{Action: &grpcPort.ChatRequest_Send{
+		Send: &grpcPort.SendMessageRequest{ThreadId: thread.Id, Text: "is it available?"},
+	}})
+	assert.NoError(t, err)
+
+	e, err := authorChat.Recv()
+	assert.NoError(t, err)
+	assert.Equal(t, grpcPort.ChatEventType_CHAT_EVENT_TYPE_MESSAGE, e.Type)
+	assert.Equal(t, "is it available?", e.GetMessage().GetText())
+	assert.Equal(t, int32(1), e.GetThread().GetUnread())
+	e, err = buyerChat.Recv()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), e.GetMessage().GetSenderId(), "the sender gets own message back")
+	assert.Equal(t, int32(0), e.GetThread().GetUnread())
+
+	threads, err := client.ListThreads(authorCtx, &grpcPort.ListThreadsRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), threads.Unread)
+
+	err = authorChat.Send(&grpcPort.ChatRequest{Action: &grpcPort.ChatRequest_MarkRead{
+		MarkRead: &grpcPort.MarkThreadReadRequest{ThreadId: thread.Id},
+	}})
+	assert.NoError(t, err)
+	e, err = buyerChat.Recv()
+	assert.NoError(t, err)
+	assert.Equal(t, grpcPort.ChatEventType_CHAT_EVENT_TYPE_READ, e.Type)
+	assert.Equal(t, e.GetThread().GetLastMessageId(), e.GetThread().GetPeerReadId())
+
+	err = authorChat.Send(&grpcPort.ChatRequest{Action: &grpcPort.ChatRequest_Send{
+		Send: &grpcPort.SendMessageRequest{ThreadId: thread.Id},
+	}})
+	assert.NoError(t, err)
+	for {
+		_, err = authorChat.Recv()
+		if err != nil {
+			break
+		}
+	}
+	assert.Equal(t, codes.InvalidArgument, status.Code(err), "an invalid request ends the stream")
+
+	list, err := client.ListMessages(buyerCtx, &grpcPort.ListMessagesRequest{ThreadId: thread.Id})
+	assert.NoError(t, err)
+	assert.Len(t, list.Messages, 1)
+	_, err = client.ListMessages(login(1, "moderator-password"), &grpcPort.ListMessagesRequest{ThreadId: thread.Id})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
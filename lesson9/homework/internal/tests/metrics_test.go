@@ -0,0 +1,106 @@
+package tests
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"homework9/internal/categories"
+	"homework9/internal/metrics"
+	"homework9/internal/money"
+	grpcPort "homework9/internal/ports/grpc"
+	"homework9/internal/ports/httpgin"
+)
+
+// scrapeMetrics возвращает метрики в текстовом формате Prometheus.
+func scrapeMetrics(t *testing.T, handler http.Handler) string {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	body, err := io.ReadAll(rec.Body)
+	assert.NoError(t, err)
+	return string(body)
+}
+
+func TestMetrics_Stats(t *testing.T) {
+	a, _, _, author := newModerationApp(t)
+	m := metrics.New(a)
+
+	published, err := a.CreateAd(author, "hello", "world", categories.OtherID, money.Money{})
+	assert.NoError(t, err)
+	_, err = a.ChangeAdStatus(author, published.ID, true, 0)
+	assert.NoError(t, err)
+	_, err = a.CreateAd(author, "draft", "world", categories.OtherID, money.Money{})
+	assert.NoError(t, err)
+	trashed, err := a.CreateAd(author, "trashed", "world", categories.OtherID, money.Money{})
+	assert.NoError(t, err)
+	assert.NoError(t, a.DeleteAd(author, trashed.ID))
+
+	body := scrapeMetrics(t, m.Handler())
+	assert.Contains(t, body, "\nads 2\n")
+	assert.Contains(t, body, "\nads_published 1\n")
+	assert.Contains(t, body, "\nads_trashed 1\n")
+	assert.Contains(t, body, "\nusers 3\n")
+	assert.Contains(t, body, "\nusers_trashed 0\n")
+}
+
+func TestMetrics_HTTP(t *testing.T) {
+	a := newApp(newRepo())
+	server := httpgin.NewHTTPServer(":18080", a, httpgin.WithMetrics(metrics.New(a)))
+	testServer := httptest.NewServer(server.Handler)
+	defer testServer.Close()
+	client := &testClient{client: testServer.Client(), baseURL: testServer.URL}
+
+	_, err := client.createUser("Oleg", "oleg-password")
+	assert.NoError(t, err)
+	_, _, err = client.getAd(100)
+	assert.Error(t, err)
+	resp, err := http.Get(testServer.URL + "/no/such/path")
+	assert.NoError(t, err)
+	resp.Body.Close()
+
+	body := scrapeMetrics(t, server.Handler)
+	assert.Contains(t, body, `http_requests_total{method="POST",route="/api/v1/users",status="200"} 1`)
+	assert.Contains(t, body, `http_requests_total{method="GET",route="/api/v1/ads/:ad_id",status="404"} 1`)
+	assert.Contains(t, body, `http_requests_total{method="GET",route="unmatched",status="404"} 1`)
+	assert.Contains(t, body, `http_request_duration_seconds_count{method="POST",route="/api/v1/users",status="200"} 1`)
+	assert.Contains(t, body, `http_requests_in_flight{method="POST",route="/api/v1/users"} 0`)
+	// Запрос самих метрик еще обрабатывается.
+	assert.Contains(t, body, `http_requests_in_flight{method="GET",route="/metrics"} 1`)
+	assert.Contains(t, body, "\nusers 1\n")
+}
+
+func TestMetrics_GRPC(t *testing.T) {
+	a := newApp(newRepo())
+	m := metrics.New(a)
+	client, ctx := newGRPCClient(t, a, grpcPort.WithMetrics(m))
+
+	_, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Name: "Oleg", Password: "oleg-password"})
+	assert.NoError(t, err)
+	_, err = client.GetUser(ctx, &grpcPort.GetUserRequest{Id: 100})
+	assert.Error(t, err)
+
+	// Поток учитывается как выполняющийся вызов, пока он открыт.
+	streamCtx, cancel := context.WithCancel(ctx)
+	_, err = client.WatchAds(streamCtx, &grpcPort.WatchAdsRequest{})
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		return strings.Contains(scrapeMetrics(t, m.Handler()), `grpc_requests_in_flight{method="/ad.AdService/WatchAds"} 1`)
+	}, time.Second, time.Millisecond)
+	cancel()
+
+	body := scrapeMetrics(t, m.Handler())
+	assert.Contains(t, body, `grpc_requests_total{code="OK",method="/ad.AdService/CreateUser"} 1`)
+	assert.Contains(t, body, `grpc_requests_total{code="NotFound",method="/ad.AdService/GetUser"} 1`)
+	assert.Contains(t, body, `grpc_request_duration_seconds_count{code="OK",method="/ad.AdService/CreateUser"} 1`)
+	assert.Contains(t, body, `grpc_requests_in_flight{method="/ad.AdService/CreateUser"} 0`)
+}
@@ -0,0 +1,248 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"homework9/internal/ads"
+	"homework9/internal/app"
+	"homework9/internal/categories"
+	"homework9/internal/money"
+	grpcPort "homework9/internal/ports/grpc"
+	"homework9/internal/users"
+)
+
+// newModerationApp создает приложение с администратором (ID 0), модератором (ID 1)
+// и обычным пользователем (ID 2).
+func newModerationApp(t *testing.T, opts ...app.Option) (a app.App, admin, moderator, author context.Context) {
+	ctx := context.Background()
+	a = newApp(newRepo(), append(opts, app.WithAdmins(0))...)
+
+	for _, name := range []string{"admin", "moderator", "author"} {
+		_, err := a.CreateUser(ctx, name, name+"-password")
+		assert.NoError(t, err)
+	}
+
+	admin = app.WithUserID(ctx, 0)
+	_, err := a.SetUserRole(admin, 1, users.RoleModerator)
+	assert.NoError(t, err)
+
+	return a, admin, app.WithUserID(ctx, 1), app.WithUserID(ctx, 2)
+}
+
+func TestModeration_Premoderation(t *testing.T) {
+	a, _, moderator, author := newModerationApp(t, app.WithPremoderation())
+
+	ad, err := a.CreateAd(author, "hello", "world", categories.OtherID, money.Money{})
+	assert.NoError(t, err)
+
+	ad, err = a.ChangeAdStatus(author, ad.ID, true, 0)
+	assert.NoError(t, err)
+	assert.False(t, ad.Published)
+	assert.Equal(t, ads.ModerationPending, ad.Moderation)
+
+	list, err := a.ListAds(author, app.AdsQuery{})
+	assert.NoError(t, err)
+	assert.Len(t, list.Ads, 0)
+
+	_, err = a.ModerationQueue(author, 0, "")
+	assert.ErrorIs(t, err, app.ErrForbidden)
+
+	queue, err := a.ModerationQueue(moderator, 0, "")
+	assert.NoError(t, err)
+	assert.Len(t, queue.Ads, 1)
+
+	ad, err = a.ApproveAd(moderator, ad.ID)
+	assert.NoError(t, err)
+	assert.True(t, ad.Published)
+	assert.Equal(t, ads.ModerationApproved, ad.Moderation)
+
+	list, err = a.ListAds(author, app.AdsQuery{})
+	assert.NoError(t, err)
+	assert.Len(t, list.Ads, 1)
+
+	queue, err = a.ModerationQueue(moderator, 0, "")
+	assert.NoError(t, err)
+	assert.Len(t, queue.Ads, 0)
+
+	_, err = a.ApproveAd(moderator, ad.ID)
+	assert.ErrorIs(t, err, app.ErrValidation)
+}
+
+func TestModeration_WithdrawFromQueue(t *testing.T) {
+	a, _, moderator, author := newModerationApp(t, app.WithPremoderation())
+
+	ad, err := a.CreateAd(author, "hello", "world", categories.OtherID, money.Money{})
+	assert.NoError(t, err)
+	_, err = a.ChangeAdStatus(author, ad.ID, true, 0)
+	assert.NoError(t, err)
+
+	ad, err = a.ChangeAdStatus(author, ad.ID, false, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, ads.ModerationNone, ad.Moderation)
+
+	queue, err := a.ModerationQueue(moderator, 0, "")
+	assert.NoError(t, err)
+	assert.Len(t, queue.Ads, 0)
+}
+
+func TestModeration_RejectPublishedAd(t *testing.T) {
+	a, _, moderator, author := newModerationApp(t)
+
+	ad, err := a.CreateAd(author, "hello", "world", categories.OtherID, money.Money{})
+	assert.NoError(t, err)
+	ad, err = a.ChangeAdStatus(author, ad.ID, true, 0)
+	assert.NoError(t, err)
+	assert.True(t, ad.Published)
+
+	_, err = a.RejectAd(moderator, ad.ID, "  ")
+	assert.ErrorIs(t, err, app.ErrValidation)
+
+	_, err = a.RejectAd(author, ad.ID, "spam")
+	assert.ErrorIs(t, err, app.ErrForbidden)
+
+	_, err = a.RejectAd(moderator, ad.ID, "spam")
+	assert.NoError(t, err)
+
+	ad, err = a.GetAd(author, ad.ID)
+	assert.NoError(t, err)
+	assert.False(t, ad.Published)
+	assert.Equal(t, ads.ModerationRejected, ad.Moderation)
+	assert.Equal(t, "spam", ad.ModerationReason)
+
+	// отклоненное объявление без премодерации все равно возвращается в очередь
+	ad, err = a.ChangeAdStatus(author, ad.ID, true, 0)
+	assert.NoError(t, err)
+	assert.False(t, ad.Published)
+	assert.Equal(t, ads.ModerationPending, ad.Moderation)
+	assert.Empty(t, ad.ModerationReason)
+}
+
+func TestModeration_UnpublishedAdVisibility(t *testing.T) {
+	a, admin, moderator, author := newModerationApp(t)
+	stranger := app.WithUserID(context.Background(), 3)
+
+	ad, err := a.CreateAd(author, "hello", "world", categories.OtherID, money.Money{})
+	assert.NoError(t, err)
+	_, err = a.RejectAd(moderator, ad.ID, "spam")
+	assert.NoError(t, err)
+
+	// Отклоненный черновик с причиной видят только автор и модераторы.
+	for _, ctx := range []context.Context{author, moderator, admin} {
+		got, err := a.GetAd(ctx, ad.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, "spam", got.ModerationReason)
+	}
+	for _, ctx := range []context.Context{context.Background(), stranger} {
+		_, err = a.GetAd(ctx, ad.ID)
+		assert.ErrorIs(t, err, app.ErrNotFound)
+	}
+
+	_, err = a.ChangeAdStatus(author, ad.ID, true, 0)
+	assert.NoError(t, err)
+	_, err = a.ApproveAd(moderator, ad.ID)
+	assert.NoError(t, err)
+	_, err = a.GetAd(context.Background(), ad.ID)
+	assert.NoError(t, err)
+}
+
+func TestModeration_SetUserRole(t *testing.T) {
+	a, admin, moderator, _ := newModerationApp(t)
+
+	_, err := a.SetUserRole(moderator, 2, users.RoleModerator)
+	assert.ErrorIs(t, err, app.ErrForbidden)
+
+	_, err = a.SetUserRole(admin, 2, "superuser")
+	assert.ErrorIs(t, err, app.ErrValidation)
+
+	user, err := a.SetUserRole(admin, 2, users.RoleModerator)
+	assert.NoError(t, err)
+	assert.Equal(t, users.RoleModerator, user.Role)
+
+	user, err = a.GetUser(admin, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, users.RoleAdmin, user.Role)
+}
+
+func TestModeration_REST(t *testing.T) {
+	a, _, _, _ := newModerationApp(t, app.WithPremoderation())
+	authorClient := newTestClient(a)
+	moderatorClient := newTestClient(a)
+
+	assert.NoError(t, authorClient.login(2, "author-password"))
+	assert.NoError(t, moderatorClient.login(1, "moderator-password"))
+
+	ad, err := authorClient.createAd(2, "hello", "world")
+	assert.NoError(t, err)
+	ad, err = authorClient.changeAdStatus(2, ad.Data.ID, true)
+	assert.NoError(t, err)
+	assert.Equal(t, "pending", ad.Data.Moderation)
+
+	_, err = authorClient.moderationQueue()
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	queue, err := moderatorClient.moderationQueue()
+	assert.NoError(t, err)
+	assert.Len(t, queue.Data, 1)
+
+	_, err = moderatorClient.rejectAd(ad.Data.ID, "")
+	assert.ErrorIs(t, err, ErrBadRequest)
+
+	ad, err = moderatorClient.rejectAd(ad.Data.ID, "no photos")
+	assert.NoError(t, err)
+	assert.Equal(t, "rejected", ad.Data.Moderation)
+
+	ad, _, err = authorClient.getAd(ad.Data.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "no photos", ad.Data.ModerationReason)
+
+	_, err = authorClient.changeAdStatus(2, ad.Data.ID, true)
+	assert.NoError(t, err)
+	ad, err = moderatorClient.approveAd(ad.Data.ID)
+	assert.NoError(t, err)
+	assert.True(t, ad.Data.Published)
+}
+
+func TestModeration_GRPC(t *testing.T) {
+	a, _, _, _ := newModerationApp(t, app.WithPremoderation())
+	client, ctx := newGRPCClient(t, a)
+
+	login := func(userID int64, password string) context.Context {
+		token, err := client.Login(ctx, &grpcPort.LoginRequest{UserId: userID, Password: password})
+		assert.NoError(t, err)
+		return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token.GetToken())
+	}
+	adminCtx := login(0, "admin-password")
+	moderatorCtx := login(1, "moderator-password")
+	authorCtx := login(2, "author-password")
+
+	_, err := client.SetUserRole(moderatorCtx, &grpcPort.SetUserRoleRequest{UserId: 2, Role: grpcPort.Role_ROLE_ADMIN})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	user, err := client.SetUserRole(adminCtx, &grpcPort.SetUserRoleRequest{UserId: 1, Role: grpcPort.Role_ROLE_MODERATOR})
+	assert.NoError(t, err)
+	assert.Equal(t, grpcPort.Role_ROLE_MODERATOR, user.Role)
+
+	ad, err := client.CreateAd(authorCtx, &grpcPort.CreateAdRequest{Title: "hello", Text: "world", CategoryId: categories.OtherID})
+	assert.NoError(t, err)
+	ad, err = client.ChangeAdStatus(authorCtx, &grpcPort.ChangeAdStatusRequest{AdId: ad.Id, Published: true})
+	assert.NoError(t, err)
+	assert.Equal(t, grpcPort.ModerationStatus_MODERATION_STATUS_PENDING, ad.Moderation)
+
+	queue, err := client.ListModerationQueue(moderatorCtx, &grpcPort.ListAdsRequest{})
+	assert.NoError(t, err)
+	assert.Len(t, queue.List, 1)
+
+	_, err = client.RejectAd(moderatorCtx, &grpcPort.RejectAdRequest{AdId: ad.Id})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	ad, err = client.ApproveAd(moderatorCtx, &grpcPort.ApproveAdRequest{AdId: ad.Id})
+	assert.NoError(t, err)
+	assert.True(t, ad.Published)
+	assert.Equal(t, grpcPort.ModerationStatus_MODERATION_STATUS_APPROVED, ad.Moderation)
+}
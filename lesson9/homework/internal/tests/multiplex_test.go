@@ -0,0 +1,178 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+
+	"homework9/internal/app"
+	"homework9/internal/categories"
+	grpcPort "homework9/internal/ports/grpc"
+	"homework9/internal/ports/httpgin"
+	"homework9/internal/ports/multiplex"
+)
+
+type singlePortServer struct {
+	addr string
+	mux  *multiplex.Mux
+	http *http.Server
+	grpc *grpc.Server
+}
+
+// startSinglePort запускает REST и gRPC на одном порту, как main с флагом -listen.
+func startSinglePort(t *testing.T, a app.App, opts ...multiplex.Option) *singlePortServer {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	s := &singlePortServer{
+		addr: lis.Addr().String(),
+		mux:  multiplex.New(lis, opts...),
+		http: httpgin.NewHTTPServer(lis.Addr().String(), a),
+		grpc: grpcPort.NewGRPCServer(a),
+	}
+	go func() {
+		_ = s.http.Serve(s.mux.HTTP())
+	}()
+	go func() {
+		_ = s.grpc.Serve(s.mux.GRPC())
+	}()
+	go func() {
+		assert.NoError(t, s.mux.Serve())
+	}()
+	t.Cleanup(func() {
+		_ = s.mux.Close()
+		_ = s.http.Close()
+		s.grpc.Stop()
+	})
+
+	return s
+}
+
+func (s *singlePortServer) dialGRPC(t *testing.T) grpcPort.AdServiceClient {
+	return grpcPort.NewAdServiceClient(s.dialGRPCConn(t))
+}
+
+func (s *singlePortServer) dialGRPCConn(t *testing.T) *grpc.ClientConn {
+	conn, err := grpc.Dial(s.addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		conn.Close()
+	})
+
+	return conn
+}
+
+func TestMultiplex_RESTAndGRPC(t *testing.T) {
+	s := startSinglePort(t, newApp(newRepo()))
+	client := s.dialGRPC(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	user, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Name: "Oleg", Password: "oleg-password"})
+	assert.NoError(t, err)
+
+	tc := &testClient{client: http.DefaultClient, baseURL: "http://" + s.addr}
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/api/v1/users/%d", tc.baseURL, user.Id), nil)
+	assert.NoError(t, err)
+	var resp userResponse
+	assert.NoError(t, tc.getResponse(req, &resp))
+	assert.Equal(t, "Oleg", resp.Data.Name)
+
+	created, err := tc.createUser("Ivan", "ivan-password")
+	assert.NoError(t, err)
+	got, err := client.GetUser(ctx, &grpcPort.GetUserRequest{Id: created.Data.ID})
+	assert.NoError(t, err)
+	assert.Equal(t, "Ivan", got.Name)
+}
+
+func TestMultiplex_GracefulShutdown(t *testing.T) {
+	a, _, _, _ := newModerationApp(t)
+	s := startSinglePort(t, a)
+	client := s.dialGRPC(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	token, err := client.Login(ctx, &grpcPort.LoginRequest{UserId: 2, Password: "author-password"})
+	assert.NoError(t, err)
+	authorCtx := metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token.GetToken())
+	_, err = client.CreateAd(authorCtx, &grpcPort.CreateAdRequest{Title: "hello", Text: "world", CategoryId: categories.OtherID})
+	assert.NoError(t, err)
+
+	// Открытый поток чата не дает gRPC серверу остановиться.
+	chatCtx, closeChat := context.WithCancel(authorCtx)
+	defer closeChat()
+	chat, err := client.Chat(chatCtx)
+	assert.NoError(t, err)
+	_, err = chat.Header()
+	assert.NoError(t, err)
+
+	// После закрытия порта открытые соединения продолжают работать, новые не принимаются.
+	assert.NoError(t, s.mux.Close())
+	_, err = client.GetUser(ctx, &grpcPort.GetUserRequest{Id: 2})
+	assert.NoError(t, err)
+	_, err = net.DialTimeout("tcp", s.addr, time.Second)
+	assert.Error(t, err)
+
+	assert.NoError(t, s.http.Shutdown(ctx))
+	stopped := make(chan struct{})
+	go func() {
+		s.grpc.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		t.Fatal("grpc server stopped with an open stream")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	closeChat()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("grpc server did not stop after the stream was closed")
+	}
+}
+
+func TestMultiplex_IdleGRPCConnectionStaysOpen(t *testing.T) {
+	s := startSinglePort(t, newApp(newRepo()), multiplex.WithSniffTimeout(50*time.Millisecond))
+	conn := s.dialGRPCConn(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	conn.Connect()
+	for state := conn.GetState(); state != connectivity.Ready; state = conn.GetState() {
+		if !assert.True(t, conn.WaitForStateChange(ctx, state), "connection is not ready") {
+			return
+		}
+	}
+
+	// Клиент не присылает запросов дольше времени на определение протокола.
+	time.Sleep(300 * time.Millisecond)
+	assert.Equal(t, connectivity.Ready, conn.GetState())
+
+	_, err := grpcPort.NewAdServiceClient(conn).CreateUser(ctx, &grpcPort.CreateUserRequest{Name: "Oleg", Password: "oleg-password"})
+	assert.NoError(t, err)
+}
+
+func TestMultiplex_SilentConnectionIsClosed(t *testing.T) {
+	s := startSinglePort(t, newApp(newRepo()), multiplex.WithSniffTimeout(50*time.Millisecond))
+
+	conn, err := net.Dial("tcp", s.addr)
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	assert.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	_, err = conn.Read(make([]byte, 1))
+	assert.ErrorIs(t, err, io.EOF)
+}
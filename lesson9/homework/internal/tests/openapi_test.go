@@ -0,0 +1,143 @@
+package tests
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"homework9/internal/ports/httpgin"
+)
+
+type openAPIDoc struct {
+	OpenAPI    string                               `json:"openapi"`
+	Paths      map[string]map[string]map[string]any `json:"paths"`
+	Components struct {
+		Schemas   map[string]any `json:"schemas"`
+		Responses map[string]any `json:"responses"`
+	} `json:"components"`
+}
+
+// getOpenAPI запускает сервер со всеми опциями и возвращает его маршруты и спецификацию.
+func getOpenAPI(t *testing.T) ([]gin.RouteInfo, openAPIDoc, []byte) {
+	t.Helper()
+
+	server := httpgin.NewHTTPServer(":18080", newApp(newRepo()))
+	engine, ok := server.Handler.(*gin.Engine)
+	assert.True(t, ok)
+
+	testServer := httptest.NewServer(server.Handler)
+	t.Cleanup(testServer.Close)
+
+	resp, err := http.Get(testServer.URL + "/api/v1/openapi.json")
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, resp.Header.Get("Content-Type"), "application/json")
+
+	raw, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+
+	var doc openAPIDoc
+	assert.NoError(t, json.Unmarshal(raw, &doc))
+
+	return engine.Routes(), doc, raw
+}
+
+var ginParamRe = regexp.MustCompile(`:(\w+)`)
+
+func TestOpenAPI_CoversRoutes(t *testing.T) {
+	routes, doc, _ := getOpenAPI(t)
+	assert.True(t, strings.HasPrefix(doc.OpenAPI, "3."))
+
+	registered := make(map[string]bool)
+	for _, route := range routes {
+		if !strings.HasPrefix(route.Path, "/api/v1/") {
+			continue
+		}
+		path := ginParamRe.ReplaceAllString(strings.TrimPrefix(route.Path, "/api/v1"), "{$1}")
+		method := strings.ToLower(route.Method)
+		registered[method+" "+path] = true
+
+		_, ok := doc.Paths[path][method]
+		assert.True(t, ok, "route %s %s is missing from the OpenAPI spec", route.Method, route.Path)
+	}
+	assert.NotEmpty(t, registered)
+
+	for path, operations := range doc.Paths {
+		for method := range operations {
+			assert.True(t, registered[method+" "+path], "spec documents unknown route %s %s", method, path)
+		}
+	}
+}
+
+func TestOpenAPI_Schemas(t *testing.T) {
+	_, doc, raw := getOpenAPI(t)
+
+	// Все ссылки указывают на существующие компоненты.
+	refs := regexp.MustCompile(`"\$ref":"#/components/(schemas|responses)/(\w+)"`).FindAllStringSubmatch(string(raw), -1)
+	assert.NotEmpty(t, refs)
+	for _, ref := range refs {
+		components := doc.Components.Schemas
+		if ref[1] == "responses" {
+			components = doc.Components.Responses
+		}
+		assert.Contains(t, components, ref[2])
+	}
+
+	errorSchema, ok := doc.Components.Schemas["Error"].(map[string]any)
+	assert.True(t, ok)
+	assert.ElementsMatch(t, []any{"data", "error"}, errorSchema["required"])
+
+	adSchema, ok := doc.Components.Schemas["AdResponse"].(map[string]any)
+	assert.True(t, ok)
+	properties, ok := adSchema["properties"].(map[string]any)
+	assert.True(t, ok)
+	assert.Contains(t, properties, "photos")
+	assert.Contains(t, properties, "favorites")
+	assert.Contains(t, adSchema["required"], "id")
+	assert.NotContains(t, adSchema["required"], "price")
+
+	createAd := doc.Paths["/ads"]["post"]
+	assert.Contains(t, createAd, "requestBody")
+	assert.Contains(t, createAd, "security")
+	assert.NotContains(t, doc.Paths["/ads"]["get"], "security")
+}
+
+func TestOpenAPI_SwaggerUI(t *testing.T) {
+	client := getTestClient()
+
+	for _, path := range []string{"/docs/", "/docs"} {
+		resp, err := client.client.Get(client.baseURL + path)
+		assert.NoError(t, err)
+		body, err := io.ReadAll(resp.Body)
+		assert.NoError(t, err)
+		resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Contains(t, resp.Header.Get("Content-Type"), "text/html")
+		assert.Contains(t, string(body), "/api/v1/openapi.json")
+		assert.NotContains(t, string(body), "https://", "assets are served locally")
+	}
+
+	for path, contentType := range map[string]string{
+		"/docs/swagger-ui.css":       "text/css",
+		"/docs/swagger-ui-bundle.js": "javascript",
+	} {
+		resp, err := client.client.Get(client.baseURL + path)
+		assert.NoError(t, err)
+		body, err := io.ReadAll(resp.Body)
+		assert.NoError(t, err)
+		resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode, path)
+		assert.Contains(t, resp.Header.Get("Content-Type"), contentType, path)
+		assert.NotEmpty(t, body, path)
+	}
+}
@@ -0,0 +1,96 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"homework9/internal/categories"
+	grpcPort "homework9/internal/ports/grpc"
+)
+
+func TestListAds_Pagination(t *testing.T) {
+	client := getTestClient()
+
+	var published []int64
+	for i := 0; i < 5; i++ {
+		published = append(published, createPublishedAd(t, client, "hello", "world").ID)
+		_, err := client.createAd(123, "draft", "not published")
+		assert.NoError(t, err)
+	}
+
+	var got []int64
+	token := ""
+	for pages := 0; ; pages++ {
+		assert.Less(t, pages, 3)
+
+		resp, err := client.listAdsPage(2, token)
+		assert.NoError(t, err)
+		assert.LessOrEqual(t, len(resp.Data), 2)
+		for _, ad := range resp.Data {
+			got = append(got, ad.ID)
+		}
+
+		token = resp.NextPageToken
+		if token == "" {
+			break
+		}
+	}
+
+	assert.Equal(t, published, got)
+}
+
+func TestListAds_PaginationWithConcurrentInsert(t *testing.T) {
+	client := getTestClient()
+
+	first := createPublishedAd(t, client, "first", "ad")
+	second := createPublishedAd(t, client, "second", "ad")
+
+	resp, err := client.listAdsPage(1, "")
+	assert.NoError(t, err)
+	assert.Len(t, resp.Data, 1)
+	assert.Equal(t, first.ID, resp.Data[0].ID)
+	assert.NotEmpty(t, resp.NextPageToken)
+
+	third := createPublishedAd(t, client, "third", "ad")
+
+	resp, err = client.listAdsPage(1, resp.NextPageToken)
+	assert.NoError(t, err)
+	assert.Len(t, resp.Data, 1)
+	assert.Equal(t, second.ID, resp.Data[0].ID)
+
+	resp, err = client.listAdsPage(1, resp.NextPageToken)
+	assert.NoError(t, err)
+	assert.Len(t, resp.Data, 1)
+	assert.Equal(t, third.ID, resp.Data[0].ID)
+	assert.Empty(t, resp.NextPageToken)
+}
+
+func TestListAds_InvalidPageToken(t *testing.T) {
+	client := getTestClient()
+
+	_, err := client.listAdsPage(1, "not a token")
+	assert.ErrorIs(t, err, ErrBadRequest)
+}
+
+func TestGRPCListAds_Pagination(t *testing.T) {
+	client, ctx := getGRPCClient(t)
+
+	for i := 0; i < 3; i++ {
+		ad, err := client.CreateAd(ctx, &grpcPort.CreateAdRequest{Title: "hello", Text: "world", UserId: 123, CategoryId: categories.OtherID})
+		assert.NoError(t, err, "client.CreateAd")
+		_, err = client.ChangeAdStatus(ctx, &grpcPort.ChangeAdStatusRequest{AdId: ad.Id, UserId: 123, Published: true})
+		assert.NoError(t, err, "client.ChangeAdStatus")
+	}
+
+	res, err := client.ListAds(ctx, &grpcPort.ListAdsRequest{PageSize: 2})
+	assert.NoError(t, err, "client.ListAds")
+	assert.Len(t, res.List, 2)
+	assert.NotEmpty(t, res.NextPageToken)
+
+	res, err = client.ListAds(ctx, &grpcPort.ListAdsRequest{PageSize: 2, PageToken: res.NextPageToken})
+	assert.NoError(t, err, "client.ListAds")
+	assert.Len(t, res.List, 1)
+	assert.Equal(t, int64(2), res.List[0].Id)
+	assert.Empty(t, res.NextPageToken)
+}
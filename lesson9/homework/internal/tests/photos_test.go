@@ -0,0 +1,342 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"homework9/internal/adapters/diskblob"
+	"homework9/internal/app"
+	"homework9/internal/categories"
+	"homework9/internal/money"
+	grpcPort "homework9/internal/ports/grpc"
+)
+
+func newPhotoApp(t *testing.T, opts ...app.Option) app.App {
+	blobs, err := diskblob.New(t.TempDir())
+	assert.NoError(t, err)
+
+	return newApp(newRepo(), append(opts, app.WithBlobStore(blobs))...)
+}
+
+func testPNG(t *testing.T, w, h int) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for x := 0; x < w; x++ {
+		img.Set(x, h/2, color.NRGBA{R: 255, A: 255})
+	}
+
+	var buf bytes.Buffer
+	assert.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func testJPEG(t *testing.T, w, h int) []byte {
+	var buf bytes.Buffer
+	assert.NoError(t, jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, w, h)), nil))
+	return buf.Bytes()
+}
+
+type namedFile struct {
+	name string
+	data []byte
+}
+
+func (tc *testClient) addPhotos(adID int64, files ...namedFile) (adResponse, error) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for _, f := range files {
+		fw, err := mw.CreateFormFile("photos", f.name)
+		if err != nil {
+			return adResponse{}, err
+		}
+		if _, err := fw.Write(f.data); err != nil {
+			return adResponse{}, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return adResponse{}, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/api/v1/ads/%d/photos", tc.baseURL, adID), &body)
+	if err != nil {
+		return adResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+
+	var response adResponse
+	err = tc.getResponse(req, &response)
+	return response, err
+}
+
+func (tc *testClient) download(path string) ([]byte, string, error) {
+	req, err := http.NewRequest(http.MethodGet, tc.baseURL+path, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to create request: %w", err)
+	}
+	if tc.token != "" {
+		req.Header.Set("Authorization", "Bearer "+tc.token)
+	}
+
+	resp, err := tc.client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, "", ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("unexpected status code: %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	return data, resp.Header.Get("Content-Type"), err
+}
+
+// signIn создает пользователя и входит от его имени.
+func (tc *testClient) signIn(t *testing.T, name string) int64 {
+	user, err := tc.createUser(name, name+"-password")
+	assert.NoError(t, err)
+	assert.NoError(t, tc.login(user.Data.ID, name+"-password"))
+
+	return user.Data.ID
+}
+
+func TestPhotos_UploadAndThumbnails(t *testing.T) {
+	client := newTestClient(newPhotoApp(t))
+	userID := client.signIn(t, "Oleg")
+
+	ad, err := client.createAd(userID, "hello", "world")
+	assert.NoError(t, err)
+
+	original := testPNG(t, 1000, 500)
+	ad, err = client.addPhotos(ad.Data.ID,
+		namedFile{"wide.png", original},
+		// расширение не важно, тип определяется по содержимому
+		namedFile{"photo.png", testJPEG(t, 100, 300)})
+	assert.NoError(t, err)
+	assert.Len(t, ad.Data.Photos, 2)
+
+	photo := ad.Data.Photos[0]
+	assert.Equal(t, "image/png", photo.ContentType)
+	assert.Equal(t, 1000, photo.Width)
+	assert.Equal(t, 500, photo.Height)
+	assert.Equal(t, "image/jpeg", ad.Data.Photos[1].ContentType)
+
+	data, contentType, err := client.download(photo.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, "image/png", contentType)
+	assert.Equal(t, original, data)
+
+	data, contentType, err = client.download(photo.Thumbnails["small"])
+	assert.NoError(t, err)
+	assert.Equal(t, "image/jpeg", contentType)
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	assert.NoError(t, err)
+	assert.Equal(t, 200, cfg.Width)
+	assert.Equal(t, 100, cfg.Height)
+
+	// маленькие изображения не увеличиваются
+	data, _, err = client.download(ad.Data.Photos[1].Thumbnails["medium"])
+	assert.NoError(t, err)
+	cfg, _, err = image.DecodeConfig(bytes.NewReader(data))
+	assert.NoError(t, err)
+	assert.Equal(t, 100, cfg.Width)
+	assert.Equal(t, 300, cfg.Height)
+}
+
+func TestPhotos_RejectsNonImages(t *testing.T) {
+	client := newTestClient(newPhotoApp(t))
+	userID := client.signIn(t, "Oleg")
+
+	ad, err := client.createAd(userID, "hello", "world")
+	assert.NoError(t, err)
+
+	_, err = client.addPhotos(ad.Data.ID, namedFile{"photo.jpg", []byte("definitely not a jpeg")})
+	assert.ErrorIs(t, err, ErrBadRequest)
+
+	_, err = client.addPhotos(ad.Data.ID)
+	assert.ErrorIs(t, err, ErrBadRequest)
+
+	client.token = ""
+	_, err = client.addPhotos(ad.Data.ID, namedFile{"photo.png", testPNG(t, 10, 10)})
+	assert.ErrorIs(t, err, ErrUnauthorized)
+}
+
+func TestPhotos_Limit(t *testing.T) {
+	a := newPhotoApp(t, app.WithMaxPhotos(2))
+	ctx := app.WithUserID(context.Background(), 123)
+
+	ad, err := a.CreateAd(ctx, "hello", "world", categories.OtherID, money.Money{})
+	assert.NoError(t, err)
+
+	_, err = a.AddPhotos(ctx, ad.ID, [][]byte{testPNG(t, 10, 10), testPNG(t, 10, 10), testPNG(t, 10, 10)})
+	assert.ErrorIs(t, err, app.ErrValidation)
+
+	ad, err = a.AddPhotos(ctx, ad.ID, [][]byte{testPNG(t, 10, 10), testPNG(t, 10, 10)})
+	assert.NoError(t, err)
+	assert.Len(t, ad.Photos, 2)
+
+	_, err = a.AddPhotos(ctx, ad.ID, [][]byte{testPNG(t, 10, 10)})
+	assert.ErrorIs(t, err, app.ErrValidation)
+}
+
+func TestPhotos_ReorderAndDelete(t *testing.T) {
+	a := newPhotoApp(t)
+	ctx := app.WithUserID(context.Background(), 123)
+
+	ad, err := a.CreateAd(ctx, "hello", "world", categories.OtherID, money.Money{})
+	assert.NoError(t, err)
+	ad, err = a.AddPhotos(ctx, ad.ID, [][]byte{testPNG(t, 10, 10), testJPEG(t, 10, 10)})
+	assert.NoError(t, err)
+	first, second := ad.Photos[0].ID, ad.Photos[1].ID
+
+	_, err = a.ReorderPhotos(ctx, ad.ID, []string{first}, 0)
+	assert.ErrorIs(t, err, app.ErrValidation)
+	_, err = a.ReorderPhotos(ctx, ad.ID, []string{first, first}, 0)
+	assert.ErrorIs(t, err, app.ErrValidation)
+
+	_, err = a.ReorderPhotos(app.WithUserID(context.Background(), 1), ad.ID, []string{second, first}, 0)
+	assert.ErrorIs(t, err, app.ErrForbidden)
+
+	ad, err = a.ReorderPhotos(ctx, ad.ID, []string{second, first}, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, second, ad.Photos[0].ID)
+
+	ad, err = a.DeletePhoto(ctx, ad.ID, second)
+	assert.NoError(t, err)
+	assert.Len(t, ad.Photos, 1)
+	assert.Equal(t, first, ad.Photos[0].ID)
+
+	_, err = a.DeletePhoto(ctx, ad.ID, second)
+	assert.ErrorIs(t, err, app.ErrNotFound)
+
+	_, _, err = a.GetPhoto(ctx, ad.ID, second, "original")
+	assert.ErrorIs(t, err, app.ErrNotFound)
+
+	r, contentType, err := a.GetPhoto(ctx, ad.ID, first, "original")
+	assert.NoError(t, err)
+	assert.Equal(t, "image/png", contentType)
+	r.Close()
+}
+
+func TestPhotos_UnpublishedAdVisibility(t *testing.T) {
+	a := newPhotoApp(t)
+	client := newTestClient(a)
+	userID := client.signIn(t, "Oleg")
+
+	ad, err := client.createAd(userID, "hello", "world")
+	assert.NoError(t, err)
+	ad, err = client.addPhotos(ad.Data.ID, namedFile{"photo.png", testPNG(t, 10, 10)})
+	assert.NoError(t, err)
+	url := ad.Data.Photos[0].URL
+
+	_, _, err = client.download(url)
+	assert.NoError(t, err, "author sees photos of a draft")
+
+	// Фотографии черновика не видны ни анонимам, ни другим пользователям.
+	token := client.token
+	client.token = ""
+	_, _, err = client.download(url)
+	assert.ErrorIs(t, err, ErrNotFound)
+	client.signIn(t, "Ivan")
+	_, _, err = client.download(url)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	client.token = token
+	_, err = client.changeAdStatus(userID, ad.Data.ID, true)
+	assert.NoError(t, err)
+	client.token = ""
+	_, _, err = client.download(url)
+	assert.NoError(t, err)
+}
+
+func TestGRPCAddPhotos(t *testing.T) {
+	client, ctx := newGRPCClient(t, newPhotoApp(t), grpcPort.WithLegacyUserID())
+
+	ad, err := client.CreateAd(ctx, &grpcPort.CreateAdRequest{Title: "hello", Text: "world", UserId: 123, CategoryId: categories.OtherID})
+	assert.NoError(t, err)
+
+	_, err = client.AddPhotos(ctx, &grpcPort.AddPhotosRequest{AdId: ad.Id, Photos: [][]byte{testPNG(t, 400, 400)}})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err), "legacy user_id is not accepted for photos")
+
+	user, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Name: "Oleg", Password: "secret"})
+	assert.NoError(t, err)
+	token, err := client.Login(ctx, &grpcPort.LoginRequest{UserId: user.Id, Password: "secret"})
+	assert.NoError(t, err)
+	authCtx := metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token.Token)
+
+	ad, err = client.CreateAd(authCtx, &grpcPort.CreateAdRequest{Title: "hello", Text: "world", CategoryId: categories.OtherID})
+	assert.NoError(t, err)
+
+	ad, err = client.AddPhotos(authCtx, &grpcPort.AddPhotosRequest{AdId: ad.Id, Photos: [][]byte{testPNG(t, 400, 400)}})
+	assert.NoError(t, err)
+	assert.Len(t, ad.Photos, 1)
+	assert.Equal(t, fmt.Sprintf("/api/v1/ads/%d/photos/%s/small", ad.Id, ad.Photos[0].Id), ad.Photos[0].SmallUrl)
+
+	ad, err = client.DeletePhoto(authCtx, &grpcPort.DeletePhotoRequest{AdId: ad.Id, PhotoId: ad.Photos[0].Id})
+	assert.NoError(t, err)
+	assert.Len(t, ad.Photos, 0)
+}
+
+func TestGRPCUploadPhotos(t *testing.T) {
+	client, ctx := newGRPCClient(t, newPhotoApp(t))
+
+	user, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Name: "Oleg", Password: "secret"})
+	assert.NoError(t, err)
+	token, err := client.Login(ctx, &grpcPort.LoginRequest{UserId: user.Id, Password: "secret"})
+	assert.NoError(t, err)
+	authCtx := metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token.Token)
+
+	ad, err := client.CreateAd(authCtx, &grpcPort.CreateAdRequest{Title: "hello", Text: "world", CategoryId: categories.OtherID})
+	assert.NoError(t, err)
+
+	// Сообщение больше лимита по умолчанию (4 МиБ) сервер не принимает.
+	_, err = client.AddPhotos(authCtx, &grpcPort.AddPhotosRequest{AdId: ad.Id, Photos: [][]byte{make([]byte, 5<<20)}})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	upload := func(photos ...[]byte) (*grpcPort.AdResponse, error) {
+		stream, err := client.UploadPhotos(authCtx)
+		assert.NoError(t, err)
+
+		for i, photo := range photos {
+			for len(photo) > 0 {
+				n := len(photo)
+				if n > 1000 {
+					n = 1000
+				}
+				req := &grpcPort.UploadPhotosRequest{Chunk: photo[:n], PhotoEnd: n == len(photo)}
+				if i == 0 {
+					req.AdId = ad.Id
+				}
+				if err := stream.Send(req); err != nil {
+					return nil, err
+				}
+				photo = photo[n:]
+			}
+		}
+
+		return stream.CloseAndRecv()
+	}
+
+	got, err := upload(testPNG(t, 400, 400), testPNG(t, 200, 300))
+	assert.NoError(t, err)
+	assert.Len(t, got.Photos, 2)
+
+	_, err = upload([]byte("not a photo"))
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
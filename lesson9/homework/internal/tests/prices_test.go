@@ -0,0 +1,363 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"homework9/internal/app"
+	"homework9/internal/categories"
+	"homework9/internal/money"
+	grpcPort "homework9/internal/ports/grpc"
+	"homework9/internal/ports/httpgin"
+)
+
+func TestMoney_ParseAndFormat(t *testing.T) {
+	m, err := money.Parse("1500.5", "rub")
+	assert.NoError(t, err)
+	assert.Equal(t, money.Money{Amount: 150050, Currency: "RUB"}, m)
+	assert.Equal(t, "1500.50", m.FormatAmount())
+
+	m, err = money.Parse("0.07", "USD")
+	assert.NoError(t, err)
+	assert.Equal(t, "0.07", m.FormatAmount())
+
+	m, err = money.Parse("1000", "JPY")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1000), m.Amount)
+
+	for _, bad := range []string{"", "-1", "1.005", "1,5", ".5", "1e3", "99999999999999999999"} {
+		_, err := money.Parse(bad, "RUB")
+		assert.ErrorIs(t, err, money.ErrInvalidAmount, bad)
+	}
+	_, err = money.Parse("10", "XXX")
+	assert.ErrorIs(t, err, money.ErrUnknownCurrency)
+	_, err = money.Parse("10.5", "JPY")
+	assert.ErrorIs(t, err, money.ErrInvalidAmount)
+}
+
+func TestMoney_Convert(t *testing.T) {
+	rates, err := money.NewRates([]money.Rate{{Currency: "USD", Value: "90"}, {Currency: "JPY", Value: "0.6"}})
+	assert.NoError(t, err)
+
+	usd, ok := rates.Convert(money.Money{Amount: 100000, Currency: "RUB"}, "USD")
+	assert.True(t, ok)
+	assert.Equal(t, money.Money{Amount: 1111, Currency: "USD"}, usd) // 11.111 -> 11.11
+
+	jpy, ok := rates.Convert(money.Money{Amount: 1, Currency: "USD"}, "JPY")
+	assert.True(t, ok)
+	assert.Equal(t, money.Money{Amount: 2, Currency: "JPY"}, jpy) // 0.9 / 0.6 = 1.5 -> 2
+
+	_, ok = rates.Convert(money.Money{Amount: 100, Currency: "EUR"}, "RUB")
+	assert.False(t, ok, "no EUR rate")
+	_, ok = rates.Convert(money.Money{}, "RUB")
+	assert.False(t, ok, "no price")
+}
+
+// newPriceApp создает приложение с администратором (ID 0), курсом USD 90 и
+// опубликованными объявлениями с ценами 1000 RUB, 15 USD (1350 RUB), без цены
+// и 500 RUB, ID объявлений возвращаются в этом порядке.
+func newPriceApp(t *testing.T) (app.App, context.Context, []int64) {
+	a := newApp(newRepo(), app.WithAdmins(0))
+	_, err := a.CreateUser(context.Background(), "admin", "admin-password")
+	assert.NoError(t, err)
+	admin := app.WithUserID(context.Background(), 0)
+
+	_, err = a.SetExchangeRate(admin, "USD", "90")
+	assert.NoError(t, err)
+
+	prices := []money.Money{
+		{Amount: 100000, Currency: "RUB"},
+		{Amount: 1500, Currency: "USD"},
+		{},
+		{Amount: 50000, Currency: "RUB"},
+	}
+	ids := make([]int64, 0, len(prices))
+	for _, price := range prices {
+		ad, err := a.CreateAd(admin, "hello", "world", categories.OtherID, price)
+		assert.NoError(t, err)
+		_, err = a.ChangeAdStatus(admin, ad.ID, true, 0)
+		assert.NoError(t, err)
+		ids = append(ids, ad.ID)
+	}
+
+	return a, admin, ids
+}
+
+func adIDs(page *app.AdsPage) []int64 {
+	ids := make([]int64, 0, len(page.Ads))
+	for _, ad := range page.Ads {
+		ids = append(ids, ad.ID)
+	}
+
+	return ids
+}
+
+func TestPrices_SortByPrice(t *testing.T) {
+	a, ctx, ids := newPriceApp(t)
+
+	page, err := a.ListAds(ctx, app.AdsQuery{Sort: app.SortByPrice})
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{ids[3], ids[0], ids[1], ids[2]}, adIDs(page))
+
+	// объявление без цены остается в конце и при обратном порядке
+	page, err = a.ListAds(ctx, app.AdsQuery{Sort: app.SortByPrice, Desc: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{ids[1], ids[0], ids[3], ids[2]}, adIDs(page))
+
+	page, err = a.ListAds(ctx, app.AdsQuery{Sort: app.SortByCreated, Desc: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{ids[3], ids[2], ids[1], ids[0]}, adIDs(page))
+
+	_, err = a.ListAds(ctx, app.AdsQuery{Sort: "title"})
+	assert.ErrorIs(t, err, app.ErrValidation)
+}
+
+func TestPrices_SortByUpdated(t *testing.T) {
+	a, ctx, ids := newPriceApp(t)
+
+	_, err := a.UpdateAd(ctx, ids[0], "hello", "updated", 0, money.Money{}, 0)
+	assert.NoError(t, err)
+
+	page, err := a.ListAds(ctx, app.AdsQuery{Sort: app.SortByUpdated, Desc: true, PageSize: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{ids[0]}, adIDs(page))
+}
+
+func TestPrices_Pagination(t *testing.T) {
+	a, ctx, ids := newPriceApp(t)
+
+	var got []int64
+	query := app.AdsQuery{Sort: app.SortByPrice, Desc: true, PageSize: 3}
+	for {
+		page, err := a.ListAds(ctx, query)
+		assert.NoError(t, err)
+		got = append(got, adIDs(page)...)
+		if page.NextPageToken == "" {
+			break
+		}
+		query.PageToken = page.NextPageToken
+	}
+	assert.Equal(t, []int64{ids[1], ids[0], ids[3], ids[2]}, got)
+
+	first, err := a.ListAds(ctx, app.AdsQuery{Sort: app.SortByPrice, PageSize: 1})
+	assert.NoError(t, err)
+	_, err = a.ListAds(ctx, app.AdsQuery{Sort: app.SortByCreated, PageToken: first.NextPageToken})
+	assert.ErrorIs(t, err, app.ErrValidation)
+	_, err = a.ListAds(ctx, app.AdsQuery{PageToken: first.NextPageToken})
+	assert.ErrorIs(t, err, app.ErrValidation)
+}
+
+func TestPrices_ExactCrossCurrencyOrder(t *testing.T) {
+	a, ctx, _ := newPriceApp(t)
+	_, err := a.SetExchangeRate(ctx, "USD", "92.35")
+	assert.NoError(t, err)
+
+	// 100 USD по курсу 92.35 ровно 9235 RUB, цены сравниваются без округления.
+	var ids []int64
+	for _, price := range []money.Money{
+		{Amount: 923501, Currency: "RUB"},
+		{Amount: 10000, Currency: "USD"},
+		{Amount: 923500, Currency: "RUB"},
+		{Amount: 923499, Currency: "RUB"},
+	} {
+		ad, err := a.CreateAd(ctx, "hello", "world", categories.OtherID, price)
+		assert.NoError(t, err)
+		_, err = a.ChangeAdStatus(ctx, ad.ID, true, 0)
+		assert.NoError(t, err)
+		ids = append(ids, ad.ID)
+	}
+
+	var got []int64
+	query := app.AdsQuery{
+		MinPrice: money.Money{Amount: 923499, Currency: "RUB"},
+		MaxPrice: money.Money{Amount: 10001, Currency: "USD"},
+		Sort:     app.SortByPrice,
+		PageSize: 1,
+	}
+	for {
+		page, err := a.ListAds(ctx, query)
+		assert.NoError(t, err)
+		got = append(got, adIDs(page)...)
+		if page.NextPageToken == "" {
+			break
+		}
+		query.PageToken = page.NextPageToken
+	}
+	assert.Equal(t, []int64{ids[3], ids[1], ids[2], ids[0]}, got)
+
+	page, err := a.ListAds(ctx, app.AdsQuery{
+		MinPrice: money.Money{Amount: 10000, Currency: "USD"},
+		MaxPrice: money.Money{Amount: 923500, Currency: "RUB"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{ids[1], ids[2]}, adIDs(page))
+}
+
+func TestPrices_CursorSurvivesRateChange(t *testing.T) {
+	a, ctx, ids := newPriceApp(t)
+	_, err := a.SetExchangeRate(ctx, "USD", "90.001")
+	assert.NoError(t, err)
+
+	first, err := a.ListAds(ctx, app.AdsQuery{Sort: app.SortByPrice, Desc: true, PageSize: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{ids[1]}, adIDs(first))
+
+	// Курсор хранит 1350.015 RUB - после смены курса такой цены в копейках нет.
+	_, err = a.SetExchangeRate(ctx, "USD", "91")
+	assert.NoError(t, err)
+
+	page, err := a.ListAds(ctx, app.AdsQuery{Sort: app.SortByPrice, Desc: true, PageToken: first.NextPageToken})
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{ids[0], ids[3], ids[2]}, adIDs(page))
+}
+
+func TestPrices_FilterAndDisplayCurrency(t *testing.T) {
+	a, ctx, ids := newPriceApp(t)
+
+	page, err := a.ListAds(ctx, app.AdsQuery{
+		MinPrice: money.Money{Amount: 1200, Currency: "USD"}, // 1080 RUB
+		Currency: "USD",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{ids[1]}, adIDs(page))
+	assert.Equal(t, money.Money{Amount: 1500, Currency: "USD"}, page.DisplayPrices[ids[1]])
+
+	page, err = a.ListAds(ctx, app.AdsQuery{
+		MinPrice: money.Money{Amount: 50000, Currency: "RUB"},
+		MaxPrice: money.Money{Amount: 100000, Currency: "RUB"},
+		Currency: "USD",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{ids[0], ids[3]}, adIDs(page))
+	assert.Equal(t, money.Money{Amount: 1111, Currency: "USD"}, page.DisplayPrices[ids[0]])
+	assert.Equal(t, money.Money{Amount: 556, Currency: "USD"}, page.DisplayPrices[ids[3]])
+
+	_, err = a.ListAds(ctx, app.AdsQuery{Currency: "EUR"})
+	assert.ErrorIs(t, err, app.ErrValidation, "no EUR rate")
+	_, err = a.ListAds(ctx, app.AdsQuery{MinPrice: money.Money{Amount: 1, Currency: "EUR"}})
+	assert.ErrorIs(t, err, app.ErrValidation, "no EUR rate")
+}
+
+func TestPrices_ExchangeRates(t *testing.T) {
+	a, admin, _ := newPriceApp(t)
+	_, err := a.CreateUser(context.Background(), "user", "user-password")
+	assert.NoError(t, err)
+
+	_, err = a.SetExchangeRate(app.WithUserID(context.Background(), 1), "EUR", "100")
+	assert.ErrorIs(t, err, app.ErrForbidden)
+	_, err = a.SetExchangeRate(admin, money.Base, "2")
+	assert.ErrorIs(t, err, app.ErrValidation)
+	_, err = a.SetExchangeRate(admin, "EUR", "-1")
+	assert.ErrorIs(t, err, app.ErrValidation)
+	_, err = a.SetExchangeRate(admin, "EUR", "1.0000000000000000000001")
+	assert.ErrorIs(t, err, app.ErrValidation, "rate too precise to compare prices")
+
+	_, err = a.SetExchangeRate(admin, "EUR", "98.5")
+	assert.NoError(t, err)
+	_, err = a.SetExchangeRate(admin, "USD", "91.25")
+	assert.NoError(t, err)
+
+	rates, err := a.ExchangeRates(admin)
+	assert.NoError(t, err)
+	assert.Equal(t, []money.Rate{{Currency: "EUR", Value: "98.5"}, {Currency: "USD", Value: "91.25"}}, rates)
+}
+
+func TestPrices_InvalidPrice(t *testing.T) {
+	a, ctx, ids := newPriceApp(t)
+
+	_, err := a.CreateAd(ctx, "hello", "world", categories.OtherID, money.Money{Amount: 100})
+	assert.ErrorIs(t, err, app.ErrValidation)
+	_, err = a.CreateAd(ctx, "hello", "world", categories.OtherID, money.Money{Amount: -1, Currency: "RUB"})
+	assert.ErrorIs(t, err, app.ErrValidation)
+
+	ad, err := a.UpdateAd(ctx, ids[2], "hello", "world", 0, money.Money{Amount: 700, Currency: "EUR"}, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, money.Money{Amount: 700, Currency: "EUR"}, ad.Price)
+
+	ad, err = a.UpdateAd(ctx, ids[2], "hello", "world", 0, money.Money{}, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, money.Money{Amount: 700, Currency: "EUR"}, ad.Price, "zero price keeps the current one")
+}
+
+func TestPrices_REST(t *testing.T) {
+	a, _, ids := newPriceApp(t)
+	client := newTestClient(a, httpgin.WithLegacyUserID())
+
+	var created adResponse
+	err := client.postJSON("/api/v1/ads", map[string]any{
+		"user_id": 0, "title": "hello", "text": "world", "category_id": categories.OtherID,
+		"price": "1500.5", "currency": "rub",
+	}, &created)
+	assert.NoError(t, err)
+	assert.Equal(t, "1500.50", created.Data.Price)
+	assert.Equal(t, "RUB", created.Data.Currency)
+
+	err = client.postJSON("/api/v1/ads", map[string]any{
+		"user_id": 0, "title": "hello", "text": "world", "category_id": categories.OtherID,
+		"price": "15,5", "currency": "RUB",
+	}, &created)
+	assert.ErrorIs(t, err, ErrBadRequest)
+
+	list, err := client.listAdsQuery(url.Values{"sort": {"price"}, "order": {"desc"}, "currency": {"usd"}})
+	assert.NoError(t, err)
+	assert.Len(t, list.Data, 4)
+	assert.Equal(t, ids[1], list.Data[0].ID)
+	assert.Equal(t, "15.00", list.Data[0].DisplayPrice)
+	assert.Equal(t, "USD", list.Data[0].DisplayCurrency)
+	assert.Equal(t, ids[2], list.Data[3].ID)
+	assert.Equal(t, "", list.Data[3].Price)
+
+	list, err = client.listAdsQuery(url.Values{"max_price": {"999.99"}})
+	assert.NoError(t, err)
+	assert.Len(t, list.Data, 1)
+	assert.Equal(t, ids[3], list.Data[0].ID)
+
+	_, err = client.listAdsQuery(url.Values{"order": {"random"}})
+	assert.ErrorIs(t, err, ErrBadRequest)
+
+	var rates struct {
+		Data []struct {
+			Currency string `json:"currency"`
+			Rate     string `json:"rate"`
+		} `json:"data"`
+	}
+	req, err := http.NewRequest(http.MethodGet, client.baseURL+"/api/v1/rates", nil)
+	assert.NoError(t, err)
+	assert.NoError(t, client.getResponse(req, &rates))
+	assert.Len(t, rates.Data, 1)
+	assert.Equal(t, "USD", rates.Data[0].Currency)
+	assert.Equal(t, "90", rates.Data[0].Rate)
+}
+
+func TestGRPCPrices(t *testing.T) {
+	a, _, ids := newPriceApp(t)
+	client, ctx := newGRPCClient(t, a, grpcPort.WithLegacyUserID())
+
+	ad, err := client.CreateAd(ctx, &grpcPort.CreateAdRequest{
+		Title: "hello", Text: "world", CategoryId: categories.OtherID,
+		Price: &grpcPort.Money{Amount: 99, Currency: "EUR"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(99), ad.GetPrice().GetAmount())
+	assert.NotNil(t, ad.GetCreatedAt())
+
+	list, err := client.ListAds(ctx, &grpcPort.ListAdsRequest{
+		Sort:     grpcPort.AdSort_AD_SORT_PRICE,
+		MinPrice: &grpcPort.Money{Amount: 60000, Currency: "RUB"},
+		Currency: "USD",
+	})
+	assert.NoError(t, err)
+	assert.Len(t, list.List, 2)
+	assert.Equal(t, ids[0], list.List[0].Id)
+	assert.Equal(t, int64(1111), list.List[0].GetDisplayPrice().GetAmount())
+	assert.Equal(t, ids[1], list.List[1].Id)
+
+	rates, err := client.ListExchangeRates(ctx, &emptypb.Empty{})
+	assert.NoError(t, err)
+	assert.Len(t, rates.Rates, 1)
+}
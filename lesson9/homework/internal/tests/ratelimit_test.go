@@ -0,0 +1,141 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"homework9/internal/categories"
+	grpcPort "homework9/internal/ports/grpc"
+	"homework9/internal/ports/httpgin"
+	"homework9/internal/ratelimit"
+)
+
+func TestRateLimit_ParseLimit(t *testing.T) {
+	limit, err := ratelimit.ParseLimit("100/1m")
+	assert.NoError(t, err)
+	assert.Equal(t, ratelimit.Limit{Requests: 100, Per: time.Minute}, limit)
+	assert.Equal(t, "100/1m0s", limit.String())
+
+	limit, err = ratelimit.ParseLimit("0")
+	assert.NoError(t, err)
+	assert.True(t, limit.Unlimited())
+
+	for _, s := range []string{"100", "x/1m", "-1/1m", "10/0s", "10/soon"} {
+		_, err = ratelimit.ParseLimit(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestRateLimit_TokenBucket(t *testing.T) {
+	clock := newFakeClock()
+	l := ratelimit.New(ratelimit.Limits{
+		Write: ratelimit.Limit{Requests: 2, Per: time.Second},
+	}, ratelimit.WithNow(clock.Now))
+
+	for i := 0; i < 2; i++ {
+		ok, _ := l.Allow(ratelimit.Write, "user:1")
+		assert.True(t, ok, "burst up to the limit")
+	}
+	ok, wait := l.Allow(ratelimit.Write, "user:1")
+	assert.False(t, ok)
+	assert.Equal(t, 500*time.Millisecond, wait)
+	assert.Equal(t, 1, ratelimit.RetryAfterSeconds(wait))
+
+	ok, _ = l.Allow(ratelimit.Write, "user:2")
+	assert.True(t, ok, "buckets are per key")
+	for i := 0; i < 10; i++ {
+		ok, _ = l.Allow(ratelimit.Read, "user:1")
+		assert.True(t, ok, "zero limit is unlimited")
+	}
+
+	clock.Advance(500 * time.Millisecond)
+	ok, _ = l.Allow(ratelimit.Write, "user:1")
+	assert.True(t, ok, "a token is refilled")
+	ok, _ = l.Allow(ratelimit.Write, "user:1")
+	assert.False(t, ok)
+
+	clock.Advance(time.Hour)
+	for i := 0; i < 2; i++ {
+		ok, _ = l.Allow(ratelimit.Write, "user:1")
+		assert.True(t, ok, "the bucket does not exceed its capacity")
+	}
+	ok, _ = l.Allow(ratelimit.Write, "user:1")
+	assert.False(t, ok)
+}
+
+func TestRateLimit_REST(t *testing.T) {
+	clock := newFakeClock()
+	limiter := ratelimit.New(ratelimit.Limits{
+		Create: ratelimit.Limit{Requests: 1, Per: time.Minute},
+	}, ratelimit.WithNow(clock.Now))
+	client := newTestClient(newApp(newRepo()), httpgin.WithRateLimiter(limiter))
+
+	user, err := client.createUser("Oleg", "secret")
+	assert.NoError(t, err)
+	_, err = client.createUser("Ivan", "secret")
+	assert.ErrorIs(t, err, ErrTooManyRequests, "anonymous clients are limited by IP")
+
+	req, err := http.NewRequest(http.MethodPost, client.baseURL+"/api/v1/users", nil)
+	assert.NoError(t, err)
+	resp, err := client.client.Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, "60", resp.Header.Get("Retry-After"))
+
+	// после входа у пользователя свое ведро, не общее с его IP
+	assert.NoError(t, client.login(user.Data.ID, "secret"))
+	ad, err := client.createAd(user.Data.ID, "hello", "world")
+	assert.NoError(t, err)
+	_, err = client.createAd(user.Data.ID, "hello", "again")
+	assert.ErrorIs(t, err, ErrTooManyRequests)
+
+	_, err = client.updateAd(user.Data.ID, ad.Data.ID, "hello", "again")
+	assert.NoError(t, err, "writes have their own limit")
+	_, _, err = client.getAd(ad.Data.ID)
+	assert.NoError(t, err, "reads have their own limit")
+
+	clock.Advance(time.Minute)
+	_, err = client.createAd(user.Data.ID, "hello", "again")
+	assert.NoError(t, err)
+}
+
+func TestRateLimit_GRPC(t *testing.T) {
+	clock := newFakeClock()
+	limiter := ratelimit.New(ratelimit.Limits{
+		Create: ratelimit.Limit{Requests: 1, Per: time.Minute},
+		Read:   ratelimit.Limit{Requests: 2, Per: time.Minute},
+	}, ratelimit.WithNow(clock.Now))
+	client, ctx := newGRPCClient(t, newApp(newRepo()), grpcPort.WithRateLimiter(limiter))
+
+	user, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Name: "Oleg"})
+	assert.NoError(t, err)
+	_, err = client.CreateUser(ctx, &grpcPort.CreateUserRequest{Name: "Ivan"})
+	st := status.Convert(err)
+	assert.Equal(t, codes.ResourceExhausted, st.Code())
+	if assert.Len(t, st.Details(), 1) {
+		info, ok := st.Details()[0].(*errdetails.RetryInfo)
+		if assert.True(t, ok) {
+			assert.Equal(t, time.Minute, info.GetRetryDelay().AsDuration())
+		}
+	}
+
+	_, err = client.CreateAd(ctx, &grpcPort.CreateAdRequest{UserId: user.Id, Title: "hello", Text: "world", CategoryId: categories.OtherID})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err), "CreateAd shares the create limit")
+
+	for i := 0; i < 2; i++ {
+		_, err = client.GetUser(ctx, &grpcPort.GetUserRequest{Id: user.Id})
+		assert.NoError(t, err)
+	}
+	_, err = client.ListAds(ctx, &grpcPort.ListAdsRequest{})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	_, err = client.DeleteUser(ctx, &grpcPort.DeleteUserRequest{Id: user.Id})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err), "writes are unlimited")
+}
@@ -0,0 +1,283 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"homework9/internal/ads"
+	"homework9/internal/app"
+	"homework9/internal/categories"
+	"homework9/internal/logging"
+	"homework9/internal/money"
+	grpcPort "homework9/internal/ports/grpc"
+)
+
+// runScheduler запускает планировщик до конца теста.
+func runScheduler(t *testing.T, a app.App) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		a.RunScheduler(ctx)
+		close(done)
+	}()
+
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+// waitAd ждет, пока объявление не будет удовлетворять cond.
+func waitAd(t *testing.T, a app.App, ctx context.Context, adID int64, cond func(ad *ads.Ad) bool) {
+	assert.Eventually(t, func() bool {
+		ad, err := a.GetAd(ctx, adID)
+		return err == nil && cond(ad)
+	}, time.Second, time.Millisecond)
+}
+
+func TestSchedule_Validation(t *testing.T) {
+	clock := newFakeClock()
+	a, _, moderator, author := newModerationApp(t, app.WithClock(clock))
+
+	ad, err := a.CreateAd(author, "hello", "world", categories.OtherID, money.Money{})
+	assert.NoError(t, err)
+
+	now := clock.Now()
+	_, err = a.ScheduleAd(author, ad.ID, now.Add(-time.Minute), time.Time{}, 0)
+	assert.ErrorIs(t, err, app.ErrValidation)
+	_, err = a.ScheduleAd(author, ad.ID, time.Time{}, now, 0)
+	assert.ErrorIs(t, err, app.ErrValidation)
+	_, err = a.ScheduleAd(author, ad.ID, now.Add(2*time.Hour), now.Add(time.Hour), 0)
+	assert.ErrorIs(t, err, app.ErrValidation)
+	_, err = a.ScheduleAd(moderator, ad.ID, now.Add(time.Hour), time.Time{}, 0)
+	assert.ErrorIs(t, err, app.ErrForbidden)
+	_, err = a.ScheduleAd(author, ad.ID, now.Add(time.Hour), time.Time{}, ad.Version+1)
+	assert.ErrorIs(t, err, app.ErrVersionConflict)
+
+	scheduled, err := a.ScheduleAd(author, ad.ID, now.Add(time.Hour), now.Add(2*time.Hour), 0)
+	assert.NoError(t, err)
+	assert.Equal(t, now.Add(time.Hour), scheduled.PublishAt)
+	assert.Equal(t, now.Add(2*time.Hour), scheduled.UnpublishAt)
+	assert.Equal(t, now, scheduled.UpdatedAt, "dates come from the injected clock")
+
+	cleared, err := a.ScheduleAd(author, ad.ID, time.Time{}, time.Time{}, 0)
+	assert.NoError(t, err)
+	assert.False(t, cleared.Scheduled())
+}
+
+func TestSchedule_PublishAndUnpublish(t *testing.T) {
+	clock := newFakeClock()
+	a, _, _, author := newModerationApp(t, app.WithClock(clock))
+	runScheduler(t, a)
+
+	ad, err := a.CreateAd(author, "hello", "world", categories.OtherID, money.Money{})
+	assert.NoError(t, err)
+	now := clock.Now()
+	_, err = a.ScheduleAd(author, ad.ID, now.Add(time.Hour), now.Add(3*time.Hour), 0)
+	assert.NoError(t, err)
+
+	clock.Advance(59 * time.Minute)
+	got, err := a.GetAd(author, ad.ID)
+	assert.NoError(t, err)
+	assert.False(t, got.Published)
+
+	clock.Advance(time.Minute)
+	waitAd(t, a, author, ad.ID, func(ad *ads.Ad) bool { return ad.Published })
+	got, err = a.GetAd(author, ad.ID)
+	assert.NoError(t, err)
+	assert.True(t, got.PublishAt.IsZero())
+	assert.Equal(t, now.Add(3*time.Hour), got.UnpublishAt)
+
+	list, err := a.ListAds(author, app.AdsQuery{})
+	assert.NoError(t, err)
+	assert.Len(t, list.Ads, 1)
+
+	clock.Advance(2 * time.Hour)
+	waitAd(t, a, author, ad.ID, func(ad *ads.Ad) bool { return !ad.Published && !ad.Scheduled() })
+
+	history, err := a.AdHistory(author, ad.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), history[len(history)-1].UserID, "changes are made on behalf of the author")
+}
+
+func TestSchedule_WakesOnNewSchedule(t *testing.T) {
+	clock := newFakeClock()
+	a, _, _, author := newModerationApp(t, app.WithClock(clock))
+	runScheduler(t, a)
+
+	first, err := a.CreateAd(author, "first", "ad", categories.OtherID, money.Money{})
+	assert.NoError(t, err)
+	second, err := a.CreateAd(author, "second", "ad", categories.OtherID, money.Money{})
+	assert.NoError(t, err)
+
+	now := clock.Now()
+	_, err = a.ScheduleAd(author, first.ID, now.Add(24*time.Hour), time.Time{}, 0)
+	assert.NoError(t, err)
+	// Планировщик спит до первого объявления и должен проснуться ради более раннего.
+	_, err = a.ScheduleAd(author, second.ID, now.Add(time.Hour), time.Time{}, 0)
+	assert.NoError(t, err)
+
+	clock.Advance(time.Hour)
+	waitAd(t, a, author, second.ID, func(ad *ads.Ad) bool { return ad.Published })
+
+	got, err := a.GetAd(author, first.ID)
+	assert.NoError(t, err)
+	assert.False(t, got.Published)
+}
+
+func TestSchedule_SurvivesRestart(t *testing.T) {
+	clock := newFakeClock()
+	repo := newRepo()
+
+	before := newApp(repo, app.WithClock(clock), app.WithPremoderation())
+	user, err := before.CreateUser(context.Background(), "author", "")
+	assert.NoError(t, err)
+	author := app.WithUserID(context.Background(), user.ID)
+
+	published, err := before.CreateAd(author, "published", "ad", categories.OtherID, money.Money{})
+	assert.NoError(t, err)
+	both, err := before.CreateAd(author, "both", "ad", categories.OtherID, money.Money{})
+	assert.NoError(t, err)
+
+	now := clock.Now()
+	_, err = before.ScheduleAd(author, published.ID, now.Add(time.Hour), time.Time{}, 0)
+	assert.NoError(t, err)
+	_, err = before.ScheduleAd(author, both.ID, now.Add(time.Hour), now.Add(2*time.Hour), 0)
+	assert.NoError(t, err)
+
+	// Сервис был остановлен, пока наступали оба времени.
+	clock.Advance(3 * time.Hour)
+	after := newApp(repo, app.WithClock(clock), app.WithPremoderation())
+	runScheduler(t, after)
+
+	waitAd(t, after, author, published.ID, func(ad *ads.Ad) bool { return !ad.Scheduled() })
+	got, err := after.GetAd(author, published.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, ads.ModerationPending, got.Moderation, "scheduled publication goes through premoderation")
+
+	waitAd(t, after, author, both.ID, func(ad *ads.Ad) bool { return !ad.Scheduled() })
+	got, err = after.GetAd(author, both.ID)
+	assert.NoError(t, err)
+	assert.False(t, got.Published)
+	assert.Equal(t, ads.ModerationNone, got.Moderation, "unpublishing withdraws the ad from the queue")
+}
+
+// brokenAdRepo не может сохранить объявление adID, пока broken.
+type brokenAdRepo struct {
+	app.Repository
+	adID   int64
+	broken *atomic.Bool
+}
+
+func (r brokenAdRepo) UpdateAd(ctx context.Context, ad ads.Ad, revision *ads.Revision) error {
+	if ad.ID == r.adID && r.broken.Load() {
+		return errors.New("ad storage is broken")
+	}
+
+	return r.Repository.UpdateAd(ctx, ad, revision)
+}
+
+func TestSchedule_FailedAdDoesNotBlockOthers(t *testing.T) {
+	clock := newFakeClock()
+	var broken atomic.Bool
+	// Объявление 0 создается первым и первым обрабатывается планировщиком.
+	repo := brokenAdRepo{Repository: newRepo(), adID: 0, broken: &broken}
+	a := newApp(repo, app.WithClock(clock), app.WithLogger(logging.New(io.Discard)))
+	user, err := a.CreateUser(context.Background(), "author", "")
+	assert.NoError(t, err)
+	author := app.WithUserID(context.Background(), user.ID)
+
+	failing, err := a.CreateAd(author, "failing", "ad", categories.OtherID, money.Money{})
+	assert.NoError(t, err)
+	other, err := a.CreateAd(author, "other", "ad", categories.OtherID, money.Money{})
+	assert.NoError(t, err)
+
+	now := clock.Now()
+	_, err = a.ScheduleAd(author, failing.ID, now.Add(time.Hour), time.Time{}, 0)
+	assert.NoError(t, err)
+	_, err = a.ScheduleAd(author, other.ID, now.Add(time.Hour), time.Time{}, 0)
+	assert.NoError(t, err)
+	broken.Store(true)
+	runScheduler(t, a)
+
+	clock.Advance(time.Hour)
+	waitAd(t, a, author, other.ID, func(ad *ads.Ad) bool { return ad.Published })
+	got, err := a.GetAd(author, failing.ID)
+	assert.NoError(t, err)
+	assert.False(t, got.Published)
+
+	// Изменение повторяется через минуту.
+	broken.Store(false)
+	clock.Advance(time.Minute)
+	waitAd(t, a, author, failing.ID, func(ad *ads.Ad) bool { return ad.Published })
+}
+
+func TestSchedule_REST(t *testing.T) {
+	clock := newFakeClock()
+	client := newTestClient(newApp(newRepo(), app.WithClock(clock)))
+
+	user, err := client.createUser("Oleg", "secret")
+	assert.NoError(t, err)
+	assert.NoError(t, client.login(user.Data.ID, "secret"))
+	ad, err := client.createAd(user.Data.ID, "hello", "world")
+	assert.NoError(t, err)
+
+	path := fmt.Sprintf("/api/v1/ads/%d/schedule", ad.Data.ID)
+	publishAt := clock.Now().Add(time.Hour)
+
+	var scheduled adResponse
+	err = client.putJSON(path, map[string]any{"publish_at": publishAt.Format(time.RFC3339)}, &scheduled)
+	assert.NoError(t, err)
+	if assert.NotNil(t, scheduled.Data.PublishAt) {
+		assert.Equal(t, publishAt.Format(time.RFC3339), *scheduled.Data.PublishAt)
+	}
+	assert.Nil(t, scheduled.Data.UnpublishAt)
+
+	err = client.putJSON(path, map[string]any{"publish_at": "yesterday"}, &scheduled)
+	assert.ErrorIs(t, err, ErrBadRequest)
+
+	var cleared adResponse
+	err = client.putJSON(path, map[string]any{"publish_at": nil}, &cleared)
+	assert.NoError(t, err)
+	assert.Nil(t, cleared.Data.PublishAt)
+}
+
+func TestSchedule_GRPC(t *testing.T) {
+	clock := newFakeClock()
+	a, _, _, _ := newModerationApp(t, app.WithClock(clock))
+	client, ctx := newGRPCClient(t, a)
+
+	token, err := client.Login(ctx, &grpcPort.LoginRequest{UserId: 2, Password: "author-password"})
+	assert.NoError(t, err)
+	authorCtx := metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token.GetToken())
+
+	ad, err := client.CreateAd(authorCtx, &grpcPort.CreateAdRequest{Title: "hello", Text: "world", CategoryId: categories.OtherID})
+	assert.NoError(t, err)
+
+	unpublishAt := clock.Now().Add(time.Hour)
+	ad, err = client.ScheduleAd(authorCtx, &grpcPort.ScheduleAdRequest{
+		AdId:            ad.Id,
+		UnpublishAt:     timestamppb.New(unpublishAt),
+		ExpectedVersion: ad.Version,
+	})
+	assert.NoError(t, err)
+	assert.Nil(t, ad.PublishAt)
+	assert.True(t, unpublishAt.Equal(ad.UnpublishAt.AsTime()))
+
+	_, err = client.ScheduleAd(authorCtx, &grpcPort.ScheduleAdRequest{
+		AdId:      ad.Id,
+		PublishAt: timestamppb.New(clock.Now().Add(-time.Hour)),
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
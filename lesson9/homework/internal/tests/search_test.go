@@ -0,0 +1,186 @@
+package tests
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"homework9/internal/app"
+	"homework9/internal/categories"
+	"homework9/internal/money"
+	"homework9/internal/search"
+)
+
+func createPublishedAd(t *testing.T, client *testClient, title string, text string) adData {
+	t.Helper()
+
+	resp, err := client.createAd(123, title, text)
+	assert.NoError(t, err)
+
+	resp, err = client.changeAdStatus(123, resp.Data.ID, true)
+	assert.NoError(t, err)
+
+	return resp.Data
+}
+
+func TestSearchAds(t *testing.T) {
+	client := getTestClient()
+
+	cat := createPublishedAd(t, client, "Продаю кошку", "Пушистая кошка, приучена к лотку")
+	dog := createPublishedAd(t, client, "Продаю собаку", "Добрая собака, дружит с кошками")
+	_ = createPublishedAd(t, client, "Велосипед", "Почти новый")
+
+	resp, err := client.searchAds("Кошки")
+	assert.NoError(t, err)
+	assert.Len(t, resp.Data, 2)
+	assert.Equal(t, cat.ID, resp.Data[0].Ad.ID)
+	assert.Equal(t, dog.ID, resp.Data[1].Ad.ID)
+	assert.Greater(t, resp.Data[0].Score, resp.Data[1].Score)
+	assert.Equal(t, "Продаю <em>кошку</em>", resp.Data[0].TitleHighlight)
+	assert.Equal(t, "Пушистая <em>кошка</em>, приучена к лотку", resp.Data[0].TextSnippet)
+}
+
+func TestSearchAds_English(t *testing.T) {
+	client := getTestClient()
+
+	ad := createPublishedAd(t, client, "Running shoes", "Barely used, size 42")
+
+	resp, err := client.searchAds("RUN")
+	assert.NoError(t, err)
+	assert.Len(t, resp.Data, 1)
+	assert.Equal(t, ad.ID, resp.Data[0].Ad.ID)
+	assert.Equal(t, "<em>Running</em> shoes", resp.Data[0].TitleHighlight)
+}
+
+func TestSearchAds_OnlyPublished(t *testing.T) {
+	client := getTestClient()
+
+	ad := createPublishedAd(t, client, "hello", "world")
+	_, err := client.createAd(123, "hello", "draft")
+	assert.NoError(t, err)
+
+	resp, err := client.searchAds("hello")
+	assert.NoError(t, err)
+	assert.Len(t, resp.Data, 1)
+	assert.Equal(t, ad.ID, resp.Data[0].Ad.ID)
+
+	_, err = client.changeAdStatus(123, ad.ID, false)
+	assert.NoError(t, err)
+
+	resp, err = client.searchAds("hello")
+	assert.NoError(t, err)
+	assert.Empty(t, resp.Data)
+}
+
+func TestSearchAds_FollowsUpdates(t *testing.T) {
+	client := getTestClient()
+
+	ad := createPublishedAd(t, client, "hello", "world")
+
+	resp, err := client.searchAds("world")
+	assert.NoError(t, err)
+	assert.Len(t, resp.Data, 1)
+
+	_, err = client.updateAd(123, ad.ID, "привет", "мир")
+	assert.NoError(t, err)
+
+	resp, err = client.searchAds("world")
+	assert.NoError(t, err)
+	assert.Empty(t, resp.Data)
+
+	resp, err = client.searchAds("мир")
+	assert.NoError(t, err)
+	assert.Len(t, resp.Data, 1)
+}
+
+// updateConcurrently параллельно меняет текст объявления на каждое из words.
+func updateConcurrently(t *testing.T, a app.App, ctx context.Context, adID int64, words []string) {
+	var wg sync.WaitGroup
+	for _, word := range words {
+		wg.Add(1)
+		go func(word string) {
+			defer wg.Done()
+			_, err := a.UpdateAd(ctx, adID, "hello", word, 0, money.Money{}, 0)
+			assert.NoError(t, err)
+		}(word)
+	}
+	wg.Wait()
+}
+
+var concurrentWords = []string{"apple", "banana", "cherry", "grape", "lemon", "mango", "melon", "orange", "peach", "plum"}
+
+func TestSearchAds_ConcurrentUpdates(t *testing.T) {
+	ctx := app.WithUserID(context.Background(), 123)
+	a := newApp(newRepo())
+
+	ad, err := a.CreateAd(ctx, "hello", "start", categories.OtherID, money.Money{})
+	assert.NoError(t, err)
+	_, err = a.ChangeAdStatus(ctx, ad.ID, true, 0)
+	assert.NoError(t, err)
+	// Индекс строится при первом поиске, дальше обновляется изменениями.
+	_, err = a.SearchAds(ctx, "hello", 0)
+	assert.NoError(t, err)
+
+	updateConcurrently(t, a, ctx, ad.ID, concurrentWords)
+	final, err := a.GetAd(ctx, ad.ID)
+	assert.NoError(t, err)
+
+	// Изменения могли дойти до индекса не по порядку, но в нем должна
+	// остаться итоговая версия.
+	for _, word := range concurrentWords {
+		results, err := a.SearchAds(ctx, word, 0)
+		assert.NoError(t, err)
+		if word == final.Text {
+			assert.Len(t, results, 1, word)
+		} else {
+			assert.Empty(t, results, word)
+		}
+	}
+}
+
+func TestSearchAds_SkipsStaleHits(t *testing.T) {
+	ctx := app.WithUserID(context.Background(), 123)
+	repo := newRepo()
+	a := newApp(repo)
+
+	var ids []int64
+	for _, text := range []string{"deleted", "unpublished", "published"} {
+		ad, err := a.CreateAd(ctx, "hello", text, categories.OtherID, money.Money{})
+		assert.NoError(t, err)
+		_, err = a.ChangeAdStatus(ctx, ad.ID, true, 0)
+		assert.NoError(t, err)
+		ids = append(ids, ad.ID)
+	}
+	_, err := a.SearchAds(ctx, "hello", 0)
+	assert.NoError(t, err)
+
+	// Изменения в обход приложения еще не дошли до индекса.
+	assert.NoError(t, repo.DeleteAd(ctx, ids[0]))
+	ad, err := repo.GetAd(ctx, ids[1])
+	assert.NoError(t, err)
+	ad.Published = false
+	assert.NoError(t, repo.UpdateAd(ctx, ad, nil))
+
+	results, err := a.SearchAds(ctx, "hello", 0)
+	assert.NoError(t, err)
+	assert.Len(t, results, 1)
+	assert.Equal(t, ids[2], results[0].Ad.ID)
+}
+
+func TestSearchAds_EmptyQuery(t *testing.T) {
+	client := getTestClient()
+
+	_, err := client.searchAds("  ")
+	assert.ErrorIs(t, err, ErrBadRequest)
+}
+
+func TestHighlight_Snippet(t *testing.T) {
+	text := "one two three four five six seven eight nine cat ten eleven twelve"
+
+	snippet := search.Highlight(text, "cats", 6)
+	assert.Equal(t, "…eight nine <em>cat</em> ten eleven twelve", snippet)
+
+	assert.Equal(t, "a &lt;b&gt; <em>cat</em>", search.Highlight("a <b> cat", "cat", 0))
+}
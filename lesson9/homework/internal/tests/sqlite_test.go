@@ -0,0 +1,40 @@
+package tests
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"homework9/internal/adapters/sqliterepo"
+	"homework9/internal/ads"
+	"homework9/internal/categories"
+)
+
+func TestSQLiteRepo_Reopen(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "ads.db")
+
+	repo, err := sqliterepo.New(ctx, path)
+	assert.NoError(t, err)
+
+	ad, err := repo.AddAd(ctx, ads.Ad{Title: "hello", Text: "world", AuthorID: 123, CategoryID: categories.OtherID}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), ad.ID)
+	assert.NoError(t, repo.Close())
+
+	repo, err = sqliterepo.New(ctx, path)
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		repo.Close()
+	})
+
+	got, err := repo.GetAd(ctx, ad.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, ad, got)
+
+	next, err := repo.AddAd(ctx, ads.Ad{Title: "best cat", Text: "not for sale", AuthorID: 123, CategoryID: categories.OtherID}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), next.ID)
+}
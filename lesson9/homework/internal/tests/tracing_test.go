@@ -0,0 +1,177 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/metadata"
+
+	"homework9/internal/app"
+	"homework9/internal/categories"
+	"homework9/internal/money"
+	grpcPort "homework9/internal/ports/grpc"
+	"homework9/internal/ports/httpgin"
+	"homework9/internal/tracing"
+)
+
+// Контекст трассировки клиента в формате W3C traceparent.
+const (
+	clientTraceID     = "4bf92f3577b34da6a3ce929d0e0e4736"
+	clientSpanID      = "00f067aa0ba902b7"
+	clientTraceparent = "00-" + clientTraceID + "-" + clientSpanID + "-01"
+)
+
+// newTracedApp создает app со спанами вокруг бизнес-логики и хранилища,
+// спаны сразу попадают в возвращаемый экспортер.
+func newTracedApp(t *testing.T) (app.App, *sdktrace.TracerProvider, *tracetest.InMemoryExporter) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	t.Cleanup(func() {
+		_ = tp.Shutdown(context.Background())
+	})
+
+	a := newApp(tracing.WrapRepository(newRepo(), tp), app.WithAdmins(0))
+	return tracing.WrapApp(a, tp), tp, exporter
+}
+
+// findSpan возвращает завершенный спан по названию.
+func findSpan(t *testing.T, exporter *tracetest.InMemoryExporter, name string) tracetest.SpanStub {
+	t.Helper()
+
+	for _, span := range exporter.GetSpans() {
+		if span.Name == name {
+			return span
+		}
+	}
+	t.Fatalf("span %q not found", name)
+	return tracetest.SpanStub{}
+}
+
+func spanAttribute(span tracetest.SpanStub, key attribute.Key) attribute.Value {
+	for _, kv := range span.Attributes {
+		if kv.Key == key {
+			return kv.Value
+		}
+	}
+
+	return attribute.Value{}
+}
+
+// assertChildOf проверяет, что child - прямой потомок parent.
+func assertChildOf(t *testing.T, child tracetest.SpanStub, parent tracetest.SpanStub) {
+	t.Helper()
+
+	assert.Equal(t, parent.SpanContext.TraceID(), child.SpanContext.TraceID(), "%s trace", child.Name)
+	assert.Equal(t, parent.SpanContext.SpanID(), child.Parent.SpanID(), "%s parent", child.Name)
+}
+
+func TestTracing_HTTP(t *testing.T) {
+	a, tp, exporter := newTracedApp(t)
+	user, err := a.CreateUser(context.Background(), "Oleg", "oleg-password")
+	assert.NoError(t, err)
+	ad, err := a.CreateAd(app.WithUserID(context.Background(), user.ID), "hello", "world", categories.OtherID, money.Money{})
+	assert.NoError(t, err)
+	exporter.Reset()
+
+	server := httpgin.NewHTTPServer(":18080", a, httpgin.WithTracing(tp), httpgin.WithLegacyUserID())
+	testServer := httptest.NewServer(server.Handler)
+	defer testServer.Close()
+
+	body := fmt.Sprintf(`{"user_id": %d, "title": "new title", "text": "new text"}`, user.ID)
+	req, err := http.NewRequest(http.MethodPut, fmt.Sprintf("%s/api/v1/ads/%d", testServer.URL, ad.ID), strings.NewReader(body))
+	assert.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("traceparent", clientTraceparent)
+	resp, err := testServer.Client().Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	serverSpan := findSpan(t, exporter, "PUT /api/v1/ads/:ad_id")
+	assert.Equal(t, clientTraceID, serverSpan.SpanContext.TraceID().String())
+	assert.Equal(t, clientSpanID, serverSpan.Parent.SpanID().String())
+	assert.True(t, serverSpan.Parent.IsRemote())
+	assert.Equal(t, trace.SpanKindServer, serverSpan.SpanKind)
+	assert.Equal(t, int64(http.StatusOK), spanAttribute(serverSpan, "http.status_code").AsInt64())
+
+	appSpan := findSpan(t, exporter, "app.UpdateAd")
+	assertChildOf(t, appSpan, serverSpan)
+	assert.Equal(t, ad.ID, spanAttribute(appSpan, "ad_id").AsInt64())
+
+	repoSpan := findSpan(t, exporter, "repo.UpdateAd")
+	assertChildOf(t, repoSpan, appSpan)
+	assert.Equal(t, ad.ID, spanAttribute(repoSpan, "ad_id").AsInt64())
+}
+
+func TestTracing_Errors(t *testing.T) {
+	a, tp, exporter := newTracedApp(t)
+	server := httpgin.NewHTTPServer(":18080", a, httpgin.WithTracing(tp), httpgin.WithLegacyUserID())
+	testServer := httptest.NewServer(server.Handler)
+	defer testServer.Close()
+
+	// Без traceparent сервер начинает новую трассировку.
+	resp, err := testServer.Client().Get(testServer.URL + "/api/v1/ads/100")
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	serverSpan := findSpan(t, exporter, "GET /api/v1/ads/:ad_id")
+	assert.False(t, serverSpan.Parent.IsValid())
+	// 404 - ошибка клиента, а не сервера.
+	assert.Equal(t, codes.Unset, serverSpan.Status.Code)
+
+	appSpan := findSpan(t, exporter, "app.GetAd")
+	assertChildOf(t, appSpan, serverSpan)
+	assert.Equal(t, codes.Error, appSpan.Status.Code)
+	assert.Equal(t, codes.Error, findSpan(t, exporter, "repo.GetAd").Status.Code)
+}
+
+func TestTracing_GRPC(t *testing.T) {
+	a, tp, exporter := newTracedApp(t)
+	client, ctx := newGRPCClient(t, a, grpcPort.WithTracing(tp), grpcPort.WithLegacyUserID())
+
+	user, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Name: "Oleg", Password: "oleg-password"})
+	assert.NoError(t, err)
+	ad, err := client.CreateAd(ctx, &grpcPort.CreateAdRequest{UserId: user.Id, Title: "hello", Text: "world", CategoryId: categories.OtherID})
+	assert.NoError(t, err)
+	exporter.Reset()
+
+	traced := metadata.AppendToOutgoingContext(ctx, "traceparent", clientTraceparent)
+	_, err = client.UpdateAd(traced, &grpcPort.UpdateAdRequest{AdId: ad.Id, UserId: user.Id, Title: "new title", Text: "new text"})
+	assert.NoError(t, err)
+
+	serverSpan := findSpan(t, exporter, "ad.AdService/UpdateAd")
+	assert.Equal(t, clientTraceID, serverSpan.SpanContext.TraceID().String())
+	assert.Equal(t, clientSpanID, serverSpan.Parent.SpanID().String())
+	assert.Equal(t, "OK", spanAttribute(serverSpan, "rpc.grpc.status_code").AsString())
+
+	appSpan := findSpan(t, exporter, "app.UpdateAd")
+	assertChildOf(t, appSpan, serverSpan)
+	assertChildOf(t, findSpan(t, exporter, "repo.UpdateAd"), appSpan)
+}
+
+func TestTracing_StdoutExporter(t *testing.T) {
+	var buf bytes.Buffer
+	exporter, err := tracing.NewStdoutExporter(&buf)
+	assert.NoError(t, err)
+	tp := tracing.NewProvider(exporter)
+
+	a := tracing.WrapApp(newApp(newRepo()), tp)
+	_, err = a.CreateUser(context.Background(), "Oleg", "oleg-password")
+	assert.NoError(t, err)
+
+	// Shutdown отправляет спаны, оставшиеся в пачке.
+	assert.NoError(t, tp.Shutdown(context.Background()))
+	assert.Contains(t, buf.String(), `"Name":"app.CreateUser"`)
+}
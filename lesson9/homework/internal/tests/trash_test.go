@@ -0,0 +1,303 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"homework9/internal/app"
+	"homework9/internal/categories"
+	"homework9/internal/logging"
+	"homework9/internal/money"
+	grpcPort "homework9/internal/ports/grpc"
+)
+
+func TestTrash_DeleteAndRestoreAd(t *testing.T) {
+	a, _, moderator, author := newModerationApp(t)
+
+	ad, err := a.CreateAd(author, "hello", "world", categories.OtherID, money.Money{})
+	assert.NoError(t, err)
+	ad, err = a.ChangeAdStatus(author, ad.ID, true, 0)
+	assert.NoError(t, err)
+
+	_, err = a.SearchAds(author, "hello", 0)
+	assert.NoError(t, err)
+
+	assert.NoError(t, a.DeleteAd(author, ad.ID))
+
+	_, err = a.GetAd(author, ad.ID)
+	assert.ErrorIs(t, err, app.ErrNotFound)
+	_, err = a.UpdateAd(author, ad.ID, "hello", "again", 0, money.Money{}, 0)
+	assert.ErrorIs(t, err, app.ErrNotFound)
+	assert.ErrorIs(t, a.DeleteAd(author, ad.ID), app.ErrNotFound)
+
+	list, err := a.ListAds(author, app.AdsQuery{})
+	assert.NoError(t, err)
+	assert.Len(t, list.Ads, 0)
+	results, err := a.SearchAds(author, "hello", 0)
+	assert.NoError(t, err)
+	assert.Len(t, results, 0)
+	tree, err := a.CategoryTree(author)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, tree[0].PublishedAds)
+
+	trash, err := a.TrashedAds(author, 0, "")
+	assert.NoError(t, err)
+	if assert.Len(t, trash.Ads, 1) {
+		assert.False(t, trash.Ads[0].DeletedAt.IsZero())
+	}
+	trash, err = a.TrashedAds(moderator, 0, "")
+	assert.NoError(t, err)
+	assert.Len(t, trash.Ads, 0)
+
+	_, err = a.RestoreAd(moderator, ad.ID)
+	assert.ErrorIs(t, err, app.ErrForbidden)
+
+	restored, err := a.RestoreAd(author, ad.ID)
+	assert.NoError(t, err)
+	assert.True(t, restored.DeletedAt.IsZero())
+	assert.True(t, restored.Published, "publication survives the trash")
+	assert.Equal(t, ad.Version+2, restored.Version)
+
+	_, err = a.RestoreAd(author, ad.ID)
+	assert.ErrorIs(t, err, app.ErrNotFound)
+
+	list, err = a.ListAds(author, app.AdsQuery{})
+	assert.NoError(t, err)
+	assert.Len(t, list.Ads, 1)
+	results, err = a.SearchAds(author, "hello", 0)
+	assert.NoError(t, err)
+	assert.Len(t, results, 1)
+}
+
+func TestTrash_DeleteAndRestoreUser(t *testing.T) {
+	a, admin, moderator, _ := newModerationApp(t)
+	ctx := context.Background()
+
+	user, err := a.CreateUser(ctx, "Oleg", "secret")
+	assert.NoError(t, err)
+	assert.ErrorIs(t, a.DeleteUser(ctx, user.ID), app.ErrUnauthenticated)
+	assert.ErrorIs(t, a.DeleteUser(moderator, user.ID), app.ErrForbidden)
+	assert.NoError(t, a.DeleteUser(app.WithUserID(ctx, user.ID), user.ID))
+
+	_, err = a.GetUser(ctx, user.ID)
+	assert.ErrorIs(t, err, app.ErrNotFound)
+	_, err = a.Login(ctx, user.ID, "secret")
+	assert.ErrorIs(t, err, app.ErrUnauthenticated)
+	assert.ErrorIs(t, a.DeleteUser(admin, user.ID), app.ErrNotFound)
+
+	_, err = a.RestoreUser(moderator, user.ID)
+	assert.ErrorIs(t, err, app.ErrForbidden)
+
+	restored, err := a.RestoreUser(admin, user.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Oleg", restored.Name)
+
+	_, err = a.Login(ctx, user.ID, "secret")
+	assert.NoError(t, err)
+	_, err = a.RestoreUser(admin, user.ID)
+	assert.ErrorIs(t, err, app.ErrNotFound)
+}
+
+func TestTrash_Purge(t *testing.T) {
+	a, admin, _, author := newModerationApp(t, app.WithTrashRetention(time.Millisecond))
+	ctx := context.Background()
+
+	kept, err := a.CreateAd(author, "kept", "ad", categories.OtherID, money.Money{})
+	assert.NoError(t, err)
+	expired, err := a.CreateAd(author, "expired", "ad", categories.OtherID, money.Money{})
+	assert.NoError(t, err)
+	assert.NoError(t, a.DeleteAd(author, expired.ID))
+
+	user, err := a.CreateUser(ctx, "Oleg", "secret")
+	assert.NoError(t, err)
+	assert.NoError(t, a.DeleteUser(admin, user.ID))
+
+	time.Sleep(5 * time.Millisecond)
+
+	_, err = a.RestoreAd(author, expired.ID)
+	assert.ErrorIs(t, err, app.ErrNotFound, "retention has expired")
+	_, err = a.RestoreUser(admin, user.ID)
+	assert.ErrorIs(t, err, app.ErrNotFound, "retention has expired")
+
+	purged, err := a.PurgeTrash(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, purged)
+
+	trash, err := a.TrashedAds(author, 0, "")
+	assert.NoError(t, err)
+	assert.Len(t, trash.Ads, 0)
+	_, err = a.AdHistory(author, expired.ID)
+	assert.ErrorIs(t, err, app.ErrNotFound)
+	_, err = a.GetAd(author, kept.ID)
+	assert.NoError(t, err)
+
+	purged, err = a.PurgeTrash(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, purged)
+}
+
+func TestTrash_PurgeKeepsRestorable(t *testing.T) {
+	a, _, _, author := newModerationApp(t)
+
+	ad, err := a.CreateAd(author, "hello", "world", categories.OtherID, money.Money{})
+	assert.NoError(t, err)
+	assert.NoError(t, a.DeleteAd(author, ad.ID))
+
+	purged, err := a.PurgeTrash(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, purged)
+
+	_, err = a.RestoreAd(author, ad.ID)
+	assert.NoError(t, err)
+}
+
+func TestTrash_RunPurgerStops(t *testing.T) {
+	a, _, _, author := newModerationApp(t, app.WithTrashRetention(time.Millisecond))
+
+	ad, err := a.CreateAd(author, "hello", "world", categories.OtherID, money.Money{})
+	assert.NoError(t, err)
+	assert.NoError(t, a.DeleteAd(author, ad.ID))
+	time.Sleep(5 * time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		app.RunPurger(ctx, a, time.Hour, logging.New(io.Discard))
+		close(done)
+	}()
+
+	assert.Eventually(t, func() bool {
+		trash, err := a.TrashedAds(author, 0, "")
+		return err == nil && len(trash.Ads) == 0
+	}, time.Second, 10*time.Millisecond, "first purge runs immediately")
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("RunPurger did not stop after cancel")
+	}
+}
+
+func TestTrash_REST(t *testing.T) {
+	client := getTestClient()
+
+	user, err := client.createUser("Oleg", "secret")
+	assert.NoError(t, err)
+	assert.NoError(t, client.login(user.Data.ID, "secret"))
+
+	ad, err := client.createAd(user.Data.ID, "hello", "world")
+	assert.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodDelete, fmt.Sprintf("%s/api/v1/ads/%d", client.baseURL, ad.Data.ID), nil)
+	assert.NoError(t, err)
+	assert.NoError(t, client.getResponse(req, &struct{}{}))
+
+	req, err = http.NewRequest(http.MethodGet, client.baseURL+"/api/v1/trash/ads", nil)
+	assert.NoError(t, err)
+	var trash adsResponse
+	assert.NoError(t, client.getResponse(req, &trash))
+	if assert.Len(t, trash.Data, 1) {
+		assert.NotNil(t, trash.Data[0].DeletedAt)
+	}
+
+	var restored adResponse
+	err = client.postJSON(fmt.Sprintf("/api/v1/ads/%d/restore", ad.Data.ID), map[string]any{}, &restored)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", restored.Data.Title)
+	assert.Nil(t, restored.Data.DeletedAt)
+
+	_, _, err = client.getAd(ad.Data.ID)
+	assert.NoError(t, err)
+
+	// восстанавливать пользователей может только администратор
+	err = client.postJSON(fmt.Sprintf("/api/v1/users/%d/restore", user.Data.ID), map[string]any{}, &struct{}{})
+	assert.ErrorIs(t, err, ErrForbidden)
+}
+
+func TestTrash_RESTDeleteUser(t *testing.T) {
+	client := getTestClient()
+
+	victim, err := client.createUser("Ivan", "ivan-secret")
+	assert.NoError(t, err)
+	user, err := client.createUser("Oleg", "secret")
+	assert.NoError(t, err)
+
+	deleteUser := func(userID int64) error {
+		req, err := http.NewRequest(http.MethodDelete, fmt.Sprintf("%s/api/v1/users/%d", client.baseURL, userID), nil)
+		assert.NoError(t, err)
+		return client.getResponse(req, &struct{}{})
+	}
+
+	assert.ErrorIs(t, deleteUser(victim.Data.ID), ErrUnauthorized)
+
+	// удалить чужой аккаунт нельзя, свой - можно
+	assert.NoError(t, client.login(user.Data.ID, "secret"))
+	assert.ErrorIs(t, deleteUser(victim.Data.ID), ErrForbidden)
+	assert.NoError(t, deleteUser(user.Data.ID))
+
+	// Токен удаленного пользователя больше не принимается, хотя еще не истек.
+	_, err = client.createAd(user.Data.ID, "hello", "world")
+	assert.ErrorIs(t, err, ErrUnauthorized)
+	client.token = ""
+
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/api/v1/users/%d", client.baseURL, victim.Data.ID), nil)
+	assert.NoError(t, err)
+	var got userResponse
+	assert.NoError(t, client.getResponse(req, &got))
+	assert.Equal(t, "Ivan", got.Data.Name)
+}
+
+func TestTrash_GRPC(t *testing.T) {
+	a, _, _, _ := newModerationApp(t)
+	client, ctx := newGRPCClient(t, a)
+
+	login := func(userID int64, password string) context.Context {
+		token, err := client.Login(ctx, &grpcPort.LoginRequest{UserId: userID, Password: password})
+		assert.NoError(t, err)
+		return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token.GetToken())
+	}
+	adminCtx := login(0, "admin-password")
+	authorCtx := login(2, "author-password")
+
+	ad, err := client.CreateAd(authorCtx, &grpcPort.CreateAdRequest{Title: "hello", Text: "world", CategoryId: categories.OtherID})
+	assert.NoError(t, err)
+	_, err = client.DeleteAd(authorCtx, &grpcPort.DeleteAdRequest{AdId: ad.Id})
+	assert.NoError(t, err)
+
+	trash, err := client.ListTrashedAds(authorCtx, &grpcPort.ListAdsRequest{})
+	assert.NoError(t, err)
+	if assert.Len(t, trash.List, 1) {
+		assert.NotNil(t, trash.List[0].DeletedAt)
+	}
+
+	restored, err := client.RestoreAd(authorCtx, &grpcPort.RestoreAdRequest{AdId: ad.Id})
+	assert.NoError(t, err)
+	assert.Nil(t, restored.DeletedAt)
+	_, err = client.RestoreAd(authorCtx, &grpcPort.RestoreAdRequest{AdId: ad.Id})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = client.DeleteUser(ctx, &grpcPort.DeleteUserRequest{Id: 1})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = client.DeleteUser(authorCtx, &grpcPort.DeleteUserRequest{Id: 1})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = client.DeleteUser(adminCtx, &grpcPort.DeleteUserRequest{Id: 1})
+	assert.NoError(t, err)
+	_, err = client.GetUser(ctx, &grpcPort.GetUserRequest{Id: 1})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = client.RestoreUser(authorCtx, &grpcPort.RestoreUserRequest{Id: 1})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	user, err := client.RestoreUser(adminCtx, &grpcPort.RestoreUserRequest{Id: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, grpcPort.Role_ROLE_MODERATOR, user.Role)
+}
@@ -7,9 +7,16 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
 
 	"homework9/internal/adapters/adrepo"
 	"homework9/internal/app"
+	"homework9/internal/categories"
 	"homework9/internal/ports/httpgin"
 )
 
@@ -19,6 +26,32 @@ type adData struct {
 	Text      string `json:"text"`
 	AuthorID  int64  `json:"author_id"`
 	Published bool   `json:"published"`
+	Version   int64  `json:"version"`
+
+	CategoryID int64 `json:"category_id"`
+
+	Price           string `json:"price"`
+	Currency        string `json:"currency"`
+	DisplayPrice    string `json:"display_price"`
+	DisplayCurrency string `json:"display_currency"`
+
+	PublishAt   *string `json:"publish_at"`
+	UnpublishAt *string `json:"unpublish_at"`
+	DeletedAt   *string `json:"deleted_at"`
+	Favorites   int     `json:"favorites"`
+
+	Moderation       string      `json:"moderation"`
+	ModerationReason string      `json:"moderation_reason"`
+	Photos           []photoData `json:"photos"`
+}
+
+type photoData struct {
+	ID          string            `json:"id"`
+	URL         string            `json:"url"`
+	Thumbnails  map[string]string `json:"thumbnails"`
+	ContentType string            `json:"content_type"`
+	Width       int               `json:"width"`
+	Height      int               `json:"height"`
 }
 
 type adResponse struct {
@@ -26,21 +59,71 @@ type adResponse struct {
 }
 
 type adsResponse struct {
-	Data []adData `json:"data"`
+	Data          []adData `json:"data"`
+	NextPageToken string   `json:"next_page_token"`
+}
+
+type searchResultData struct {
+	Ad             adData  `json:"ad"`
+	Score          float64 `json:"score"`
+	TitleHighlight string  `json:"title_highlight"`
+	TextSnippet    string  `json:"text_snippet"`
+}
+
+type searchResponse struct {
+	Data []searchResultData `json:"data"`
+}
+
+type userData struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+	Role string `json:"role"`
+}
+
+type userResponse struct {
+	Data userData `json:"data"`
+}
+
+type tokenResponse struct {
+	Data struct {
+		Token string `json:"token"`
+	} `json:"data"`
 }
 
 var (
 	ErrBadRequest = fmt.Errorf("bad request")
 	ErrForbidden  = fmt.Errorf("forbidden")
+
+	ErrPreconditionFailed = fmt.Errorf("precondition failed")
+	ErrUnauthorized       = fmt.Errorf("unauthorized")
+	ErrTooManyRequests    = fmt.Errorf("too many requests")
+	ErrNotFound           = fmt.Errorf("not found")
 )
 
+// newRepo создает репозиторий для тестового сервера, TestMain подменяет его для каждого бэкенда.
+var newRepo = adrepo.New
+
+// newApp - app.NewApp с дешевым хешированием паролей, чтобы тесты не тратили
+// время на bcrypt.
+func newApp(repo app.Repository, opts ...app.Option) app.App {
+	return app.NewApp(repo, append([]app.Option{app.WithPasswordCost(bcrypt.MinCost)}, opts...)...)
+}
+
 type testClient struct {
 	client  *http.Client
 	baseURL string
+	// token, если задан, передается в заголовке Authorization.
+	token string
+}
+
+// getTestClient возвращает клиент сервера, который принимает устаревший user_id
+// в теле запроса: на нем проверяется API для клиентов без токенов.
+func getTestClient(opts ...httpgin.Option) *testClient {
+	return newTestClient(newApp(newRepo()), append([]httpgin.Option{httpgin.WithLegacyUserID()}, opts...)...)
 }
 
-func getTestClient() *testClient {
-	server := httpgin.NewHTTPServer(":18080", app.NewApp(adrepo.New()))
+func newTestClient(a app.App, opts ...httpgin.Option) *testClient {
+	server := httpgin.NewHTTPServer(":18080", a, opts...)
 	testServer := httptest.NewServer(server.Handler)
 
 	return &testClient{
@@ -50,39 +133,128 @@ func getTestClient() *testClient {
 }
 
 func (tc *testClient) getResponse(req *http.Request, out any) error {
+	_, err := tc.getResponseWithHeaders(req, out)
+	return err
+}
+
+func (tc *testClient) getResponseWithHeaders(req *http.Request, out any) (http.Header, error) {
+	if tc.token != "" {
+		req.Header.Set("Authorization", "Bearer "+tc.token)
+	}
+
 	resp, err := tc.client.Do(req)
 	if err != nil {
-		return fmt.Errorf("unexpected error: %w", err)
+		return nil, fmt.Errorf("unexpected error: %w", err)
 	}
+	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		if resp.StatusCode == http.StatusBadRequest {
-			return ErrBadRequest
+			return nil, ErrBadRequest
+		}
+		if resp.StatusCode == http.StatusUnauthorized {
+			return nil, ErrUnauthorized
 		}
 		if resp.StatusCode == http.StatusForbidden {
-			return ErrForbidden
+			return nil, ErrForbidden
+		}
+		if resp.StatusCode == http.StatusPreconditionFailed {
+			return nil, ErrPreconditionFailed
 		}
-		return fmt.Errorf("unexpected status code: %s", resp.Status)
+		if resp.StatusCode == http.StatusTooManyRequests {
+			return nil, ErrTooManyRequests
+		}
+		return nil, fmt.Errorf("unexpected status code: %s", resp.Status)
 	}
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return fmt.Errorf("unable to read response: %w", err)
+		return nil, fmt.Errorf("unable to read response: %w", err)
 	}
 
 	err = json.Unmarshal(respBody, out)
 	if err != nil {
-		return fmt.Errorf("unable to unmarshal: %w", err)
+		return nil, fmt.Errorf("unable to unmarshal: %w", err)
 	}
 
+	return resp.Header, nil
+}
+
+func (tc *testClient) postJSON(path string, body map[string]any, out any) error {
+	return tc.sendJSON(http.MethodPost, path, body, out)
+}
+
+func (tc *testClient) putJSON(path string, body map[string]any, out any) error {
+	return tc.sendJSON(http.MethodPut, path, body, out)
+}
+
+func (tc *testClient) sendJSON(method string, path string, body map[string]any, out any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("unable to marshal: %w", err)
+	}
+
+	req, err := http.NewRequest(method, tc.baseURL+path, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("unable to create request: %w", err)
+	}
+
+	req.Header.Add("Content-Type", "application/json")
+
+	return tc.getResponse(req, out)
+}
+
+func (tc *testClient) moderationQueue() (adsResponse, error) {
+	req, err := http.NewRequest(http.MethodGet, tc.baseURL+"/api/v1/moderation/queue", nil)
+	if err != nil {
+		return adsResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	var response adsResponse
+	err = tc.getResponse(req, &response)
+	return response, err
+}
+
+func (tc *testClient) approveAd(adID int64) (adResponse, error) {
+	var response adResponse
+	err := tc.postJSON(fmt.Sprintf("/api/v1/moderation/ads/%d/approve", adID), map[string]any{}, &response)
+	return response, err
+}
+
+func (tc *testClient) rejectAd(adID int64, reason string) (adResponse, error) {
+	var response adResponse
+	err := tc.postJSON(fmt.Sprintf("/api/v1/moderation/ads/%d/reject", adID), map[string]any{"reason": reason}, &response)
+	return response, err
+}
+
+func (tc *testClient) createUser(name string, password string) (userResponse, error) {
+	var response userResponse
+	err := tc.postJSON("/api/v1/users", map[string]any{"name": name, "password": password}, &response)
+	return response, err
+}
+
+// login получает токен и запоминает его для следующих запросов.
+func (tc *testClient) login(userID int64, password string) error {
+	var response tokenResponse
+	err := tc.postJSON("/api/v1/login", map[string]any{"user_id": userID, "password": password}, &response)
+	if err != nil {
+		return err
+	}
+
+	tc.token = response.Data.Token
 	return nil
 }
 
 func (tc *testClient) createAd(userID int64, title string, text string) (adResponse, error) {
+	return tc.createAdInCategory(userID, title, text, categories.OtherID)
+}
+
+func (tc *testClient) createAdInCategory(userID int64, title string, text string, categoryID int64) (adResponse, error) {
 	body := map[string]any{
-		"user_id": userID,
-		"title":   title,
-		"text":    text,
+		"user_id":     userID,
+		"title":       title,
+		"text":        text,
+		"category_id": categoryID,
 	}
 
 	data, err := json.Marshal(body)
@@ -161,11 +333,73 @@ func (tc *testClient) updateAd(userID int64, adID int64, title string, text stri
 	return response, nil
 }
 
+// updateAdIfMatch обновляет объявление с заголовком If-Match и возвращает ETag ответа.
+func (tc *testClient) updateAdIfMatch(userID int64, adID int64, title string, text string, etag string) (adResponse, string, error) {
+	body := map[string]any{
+		"user_id": userID,
+		"title":   title,
+		"text":    text,
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return adResponse{}, "", fmt.Errorf("unable to marshal: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPut, fmt.Sprintf(tc.baseURL+"/api/v1/ads/%d", adID), bytes.NewReader(data))
+	if err != nil {
+		return adResponse{}, "", fmt.Errorf("unable to create request: %w", err)
+	}
+
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("If-Match", etag)
+
+	var response adResponse
+	header, err := tc.getResponseWithHeaders(req, &response)
+	if err != nil {
+		return adResponse{}, "", err
+	}
+
+	return response, header.Get("ETag"), nil
+}
+
+func (tc *testClient) getAd(adID int64) (adResponse, string, error) {
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf(tc.baseURL+"/api/v1/ads/%d", adID), nil)
+	if err != nil {
+		return adResponse{}, "", fmt.Errorf("unable to create request: %w", err)
+	}
+
+	var response adResponse
+	header, err := tc.getResponseWithHeaders(req, &response)
+	if err != nil {
+		return adResponse{}, "", err
+	}
+
+	return response, header.Get("ETag"), nil
+}
+
 func (tc *testClient) listAds() (adsResponse, error) {
+	return tc.listAdsPage(0, "")
+}
+
+func (tc *testClient) listAdsPage(pageSize int, pageToken string) (adsResponse, error) {
+	query := url.Values{}
+	if pageSize > 0 {
+		query.Set("page_size", strconv.Itoa(pageSize))
+	}
+	if pageToken != "" {
+		query.Set("page_token", pageToken)
+	}
+
+	return tc.listAdsQuery(query)
+}
+
+func (tc *testClient) listAdsQuery(query url.Values) (adsResponse, error) {
 	req, err := http.NewRequest(http.MethodGet, tc.baseURL+"/api/v1/ads", nil)
 	if err != nil {
 		return adsResponse{}, fmt.Errorf("unable to create request: %w", err)
 	}
+	req.URL.RawQuery = query.Encode()
 
 	var response adsResponse
 	err = tc.getResponse(req, &response)
@@ -175,3 +409,73 @@ func (tc *testClient) listAds() (adsResponse, error) {
 
 	return response, nil
 }
+
+func (tc *testClient) searchAds(query string) (searchResponse, error) {
+	req, err := http.NewRequest(http.MethodGet, tc.baseURL+"/api/v1/ads/search", nil)
+	if err != nil {
+		return searchResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+	req.URL.RawQuery = url.Values{"q": {query}}.Encode()
+
+	var response searchResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return searchResponse{}, err
+	}
+
+	return response, nil
+}
+
+// fakeClock - app.Clock, время которого двигает только Advance.
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []fakeTimer
+}
+
+type fakeTimer struct {
+	at time.Time
+	ch chan time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+	c.waiters = append(c.waiters, fakeTimer{at: c.now.Add(d), ch: ch})
+
+	return ch
+}
+
+// Advance переводит часы на d вперед и срабатывает наступившие таймеры.
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+	waiters := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.at.After(c.now) {
+			waiters = append(waiters, w)
+			continue
+		}
+		w.ch <- c.now
+	}
+	c.waiters = waiters
+}
@@ -0,0 +1,131 @@
+package tests
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"homework9/internal/app"
+	"homework9/internal/categories"
+	"homework9/internal/money"
+	grpcPort "homework9/internal/ports/grpc"
+)
+
+func TestUpdateAd_Versions(t *testing.T) {
+	client := getTestClient()
+
+	created, err := client.createAd(123, "hello", "world")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), created.Data.Version)
+
+	resp, err := client.changeAdStatus(123, created.Data.ID, true)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), resp.Data.Version)
+
+	// Статус не изменился - версия тоже.
+	resp, err = client.changeAdStatus(123, created.Data.ID, true)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), resp.Data.Version)
+}
+
+func TestUpdateAd_IfMatch(t *testing.T) {
+	client := getTestClient()
+	// Черновик видит только автор, поэтому запросы идут с его токеном.
+	userID := client.signIn(t, "Oleg")
+
+	created, err := client.createAd(userID, "hello", "world")
+	assert.NoError(t, err)
+
+	_, etag, err := client.getAd(created.Data.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, `"1"`, etag)
+
+	resp, newETag, err := client.updateAdIfMatch(userID, created.Data.ID, "привет", "мир", etag)
+	assert.NoError(t, err)
+	assert.Equal(t, "привет", resp.Data.Title)
+	assert.Equal(t, `"2"`, newETag)
+
+	// Второй редактор прочитал объявление до первого изменения.
+	_, _, err = client.updateAdIfMatch(userID, created.Data.ID, "hello", "again", etag)
+	assert.ErrorIs(t, err, ErrPreconditionFailed)
+
+	// Слабый тег не совпадает даже с текущей версией (строгое сравнение).
+	_, _, err = client.updateAdIfMatch(userID, created.Data.ID, "hello", "weak", "W/"+newETag)
+	assert.ErrorIs(t, err, ErrPreconditionFailed)
+
+	// Из списка тегов достаточно совпадения одного, "*" совпадает с любой версией.
+	resp, newETag, err = client.updateAdIfMatch(userID, created.Data.ID, "hello", "list", etag+", W/"+newETag+", "+newETag)
+	assert.NoError(t, err)
+	assert.Equal(t, "list", resp.Data.Text)
+	assert.Equal(t, `"3"`, newETag)
+	_, _, err = client.updateAdIfMatch(userID, created.Data.ID, "hello", "list", `"1", "2"`)
+	assert.ErrorIs(t, err, ErrPreconditionFailed)
+	resp, _, err = client.updateAdIfMatch(userID, created.Data.ID, "hello", "any", "*")
+	assert.NoError(t, err)
+	assert.Equal(t, "any", resp.Data.Text)
+
+	// Тег, который сервер не выдавал, не совпадает с текущей версией.
+	for _, tag := range []string{"W/1", `"v1"`, `"0"`, `"4`} {
+		_, _, err = client.updateAdIfMatch(userID, created.Data.ID, "hello", "again", tag)
+		assert.ErrorIs(t, err, ErrPreconditionFailed, tag)
+	}
+}
+
+func TestGRPCUpdateAd_StaleVersion(t *testing.T) {
+	client, ctx := getGRPCClient(t)
+
+	ad, err := client.CreateAd(ctx, &grpcPort.CreateAdRequest{Title: "hello", Text: "world", UserId: 123, CategoryId: categories.OtherID})
+	assert.NoError(t, err, "client.CreateAd")
+
+	updated, err := client.UpdateAd(ctx, &grpcPort.UpdateAdRequest{
+		AdId: ad.Id, UserId: 123, Title: "привет", Text: "мир", ExpectedVersion: ad.Version,
+	})
+	assert.NoError(t, err, "client.UpdateAd")
+	assert.Equal(t, ad.Version+1, updated.Version)
+
+	_, err = client.ChangeAdStatus(ctx, &grpcPort.ChangeAdStatusRequest{
+		AdId: ad.Id, UserId: 123, Published: true, ExpectedVersion: ad.Version,
+	})
+	assert.Equal(t, codes.Aborted, status.Code(err))
+}
+
+func TestUpdateAd_ConcurrentEditors(t *testing.T) {
+	ctx := app.WithUserID(context.Background(), 123)
+	a := newApp(newRepo())
+
+	ad, err := a.CreateAd(ctx, "hello", "world", categories.OtherID, money.Money{})
+	assert.NoError(t, err)
+
+	const editors = 10
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		succeeded int
+	)
+	for i := 0; i < editors; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			_, err := a.UpdateAd(ctx, ad.ID, "title", "text", 0, money.Money{}, ad.Version)
+			if err == nil {
+				mu.Lock()
+				succeeded++
+				mu.Unlock()
+				return
+			}
+			assert.ErrorIs(t, err, app.ErrVersionConflict)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 1, succeeded)
+
+	got, err := a.GetAd(ctx, ad.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, ad.Version+1, got.Version)
+}
@@ -0,0 +1,243 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"homework9/internal/app"
+	"homework9/internal/categories"
+	"homework9/internal/money"
+	grpcPort "homework9/internal/ports/grpc"
+)
+
+func TestGRPCWatchAds(t *testing.T) {
+	client, ctx := getGRPCClient(t)
+
+	author := int64(123)
+	stream, err := client.WatchAds(ctx, &grpcPort.WatchAdsRequest{AuthorId: &author})
+	assert.NoError(t, err, "client.WatchAds")
+	// Подписка регистрируется асинхронно, дожидаемся ее через первое событие ниже.
+	time.Sleep(50 * time.Millisecond)
+
+	ad, err := client.CreateAd(ctx, &grpcPort.CreateAdRequest{Title: "hello", Text: "world", UserId: author, CategoryId: categories.OtherID})
+	assert.NoError(t, err, "client.CreateAd")
+	_, err = client.CreateAd(ctx, &grpcPort.CreateAdRequest{Title: "other", Text: "author", UserId: 100, CategoryId: categories.OtherID})
+	assert.NoError(t, err, "client.CreateAd")
+	_, err = client.ChangeAdStatus(ctx, &grpcPort.ChangeAdStatusRequest{AdId: ad.Id, UserId: author, Published: true})
+	assert.NoError(t, err, "client.ChangeAdStatus")
+	_, err = client.UpdateAd(ctx, &grpcPort.UpdateAdRequest{AdId: ad.Id, UserId: author, Title: "привет", Text: "мир"})
+	assert.NoError(t, err, "client.UpdateAd")
+	_, err = client.ChangeAdStatus(ctx, &grpcPort.ChangeAdStatusRequest{AdId: ad.Id, UserId: author, Published: false})
+	assert.NoError(t, err, "client.ChangeAdStatus")
+	_, err = client.DeleteAd(ctx, &grpcPort.DeleteAdRequest{AdId: ad.Id, AuthorId: author})
+	assert.NoError(t, err, "client.DeleteAd")
+
+	// Создание и удаление черновика скрыты: по автору отбираются только
+	// события, автор которых виден подписчику.
+	expected := []grpcPort.AdEventType{
+		grpcPort.AdEventType_AD_EVENT_TYPE_PUBLISHED,
+		grpcPort.AdEventType_AD_EVENT_TYPE_UPDATED,
+		grpcPort.AdEventType_AD_EVENT_TYPE_UNPUBLISHED,
+	}
+	for _, eventType := range expected {
+		e, err := stream.Recv()
+		assert.NoError(t, err, "stream.Recv")
+		assert.Equal(t, eventType, e.Type)
+		assert.Equal(t, ad.Id, e.Ad.Id)
+		assert.NotEmpty(t, e.ResumeToken)
+	}
+}
+
+func TestGRPCWatchAds_Resume(t *testing.T) {
+	client, ctx := getGRPCClient(t)
+
+	published := true
+	watchCtx, cancel := context.WithCancel(ctx)
+	stream, err := client.WatchAds(watchCtx, &grpcPort.WatchAdsRequest{Published: &published})
+	assert.NoError(t, err, "client.WatchAds")
+	time.Sleep(50 * time.Millisecond)
+
+	ad, err := client.CreateAd(ctx, &grpcPort.CreateAdRequest{Title: "hello", Text: "world", UserId: 123, CategoryId: categories.OtherID})
+	assert.NoError(t, err, "client.CreateAd")
+	_, err = client.ChangeAdStatus(ctx, &grpcPort.ChangeAdStatusRequest{AdId: ad.Id, UserId: 123, Published: true})
+	assert.NoError(t, err, "client.ChangeAdStatus")
+
+	first, err := stream.Recv()
+	assert.NoError(t, err, "stream.Recv")
+	assert.Equal(t, grpcPort.AdEventType_AD_EVENT_TYPE_PUBLISHED, first.Type)
+	cancel()
+
+	// Пока клиент отключен, объявление меняют и снимают с публикации.
+	_, err = client.UpdateAd(ctx, &grpcPort.UpdateAdRequest{AdId: ad.Id, UserId: 123, Title: "привет", Text: "мир"})
+	assert.NoError(t, err, "client.UpdateAd")
+	_, err = client.ChangeAdStatus(ctx, &grpcPort.ChangeAdStatusRequest{AdId: ad.Id, UserId: 123, Published: false})
+	assert.NoError(t, err, "client.ChangeAdStatus")
+
+	stream, err = client.WatchAds(ctx, &grpcPort.WatchAdsRequest{Published: &published, ResumeToken: first.ResumeToken})
+	assert.NoError(t, err, "client.WatchAds")
+
+	e, err := stream.Recv()
+	assert.NoError(t, err, "stream.Recv")
+	assert.Equal(t, grpcPort.AdEventType_AD_EVENT_TYPE_UPDATED, e.Type)
+	assert.Equal(t, "привет", e.Ad.Title)
+
+	e, err = stream.Recv()
+	assert.NoError(t, err, "stream.Recv")
+	assert.Equal(t, grpcPort.AdEventType_AD_EVENT_TYPE_UNPUBLISHED, e.Type)
+}
+
+func TestGRPCWatchAds_InvalidResumeToken(t *testing.T) {
+	client, ctx := getGRPCClient(t)
+
+	stream, err := client.WatchAds(ctx, &grpcPort.WatchAdsRequest{ResumeToken: "eyJlcG9jaCI6MSwic2VxIjoxfQ"})
+	assert.NoError(t, err, "client.WatchAds")
+
+	_, err = stream.Recv()
+	assert.Equal(t, codes.OutOfRange, status.Code(err))
+}
+
+func TestWatchAds_SlowSubscriber(t *testing.T) {
+	ctx := app.WithUserID(context.Background(), 123)
+	a := newApp(newRepo())
+
+	sub, err := a.WatchAds(ctx, app.WatchFilter{})
+	assert.NoError(t, err)
+	defer sub.Close()
+
+	// Подписчик ничего не читает, а запись не должна из-за него блокироваться.
+	for i := 0; i < 100; i++ {
+		_, err := a.CreateAd(ctx, "hello", "world", categories.OtherID, money.Money{})
+		assert.NoError(t, err)
+	}
+
+	var (
+		received int
+		last     app.AdEvent
+	)
+	for {
+		e, err := sub.Next(ctx)
+		if err != nil {
+			assert.ErrorIs(t, err, app.ErrSlowSubscriber)
+			break
+		}
+		received++
+		last = e
+	}
+	assert.Less(t, received, 100)
+
+	// С токеном последнего полученного события можно дочитать остальные.
+	sub, err = a.WatchAds(ctx, app.WatchFilter{ResumeToken: last.ResumeToken})
+	assert.NoError(t, err)
+	defer sub.Close()
+
+	e, err := sub.Next(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, last.Ad.ID+1, e.Ad.ID)
+}
+
+func TestWatchAds_ConcurrentUpdatesInVersionOrder(t *testing.T) {
+	ctx := app.WithUserID(context.Background(), 123)
+	a := newApp(newRepo())
+
+	ad, err := a.CreateAd(ctx, "hello", "start", categories.OtherID, money.Money{})
+	assert.NoError(t, err)
+	_, err = a.ChangeAdStatus(ctx, ad.ID, true, 0)
+	assert.NoError(t, err)
+
+	sub, err := a.WatchAds(ctx, app.WatchFilter{})
+	assert.NoError(t, err)
+	defer sub.Close()
+
+	updateConcurrently(t, a, ctx, ad.ID, concurrentWords)
+	final, err := a.GetAd(ctx, ad.ID)
+	assert.NoError(t, err)
+
+	// События могут пропускать версии, но не возвращаются к более старым и
+	// заканчиваются итоговой.
+	var version int64
+	for version < final.Version {
+		e, err := sub.Next(ctx)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Greater(t, e.Ad.Version, version)
+		version = e.Ad.Version
+	}
+}
+
+func TestWatchAds_AfterHistoryWrapAround(t *testing.T) {
+	ctx := app.WithUserID(context.Background(), 123)
+	a := newApp(newRepo())
+
+	ad, err := a.CreateAd(ctx, "hello", "world", categories.OtherID, money.Money{})
+	assert.NoError(t, err)
+	_, err = a.ChangeAdStatus(ctx, ad.ID, true, 0)
+	assert.NoError(t, err)
+
+	// События первого объявления вытесняются из истории вместе с его версией.
+	for i := 0; i < 1100; i++ {
+		_, err = a.CreateAd(ctx, "other", "ad", categories.OtherID, money.Money{})
+		assert.NoError(t, err)
+	}
+
+	sub, err := a.WatchAds(ctx, app.WatchFilter{})
+	assert.NoError(t, err)
+	defer sub.Close()
+
+	updated, err := a.UpdateAd(ctx, ad.ID, "привет", "мир", categories.OtherID, money.Money{}, 0)
+	assert.NoError(t, err)
+
+	e, err := sub.Next(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, app.AdUpdated, e.Type)
+	assert.Equal(t, updated.Version, e.Ad.Version)
+}
+
+func TestWatchAds_HidesUnpublishedContent(t *testing.T) {
+	ctx := app.WithUserID(context.Background(), 123)
+	a := newApp(newRepo())
+
+	sub, err := a.WatchAds(context.Background(), app.WatchFilter{})
+	assert.NoError(t, err)
+	defer sub.Close()
+
+	ad, err := a.CreateAd(ctx, "draft", "secret", categories.OtherID, money.Money{})
+	assert.NoError(t, err)
+	_, err = a.UpdateAd(ctx, ad.ID, "draft", "still secret", categories.OtherID, money.Money{}, 0)
+	assert.NoError(t, err)
+	_, err = a.ChangeAdStatus(ctx, ad.ID, true, 0)
+	assert.NoError(t, err)
+	_, err = a.ChangeAdStatus(ctx, ad.ID, false, 0)
+	assert.NoError(t, err)
+	assert.NoError(t, a.DeleteAd(ctx, ad.ID))
+
+	// Черновик виден подписчику только по идентификатору и типу события.
+	for _, eventType := range []app.AdEventType{app.AdCreated, app.AdUpdated} {
+		e, err := sub.Next(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, eventType, e.Type)
+		assert.Equal(t, ad.ID, e.Ad.ID)
+		assert.Empty(t, e.Ad.Title)
+		assert.Empty(t, e.Ad.Text)
+		assert.Zero(t, e.Ad.AuthorID)
+	}
+
+	// Публикация и снятие с публикации видны целиком.
+	for _, eventType := range []app.AdEventType{app.AdPublished, app.AdUnpublished} {
+		e, err := sub.Next(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, eventType, e.Type)
+		assert.Equal(t, "still secret", e.Ad.Text)
+	}
+
+	e, err := sub.Next(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, app.AdDeleted, e.Type)
+	assert.Equal(t, ad.ID, e.Ad.ID)
+	assert.Empty(t, e.Ad.Text)
+}
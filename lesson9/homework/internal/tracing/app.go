@@ -0,0 +1,363 @@
+package tracing
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"homework9/internal/ads"
+	"homework9/internal/app"
+	"homework9/internal/categories"
+	"homework9/internal/messages"
+	"homework9/internal/money"
+	"homework9/internal/photos"
+	"homework9/internal/users"
+)
+
+// tracedApp создает спан app.<Метод> на каждый вызов бизнес-логики.
+type tracedApp struct {
+	next   app.App
+	tracer trace.Tracer
+}
+
+// WrapApp добавляет спаны вокруг методов a. RunScheduler работает все время
+// жизни сервиса и спана не получает, спаны есть у вызовов хранилища из него.
+func WrapApp(a app.App, tp trace.TracerProvider) app.App {
+	return &tracedApp{next: a, tracer: tp.Tracer(InstrumentationName)}
+}
+
+func (t *tracedApp) CreateAd(ctx context.Context, title string, text string, categoryID int64, price money.Money) (_ *ads.Ad, err error) {
+	ctx, span := t.tracer.Start(ctx, "app.CreateAd", trace.WithAttributes(attribute.Int64("category_id", categoryID)))
+	defer func() { endSpan(span, err) }()
+
+	return t.next.CreateAd(ctx, title, text, categoryID, price)
+}
+
+func (t *tracedApp) ChangeAdStatus(ctx context.Context, adID int64, published bool, expectedVersion int64) (_ *ads.Ad, err error) {
+	ctx, span := t.tracer.Start(ctx, "app.ChangeAdStatus", trace.WithAttributes(attribute.Int64("ad_id", adID)))
+	defer func() { endSpan(span, err) }()
+
+	return t.next.ChangeAdStatus(ctx, adID, published, expectedVersion)
+}
+
+func (t *tracedApp) ScheduleAd(ctx context.Context, adID int64, publishAt time.Time, unpublishAt time.Time, expectedVersion int64) (_ *ads.Ad, err error) {
+	ctx, span := t.tracer.Start(ctx, "app.ScheduleAd", trace.WithAttributes(attribute.Int64("ad_id", adID)))
+	defer func() { endSpan(span, err) }()
+
+	return t.next.ScheduleAd(ctx, adID, publishAt, unpublishAt, expectedVersion)
+}
+
+func (t *tracedApp) RunScheduler(ctx context.Context) {
+	t.next.RunScheduler(ctx)
+}
+
+func (t *tracedApp) UpdateAd(ctx context.Context, adID int64, title string, text string, categoryID int64, price money.Money, expectedVersion int64) (_ *ads.Ad, err error) {
+	ctx, span := t.tracer.Start(ctx, "app.UpdateAd", trace.WithAttributes(attribute.Int64("ad_id", adID), attribute.Int64("category_id", categoryID)))
+	defer func() { endSpan(span, err) }()
+
+	return t.next.UpdateAd(ctx, adID, title, text, categoryID, price, expectedVersion)
+}
+
+func (t *tracedApp) GetAd(ctx context.Context, adID int64) (_ *ads.Ad, err error) {
+	ctx, span := t.tracer.Start(ctx, "app.GetAd", trace.WithAttributes(attribute.Int64("ad_id", adID)))
+	defer func() { endSpan(span, err) }()
+
+	return t.next.GetAd(ctx, adID)
+}
+
+func (t *tracedApp) ListAds(ctx context.Context, query app.AdsQuery) (_ *app.AdsPage, err error) {
+	ctx, span := t.tracer.Start(ctx, "app.ListAds")
+	defer func() { endSpan(span, err) }()
+
+	return t.next.ListAds(ctx, query)
+}
+
+func (t *tracedApp) SearchAds(ctx context.Context, query string, limit int) (_ []app.AdSearchResult, err error) {
+	ctx, span := t.tracer.Start(ctx, "app.SearchAds")
+	defer func() { endSpan(span, err) }()
+
+	return t.next.SearchAds(ctx, query, limit)
+}
+
+func (t *tracedApp) WatchAds(ctx context.Context, filter app.WatchFilter) (_ *app.Subscription, err error) {
+	ctx, span := t.tracer.Start(ctx, "app.WatchAds")
+	defer func() { endSpan(span, err) }()
+
+	return t.next.WatchAds(ctx, filter)
+}
+
+func (t *tracedApp) DeleteAd(ctx context.Context, adID int64) (err error) {
+	ctx, span := t.tracer.Start(ctx, "app.DeleteAd", trace.WithAttributes(attribute.Int64("ad_id", adID)))
+	defer func() { endSpan(span, err) }()
+
+	return t.next.DeleteAd(ctx, adID)
+}
+
+func (t *tracedApp) RestoreAd(ctx context.Context, adID int64) (_ *ads.Ad, err error) {
+	ctx, span := t.tracer.Start(ctx, "app.RestoreAd", trace.WithAttributes(attribute.Int64("ad_id", adID)))
+	defer func() { endSpan(span, err) }()
+
+	return t.next.RestoreAd(ctx, adID)
+}
+
+func (t *tracedApp) TrashedAds(ctx context.Context, pageSize int, pageToken string) (_ *app.AdsPage, err error) {
+	ctx, span := t.tracer.Start(ctx, "app.TrashedAds")
+	defer func() { endSpan(span, err) }()
+
+	return t.next.TrashedAds(ctx, pageSize, pageToken)
+}
+
+func (t *tracedApp) CreateUser(ctx context.Context, name string, password string) (_ *users.User, err error) {
+	ctx, span := t.tracer.Start(ctx, "app.CreateUser")
+	defer func() { endSpan(span, err) }()
+
+	return t.next.CreateUser(ctx, name, password)
+}
+
+func (t *tracedApp) GetUser(ctx context.Context, userID int64) (_ *users.User, err error) {
+	ctx, span := t.tracer.Start(ctx, "app.GetUser", trace.WithAttributes(attribute.Int64("user_id", userID)))
+	defer func() { endSpan(span, err) }()
+
+	return t.next.GetUser(ctx, userID)
+}
+
+func (t *tracedApp) DeleteUser(ctx context.Context, userID int64) (err error) {
+	ctx, span := t.tracer.Start(ctx, "app.DeleteUser", trace.WithAttributes(attribute.Int64("user_id", userID)))
+	defer func() { endSpan(span, err) }()
+
+	return t.next.DeleteUser(ctx, userID)
+}
+
+func (t *tracedApp) RestoreUser(ctx context.Context, userID int64) (_ *users.User, err error) {
+	ctx, span := t.tracer.Start(ctx, "app.RestoreUser", trace.WithAttributes(attribute.Int64("user_id", userID)))
+	defer func() { endSpan(span, err) }()
+
+	return t.next.RestoreUser(ctx, userID)
+}
+
+func (t *tracedApp) PurgeTrash(ctx context.Context) (_ int, err error) {
+	ctx, span := t.tracer.Start(ctx, "app.PurgeTrash")
+	defer func() { endSpan(span, err) }()
+
+	return t.next.PurgeTrash(ctx)
+}
+
+func (t *tracedApp) Login(ctx context.Context, userID int64, password string) (_ *app.Token, err error) {
+	ctx, span := t.tracer.Start(ctx, "app.Login", trace.WithAttributes(attribute.Int64("user_id", userID)))
+	defer func() { endSpan(span, err) }()
+
+	return t.next.Login(ctx, userID, password)
+}
+
+func (t *tracedApp) Authenticate(ctx context.Context, token string) (_ int64, err error) {
+	ctx, span := t.tracer.Start(ctx, "app.Authenticate")
+	defer func() { endSpan(span, err) }()
+
+	return t.next.Authenticate(ctx, token)
+}
+
+func (t *tracedApp) ModerationQueue(ctx context.Context, pageSize int, pageToken string) (_ *app.AdsPage, err error) {
+	ctx, span := t.tracer.Start(ctx, "app.ModerationQueue")
+	defer func() { endSpan(span, err) }()
+
+	return t.next.ModerationQueue(ctx, pageSize, pageToken)
+}
+
+func (t *tracedApp) ApproveAd(ctx context.Context, adID int64) (_ *ads.Ad, err error) {
+	ctx, span := t.tracer.Start(ctx, "app.ApproveAd", trace.WithAttributes(attribute.Int64("ad_id", adID)))
+	defer func() { endSpan(span, err) }()
+
+	return t.next.ApproveAd(ctx, adID)
+}
+
+func (t *tracedApp) RejectAd(ctx context.Context, adID int64, reason string) (_ *ads.Ad, err error) {
+	ctx, span := t.tracer.Start(ctx, "app.RejectAd", trace.WithAttributes(attribute.Int64("ad_id", adID)))
+	defer func() { endSpan(span, err) }()
+
+	return t.next.RejectAd(ctx, adID, reason)
+}
+
+func (t *tracedApp) SetUserRole(ctx context.Context, userID int64, role users.Role) (_ *users.User, err error) {
+	ctx, span := t.tracer.Start(ctx, "app.SetUserRole", trace.WithAttributes(attribute.Int64("user_id", userID)))
+	defer func() { endSpan(span, err) }()
+
+	return t.next.SetUserRole(ctx, userID, role)
+}
+
+func (t *tracedApp) AddPhotos(ctx context.Context, adID int64, files [][]byte) (_ *ads.Ad, err error) {
+	ctx, span := t.tracer.Start(ctx, "app.AddPhotos", trace.WithAttributes(attribute.Int64("ad_id", adID)))
+	defer func() { endSpan(span, err) }()
+
+	return t.next.AddPhotos(ctx, adID, files)
+}
+
+func (t *tracedApp) ReorderPhotos(ctx context.Context, adID int64, photoIDs []string, expectedVersion int64) (_ *ads.Ad, err error) {
+	ctx, span := t.tracer.Start(ctx, "app.ReorderPhotos", trace.WithAttributes(attribute.Int64("ad_id", adID)))
+	defer func() { endSpan(span, err) }()
+
+	return t.next.ReorderPhotos(ctx, adID, photoIDs, expectedVersion)
+}
+
+func (t *tracedApp) DeletePhoto(ctx context.Context, adID int64, photoID string) (_ *ads.Ad, err error) {
+	ctx, span := t.tracer.Start(ctx, "app.DeletePhoto", trace.WithAttributes(attribute.Int64("ad_id", adID)))
+	defer func() { endSpan(span, err) }()
+
+	return t.next.DeletePhoto(ctx, adID, photoID)
+}
+
+func (t *tracedApp) GetPhoto(ctx context.Context, adID int64, photoID string, variant photos.Variant) (_ io.ReadCloser, _ string, err error) {
+	ctx, span := t.tracer.Start(ctx, "app.GetPhoto", trace.WithAttributes(attribute.Int64("ad_id", adID)))
+	defer func() { endSpan(span, err) }()
+
+	return t.next.GetPhoto(ctx, adID, photoID, variant)
+}
+
+func (t *tracedApp) CategoryTree(ctx context.Context) (_ []app.CategoryNode, err error) {
+	ctx, span := t.tracer.Start(ctx, "app.CategoryTree")
+	defer func() { endSpan(span, err) }()
+
+	return t.next.CategoryTree(ctx)
+}
+
+func (t *tracedApp) CreateCategory(ctx context.Context, name string, parentID int64) (_ *categories.Category, err error) {
+	ctx, span := t.tracer.Start(ctx, "app.CreateCategory", trace.WithAttributes(attribute.Int64("parent_id", parentID)))
+	defer func() { endSpan(span, err) }()
+
+	return t.next.CreateCategory(ctx, name, parentID)
+}
+
+func (t *tracedApp) UpdateCategory(ctx context.Context, categoryID int64, name string, parentID int64) (_ *categories.Category, err error) {
+	ctx, span := t.tracer.Start(ctx, "app.UpdateCategory", trace.WithAttributes(attribute.Int64("category_id", categoryID), attribute.Int64("parent_id", parentID)))
+	defer func() { endSpan(span, err) }()
+
+	return t.next.UpdateCategory(ctx, categoryID, name, parentID)
+}
+
+func (t *tracedApp) DeleteCategory(ctx context.Context, categoryID int64) (err error) {
+	ctx, span := t.tracer.Start(ctx, "app.DeleteCategory", trace.WithAttributes(attribute.Int64("category_id", categoryID)))
+	defer func() { endSpan(span, err) }()
+
+	return t.next.DeleteCategory(ctx, categoryID)
+}
+
+func (t *tracedApp) ExchangeRates(ctx context.Context) (_ []money.Rate, err error) {
+	ctx, span := t.tracer.Start(ctx, "app.ExchangeRates")
+	defer func() { endSpan(span, err) }()
+
+	return t.next.ExchangeRates(ctx)
+}
+
+func (t *tracedApp) SetExchangeRate(ctx context.Context, currency money.Currency, value string) (_ *money.Rate, err error) {
+	ctx, span := t.tracer.Start(ctx, "app.SetExchangeRate")
+	defer func() { endSpan(span, err) }()
+
+	return t.next.SetExchangeRate(ctx, currency, value)
+}
+
+func (t *tracedApp) AdHistory(ctx context.Context, adID int64) (_ []ads.Revision, err error) {
+	ctx, span := t.tracer.Start(ctx, "app.AdHistory", trace.WithAttributes(attribute.Int64("ad_id", adID)))
+	defer func() { endSpan(span, err) }()
+
+	return t.next.AdHistory(ctx, adID)
+}
+
+func (t *tracedApp) RevertAd(ctx context.Context, adID int64, version int64, expectedVersion int64) (_ *ads.Ad, err error) {
+	ctx, span := t.tracer.Start(ctx, "app.RevertAd", trace.WithAttributes(attribute.Int64("ad_id", adID)))
+	defer func() { endSpan(span, err) }()
+
+	return t.next.RevertAd(ctx, adID, version, expectedVersion)
+}
+
+func (t *tracedApp) AddFavorite(ctx context.Context, adID int64) (_ *ads.Ad, err error) {
+	ctx, span := t.tracer.Start(ctx, "app.AddFavorite", trace.WithAttributes(attribute.Int64("ad_id", adID)))
+	defer func() { endSpan(span, err) }()
+
+	return t.next.AddFavorite(ctx, adID)
+}
+
+func (t *tracedApp) RemoveFavorite(ctx context.Context, adID int64) (err error) {
+	ctx, span := t.tracer.Start(ctx, "app.RemoveFavorite", trace.WithAttributes(attribute.Int64("ad_id", adID)))
+	defer func() { endSpan(span, err) }()
+
+	return t.next.RemoveFavorite(ctx, adID)
+}
+
+func (t *tracedApp) Favorites(ctx context.Context, pageSize int, pageToken string) (_ *app.AdsPage, err error) {
+	ctx, span := t.tracer.Start(ctx, "app.Favorites")
+	defer func() { endSpan(span, err) }()
+
+	return t.next.Favorites(ctx, pageSize, pageToken)
+}
+
+func (t *tracedApp) Notifications(ctx context.Context, pageSize int, pageToken string) (_ *app.NotificationsPage, err error) {
+	ctx, span := t.tracer.Start(ctx, "app.Notifications")
+	defer func() { endSpan(span, err) }()
+
+	return t.next.Notifications(ctx, pageSize, pageToken)
+}
+
+func (t *tracedApp) OpenThread(ctx context.Context, adID int64) (_ *messages.Thread, err error) {
+	ctx, span := t.tracer.Start(ctx, "app.OpenThread", trace.WithAttributes(attribute.Int64("ad_id", adID)))
+	defer func() { endSpan(span, err) }()
+
+	return t.next.OpenThread(ctx, adID)
+}
+
+func (t *tracedApp) Threads(ctx context.Context, pageSize int, pageToken string) (_ *app.ThreadsPage, err error) {
+	ctx, span := t.tracer.Start(ctx, "app.Threads")
+	defer func() { endSpan(span, err) }()
+
+	return t.next.Threads(ctx, pageSize, pageToken)
+}
+
+func (t *tracedApp) SendMessage(ctx context.Context, threadID int64, text string) (_ *messages.Message, err error) {
+	ctx, span := t.tracer.Start(ctx, "app.SendMessage", trace.WithAttributes(attribute.Int64("thread_id", threadID)))
+	defer func() { endSpan(span, err) }()
+
+	return t.next.SendMessage(ctx, threadID, text)
+}
+
+func (t *tracedApp) Messages(ctx context.Context, threadID int64, pageSize int, pageToken string) (_ *app.MessagesPage, err error) {
+	ctx, span := t.tracer.Start(ctx, "app.Messages", trace.WithAttributes(attribute.Int64("thread_id", threadID)))
+	defer func() { endSpan(span, err) }()
+
+	return t.next.Messages(ctx, threadID, pageSize, pageToken)
+}
+
+func (t *tracedApp) MarkThreadRead(ctx context.Context, threadID int64, readID int64) (_ *messages.Thread, err error) {
+	ctx, span := t.tracer.Start(ctx, "app.MarkThreadRead", trace.WithAttributes(attribute.Int64("thread_id", threadID), attribute.Int64("read_id", readID)))
+	defer func() { endSpan(span, err) }()
+
+	return t.next.MarkThreadRead(ctx, threadID, readID)
+}
+
+func (t *tracedApp) UnreadMessages(ctx context.Context) (_ int, err error) {
+	ctx, span := t.tracer.Start(ctx, "app.UnreadMessages")
+	defer func() { endSpan(span, err) }()
+
+	return t.next.UnreadMessages(ctx)
+}
+
+func (t *tracedApp) Chat(ctx context.Context) (_ *app.ChatSubscription, err error) {
+	ctx, span := t.tracer.Start(ctx, "app.Chat")
+	defer func() { endSpan(span, err) }()
+
+	return t.next.Chat(ctx)
+}
+
+func (t *tracedApp) Stats(ctx context.Context) (_ app.Stats, err error) {
+	ctx, span := t.tracer.Start(ctx, "app.Stats")
+	defer func() { endSpan(span, err) }()
+
+	return t.next.Stats(ctx)
+}
+
+func (t *tracedApp) Ping(ctx context.Context) (err error) {
+	ctx, span := t.tracer.Start(ctx, "app.Ping")
+	defer func() { endSpan(span, err) }()
+
+	return t.next.Ping(ctx)
+}
@@ -0,0 +1,259 @@
+package tracing
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"homework9/internal/ads"
+	"homework9/internal/app"
+	"homework9/internal/categories"
+	"homework9/internal/favorites"
+	"homework9/internal/messages"
+	"homework9/internal/money"
+	"homework9/internal/users"
+)
+
+// tracedRepository создает спан repo.<Метод> на каждый вызов хранилища.
+type tracedRepository struct {
+	next   app.Repository
+	tracer trace.Tracer
+}
+
+// WrapRepository добавляет спаны вокруг методов repo.
+func WrapRepository(repo app.Repository, tp trace.TracerProvider) app.Repository {
+	return &tracedRepository{next: repo, tracer: tp.Tracer(InstrumentationName)}
+}
+
+func (t *tracedRepository) AddAd(ctx context.Context, ad ads.Ad, revision *ads.Revision) (_ ads.Ad, err error) {
+	ctx, span := t.tracer.Start(ctx, "repo.AddAd")
+	defer func() { endSpan(span, err) }()
+
+	return t.next.AddAd(ctx, ad, revision)
+}
+
+func (t *tracedRepository) GetAd(ctx context.Context, adID int64) (_ ads.Ad, err error) {
+	ctx, span := t.tracer.Start(ctx, "repo.GetAd", trace.WithAttributes(attribute.Int64("ad_id", adID)))
+	defer func() { endSpan(span, err) }()
+
+	return t.next.GetAd(ctx, adID)
+}
+
+func (t *tracedRepository) UpdateAd(ctx context.Context, ad ads.Ad, revision *ads.Revision) (err error) {
+	ctx, span := t.tracer.Start(ctx, "repo.UpdateAd", trace.WithAttributes(attribute.Int64("ad_id", ad.ID)))
+	defer func() { endSpan(span, err) }()
+
+	return t.next.UpdateAd(ctx, ad, revision)
+}
+
+func (t *tracedRepository) DeleteAd(ctx context.Context, adID int64) (err error) {
+	ctx, span := t.tracer.Start(ctx, "repo.DeleteAd", trace.WithAttributes(attribute.Int64("ad_id", adID)))
+	defer func() { endSpan(span, err) }()
+
+	return t.next.DeleteAd(ctx, adID)
+}
+
+func (t *tracedRepository) ListAds(ctx context.Context, filter app.AdFilter) (_ []ads.Ad, err error) {
+	ctx, span := t.tracer.Start(ctx, "repo.ListAds")
+	defer func() { endSpan(span, err) }()
+
+	return t.next.ListAds(ctx, filter)
+}
+
+func (t *tracedRepository) AddUser(ctx context.Context, user users.User) (_ users.User, err error) {
+	ctx, span := t.tracer.Start(ctx, "repo.AddUser")
+	defer func() { endSpan(span, err) }()
+
+	return t.next.AddUser(ctx, user)
+}
+
+func (t *tracedRepository) GetUser(ctx context.Context, userID int64) (_ users.User, err error) {
+	ctx, span := t.tracer.Start(ctx, "repo.GetUser", trace.WithAttributes(attribute.Int64("user_id", userID)))
+	defer func() { endSpan(span, err) }()
+
+	return t.next.GetUser(ctx, userID)
+}
+
+func (t *tracedRepository) UpdateUser(ctx context.Context, user users.User) (err error) {
+	ctx, span := t.tracer.Start(ctx, "repo.UpdateUser", trace.WithAttributes(attribute.Int64("user_id", user.ID)))
+	defer func() { endSpan(span, err) }()
+
+	return t.next.UpdateUser(ctx, user)
+}
+
+func (t *tracedRepository) DeleteUser(ctx context.Context, userID int64) (err error) {
+	ctx, span := t.tracer.Start(ctx, "repo.DeleteUser", trace.WithAttributes(attribute.Int64("user_id", userID)))
+	defer func() { endSpan(span, err) }()
+
+	return t.next.DeleteUser(ctx, userID)
+}
+
+func (t *tracedRepository) ListTrashedUsers(ctx context.Context, deletedBefore time.Time) (_ []users.User, err error) {
+	ctx, span := t.tracer.Start(ctx, "repo.ListTrashedUsers")
+	defer func() { endSpan(span, err) }()
+
+	return t.next.ListTrashedUsers(ctx, deletedBefore)
+}
+
+func (t *tracedRepository) AddCategory(ctx context.Context, category categories.Category) (_ categories.Category, err error) {
+	ctx, span := t.tracer.Start(ctx, "repo.AddCategory")
+	defer func() { endSpan(span, err) }()
+
+	return t.next.AddCategory(ctx, category)
+}
+
+func (t *tracedRepository) GetCategory(ctx context.Context, categoryID int64) (_ categories.Category, err error) {
+	ctx, span := t.tracer.Start(ctx, "repo.GetCategory", trace.WithAttributes(attribute.Int64("category_id", categoryID)))
+	defer func() { endSpan(span, err) }()
+
+	return t.next.GetCategory(ctx, categoryID)
+}
+
+func (t *tracedRepository) UpdateCategory(ctx context.Context, category categories.Category) (err error) {
+	ctx, span := t.tracer.Start(ctx, "repo.UpdateCategory")
+	defer func() { endSpan(span, err) }()
+
+	return t.next.UpdateCategory(ctx, category)
+}
+
+func (t *tracedRepository) DeleteCategory(ctx context.Context, categoryID int64) (err error) {
+	ctx, span := t.tracer.Start(ctx, "repo.DeleteCategory", trace.WithAttributes(attribute.Int64("category_id", categoryID)))
+	defer func() { endSpan(span, err) }()
+
+	return t.next.DeleteCategory(ctx, categoryID)
+}
+
+func (t *tracedRepository) ListCategories(ctx context.Context) (_ []categories.Category, err error) {
+	ctx, span := t.tracer.Start(ctx, "repo.ListCategories")
+	defer func() { endSpan(span, err) }()
+
+	return t.next.ListCategories(ctx)
+}
+
+func (t *tracedRepository) CountPublishedAds(ctx context.Context) (_ map[int64]int, err error) {
+	ctx, span := t.tracer.Start(ctx, "repo.CountPublishedAds")
+	defer func() { endSpan(span, err) }()
+
+	return t.next.CountPublishedAds(ctx)
+}
+
+func (t *tracedRepository) SetRate(ctx context.Context, rate money.Rate) (err error) {
+	ctx, span := t.tracer.Start(ctx, "repo.SetRate")
+	defer func() { endSpan(span, err) }()
+
+	return t.next.SetRate(ctx, rate)
+}
+
+func (t *tracedRepository) ListRates(ctx context.Context) (_ []money.Rate, err error) {
+	ctx, span := t.tracer.Start(ctx, "repo.ListRates")
+	defer func() { endSpan(span, err) }()
+
+	return t.next.ListRates(ctx)
+}
+
+func (t *tracedRepository) ListRevisions(ctx context.Context, adID int64) (_ []ads.Revision, err error) {
+	ctx, span := t.tracer.Start(ctx, "repo.ListRevisions", trace.WithAttributes(attribute.Int64("ad_id", adID)))
+	defer func() { endSpan(span, err) }()
+
+	return t.next.ListRevisions(ctx, adID)
+}
+
+func (t *tracedRepository) AddFavorite(ctx context.Context, favorite favorites.Favorite) (err error) {
+	ctx, span := t.tracer.Start(ctx, "repo.AddFavorite")
+	defer func() { endSpan(span, err) }()
+
+	return t.next.AddFavorite(ctx, favorite)
+}
+
+func (t *tracedRepository) RemoveFavorite(ctx context.Context, userID int64, adID int64) (err error) {
+	ctx, span := t.tracer.Start(ctx, "repo.RemoveFavorite", trace.WithAttributes(attribute.Int64("user_id", userID), attribute.Int64("ad_id", adID)))
+	defer func() { endSpan(span, err) }()
+
+	return t.next.RemoveFavorite(ctx, userID, adID)
+}
+
+func (t *tracedRepository) ListWatchers(ctx context.Context, adID int64) (_ []int64, err error) {
+	ctx, span := t.tracer.Start(ctx, "repo.ListWatchers", trace.WithAttributes(attribute.Int64("ad_id", adID)))
+	defer func() { endSpan(span, err) }()
+
+	return t.next.ListWatchers(ctx, adID)
+}
+
+func (t *tracedRepository) AddNotification(ctx context.Context, notification favorites.Notification) (_ favorites.Notification, err error) {
+	ctx, span := t.tracer.Start(ctx, "repo.AddNotification")
+	defer func() { endSpan(span, err) }()
+
+	return t.next.AddNotification(ctx, notification)
+}
+
+func (t *tracedRepository) ListNotifications(ctx context.Context, userID int64, fromID int64, limit int) (_ []favorites.Notification, err error) {
+	ctx, span := t.tracer.Start(ctx, "repo.ListNotifications", trace.WithAttributes(attribute.Int64("user_id", userID), attribute.Int64("from_id", fromID)))
+	defer func() { endSpan(span, err) }()
+
+	return t.next.ListNotifications(ctx, userID, fromID, limit)
+}
+
+func (t *tracedRepository) AddThread(ctx context.Context, thread messages.Thread) (_ messages.Thread, err error) {
+	ctx, span := t.tracer.Start(ctx, "repo.AddThread")
+	defer func() { endSpan(span, err) }()
+
+	return t.next.AddThread(ctx, thread)
+}
+
+func (t *tracedRepository) GetThread(ctx context.Context, threadID int64) (_ messages.Thread, err error) {
+	ctx, span := t.tracer.Start(ctx, "repo.GetThread", trace.WithAttributes(attribute.Int64("thread_id", threadID)))
+	defer func() { endSpan(span, err) }()
+
+	return t.next.GetThread(ctx, threadID)
+}
+
+func (t *tracedRepository) ListThreads(ctx context.Context, userID int64, fromID int64, limit int) (_ []messages.Thread, err error) {
+	ctx, span := t.tracer.Start(ctx, "repo.ListThreads", trace.WithAttributes(attribute.Int64("user_id", userID), attribute.Int64("from_id", fromID)))
+	defer func() { endSpan(span, err) }()
+
+	return t.next.ListThreads(ctx, userID, fromID, limit)
+}
+
+func (t *tracedRepository) MarkThreadRead(ctx context.Context, threadID int64, userID int64, readID int64) (err error) {
+	ctx, span := t.tracer.Start(ctx, "repo.MarkThreadRead", trace.WithAttributes(attribute.Int64("thread_id", threadID), attribute.Int64("user_id", userID), attribute.Int64("read_id", readID)))
+	defer func() { endSpan(span, err) }()
+
+	return t.next.MarkThreadRead(ctx, threadID, userID, readID)
+}
+
+func (t *tracedRepository) CountUnread(ctx context.Context, userID int64) (_ int, err error) {
+	ctx, span := t.tracer.Start(ctx, "repo.CountUnread", trace.WithAttributes(attribute.Int64("user_id", userID)))
+	defer func() { endSpan(span, err) }()
+
+	return t.next.CountUnread(ctx, userID)
+}
+
+func (t *tracedRepository) AddMessage(ctx context.Context, message messages.Message) (_ messages.Message, err error) {
+	ctx, span := t.tracer.Start(ctx, "repo.AddMessage")
+	defer func() { endSpan(span, err) }()
+
+	return t.next.AddMessage(ctx, message)
+}
+
+func (t *tracedRepository) ListMessages(ctx context.Context, threadID int64, fromID int64, limit int) (_ []messages.Message, err error) {
+	ctx, span := t.tracer.Start(ctx, "repo.ListMessages", trace.WithAttributes(attribute.Int64("thread_id", threadID), attribute.Int64("from_id", fromID)))
+	defer func() { endSpan(span, err) }()
+
+	return t.next.ListMessages(ctx, threadID, fromID, limit)
+}
+
+func (t *tracedRepository) Stats(ctx context.Context) (_ app.Stats, err error) {
+	ctx, span := t.tracer.Start(ctx, "repo.Stats")
+	defer func() { endSpan(span, err) }()
+
+	return t.next.Stats(ctx)
+}
+
+func (t *tracedRepository) Ping(ctx context.Context) (err error) {
+	ctx, span := t.tracer.Start(ctx, "repo.Ping")
+	defer func() { endSpan(span, err) }()
+
+	return t.next.Ping(ctx)
+}
@@ -0,0 +1,46 @@
+package tracing
+
+import (
+	"io"
+
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// InstrumentationName - имя трейсера, которым создаются все спаны сервиса.
+const InstrumentationName = "homework9"
+
+// Propagator переносит контекст трассировки в заголовке traceparent по W3C
+// Trace Context, в HTTP заголовках и в метаданных gRPC.
+var Propagator propagation.TextMapPropagator = propagation.TraceContext{}
+
+// NewProvider создает провайдер, который отправляет завершенные спаны в
+// exporter пачками. Перед выходом нужно вызвать Shutdown, чтобы отправить
+// оставшиеся спаны.
+func NewProvider(exporter sdktrace.SpanExporter, opts ...sdktrace.TracerProviderOption) *sdktrace.TracerProvider {
+	opts = append([]sdktrace.TracerProviderOption{
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(InstrumentationName))),
+	}, opts...)
+
+	return sdktrace.NewTracerProvider(opts...)
+}
+
+// NewStdoutExporter создает экспортер, который пишет спаны в w в формате JSON.
+func NewStdoutExporter(w io.Writer) (sdktrace.SpanExporter, error) {
+	return stdouttrace.New(stdouttrace.WithWriter(w))
+}
+
+// endSpan отмечает спан ошибкой, если она есть, и завершает его.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,37 @@
+package users
+
+import "time"
+
+type User struct {
+	ID   int64
+	Name string
+	// PasswordHash - bcrypt-хеш пароля, пустой у пользователей без пароля (они не могут войти).
+	PasswordHash []byte
+	// Role пуста у пользователей, созданных до появления ролей, и означает RoleUser.
+	Role Role
+	// DeletedAt - время перемещения в корзину (UTC), нулевое - пользователь не в корзине.
+	DeletedAt time.Time
+}
+
+// Trashed сообщает, находится ли пользователь в корзине.
+func (u User) Trashed() bool {
+	return !u.DeletedAt.IsZero()
+}
+
+type Role string
+
+const (
+	RoleUser      Role = "user"
+	RoleModerator Role = "moderator"
+	RoleAdmin     Role = "admin"
+)
+
+// Valid сообщает, является ли r известной ролью.
+func (r Role) Valid() bool {
+	switch r {
+	case RoleUser, RoleModerator, RoleAdmin:
+		return true
+	}
+
+	return false
+}